@@ -1,34 +1,62 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/rauf/payment-service/cmd/api/handlers"
 	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/callback"
 	"github.com/rauf/payment-service/internal/config"
 	"github.com/rauf/payment-service/internal/consts"
 	"github.com/rauf/payment-service/internal/database"
+	"github.com/rauf/payment-service/internal/fee"
 	"github.com/rauf/payment-service/internal/gateway"
-	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/outbox"
+	"github.com/rauf/payment-service/internal/reconcile"
 	"github.com/rauf/payment-service/internal/registry"
 	"github.com/rauf/payment-service/internal/repo"
 	"github.com/rauf/payment-service/internal/router"
 	"github.com/rauf/payment-service/internal/service"
+	"github.com/rauf/payment-service/internal/webhook"
+	"github.com/rauf/payment-service/internal/worker"
 	"github.com/sony/gobreaker/v2"
 )
 
 // Application is the main application struct that holds the dependencies.
 type Application struct {
-	Registry       *registry.Registry[gateway.PaymentGateway]
-	PaymentHandler *handlers.PaymentHandler
+	Registry              *registry.Registry[gateway.PaymentGateway]
+	PaymentHandler        *handlers.PaymentHandler
+	WebhookHandler        *handlers.WebhookHandler
+	CallbackHandler       *handlers.CallbackHandler
+	ReconciliationHandler *handlers.ReconciliationHandler
+	LedgerHandler         *handlers.LedgerHandler
+	RoutingHandler        *handlers.RoutingHandler
+	Workers               []worker.Worker
 }
 
-func NewApplication(regis *registry.Registry[gateway.PaymentGateway], ph *handlers.PaymentHandler) *Application {
+func NewApplication(regis *registry.Registry[gateway.PaymentGateway], ph *handlers.PaymentHandler, wh *handlers.WebhookHandler, ch *handlers.CallbackHandler, rh *handlers.ReconciliationHandler, lh *handlers.LedgerHandler, rth *handlers.RoutingHandler, workers []worker.Worker) *Application {
 	return &Application{
-		Registry:       regis,
-		PaymentHandler: ph,
+		Registry:              regis,
+		PaymentHandler:        ph,
+		WebhookHandler:        wh,
+		CallbackHandler:       ch,
+		ReconciliationHandler: rh,
+		LedgerHandler:         lh,
+		RoutingHandler:        rth,
+		Workers:               workers,
+	}
+}
+
+// StartWorkers starts all the background workers. They stop when the context is cancelled.
+func (a *Application) StartWorkers(ctx context.Context) {
+	for _, w := range a.Workers {
+		go w.Start(ctx)
 	}
 }
 
@@ -42,23 +70,226 @@ func setupApplication() (*Application, error) {
 		},
 	}
 
-	gatewayRegistry, err := createGatewayRegistry()
+	conf := config.NewConfig()
+	callbackVerifiers, err := createCallbackVerifiers(conf.Callbacks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create callback verifiers: %w", err)
+	}
+	gatewayRegistry, err := createGatewayRegistry(callbackVerifiers)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gateway registry: %w", err)
 	}
-	conf := config.NewConfig()
 	db, err := database.NewDatabase(conf.Database)
-	r := router.NewRouter(gatewayRegistry, settings)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database: %w", err)
 	}
-	paymentRepo := repo.NewPaymentRepo(models.New(db))
-	paymentService := service.NewPaymentService(r, paymentRepo)
+	routingRules, err := loadRoutingRules(conf.Routing.RulesFile, gatewayRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing rules: %w", err)
+	}
+	var scorer *router.Scorer
+	if conf.Routing.Adaptive {
+		scorer = router.NewScorer(router.ScorerConfig{
+			Window:        conf.Routing.Scorer.Window,
+			Buckets:       conf.Routing.Scorer.Buckets,
+			LatencyTarget: conf.Routing.Scorer.LatencyTarget,
+			Exploration:   conf.Routing.Scorer.Exploration,
+		})
+	}
+	fees, err := loadFeeSchedules(conf.Fee.SchedulesFile, gatewayRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fee schedules: %w", err)
+	}
+	var costs *fee.Schedules
+	if conf.Routing.CostBased {
+		if fees == nil {
+			return nil, errors.New("cost-based routing needs fee schedules")
+		}
+		costs = fees
+	}
+	r := router.NewRouter(gatewayRegistry, settings, routingRules, scorer, costs)
+	paymentRepo := repo.NewPaymentRepo(db.DB)
+	paymentService := service.NewPaymentService(r, paymentRepo, conf.Payment, fees)
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
-	return NewApplication(gatewayRegistry, paymentHandler), nil
+	routingHandler := handlers.NewRoutingHandler(paymentService)
+	callbackService := service.NewCallbackService(paymentService, paymentRepo, conf.CallbackInbox)
+	callbackHandler := handlers.NewCallbackHandler(gatewayRegistry, callbackService)
+
+	settlementParsers, err := reconcile.NewParsers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create settlement report parsers: %w", err)
+	}
+	reconciliationService := service.NewReconciliationService(paymentRepo, settlementParsers)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+
+	ledgerService := service.NewLedgerService(paymentRepo)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+
+	webhookService := service.NewWebhookService(paymentRepo, webhook.NewSender(&http.Client{Timeout: conf.Webhook.Timeout}), conf.Webhook)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	sink, err := createEventPublisher(conf.Outbox)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event publisher: %w", err)
+	}
+	// Events go to the configured sink and, for transaction events, to the merchants' webhook endpoints.
+	publisher := outbox.NewInProcessPublisher()
+	publisher.Subscribe(sink.Publish)
+	publisher.Subscribe(webhookService.EnqueueDeliveries, service.WebhookEventTypes...)
+	relay := outbox.NewRelay(paymentRepo, publisher, conf.Outbox.BatchSize)
+
+	workers := []worker.Worker{
+		{
+			Name:     "authorization-expiry",
+			Interval: conf.Payment.AuthorizationExpiryInterval,
+			Run:      paymentService.ExpireAuthorizations,
+		},
+		{
+			Name:     "initiated-sweeper",
+			Interval: conf.Payment.InitiatedSweepInterval,
+			Run:      paymentService.SweepInitiatedTransactions,
+		},
+		{
+			Name:     "status-poller",
+			Interval: conf.Payment.StatusPollInterval,
+			Run:      paymentService.PollPendingTransactions,
+		},
+		{
+			Name:     "idempotency-key-cleanup",
+			Interval: conf.Payment.IdempotencyKeyCleanupInterval,
+			Run:      paymentService.DeleteExpiredIdempotencyKeys,
+		},
+		{
+			Name:     "outbox-relay",
+			Interval: conf.Outbox.RelayInterval,
+			Run:      relay.Run,
+		},
+		{
+			Name:     "webhook-delivery",
+			Interval: conf.Webhook.DeliveryInterval,
+			Run:      webhookService.DeliverPending,
+		},
+		{
+			Name:     "callback-inbox",
+			Interval: conf.CallbackInbox.ReplayInterval,
+			Run:      callbackService.ApplyParked,
+		},
+		{
+			Name:     "ledger-check",
+			Interval: conf.Ledger.CheckInterval,
+			Run:      ledgerService.VerifyLedger,
+		},
+	}
+	if fees != nil {
+		// Until the volumes are known, every transaction is priced at the base tier.
+		if err := paymentService.RefreshFeeVolumes(context.Background()); err != nil {
+			slog.Warn("Failed to load fee volumes, retrying on the next refresh", "error", err)
+		}
+		workers = append(workers, worker.Worker{
+			Name:     "fee-volume-refresh",
+			Interval: conf.Fee.VolumeRefreshInterval,
+			Run:      paymentService.RefreshFeeVolumes,
+		})
+	}
+	return NewApplication(gatewayRegistry, paymentHandler, webhookHandler, callbackHandler, reconciliationHandler, ledgerHandler, routingHandler, workers), nil
+}
+
+// createCallbackVerifiers authenticates gateway A callbacks by their HMAC headers and gateway B callbacks by
+// the signature in their XML body, optionally restricted to an IP allowlist.
+func createCallbackVerifiers(conf map[string]config.CallbackConfig) (map[string]callback.Verifier, error) {
+	signatures := map[string]func(secret string, tolerance time.Duration) callback.Verifier{
+		consts.GatewayA: func(secret string, tolerance time.Duration) callback.Verifier {
+			return callback.NewHMACVerifier(secret, tolerance)
+		},
+		consts.GatewayB: func(secret string, tolerance time.Duration) callback.Verifier {
+			return callback.NewXMLSignatureVerifier(secret, tolerance)
+		},
+	}
+
+	verifiers := make(map[string]callback.Verifier, len(signatures))
+	for name, newSignature := range signatures {
+		c := conf[name]
+		if c.Secret == "" {
+			slog.Warn("No callback secret configured, rejecting all callbacks", "gateway", name)
+			verifiers[name] = callback.Reject("no callback secret configured for " + name)
+			continue
+		}
+		var chain []callback.Verifier
+		if len(c.AllowedIPs) > 0 {
+			allowlist, err := callback.NewIPAllowlist(c.AllowedIPs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse allowed IPs of %s: %w", name, err)
+			}
+			chain = append(chain, allowlist)
+		}
+		verifiers[name] = callback.Chain(append(chain, newSignature(c.Secret, c.Tolerance))...)
+	}
+	return verifiers, nil
 }
 
-func createGatewayRegistry() (*registry.Registry[gateway.PaymentGateway], error) {
+// loadRoutingRules reads the routing rules from the file and checks that their gateways are registered. Without
+// a file there are no rules.
+func loadRoutingRules(path string, gateways *registry.Registry[gateway.PaymentGateway]) (*router.Rules, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open routing rules: %w", err)
+	}
+	defer f.Close()
+
+	rules, err := router.LoadRules(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules.List() {
+		for _, name := range rule.Gateways {
+			if _, err := gateways.Get(name); err != nil {
+				return nil, fmt.Errorf("routing rule %s uses unknown gateway %s", rule.Name, name)
+			}
+		}
+	}
+	slog.Info("Loaded routing rules", "file", path, "rules", len(rules.List()))
+	return rules, nil
+}
+
+// loadFeeSchedules reads the fee schedules from the file and checks that their gateways are registered.
+// Without a file there are no schedules.
+func loadFeeSchedules(path string, gateways *registry.Registry[gateway.PaymentGateway]) (*fee.Schedules, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fee schedules: %w", err)
+	}
+	defer f.Close()
+
+	schedules, err := fee.LoadSchedules(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range schedules.Gateways() {
+		if _, err := gateways.Get(name); err != nil {
+			return nil, fmt.Errorf("fee schedule of unknown gateway %s", name)
+		}
+	}
+	slog.Info("Loaded fee schedules", "file", path, "gateways", len(schedules.Gateways()))
+	return schedules, nil
+}
+
+func createEventPublisher(conf config.OutboxConfig) (outbox.Publisher, error) {
+	switch conf.Publisher {
+	case "log":
+		return outbox.NewLogPublisher(), nil
+	case "file":
+		return outbox.NewFilePublisher(conf.FilePath)
+	}
+	return nil, fmt.Errorf("unknown event publisher %q", conf.Publisher)
+}
+
+func createGatewayRegistry(callbackVerifiers map[string]callback.Verifier) (*registry.Registry[gateway.PaymentGateway], error) {
 	gatewayRegistry := registry.NewRegistry[gateway.PaymentGateway]()
 	httpClient := &http.Client{
 		Timeout: 10 * time.Second, // specify the timeout for the http client, should be configurable
@@ -72,7 +303,8 @@ func createGatewayRegistry() (*registry.Registry[gateway.PaymentGateway], error)
 		backoff.RetryConfig{
 			MaxRetries: 3,
 			Backoff:    backoff.NewExponentialBackoff(1*time.Second, 1.2, 2*time.Second),
-		}))
+		},
+		callbackVerifiers[consts.GatewayA]))
 	if err != nil {
 		return nil, fmt.Errorf("failed to register Gateway-A: %w", err)
 	}
@@ -84,7 +316,8 @@ func createGatewayRegistry() (*registry.Registry[gateway.PaymentGateway], error)
 		backoff.RetryConfig{
 			MaxRetries: 3,
 			Backoff:    backoff.NewExponentialBackoff(1*time.Second, 1.2, 2*time.Second),
-		}))
+		},
+		callbackVerifiers[consts.GatewayB]))
 	if err != nil {
 		return nil, fmt.Errorf("failed to register Gateway-B: %w", err)
 	}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/rauf/payment-service/internal/callback"
+	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/service"
+)
+
+// maxCallbackBodyBytes bounds the callback bodies read before they are authenticated.
+const maxCallbackBodyBytes = 1 << 20
+
+// CallbackHandler dispatches gateway callbacks to the gateway they are addressed to and manages the
+// callback inbox.
+type CallbackHandler struct {
+	gateways        gatewayRegistry
+	callbackService callbackService
+}
+
+// interface on consumer side
+type gatewayRegistry interface {
+	Get(name string) (gateway.PaymentGateway, error)
+}
+
+// interface on consumer side
+type callbackService interface {
+	Receive(ctx context.Context, callback models.GatewayCallback) (models.CallbackInboxEntry, bool, error)
+	ListInbox(ctx context.Context, filter models.CallbackInboxFilter) (models.CallbackInboxPage, error)
+	Replay(ctx context.Context, id int64) (models.CallbackInboxEntry, error)
+}
+
+func NewCallbackHandler(gateways gatewayRegistry, callbackService callbackService) *CallbackHandler {
+	return &CallbackHandler{
+		gateways:        gateways,
+		callbackService: callbackService,
+	}
+}
+
+// HandleGatewayCallback updates the status of a transaction from a gateway callback. The gateway must
+// implement gateway.CallbackParser, which verifies and decodes the callback in its own format. Verified
+// callbacks are stored in the callback inbox, and callbacks for transactions that are not stored yet are
+// accepted and applied later.
+func (h *CallbackHandler) HandleGatewayCallback(w http.ResponseWriter, r *http.Request) Response {
+	name := r.PathValue("name")
+	slog.InfoContext(r.Context(), "Gateway callback request received", "gateway", name, "method", r.Method, "url", r.URL.Path)
+
+	g, err := h.gateways.Get(name)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, "gateway not found", nil, err)
+	}
+	parser, ok := g.(gateway.CallbackParser)
+	if !ok {
+		return NewResponse(http.StatusNotFound, "gateway does not accept callbacks", nil, nil)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBodyBytes))
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to read request", nil, err)
+	}
+	cb, err := parser.ParseCallback(r, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, callback.ErrUnverified):
+			slog.WarnContext(r.Context(), "Rejected unverified callback", "gateway", name, "remote_addr", r.RemoteAddr, "error", err)
+			return NewResponse(http.StatusUnauthorized, "callback verification failed", nil, err)
+		case errors.Is(err, gateway.ErrInvalidCallback):
+			return NewResponse(http.StatusBadRequest, "invalid callback", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to parse callback", nil, err)
+	}
+
+	entry, duplicate, err := h.callbackService.Receive(r.Context(), models.GatewayCallback{
+		Gateway:           name,
+		RefID:             cb.RefID,
+		Status:            cb.Status,
+		GatewayStatusCode: cb.GatewayStatusCode,
+		DeclineReason:     cb.DeclineReason,
+		DedupeKey:         cb.DedupeKey,
+		RawPayload:        string(body),
+	})
+	switch {
+	case err != nil:
+		return updateStatusErrorResponse(err)
+	case duplicate:
+		return NewResponse(http.StatusOK, "callback already received", nil, nil)
+	case entry.Status == callbackInboxStatusParked:
+		return NewResponse(http.StatusAccepted, "callback parked until the transaction is created", nil, nil)
+	}
+	return NewResponse(http.StatusOK, "status updated successfully", nil, nil)
+}
+
+func (h *CallbackHandler) HandleListCallbackInbox(_ http.ResponseWriter, r *http.Request) Response {
+	apiRequest := newListCallbackInboxApiRequest(r.URL.Query())
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	page, err := h.callbackService.ListInbox(r.Context(), models.CallbackInboxFilter{
+		Gateway: apiRequest.Gateway,
+		RefID:   apiRequest.RefID,
+		Status:  apiRequest.Status,
+		Cursor:  apiRequest.Cursor,
+		Limit:   apiRequest.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return NewResponse(http.StatusBadRequest, "invalid cursor", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to list callback inbox", nil, err)
+	}
+
+	apiResponse := listCallbackInboxApiResponse{
+		Callbacks:  make([]callbackInboxEntryApiResponse, 0, len(page.Entries)),
+		NextCursor: page.NextCursor,
+	}
+	for _, entry := range page.Entries {
+		apiResponse.Callbacks = append(apiResponse.Callbacks, toCallbackInboxEntryApiResponse(entry))
+	}
+	return NewResponse(http.StatusOK, "callback inbox listed successfully", apiResponse, nil)
+}
+
+func (h *CallbackHandler) HandleReplayCallback(_ http.ResponseWriter, r *http.Request) Response {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, "invalid callback ID", nil, err)
+	}
+
+	entry, err := h.callbackService.Replay(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCallbackNotFound) {
+			return NewResponse(http.StatusNotFound, "callback not found", nil, err)
+		}
+		return updateStatusErrorResponse(err)
+	}
+	return NewResponse(http.StatusOK, "callback replayed successfully", toCallbackInboxEntryApiResponse(entry), nil)
+}
+
+func toCallbackInboxEntryApiResponse(e models.CallbackInboxEntry) callbackInboxEntryApiResponse {
+	return callbackInboxEntryApiResponse{
+		ID:                e.ID,
+		Gateway:           e.Gateway,
+		RefID:             e.RefID,
+		DedupeKey:         e.DedupeKey,
+		Status:            e.Status,
+		ReportedStatus:    e.ReportedStatus,
+		GatewayStatusCode: e.GatewayStatusCode,
+		DeclineReason:     e.DeclineReason,
+		RawPayload:        e.RawPayload,
+		Attempts:          e.Attempts,
+		LastError:         e.LastError,
+		CreatedAt:         e.CreatedAt,
+		UpdatedAt:         e.UpdatedAt,
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/callback"
+	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/registry"
+	"github.com/rauf/payment-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCallbackService struct {
+	mock.Mock
+}
+
+func (m *MockCallbackService) Receive(ctx context.Context, callback models.GatewayCallback) (models.CallbackInboxEntry, bool, error) {
+	args := m.Called(ctx, callback)
+	return args.Get(0).(models.CallbackInboxEntry), args.Bool(1), args.Error(2)
+}
+
+func (m *MockCallbackService) ListInbox(ctx context.Context, filter models.CallbackInboxFilter) (models.CallbackInboxPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(models.CallbackInboxPage), args.Error(1)
+}
+
+func (m *MockCallbackService) Replay(ctx context.Context, id int64) (models.CallbackInboxEntry, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.CallbackInboxEntry), args.Error(1)
+}
+
+type verifierFunc func(r *http.Request, body []byte) error
+
+func (f verifierFunc) Verify(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+// plainGateway is a gateway that does not accept callbacks.
+type plainGateway struct {
+	gateway.PaymentGateway
+}
+
+func TestHandleGatewayCallback(t *testing.T) {
+	verified := verifierFunc(func(*http.Request, []byte) error { return nil })
+
+	tests := []struct {
+		name           string
+		gateway        string
+		body           string
+		verifier       callback.Verifier
+		inboxStatus    string
+		duplicate      bool
+		mockError      error
+		callReceive    bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Gateway A callback",
+			gateway:        "gatewayA",
+			body:           `{"ref_id":"ref123","status":"SUCCESS"}`,
+			verifier:       verified,
+			callReceive:    true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"status updated successfully"}`,
+		},
+		{
+			name:           "Gateway B callback",
+			gateway:        "gatewayB",
+			body:           `<callback><ref_id>ref123</ref_id><status>success</status></callback>`,
+			verifier:       verified,
+			callReceive:    true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"status updated successfully"}`,
+		},
+		{
+			name:           "Duplicate callback",
+			gateway:        "gatewayA",
+			body:           `{"ref_id":"ref123","status":"success"}`,
+			verifier:       verified,
+			inboxStatus:    "applied",
+			duplicate:      true,
+			callReceive:    true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"callback already received"}`,
+		},
+		{
+			name:           "Callback before the transaction",
+			gateway:        "gatewayA",
+			body:           `{"ref_id":"ref123","status":"success"}`,
+			verifier:       verified,
+			inboxStatus:    "parked",
+			callReceive:    true,
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"code":202,"message":"callback parked until the transaction is created"}`,
+		},
+		{
+			name:           "Late pending callback",
+			gateway:        "gatewayB",
+			body:           `<callback><ref_id>ref123</ref_id><status>pending</status></callback>`,
+			verifier:       verified,
+			mockError:      service.ErrIllegalTransition,
+			callReceive:    true,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"code":409,"message":"illegal status transition"}`,
+		},
+		{
+			name:           "Unverified callback",
+			gateway:        "gatewayA",
+			body:           `{"ref_id":"ref123","status":"success"}`,
+			verifier:       callback.Reject("bad signature"),
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"code":401,"message":"callback verification failed"}`,
+		},
+		{
+			name:           "Malformed XML",
+			gateway:        "gatewayB",
+			body:           `<callback><ref_id>`,
+			verifier:       verified,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid callback"}`,
+		},
+		{
+			name:           "Unknown status",
+			gateway:        "gatewayA",
+			body:           `{"ref_id":"ref123","status":"settled"}`,
+			verifier:       verified,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid callback"}`,
+		},
+		{
+			name:           "Unknown gateway",
+			gateway:        "gatewayC",
+			body:           `{}`,
+			verifier:       verified,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":404,"message":"gateway not found"}`,
+		},
+		{
+			name:           "Gateway without callbacks",
+			gateway:        "plain",
+			body:           `{}`,
+			verifier:       verified,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":404,"message":"gateway does not accept callbacks"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateways := registry.NewRegistry[gateway.PaymentGateway]()
+			_ = gateways.Register("gatewayA", gateway.NewGatewayA("gatewayA", http.MethodPost, "", http.DefaultClient, backoff.RetryConfig{}, tt.verifier))
+			_ = gateways.Register("gatewayB", gateway.NewGatewayB("gatewayB", http.MethodPost, "", http.DefaultClient, backoff.RetryConfig{}, tt.verifier))
+			_ = gateways.Register("plain", plainGateway{})
+
+			mockService := new(MockCallbackService)
+			handler := NewCallbackHandler(gateways, mockService)
+			if tt.callReceive {
+				matchesCallback := mock.MatchedBy(func(cb models.GatewayCallback) bool {
+					return cb.Gateway == tt.gateway && cb.RefID == "ref123" && cb.DedupeKey != "" && cb.RawPayload == tt.body
+				})
+				entry := models.CallbackInboxEntry{Status: cmp.Or(tt.inboxStatus, "applied")}
+				mockService.On("Receive", mock.Anything, matchesCallback).Return(entry, tt.duplicate, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/gateways/"+tt.gateway+"/callback", strings.NewReader(tt.body))
+			req.SetPathValue("name", tt.gateway)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleGatewayCallback(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleReplayCallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockEntry      models.CallbackInboxEntry
+		mockError      error
+		callReplay     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Replayed",
+			id:             "7",
+			mockEntry:      models.CallbackInboxEntry{ID: 7, Gateway: "gatewayA", RefID: "ref123", DedupeKey: "ref123:00", Status: "applied", ReportedStatus: "success", RawPayload: "{}", Attempts: 2},
+			callReplay:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"code":200,"message":"callback replayed successfully","data":{"id":7,"gateway":"gatewayA","ref_id":"ref123",
+				"dedupe_key":"ref123:00","status":"applied","reported_status":"success","raw_payload":"{}","attempts":2,
+				"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:           "Illegal transition",
+			id:             "7",
+			mockError:      service.ErrIllegalTransition,
+			callReplay:     true,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"code":409,"message":"illegal status transition"}`,
+		},
+		{
+			name:           "Not found",
+			id:             "8",
+			mockError:      service.ErrCallbackNotFound,
+			callReplay:     true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":404,"message":"callback not found"}`,
+		},
+		{
+			name:           "Invalid ID",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid callback ID"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCallbackService)
+			handler := NewCallbackHandler(registry.NewRegistry[gateway.PaymentGateway](), mockService)
+			if tt.callReplay {
+				mockService.On("Replay", mock.Anything, mock.AnythingOfType("int64")).Return(tt.mockEntry, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/admin/callbacks/"+tt.id+"/replay", nil)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleReplayCallback(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/serde"
+	"github.com/rauf/payment-service/internal/service"
+)
+
+// LedgerHandler exposes the balances of the ledger, manual fee and adjustment entries and the ledger check.
+type LedgerHandler struct {
+	ledgerService ledgerService
+	jsonSerde     serde.Serde
+}
+
+// interface on consumer side
+type ledgerService interface {
+	GetCustomerBalances(ctx context.Context, customerID string) ([]models.LedgerBalance, error)
+	ListAccounts(ctx context.Context, filter models.LedgerAccountFilter) (models.LedgerAccountPage, error)
+	PostFee(ctx context.Context, req models.FeeRequest) (models.JournalEntry, error)
+	PostAdjustment(ctx context.Context, req models.AdjustmentRequest) (models.JournalEntry, error)
+	CheckLedger(ctx context.Context) (models.LedgerCheck, error)
+}
+
+func NewLedgerHandler(ledgerService ledgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+		jsonSerde:     serde.NewJSONSerde(),
+	}
+}
+
+func (h *LedgerHandler) HandleGetCustomerBalances(_ http.ResponseWriter, r *http.Request) Response {
+	customerID := r.PathValue("customer_id")
+	balances, err := h.ledgerService.GetCustomerBalances(r.Context(), customerID)
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, "failed to get balances", nil, err)
+	}
+
+	apiResponse := customerBalancesApiResponse{
+		CustomerID: customerID,
+		Balances:   make([]balanceApiResponse, 0, len(balances)),
+	}
+	for _, b := range balances {
+		apiResponse.Balances = append(apiResponse.Balances, balanceApiResponse{
+			Balance:  json.Number(b.Balance.Decimal()),
+			Currency: b.Balance.Currency().String(),
+		})
+	}
+	return NewResponse(http.StatusOK, "balances fetched successfully", apiResponse, nil)
+}
+
+func (h *LedgerHandler) HandleListLedgerAccounts(_ http.ResponseWriter, r *http.Request) Response {
+	apiRequest := newListLedgerAccountsApiRequest(r.URL.Query())
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	page, err := h.ledgerService.ListAccounts(r.Context(), models.LedgerAccountFilter{
+		AccountType: apiRequest.AccountType,
+		Owner:       apiRequest.Owner,
+		Cursor:      apiRequest.Cursor,
+		Limit:       apiRequest.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return NewResponse(http.StatusBadRequest, "invalid cursor", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to list ledger accounts", nil, err)
+	}
+
+	apiResponse := listLedgerAccountsApiResponse{
+		Accounts:   make([]ledgerAccountApiResponse, 0, len(page.Accounts)),
+		NextCursor: page.NextCursor,
+	}
+	for _, a := range page.Accounts {
+		apiResponse.Accounts = append(apiResponse.Accounts, ledgerAccountApiResponse{
+			ID:          a.AccountID,
+			AccountType: a.AccountType,
+			Owner:       a.Owner,
+			Balance:     json.Number(a.Balance.Decimal()),
+			Currency:    a.Balance.Currency().String(),
+		})
+	}
+	return NewResponse(http.StatusOK, "ledger accounts listed successfully", apiResponse, nil)
+}
+
+// HandlePostFee posts a fee charged by a gateway, e.g. from its invoice.
+func (h *LedgerHandler) HandlePostFee(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Ledger fee request received", "method", r.Method, "url", r.URL.Path)
+
+	var apiRequest feeApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	entry, err := h.ledgerService.PostFee(r.Context(), models.FeeRequest{
+		Gateway:     apiRequest.Gateway,
+		Amount:      apiRequest.amount,
+		Description: apiRequest.Description,
+	})
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, "failed to post fee", nil, err)
+	}
+	return NewResponse(http.StatusCreated, "fee posted successfully", toJournalEntryApiResponse(entry), nil)
+}
+
+// HandlePostAdjustment moves money between an account and suspense.
+func (h *LedgerHandler) HandlePostAdjustment(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Ledger adjustment request received", "method", r.Method, "url", r.URL.Path)
+
+	var apiRequest adjustmentApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	entry, err := h.ledgerService.PostAdjustment(r.Context(), models.AdjustmentRequest{
+		AccountType: apiRequest.AccountType,
+		Owner:       apiRequest.Owner,
+		Amount:      apiRequest.amount,
+		Description: apiRequest.Description,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLedgerAccount) {
+			return NewResponse(http.StatusBadRequest, "invalid ledger account", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to post adjustment", nil, err)
+	}
+	return NewResponse(http.StatusCreated, "adjustment posted successfully", toJournalEntryApiResponse(entry), nil)
+}
+
+// HandleCheckLedger checks that the postings of the ledger sum to zero in every currency.
+func (h *LedgerHandler) HandleCheckLedger(_ http.ResponseWriter, r *http.Request) Response {
+	check, err := h.ledgerService.CheckLedger(r.Context())
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, "failed to check ledger", nil, err)
+	}
+
+	apiResponse := ledgerCheckApiResponse{
+		Balanced:          check.Balanced,
+		Totals:            make([]ledgerTotalApiResponse, 0, len(check.Totals)),
+		UnbalancedEntries: make([]unbalancedEntryApiResponse, 0, len(check.UnbalancedEntries)),
+	}
+	for _, t := range check.Totals {
+		apiResponse.Totals = append(apiResponse.Totals, ledgerTotalApiResponse{
+			Total:    json.Number(t.Total.Decimal()),
+			Currency: t.Total.Currency().String(),
+			Postings: t.Postings,
+		})
+	}
+	for _, e := range check.UnbalancedEntries {
+		apiResponse.UnbalancedEntries = append(apiResponse.UnbalancedEntries, unbalancedEntryApiResponse{
+			EntryID:  e.EntryID,
+			Total:    json.Number(e.Total.Decimal()),
+			Currency: e.Total.Currency().String(),
+		})
+	}
+	return NewResponse(http.StatusOK, "ledger checked successfully", apiResponse, nil)
+}
+
+func toJournalEntryApiResponse(e models.JournalEntry) journalEntryApiResponse {
+	res := journalEntryApiResponse{
+		ID:          e.ID,
+		Kind:        e.Kind,
+		Description: e.Description,
+		Postings:    make([]postingApiResponse, 0, len(e.Postings)),
+		CreatedAt:   e.CreatedAt,
+	}
+	for _, p := range e.Postings {
+		res.Postings = append(res.Postings, postingApiResponse{
+			AccountType: p.AccountType,
+			Owner:       p.Owner,
+			Amount:      json.Number(p.Amount.Decimal()),
+			Currency:    p.Amount.Currency().String(),
+		})
+	}
+	return res
+}
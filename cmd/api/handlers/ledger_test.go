@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLedgerService struct {
+	mock.Mock
+}
+
+func (m *MockLedgerService) GetCustomerBalances(ctx context.Context, customerID string) ([]models.LedgerBalance, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]models.LedgerBalance), args.Error(1)
+}
+
+func (m *MockLedgerService) ListAccounts(ctx context.Context, filter models.LedgerAccountFilter) (models.LedgerAccountPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(models.LedgerAccountPage), args.Error(1)
+}
+
+func (m *MockLedgerService) PostFee(ctx context.Context, req models.FeeRequest) (models.JournalEntry, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.JournalEntry), args.Error(1)
+}
+
+func (m *MockLedgerService) PostAdjustment(ctx context.Context, req models.AdjustmentRequest) (models.JournalEntry, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.JournalEntry), args.Error(1)
+}
+
+func (m *MockLedgerService) CheckLedger(ctx context.Context) (models.LedgerCheck, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(models.LedgerCheck), args.Error(1)
+}
+
+func TestHandleGetCustomerBalances(t *testing.T) {
+	mockService := new(MockLedgerService)
+	handler := NewLedgerHandler(mockService)
+	mockService.On("GetCustomerBalances", mock.Anything, "cust1").Return([]models.LedgerBalance{
+		{AccountID: 1, AccountType: "customer", Owner: "cust1", Balance: money.MustParse("90.50", "USD")},
+		{AccountID: 4, AccountType: "customer", Owner: "cust1", Balance: money.MustParse("-5", "JPY")},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/customers/cust1/balances", nil)
+	req.SetPathValue("customer_id", "cust1")
+	rr := httptest.NewRecorder()
+
+	res := handler.HandleGetCustomerBalances(rr, req)
+	writeResponse(rr, req, res)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"code":200,"message":"balances fetched successfully","data":{"customer_id":"cust1",
+		"balances":[{"balance":90.50,"currency":"USD"},{"balance":-5,"currency":"JPY"}]}}`, rr.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlePostFee(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockError      error
+		callPost       bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Posted",
+			body:           `{"gateway":"gatewayA","amount":1.25,"currency":"USD","description":"September invoice"}`,
+			callPost:       true,
+			expectedStatus: http.StatusCreated,
+			expectedBody: `{"code":201,"message":"fee posted successfully","data":{"id":7,"kind":"fee","description":"September invoice",
+				"postings":[{"account_type":"fees","owner":"gatewayA","amount":1.25,"currency":"USD"},
+					{"account_type":"gateway_clearing","owner":"gatewayA","amount":-1.25,"currency":"USD"}],
+				"created_at":"0001-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:           "Service error",
+			body:           `{"gateway":"gatewayA","amount":1.25,"currency":"USD"}`,
+			mockError:      errors.New("database error"),
+			callPost:       true,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"code":500,"message":"failed to post fee"}`,
+		},
+		{
+			name:           "Negative amount",
+			body:           `{"gateway":"gatewayA","amount":-1.25,"currency":"USD"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"amount","message":"must be greater than 0"}]}}`,
+		},
+		{
+			name:           "Too precise amount",
+			body:           `{"gateway":"gatewayA","amount":1.255,"currency":"USD"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"amount","message":"must have at most 2 decimal places for USD"}]}}`,
+		},
+		{
+			name:           "Missing gateway and currency",
+			body:           `{"amount":1.25}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"code":400,"message":"failed to validate request","data":{"errors":[
+				{"field":"gateway","message":"cannot be empty"},{"field":"currency","message":"must be 3 characters long"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLedgerService)
+			handler := NewLedgerHandler(mockService)
+			if tt.callPost {
+				fee := money.MustParse("1.25", "USD")
+				entry := models.JournalEntry{
+					ID:          7,
+					Kind:        "fee",
+					Description: "September invoice",
+					Postings: []models.LedgerPostingDetails{
+						{AccountType: "fees", Owner: "gatewayA", Amount: fee},
+						{AccountType: "gateway_clearing", Owner: "gatewayA", Amount: fee.Neg()},
+					},
+				}
+				matchesFee := mock.MatchedBy(func(req models.FeeRequest) bool {
+					return req.Gateway == "gatewayA" && req.Amount == fee
+				})
+				mockService.On("PostFee", mock.Anything, matchesFee).Return(entry, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/admin/ledger/fees", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			res := handler.HandlePostFee(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlePostAdjustment(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockError      error
+		callPost       bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Credit to a customer",
+			body:           `{"account_type":"customer","owner":"cust1","amount":-10,"currency":"USD"}`,
+			callPost:       true,
+			expectedStatus: http.StatusCreated,
+			expectedBody: `{"code":201,"message":"adjustment posted successfully","data":{"id":8,"kind":"adjustment","postings":[],
+				"created_at":"0001-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:           "Invalid account",
+			body:           `{"account_type":"customer","owner":"cust1","amount":-10,"currency":"USD"}`,
+			mockError:      service.ErrInvalidLedgerAccount,
+			callPost:       true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid ledger account"}`,
+		},
+		{
+			name:           "Suspense account",
+			body:           `{"account_type":"suspense","owner":"cust1","amount":10,"currency":"USD"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"code":400,"message":"failed to validate request","data":{"errors":[
+				{"field":"account_type","message":"must be customer, gateway_clearing or fees"}]}}`,
+		},
+		{
+			name:           "Zero amount",
+			body:           `{"account_type":"fees","owner":"gatewayA","amount":0,"currency":"USD"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"amount","message":"cannot be zero"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLedgerService)
+			handler := NewLedgerHandler(mockService)
+			if tt.callPost {
+				matchesAdjustment := mock.MatchedBy(func(req models.AdjustmentRequest) bool {
+					return req.AccountType == "customer" && req.Owner == "cust1" && req.Amount == money.MustParse("-10", "USD")
+				})
+				entry := models.JournalEntry{ID: 8, Kind: "adjustment"}
+				mockService.On("PostAdjustment", mock.Anything, matchesAdjustment).Return(entry, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/admin/ledger/adjustments", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			res := handler.HandlePostAdjustment(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleCheckLedger(t *testing.T) {
+	mockService := new(MockLedgerService)
+	handler := NewLedgerHandler(mockService)
+	mockService.On("CheckLedger", mock.Anything).Return(models.LedgerCheck{
+		Balanced: false,
+		Totals: []models.LedgerTotal{
+			{Total: money.MustParse("0", "EUR"), Postings: 4},
+			{Total: money.MustParse("0.01", "USD"), Postings: 9},
+		},
+		UnbalancedEntries: []models.UnbalancedLedgerEntry{{EntryID: 3, Total: money.MustParse("0.01", "USD")}},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/ledger/check", nil)
+	rr := httptest.NewRecorder()
+
+	res := handler.HandleCheckLedger(rr, req)
+	writeResponse(rr, req, res)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"code":200,"message":"ledger checked successfully","data":{"balanced":false,
+		"totals":[{"total":0.00,"currency":"EUR","postings":4},{"total":0.01,"currency":"USD","postings":9}],
+		"unbalanced_entries":[{"entry_id":3,"total":0.01,"currency":"USD"}]}}`, rr.Body.String())
+	mockService.AssertExpectations(t)
+}
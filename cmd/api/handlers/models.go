@@ -2,22 +2,54 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/money"
 	"github.com/rauf/payment-service/internal/validation"
 )
 
+// maxIdempotencyKeyLength is the size of the idempotency_key.key column.
+const maxIdempotencyKeyLength = 255
+
+// maxMerchantIDLength is the size of the transaction.merchant_id column.
+const maxMerchantIDLength = 100
+
+// maxCustomerIDLength is the size of the transaction.customer_id and destination_customer_id columns.
+const maxCustomerIDLength = 100
+
+// transactionTypeTransfer moves money between two customers without a gateway.
+const transactionTypeTransfer = "transfer"
+
 var (
 	allowedTransactionTypes = map[string]struct{}{
 		"deposit":    {},
 		"withdrawal": {},
+		"transfer":   {},
 	}
 	allowedTransactionStatuses = map[string]struct{}{
 		"pending": {},
 		"success": {},
 		"failed":  {},
 	}
+	// transactionStatusFilters are all statuses a transaction can be in, including the ones
+	// that are only reachable through the API.
+	transactionStatusFilters = map[string]struct{}{
+		"initiated":    {},
+		"needs_review": {},
+		"pending":      {},
+		"success":      {},
+		"failed":       {},
+		"authorized":   {},
+		"captured":     {},
+		"voided":       {},
+	}
 )
 
 type (
@@ -25,7 +57,7 @@ type (
 		validate() validation.Errors
 	}
 	transactionApiRequest struct {
-		Amount           float64         `json:"amount"`
+		Amount           json.Number     `json:"amount"`
 		Type             string          `json:"type"`
 		Currency         string          `json:"currency"`
 		PaymentMethod    string          `json:"payment_method"`
@@ -33,48 +65,285 @@ type (
 		CustomerID       string          `json:"customer_id"`
 		PreferredGateway string          `json:"preferred_gateway"`
 		Metadata         json.RawMessage `json:"metadata,omitempty"`
+		MerchantID       string          `json:"merchant_id,omitempty"`
+		// DestinationCustomerID is the customer a transfer is sent to.
+		DestinationCustomerID string `json:"destination_customer_id,omitempty"`
+		IdempotencyKey        string `json:"-"`
+		// amount is the parsed Amount, set by validate.
+		amount money.Money
 	}
 	transactionApiResponse struct {
-		RefID     string    `json:"ref_id"`
-		Status    string    `json:"status"`
+		Reference     string    `json:"reference,omitempty"`
+		RefID         string    `json:"ref_id"`
+		Status        string    `json:"status"`
+		DeclineReason string    `json:"decline_reason,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+		Gateway       string    `json:"gateway"`
+	}
+	transactionDetailsApiResponse struct {
+		Reference              string          `json:"reference"`
+		RefID                  string          `json:"ref_id"`
+		Gateway                string          `json:"gateway"`
+		Type                   string          `json:"type"`
+		Status                 string          `json:"status"`
+		GatewayStatusCode      string          `json:"gateway_status_code,omitempty"`
+		DeclineReason          string          `json:"decline_reason,omitempty"`
+		Amount                 json.Number     `json:"amount"`
+		CapturedAmount         json.Number     `json:"captured_amount,omitempty"`
+		RefundedAmount         json.Number     `json:"refunded_amount"`
+		Currency               string          `json:"currency"`
+		PaymentMethod          string          `json:"payment_method"`
+		Description            string          `json:"description,omitempty"`
+		CustomerID             string          `json:"customer_id"`
+		MerchantID             string          `json:"merchant_id,omitempty"`
+		DestinationCustomerID  string          `json:"destination_customer_id,omitempty"`
+		PreferredGateway       string          `json:"preferred_gateway,omitempty"`
+		RoutingRule            string          `json:"routing_rule,omitempty"`
+		ExpectedFee            json.Number     `json:"expected_fee,omitempty"`
+		Metadata               json.RawMessage `json:"metadata,omitempty"`
+		AuthorizationExpiresAt *time.Time      `json:"authorization_expires_at,omitempty"`
+		CreatedAt              time.Time       `json:"created_at"`
+		UpdatedAt              time.Time       `json:"updated_at"`
+	}
+	statusChangeApiResponse struct {
+		From      string    `json:"from"`
+		To        string    `json:"to"`
+		Source    string    `json:"source"`
 		CreatedAt time.Time `json:"created_at"`
-		Gateway   string    `json:"gateway"`
+	}
+	listTransactionsApiRequest struct {
+		CustomerID  string
+		Reference   string
+		Status      string
+		Gateway     string
+		Type        string
+		Currency    string
+		MinAmount   string
+		MaxAmount   string
+		CreatedFrom time.Time
+		CreatedTo   time.Time
+		Cursor      string
+		Limit       int
+		// parseErrs holds the query parameters that could not be parsed.
+		parseErrs validation.Errors
+	}
+	listTransactionsApiResponse struct {
+		Transactions []transactionDetailsApiResponse `json:"transactions"`
+		NextCursor   string                          `json:"next_cursor,omitempty"`
+	}
+	captureApiRequest struct {
+		Gateway string      `json:"gateway"`
+		Amount  json.Number `json:"amount,omitempty"`
+	}
+	voidApiRequest struct {
+		Gateway string `json:"gateway"`
+	}
+	refundApiRequest struct {
+		Gateway string      `json:"gateway"`
+		Amount  json.Number `json:"amount,omitempty"`
+		Reason  string      `json:"reason,omitempty"`
+	}
+	refundApiResponse struct {
+		ID               int32       `json:"id"`
+		RefID            string      `json:"ref_id"`
+		TransactionRefID string      `json:"transaction_ref_id"`
+		Amount           json.Number `json:"amount"`
+		Currency         string      `json:"currency"`
+		Status           string      `json:"status"`
+		CreatedAt        time.Time   `json:"created_at"`
+		Gateway          string      `json:"gateway"`
 	}
 	updateStatusApiRequest struct {
 		Gateway string `json:"gateway"`
 		Status  string `json:"status"`
 	}
-	gatewayACallbackRequest struct {
-		RefID     string    `json:"ref_id"`
-		Status    string    `json:"status"`
-		CreatedAt time.Time `json:"created_at"`
-	}
-	gatewayBCallbackRequest struct {
-		RefID     string    `xml:"ref_id"`
-		Status    string    `xml:"status"`
-		CreatedAt time.Time `xml:"created_at"`
+	resolveTransactionApiRequest struct {
+		Gateway       string `json:"gateway,omitempty"`
+		RefID         string `json:"ref_id,omitempty"`
+		Status        string `json:"status"`
+		DeclineReason string `json:"decline_reason,omitempty"`
 	}
 )
 
 func (d *transactionApiRequest) validate() validation.Errors {
 	var errors validation.Errors
-	if d.Amount <= 0 {
-		errors.Add("amount", "must be greater than 0")
+	currency, currencyErr := money.ParseCurrency(d.Currency)
+	if currencyErr != nil {
+		// The precision of the amount depends on the currency, so only its sign can be checked.
+		if f, err := d.Amount.Float64(); err != nil || f <= 0 {
+			errors.Add("amount", "must be greater than 0")
+		}
+	} else if msg := d.parseAmount(currency); msg != "" {
+		errors.Add("amount", msg)
 	}
 	if len(d.Currency) != 3 {
 		errors.Add("currency", "must be 3 characters long")
+	} else if currencyErr != nil {
+		errors.Add("currency", "not a supported ISO 4217 currency")
 	}
-	if d.PaymentMethod == "" {
+	transfer := strings.EqualFold(d.Type, transactionTypeTransfer)
+	// Transfers do not reach a gateway, so they need no payment method.
+	if d.PaymentMethod == "" && !transfer {
 		errors.Add("payment_method", "cannot be empty")
 	}
 	if d.CustomerID == "" {
 		errors.Add("customer_id", "cannot be empty")
+	} else if len(d.CustomerID) > maxCustomerIDLength {
+		errors.Add("customer_id", "must be at most 100 characters long")
 	}
 	if d.Type == "" {
 		errors.Add("type", "cannot be empty")
 	} else if _, ok := allowedTransactionTypes[strings.ToLower(d.Type)]; !ok {
 		errors.Add("type", "not valid transaction type")
 	}
+	switch {
+	case !transfer && d.DestinationCustomerID != "":
+		errors.Add("destination_customer_id", "only allowed for transfers")
+	case !transfer:
+	case d.DestinationCustomerID == "":
+		errors.Add("destination_customer_id", "cannot be empty")
+	case d.DestinationCustomerID == d.CustomerID:
+		errors.Add("destination_customer_id", "must differ from customer_id")
+	case len(d.DestinationCustomerID) > maxCustomerIDLength:
+		errors.Add("destination_customer_id", "must be at most 100 characters long")
+	}
+	if transfer && d.PreferredGateway != "" {
+		errors.Add("preferred_gateway", "not allowed for transfers")
+	}
+	if len(d.MerchantID) > maxMerchantIDLength {
+		errors.Add("merchant_id", "must be at most 100 characters long")
+	}
+	if len(d.IdempotencyKey) > maxIdempotencyKeyLength {
+		errors.Add("idempotency_key", "must be at most 255 characters long")
+	}
+	return errors
+}
+
+// parseAmount sets the parsed amount and returns the validation message of an invalid amount.
+func (d *transactionApiRequest) parseAmount(currency money.Currency) string {
+	amount, err := money.Parse(d.Amount.String(), currency.String())
+	switch {
+	case errors.Is(err, money.ErrTooPrecise):
+		return fmt.Sprintf("must have at most %d decimal places for %s", currency.Exponent(), currency)
+	case err != nil:
+		return "must be a decimal number"
+	case !amount.IsPositive():
+		return "must be greater than 0"
+	}
+	d.amount = amount
+	return ""
+}
+
+// newListTransactionsApiRequest reads the listing filters from the query string.
+func newListTransactionsApiRequest(query url.Values) listTransactionsApiRequest {
+	d := listTransactionsApiRequest{
+		CustomerID: query.Get("customer_id"),
+		Reference:  query.Get("reference"),
+		Status:     query.Get("status"),
+		Gateway:    query.Get("gateway"),
+		Type:       query.Get("type"),
+		Currency:   query.Get("currency"),
+		Cursor:     query.Get("cursor"),
+	}
+
+	parseAmount := func(field string, dst *string) {
+		if v := query.Get(field); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				d.parseErrs.Add(field, "must be a number")
+				return
+			}
+			*dst = v
+		}
+	}
+	parseTime := func(field string, dst *time.Time) {
+		if v := query.Get(field); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				d.parseErrs.Add(field, "must be an RFC 3339 timestamp")
+				return
+			}
+			*dst = t.UTC()
+		}
+	}
+	parseAmount("min_amount", &d.MinAmount)
+	parseAmount("max_amount", &d.MaxAmount)
+	parseTime("created_from", &d.CreatedFrom)
+	parseTime("created_to", &d.CreatedTo)
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			d.parseErrs.Add("limit", "must be an integer")
+		} else {
+			d.Limit = limit
+		}
+	}
+	return d
+}
+
+func (d *listTransactionsApiRequest) validate() validation.Errors {
+	errors := d.parseErrs
+	if d.Status != "" {
+		if _, ok := transactionStatusFilters[strings.ToLower(d.Status)]; !ok {
+			errors.Add("status", "not valid transaction status")
+		}
+	}
+	if d.Type != "" {
+		if _, ok := allowedTransactionTypes[strings.ToLower(d.Type)]; !ok {
+			errors.Add("type", "not valid transaction type")
+		}
+	}
+	if d.Currency != "" && len(d.Currency) != 3 {
+		errors.Add("currency", "must be 3 characters long")
+	}
+	// Unparsable amounts are already in parseErrs and left empty.
+	minAmount, _ := strconv.ParseFloat(d.MinAmount, 64)
+	maxAmount, _ := strconv.ParseFloat(d.MaxAmount, 64)
+	if minAmount < 0 {
+		errors.Add("min_amount", "cannot be negative")
+	}
+	if maxAmount < 0 {
+		errors.Add("max_amount", "cannot be negative")
+	}
+	if d.MinAmount != "" && d.MaxAmount != "" && minAmount > maxAmount {
+		errors.Add("max_amount", "must be greater than or equal to min_amount")
+	}
+	if !d.CreatedFrom.IsZero() && !d.CreatedTo.IsZero() && !d.CreatedFrom.Before(d.CreatedTo) {
+		errors.Add("created_to", "must be after created_from")
+	}
+	if d.Limit < 0 || d.Limit > 100 {
+		errors.Add("limit", "must be between 1 and 100")
+	}
+	return errors
+}
+
+func (d *captureApiRequest) validate() validation.Errors {
+	var errors validation.Errors
+	if d.Gateway == "" {
+		errors.Add("gateway", "cannot be empty")
+	}
+	if strings.HasPrefix(d.Amount.String(), "-") {
+		errors.Add("amount", "cannot be negative")
+	}
+	return errors
+}
+
+func (d *voidApiRequest) validate() validation.Errors {
+	var errors validation.Errors
+	if d.Gateway == "" {
+		errors.Add("gateway", "cannot be empty")
+	}
+	return errors
+}
+
+func (d *refundApiRequest) validate() validation.Errors {
+	var errors validation.Errors
+	if d.Gateway == "" {
+		errors.Add("gateway", "cannot be empty")
+	}
+	if strings.HasPrefix(d.Amount.String(), "-") {
+		errors.Add("amount", "cannot be negative")
+	}
 	return errors
 }
 
@@ -91,28 +360,490 @@ func (c *updateStatusApiRequest) validate() validation.Errors {
 	return errors
 }
 
-func (r gatewayACallbackRequest) validate() validation.Errors {
+// declineReasons are the decline reasons an operator can give a failed transaction.
+var declineReasons = map[string]struct{}{
+	gateway.DeclineReasonInsufficientFunds: {},
+	gateway.DeclineReasonDoNotHonor:        {},
+	gateway.DeclineReasonFraud:             {},
+	gateway.DeclineReasonExpiredCard:       {},
+	gateway.DeclineReasonTechnical:         {},
+}
+
+// validate requires the gateway and its reference of a transaction the gateway processed. A failed
+// transaction may never have reached a gateway.
+func (c *resolveTransactionApiRequest) validate() validation.Errors {
 	var errors validation.Errors
-	if r.RefID == "" {
-		errors.Add("ref_id", "cannot be empty")
-	}
-	if r.Status == "" {
+	status := strings.ToLower(c.Status)
+	if status == "" {
 		errors.Add("status", "cannot be empty")
-	} else if _, ok := allowedTransactionStatuses[strings.ToLower(r.Status)]; !ok {
+	} else if _, ok := allowedTransactionStatuses[status]; !ok {
 		errors.Add("status", "not valid transaction status")
 	}
+	if status == "failed" {
+		if _, ok := declineReasons[strings.ToLower(c.DeclineReason)]; c.DeclineReason != "" && !ok {
+			errors.Add("decline_reason", "not valid decline reason")
+		}
+		return errors
+	}
+	if c.Gateway == "" {
+		errors.Add("gateway", "cannot be empty")
+	}
+	if c.RefID == "" {
+		errors.Add("ref_id", "cannot be empty")
+	}
+	if c.DeclineReason != "" {
+		errors.Add("decline_reason", "only allowed for failed transactions")
+	}
 	return errors
 }
 
-func (r gatewayBCallbackRequest) validate() validation.Errors {
+var webhookDeliveryStatuses = map[string]struct{}{
+	"pending":   {},
+	"delivered": {},
+	"dead":      {},
+}
+
+type (
+	webhookEndpointApiRequest struct {
+		URL string `json:"url"`
+	}
+	webhookEndpointApiResponse struct {
+		ID        int32     `json:"id"`
+		URL       string    `json:"url"`
+		Secret    string    `json:"secret,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	listWebhookDeliveriesApiRequest struct {
+		Status string
+		Cursor string
+		Limit  int
+		// parseErrs holds the query parameters that could not be parsed.
+		parseErrs validation.Errors
+	}
+	webhookDeliveryApiResponse struct {
+		ID            int64     `json:"id"`
+		EndpointID    int32     `json:"endpoint_id"`
+		EventID       int64     `json:"event_id"`
+		EventType     string    `json:"event_type"`
+		Status        string    `json:"status"`
+		Attempts      int32     `json:"attempts"`
+		NextAttemptAt time.Time `json:"next_attempt_at"`
+		ResponseCode  int32     `json:"response_code,omitempty"`
+		LastError     string    `json:"last_error,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
+	}
+	listWebhookDeliveriesApiResponse struct {
+		Deliveries []webhookDeliveryApiResponse `json:"deliveries"`
+		NextCursor string                       `json:"next_cursor,omitempty"`
+	}
+)
+
+const callbackInboxStatusParked = "parked"
+
+var callbackInboxStatuses = map[string]struct{}{
+	"received":                {},
+	"applied":                 {},
+	callbackInboxStatusParked: {},
+	"rejected":                {},
+}
+
+type (
+	listCallbackInboxApiRequest struct {
+		Gateway string
+		RefID   string
+		Status  string
+		Cursor  string
+		Limit   int
+		// parseErrs holds the query parameters that could not be parsed.
+		parseErrs validation.Errors
+	}
+	callbackInboxEntryApiResponse struct {
+		ID                int64     `json:"id"`
+		Gateway           string    `json:"gateway"`
+		RefID             string    `json:"ref_id"`
+		DedupeKey         string    `json:"dedupe_key"`
+		Status            string    `json:"status"`
+		ReportedStatus    string    `json:"reported_status"`
+		GatewayStatusCode string    `json:"gateway_status_code,omitempty"`
+		DeclineReason     string    `json:"decline_reason,omitempty"`
+		RawPayload        string    `json:"raw_payload"`
+		Attempts          int32     `json:"attempts"`
+		LastError         string    `json:"last_error,omitempty"`
+		CreatedAt         time.Time `json:"created_at"`
+		UpdatedAt         time.Time `json:"updated_at"`
+	}
+	listCallbackInboxApiResponse struct {
+		Callbacks  []callbackInboxEntryApiResponse `json:"callbacks"`
+		NextCursor string                          `json:"next_cursor,omitempty"`
+	}
+)
+
+func (d *webhookEndpointApiRequest) validate() validation.Errors {
 	var errors validation.Errors
-	if r.RefID == "" {
-		errors.Add("ref_id", "cannot be empty")
+	u, err := url.Parse(d.URL)
+	switch {
+	case d.URL == "":
+		errors.Add("url", "cannot be empty")
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		errors.Add("url", "must be an absolute http or https URL")
 	}
-	if r.Status == "" {
-		errors.Add("status", "cannot be empty")
-	} else if _, ok := allowedTransactionStatuses[strings.ToLower(r.Status)]; !ok {
-		errors.Add("status", "not valid transaction status")
+	return errors
+}
+
+// newListWebhookDeliveriesApiRequest reads the delivery log filters from the query string.
+func newListWebhookDeliveriesApiRequest(query url.Values) listWebhookDeliveriesApiRequest {
+	d := listWebhookDeliveriesApiRequest{
+		Status: query.Get("status"),
+		Cursor: query.Get("cursor"),
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			d.parseErrs.Add("limit", "must be an integer")
+		} else {
+			d.Limit = limit
+		}
+	}
+	return d
+}
+
+func (d *listWebhookDeliveriesApiRequest) validate() validation.Errors {
+	errors := d.parseErrs
+	if d.Status != "" {
+		if _, ok := webhookDeliveryStatuses[strings.ToLower(d.Status)]; !ok {
+			errors.Add("status", "not valid delivery status")
+		}
+	}
+	if d.Limit < 0 || d.Limit > 100 {
+		errors.Add("limit", "must be between 1 and 100")
 	}
 	return errors
 }
+
+// newListCallbackInboxApiRequest reads the callback inbox filters from the query string.
+func newListCallbackInboxApiRequest(query url.Values) listCallbackInboxApiRequest {
+	d := listCallbackInboxApiRequest{
+		Gateway: query.Get("gateway"),
+		RefID:   query.Get("ref_id"),
+		Status:  query.Get("status"),
+		Cursor:  query.Get("cursor"),
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			d.parseErrs.Add("limit", "must be an integer")
+		} else {
+			d.Limit = limit
+		}
+	}
+	return d
+}
+
+func (d *listCallbackInboxApiRequest) validate() validation.Errors {
+	errors := d.parseErrs
+	if d.Status != "" {
+		if _, ok := callbackInboxStatuses[strings.ToLower(d.Status)]; !ok {
+			errors.Add("status", "not valid callback status")
+		}
+	}
+	if d.Limit < 0 || d.Limit > 100 {
+		errors.Add("limit", "must be between 1 and 100")
+	}
+	return errors
+}
+
+type (
+	createReconciliationApiRequest struct {
+		Gateway    string
+		PeriodFrom time.Time
+		PeriodTo   time.Time
+		// parseErrs holds the query parameters that could not be parsed.
+		parseErrs validation.Errors
+	}
+	listReconciliationsApiRequest struct {
+		Gateway string
+		Cursor  string
+		Limit   int
+		// parseErrs holds the query parameters that could not be parsed.
+		parseErrs validation.Errors
+	}
+	reconciliationApiResponse struct {
+		ID               int64                                  `json:"id"`
+		Gateway          string                                 `json:"gateway"`
+		PeriodFrom       time.Time                              `json:"period_from"`
+		PeriodTo         time.Time                              `json:"period_to"`
+		Records          int32                                  `json:"records"`
+		Matched          int32                                  `json:"matched"`
+		DiscrepancyCount int32                                  `json:"discrepancy_count"`
+		Discrepancies    []reconciliationDiscrepancyApiResponse `json:"discrepancies,omitempty"`
+		CreatedAt        time.Time                              `json:"created_at"`
+	}
+	reconciliationDiscrepancyApiResponse struct {
+		Kind             string      `json:"kind"`
+		RefID            string      `json:"ref_id"`
+		RecordedAmount   json.Number `json:"recorded_amount,omitempty"`
+		RecordedCurrency string      `json:"recorded_currency,omitempty"`
+		RecordedStatus   string      `json:"recorded_status,omitempty"`
+		SettledAmount    json.Number `json:"settled_amount,omitempty"`
+		SettledCurrency  string      `json:"settled_currency,omitempty"`
+		SettledStatus    string      `json:"settled_status,omitempty"`
+	}
+	listReconciliationsApiResponse struct {
+		Reconciliations []reconciliationApiResponse `json:"reconciliations"`
+		NextCursor      string                      `json:"next_cursor,omitempty"`
+	}
+)
+
+// newCreateReconciliationApiRequest reads the gateway and the settlement period from the query string, as the
+// body is the settlement report itself.
+func newCreateReconciliationApiRequest(query url.Values) createReconciliationApiRequest {
+	d := createReconciliationApiRequest{
+		Gateway: query.Get("gateway"),
+	}
+	// Both bounds are required, so a missing one is reported with the unparsable ones.
+	parseTime := func(field string, dst *time.Time) {
+		v := query.Get(field)
+		if v == "" {
+			d.parseErrs.Add(field, "cannot be empty")
+			return
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			d.parseErrs.Add(field, "must be an RFC 3339 timestamp")
+			return
+		}
+		*dst = t.UTC()
+	}
+	parseTime("from", &d.PeriodFrom)
+	parseTime("to", &d.PeriodTo)
+	return d
+}
+
+func (d *createReconciliationApiRequest) validate() validation.Errors {
+	errors := d.parseErrs
+	if d.Gateway == "" {
+		errors.Add("gateway", "cannot be empty")
+	}
+	if !d.PeriodFrom.IsZero() && !d.PeriodTo.IsZero() && !d.PeriodFrom.Before(d.PeriodTo) {
+		errors.Add("to", "must be after from")
+	}
+	return errors
+}
+
+// newListReconciliationsApiRequest reads the reconciliation run filters from the query string.
+func newListReconciliationsApiRequest(query url.Values) listReconciliationsApiRequest {
+	d := listReconciliationsApiRequest{
+		Gateway: query.Get("gateway"),
+		Cursor:  query.Get("cursor"),
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			d.parseErrs.Add("limit", "must be an integer")
+		} else {
+			d.Limit = limit
+		}
+	}
+	return d
+}
+
+func (d *listReconciliationsApiRequest) validate() validation.Errors {
+	errors := d.parseErrs
+	if d.Limit < 0 || d.Limit > 100 {
+		errors.Add("limit", "must be between 1 and 100")
+	}
+	return errors
+}
+
+// adjustableLedgerAccounts are the account types adjustments can move money to or from, against suspense.
+var adjustableLedgerAccounts = map[string]struct{}{
+	"customer":         {},
+	"gateway_clearing": {},
+	"fees":             {},
+}
+
+// ledgerAccountTypes are all ledger account types.
+var ledgerAccountTypes = map[string]struct{}{
+	"customer":         {},
+	"gateway_clearing": {},
+	"fees":             {},
+	"suspense":         {},
+}
+
+type (
+	balanceApiResponse struct {
+		Balance  json.Number `json:"balance"`
+		Currency string      `json:"currency"`
+	}
+	customerBalancesApiResponse struct {
+		CustomerID string               `json:"customer_id"`
+		Balances   []balanceApiResponse `json:"balances"`
+	}
+	listLedgerAccountsApiRequest struct {
+		AccountType string
+		Owner       string
+		Cursor      string
+		Limit       int
+		// parseErrs holds the query parameters that could not be parsed.
+		parseErrs validation.Errors
+	}
+	ledgerAccountApiResponse struct {
+		ID          int64       `json:"id"`
+		AccountType string      `json:"account_type"`
+		Owner       string      `json:"owner,omitempty"`
+		Balance     json.Number `json:"balance"`
+		Currency    string      `json:"currency"`
+	}
+	listLedgerAccountsApiResponse struct {
+		Accounts   []ledgerAccountApiResponse `json:"accounts"`
+		NextCursor string                     `json:"next_cursor,omitempty"`
+	}
+	feeApiRequest struct {
+		Gateway     string      `json:"gateway"`
+		Amount      json.Number `json:"amount"`
+		Currency    string      `json:"currency"`
+		Description string      `json:"description,omitempty"`
+		// amount is the parsed Amount, set by validate.
+		amount money.Money
+	}
+	adjustmentApiRequest struct {
+		AccountType string      `json:"account_type"`
+		Owner       string      `json:"owner"`
+		Amount      json.Number `json:"amount"`
+		Currency    string      `json:"currency"`
+		Description string      `json:"description,omitempty"`
+		// amount is the parsed Amount, set by validate.
+		amount money.Money
+	}
+	journalEntryApiResponse struct {
+		ID          int64                `json:"id"`
+		Kind        string               `json:"kind"`
+		Description string               `json:"description,omitempty"`
+		Postings    []postingApiResponse `json:"postings"`
+		CreatedAt   time.Time            `json:"created_at"`
+	}
+	postingApiResponse struct {
+		AccountType string      `json:"account_type"`
+		Owner       string      `json:"owner,omitempty"`
+		Amount      json.Number `json:"amount"`
+		Currency    string      `json:"currency"`
+	}
+	ledgerCheckApiResponse struct {
+		Balanced          bool                         `json:"balanced"`
+		Totals            []ledgerTotalApiResponse     `json:"totals"`
+		UnbalancedEntries []unbalancedEntryApiResponse `json:"unbalanced_entries"`
+	}
+	ledgerTotalApiResponse struct {
+		Total    json.Number `json:"total"`
+		Currency string      `json:"currency"`
+		Postings int64       `json:"postings"`
+	}
+	unbalancedEntryApiResponse struct {
+		EntryID  int64       `json:"entry_id"`
+		Total    json.Number `json:"total"`
+		Currency string      `json:"currency"`
+	}
+)
+
+// newListLedgerAccountsApiRequest reads the ledger account filters from the query string.
+func newListLedgerAccountsApiRequest(query url.Values) listLedgerAccountsApiRequest {
+	d := listLedgerAccountsApiRequest{
+		AccountType: query.Get("account_type"),
+		Owner:       query.Get("owner"),
+		Cursor:      query.Get("cursor"),
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			d.parseErrs.Add("limit", "must be an integer")
+		} else {
+			d.Limit = limit
+		}
+	}
+	return d
+}
+
+func (d *listLedgerAccountsApiRequest) validate() validation.Errors {
+	errors := d.parseErrs
+	if d.AccountType != "" {
+		if _, ok := ledgerAccountTypes[strings.ToLower(d.AccountType)]; !ok {
+			errors.Add("account_type", "not valid ledger account type")
+		}
+	}
+	if d.Limit < 0 || d.Limit > 100 {
+		errors.Add("limit", "must be between 1 and 100")
+	}
+	return errors
+}
+
+func (d *feeApiRequest) validate() validation.Errors {
+	var errors validation.Errors
+	if d.Gateway == "" {
+		errors.Add("gateway", "cannot be empty")
+	}
+	d.amount = parseLedgerAmount(&errors, d.Amount, d.Currency)
+	if d.amount.IsNegative() {
+		errors.Add("amount", "must be greater than 0")
+	}
+	return errors
+}
+
+func (d *adjustmentApiRequest) validate() validation.Errors {
+	var errors validation.Errors
+	if _, ok := adjustableLedgerAccounts[strings.ToLower(d.AccountType)]; !ok {
+		errors.Add("account_type", "must be customer, gateway_clearing or fees")
+	}
+	if d.Owner == "" {
+		errors.Add("owner", "cannot be empty")
+	}
+	d.amount = parseLedgerAmount(&errors, d.Amount, d.Currency)
+	return errors
+}
+
+// parseLedgerAmount parses a non-zero, possibly negative, amount of a ledger entry and adds the validation
+// messages of an invalid amount or currency to errs.
+func parseLedgerAmount(errs *validation.Errors, amount json.Number, currency string) money.Money {
+	c, err := money.ParseCurrency(currency)
+	if err != nil {
+		if len(currency) != 3 {
+			errs.Add("currency", "must be 3 characters long")
+		} else {
+			errs.Add("currency", "not a supported ISO 4217 currency")
+		}
+		return money.Money{}
+	}
+	m, err := money.Parse(amount.String(), c.String())
+	switch {
+	case errors.Is(err, money.ErrTooPrecise):
+		errs.Add("amount", fmt.Sprintf("must have at most %d decimal places for %s", c.Exponent(), c))
+	case err != nil:
+		errs.Add("amount", "must be a decimal number")
+	case m.IsZero():
+		errs.Add("amount", "cannot be zero")
+	}
+	return m
+}
+
+type (
+	routingDecisionApiResponse struct {
+		// Rule is the routing rule that matched, empty if none did.
+		Rule     string                     `json:"rule,omitempty"`
+		Gateways []routedGatewayApiResponse `json:"gateways"`
+	}
+	routedGatewayApiResponse struct {
+		Name    string `json:"name"`
+		Circuit string `json:"circuit"`
+	}
+	routingRuleApiResponse struct {
+		Name     string         `json:"name"`
+		Gateways []string       `json:"gateways,omitempty"`
+		Weights  map[string]int `json:"weights,omitempty"`
+	}
+	listRoutingRulesApiResponse struct {
+		Rules []routingRuleApiResponse `json:"rules"`
+	}
+	routingWeightsApiRequest struct {
+		Weights map[string]int `json:"weights"`
+	}
+)
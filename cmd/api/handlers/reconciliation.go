@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/service"
+)
+
+// maxSettlementReportBytes bounds the settlement reports uploaded for reconciliation.
+const maxSettlementReportBytes = 10 << 20
+
+// ReconciliationHandler reconciles gateway settlement reports and lists the reconciliation runs.
+type ReconciliationHandler struct {
+	reconciliationService reconciliationService
+}
+
+// interface on consumer side
+type reconciliationService interface {
+	Reconcile(ctx context.Context, req models.ReconcileRequest) (models.Reconciliation, error)
+	GetReconciliation(ctx context.Context, id int64) (models.Reconciliation, error)
+	ListReconciliations(ctx context.Context, filter models.ReconciliationFilter) (models.ReconciliationPage, error)
+}
+
+func NewReconciliationHandler(reconciliationService reconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// HandleCreateReconciliation reconciles the settlement report in the body, in the format of the gateway, with
+// the transactions of the gateway created in the period.
+func (h *ReconciliationHandler) HandleCreateReconciliation(w http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Reconciliation request received", "method", r.Method, "url", r.URL.Path)
+
+	apiRequest := newCreateReconciliationApiRequest(r.URL.Query())
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	reconciliation, err := h.reconciliationService.Reconcile(r.Context(), models.ReconcileRequest{
+		Gateway:    apiRequest.Gateway,
+		PeriodFrom: apiRequest.PeriodFrom,
+		PeriodTo:   apiRequest.PeriodTo,
+		Report:     http.MaxBytesReader(w, r.Body, maxSettlementReportBytes),
+	})
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, service.ErrNoSettlementParser):
+			return NewResponse(http.StatusNotFound, "gateway has no settlement report format", nil, err)
+		case errors.As(err, &maxBytesErr):
+			return NewResponse(http.StatusRequestEntityTooLarge, "settlement report too large", nil, err)
+		case errors.Is(err, service.ErrInvalidSettlementReport):
+			return NewResponse(http.StatusBadRequest, "invalid settlement report", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to reconcile", nil, err)
+	}
+	return NewResponse(http.StatusCreated, "reconciliation created successfully", toReconciliationApiResponse(reconciliation), nil)
+}
+
+func (h *ReconciliationHandler) HandleListReconciliations(_ http.ResponseWriter, r *http.Request) Response {
+	apiRequest := newListReconciliationsApiRequest(r.URL.Query())
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	page, err := h.reconciliationService.ListReconciliations(r.Context(), models.ReconciliationFilter{
+		Gateway: apiRequest.Gateway,
+		Cursor:  apiRequest.Cursor,
+		Limit:   apiRequest.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return NewResponse(http.StatusBadRequest, "invalid cursor", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to list reconciliations", nil, err)
+	}
+
+	apiResponse := listReconciliationsApiResponse{
+		Reconciliations: make([]reconciliationApiResponse, 0, len(page.Reconciliations)),
+		NextCursor:      page.NextCursor,
+	}
+	for _, reconciliation := range page.Reconciliations {
+		apiResponse.Reconciliations = append(apiResponse.Reconciliations, toReconciliationApiResponse(reconciliation))
+	}
+	return NewResponse(http.StatusOK, "reconciliations listed successfully", apiResponse, nil)
+}
+
+func (h *ReconciliationHandler) HandleGetReconciliation(_ http.ResponseWriter, r *http.Request) Response {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, "invalid reconciliation ID", nil, err)
+	}
+
+	reconciliation, err := h.reconciliationService.GetReconciliation(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrReconciliationNotFound) {
+			return NewResponse(http.StatusNotFound, "reconciliation not found", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to get reconciliation", nil, err)
+	}
+	return NewResponse(http.StatusOK, "reconciliation fetched successfully", toReconciliationApiResponse(reconciliation), nil)
+}
+
+func toReconciliationApiResponse(r models.Reconciliation) reconciliationApiResponse {
+	res := reconciliationApiResponse{
+		ID:               r.ID,
+		Gateway:          r.Gateway,
+		PeriodFrom:       r.PeriodFrom,
+		PeriodTo:         r.PeriodTo,
+		Records:          r.Records,
+		Matched:          r.Matched,
+		DiscrepancyCount: r.DiscrepancyCount,
+		CreatedAt:        r.CreatedAt,
+	}
+	for _, d := range r.Discrepancies {
+		discrepancy := reconciliationDiscrepancyApiResponse{
+			Kind:           d.Kind,
+			RefID:          d.RefID,
+			RecordedStatus: d.RecordedStatus,
+			SettledStatus:  d.SettledStatus,
+		}
+		// The zero amount is the side of a missing or extra transaction that has no record.
+		if d.RecordedAmount != (money.Money{}) {
+			discrepancy.RecordedAmount = json.Number(d.RecordedAmount.Decimal())
+			discrepancy.RecordedCurrency = d.RecordedAmount.Currency().String()
+		}
+		if d.SettledAmount != (money.Money{}) {
+			discrepancy.SettledAmount = json.Number(d.SettledAmount.Decimal())
+			discrepancy.SettledCurrency = d.SettledAmount.Currency().String()
+		}
+		res.Discrepancies = append(res.Discrepancies, discrepancy)
+	}
+	return res
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReconciliationService struct {
+	mock.Mock
+}
+
+func (m *MockReconciliationService) Reconcile(ctx context.Context, req models.ReconcileRequest) (models.Reconciliation, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.Reconciliation), args.Error(1)
+}
+
+func (m *MockReconciliationService) GetReconciliation(ctx context.Context, id int64) (models.Reconciliation, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Reconciliation), args.Error(1)
+}
+
+func (m *MockReconciliationService) ListReconciliations(ctx context.Context, filter models.ReconciliationFilter) (models.ReconciliationPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(models.ReconciliationPage), args.Error(1)
+}
+
+func TestHandleCreateReconciliation(t *testing.T) {
+	from := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	reconciliation := models.Reconciliation{
+		ID:               3,
+		Gateway:          "gatewayA",
+		PeriodFrom:       from,
+		PeriodTo:         to,
+		Records:          2,
+		Matched:          1,
+		DiscrepancyCount: 2,
+		Discrepancies: []models.ReconciliationDiscrepancyDetails{
+			{Kind: "amount_mismatch", RefID: "ref1", RecordedAmount: money.MustParse("10.00", "USD"), RecordedStatus: "success", SettledAmount: money.MustParse("9.50", "USD"), SettledStatus: "settled"},
+			{Kind: "missing", RefID: "ref2", RecordedAmount: money.MustParse("5.00", "USD"), RecordedStatus: "success"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockResult     models.Reconciliation
+		mockError      error
+		callReconcile  bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Reconciled",
+			query:          "gateway=gatewayA&from=2024-09-01T00:00:00Z&to=2024-09-02T00:00:00Z",
+			mockResult:     reconciliation,
+			callReconcile:  true,
+			expectedStatus: http.StatusCreated,
+			expectedBody: `{"code":201,"message":"reconciliation created successfully","data":{"id":3,"gateway":"gatewayA",
+				"period_from":"2024-09-01T00:00:00Z","period_to":"2024-09-02T00:00:00Z","records":2,"matched":1,"discrepancy_count":2,
+				"discrepancies":[
+					{"kind":"amount_mismatch","ref_id":"ref1","recorded_amount":10.00,"recorded_currency":"USD","recorded_status":"success",
+						"settled_amount":9.50,"settled_currency":"USD","settled_status":"settled"},
+					{"kind":"missing","ref_id":"ref2","recorded_amount":5.00,"recorded_currency":"USD","recorded_status":"success"}
+				],
+				"created_at":"0001-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:           "Invalid report",
+			query:          "gateway=gatewayA&from=2024-09-01T00:00:00Z&to=2024-09-02T00:00:00Z",
+			mockError:      service.ErrInvalidSettlementReport,
+			callReconcile:  true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid settlement report"}`,
+		},
+		{
+			name:           "Gateway without report format",
+			query:          "gateway=gatewayC&from=2024-09-01T00:00:00Z&to=2024-09-02T00:00:00Z",
+			mockError:      service.ErrNoSettlementParser,
+			callReconcile:  true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":404,"message":"gateway has no settlement report format"}`,
+		},
+		{
+			name:           "Service error",
+			query:          "gateway=gatewayA&from=2024-09-01T00:00:00Z&to=2024-09-02T00:00:00Z",
+			mockError:      errors.New("database error"),
+			callReconcile:  true,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"code":500,"message":"failed to reconcile"}`,
+		},
+		{
+			name:           "Missing parameters",
+			query:          "from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"code":400,"message":"failed to validate request","data":{"errors":[
+				{"field":"from","message":"must be an RFC 3339 timestamp"},
+				{"field":"to","message":"cannot be empty"},
+				{"field":"gateway","message":"cannot be empty"}]}}`,
+		},
+		{
+			name:           "Period ends before it starts",
+			query:          "gateway=gatewayA&from=2024-09-02T00:00:00Z&to=2024-09-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"to","message":"must be after from"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockReconciliationService)
+			handler := NewReconciliationHandler(mockService)
+			if tt.callReconcile {
+				matchesRequest := mock.MatchedBy(func(req models.ReconcileRequest) bool {
+					report, err := io.ReadAll(req.Report)
+					return err == nil && string(report) == "ref_id,amount,currency,status\n" && req.PeriodFrom.Equal(from) && req.PeriodTo.Equal(to)
+				})
+				mockService.On("Reconcile", mock.Anything, matchesRequest).Return(tt.mockResult, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/admin/reconciliations?"+tt.query, strings.NewReader("ref_id,amount,currency,status\n"))
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleCreateReconciliation(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetReconciliation(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockResult     models.Reconciliation
+		mockError      error
+		callGet        bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Found",
+			id:   "3",
+			mockResult: models.Reconciliation{
+				ID:               3,
+				Gateway:          "gatewayB",
+				Records:          1,
+				DiscrepancyCount: 1,
+				Discrepancies: []models.ReconciliationDiscrepancyDetails{
+					{Kind: "extra", RefID: "ref9", SettledAmount: money.MustParse("1.00", "EUR"), SettledStatus: "settled"},
+				},
+			},
+			callGet:        true,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"code":200,"message":"reconciliation fetched successfully","data":{"id":3,"gateway":"gatewayB",
+				"period_from":"0001-01-01T00:00:00Z","period_to":"0001-01-01T00:00:00Z","records":1,"matched":0,"discrepancy_count":1,
+				"discrepancies":[{"kind":"extra","ref_id":"ref9","settled_amount":1.00,"settled_currency":"EUR","settled_status":"settled"}],
+				"created_at":"0001-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:           "Not found",
+			id:             "4",
+			mockError:      service.ErrReconciliationNotFound,
+			callGet:        true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":404,"message":"reconciliation not found"}`,
+		},
+		{
+			name:           "Invalid ID",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid reconciliation ID"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockReconciliationService)
+			handler := NewReconciliationHandler(mockService)
+			if tt.callGet {
+				mockService.On("GetReconciliation", mock.Anything, mock.AnythingOfType("int64")).Return(tt.mockResult, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("GET", "/api/v1/admin/reconciliations/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleGetReconciliation(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
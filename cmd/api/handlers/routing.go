@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/serde"
+	"github.com/rauf/payment-service/internal/service"
+)
+
+// RoutingHandler shows how transactions are routed to the gateways and changes the weights of the routing
+// rules.
+type RoutingHandler struct {
+	routingService routingService
+	jsonSerde      serde.Serde
+}
+
+// interface on consumer side
+type routingService interface {
+	DryRunRouting(ctx context.Context, transaction models.TransactionRequest) (models.RoutingDecision, error)
+	ListRoutingRules(ctx context.Context) []models.RoutingRule
+	SetRoutingWeights(ctx context.Context, rule string, weights map[string]int) error
+}
+
+func NewRoutingHandler(routingService routingService) *RoutingHandler {
+	return &RoutingHandler{
+		routingService: routingService,
+		jsonSerde:      serde.NewJSONSerde(),
+	}
+}
+
+// HandleDryRunRouting returns the gateways a transaction request would be sent to, in order, and the routing
+// rule that picked them. Nothing is stored or sent.
+func (h *RoutingHandler) HandleDryRunRouting(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Routing dry run request received", "method", r.Method, "url", r.URL.Path)
+
+	var apiRequest transactionApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+	validationErrs := apiRequest.validate()
+	if strings.EqualFold(apiRequest.Type, transactionTypeTransfer) {
+		validationErrs.Add("type", "transfers are not routed to gateways")
+	}
+	if !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	decision, err := h.routingService.DryRunRouting(r.Context(), models.TransactionRequest{
+		Type:             apiRequest.Type,
+		Amount:           apiRequest.amount,
+		PaymentMethod:    apiRequest.PaymentMethod,
+		Description:      apiRequest.Description,
+		CustomerID:       apiRequest.CustomerID,
+		PreferredGateway: apiRequest.PreferredGateway,
+		Metadata:         apiRequest.Metadata,
+		MerchantID:       apiRequest.MerchantID,
+	})
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, "failed to route transaction", nil, err)
+	}
+
+	apiResponse := routingDecisionApiResponse{
+		Rule:     decision.Rule,
+		Gateways: make([]routedGatewayApiResponse, 0, len(decision.Gateways)),
+	}
+	for _, g := range decision.Gateways {
+		apiResponse.Gateways = append(apiResponse.Gateways, routedGatewayApiResponse{
+			Name:    g.Name,
+			Circuit: g.Circuit,
+		})
+	}
+	return NewResponse(http.StatusOK, "transaction routed successfully", apiResponse, nil)
+}
+
+func (h *RoutingHandler) HandleListRoutingRules(_ http.ResponseWriter, r *http.Request) Response {
+	rules := h.routingService.ListRoutingRules(r.Context())
+	apiResponse := listRoutingRulesApiResponse{
+		Rules: make([]routingRuleApiResponse, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		apiResponse.Rules = append(apiResponse.Rules, routingRuleApiResponse{
+			Name:     rule.Name,
+			Gateways: rule.Gateways,
+			Weights:  rule.Weights,
+		})
+	}
+	return NewResponse(http.StatusOK, "routing rules listed successfully", apiResponse, nil)
+}
+
+// HandleSetRoutingWeights changes how the traffic of a routing rule is split between its gateways. Empty
+// weights stop the split.
+func (h *RoutingHandler) HandleSetRoutingWeights(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Routing weights request received", "method", r.Method, "url", r.URL.Path)
+
+	rule := r.PathValue("name")
+	var apiRequest routingWeightsApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+
+	if err := h.routingService.SetRoutingWeights(r.Context(), rule, apiRequest.Weights); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRoutingRuleNotFound):
+			return NewResponse(http.StatusNotFound, "routing rule not found", nil, err)
+		case errors.Is(err, service.ErrInvalidRoutingWeights):
+			return NewResponse(http.StatusBadRequest, "invalid routing weights", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to set routing weights", nil, err)
+	}
+	return NewResponse(http.StatusOK, "routing weights updated successfully", routingRuleApiResponse{
+		Name:    rule,
+		Weights: apiRequest.Weights,
+	}, nil)
+}
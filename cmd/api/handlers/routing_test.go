@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRoutingService struct {
+	mock.Mock
+}
+
+func (m *MockRoutingService) DryRunRouting(ctx context.Context, transaction models.TransactionRequest) (models.RoutingDecision, error) {
+	args := m.Called(ctx, transaction)
+	return args.Get(0).(models.RoutingDecision), args.Error(1)
+}
+
+func (m *MockRoutingService) ListRoutingRules(ctx context.Context) []models.RoutingRule {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.RoutingRule)
+}
+
+func (m *MockRoutingService) SetRoutingWeights(ctx context.Context, rule string, weights map[string]int) error {
+	args := m.Called(ctx, rule, weights)
+	return args.Error(0)
+}
+
+func TestHandleDryRunRouting(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockError      error
+		callDryRun     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Matched rule",
+			body:           `{"amount":1500,"type":"deposit","currency":"USD","payment_method":"card","customer_id":"cust1"}`,
+			callDryRun:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"code":200,"message":"transaction routed successfully","data":{"rule":"large-usd-cards",
+				"gateways":[{"name":"gatewayB","circuit":"open"},{"name":"gatewayA","circuit":"closed"}]}}`,
+		},
+		{
+			name:           "Routing error",
+			body:           `{"amount":1500,"type":"deposit","currency":"USD","payment_method":"card","customer_id":"cust1"}`,
+			mockError:      errors.New("no gateways"),
+			callDryRun:     true,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"code":500,"message":"failed to route transaction"}`,
+		},
+		{
+			name:           "Transfer",
+			body:           `{"amount":1500,"type":"transfer","currency":"USD","customer_id":"cust1","destination_customer_id":"cust2"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"type","message":"transfers are not routed to gateways"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRoutingService)
+			handler := NewRoutingHandler(mockService)
+			if tt.callDryRun {
+				matchesRequest := mock.MatchedBy(func(req models.TransactionRequest) bool {
+					return req.Amount == money.MustParse("1500", "USD") && req.PaymentMethod == "card"
+				})
+				decision := models.RoutingDecision{
+					Rule: "large-usd-cards",
+					Gateways: []models.RoutedGateway{
+						{Name: "gatewayB", Circuit: "open"},
+						{Name: "gatewayA", Circuit: "closed"},
+					},
+				}
+				mockService.On("DryRunRouting", mock.Anything, matchesRequest).Return(decision, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/admin/routing/dry-run", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleDryRunRouting(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleListRoutingRules(t *testing.T) {
+	mockService := new(MockRoutingService)
+	handler := NewRoutingHandler(mockService)
+	mockService.On("ListRoutingRules", mock.Anything).Return([]models.RoutingRule{
+		{Name: "usd-cards", Gateways: []string{"gatewayA", "gatewayB"}, Weights: map[string]int{"gatewayA": 80, "gatewayB": 20}},
+		{Name: "vip", Gateways: []string{"gatewayB"}},
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/routing/rules", nil)
+	rr := httptest.NewRecorder()
+
+	res := handler.HandleListRoutingRules(rr, req)
+	writeResponse(rr, req, res)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"code":200,"message":"routing rules listed successfully","data":{"rules":[
+		{"name":"usd-cards","gateways":["gatewayA","gatewayB"],"weights":{"gatewayA":80,"gatewayB":20}},
+		{"name":"vip","gateways":["gatewayB"]}]}}`, rr.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleSetRoutingWeights(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockError      error
+		callSet        bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Updated",
+			body:           `{"weights":{"gatewayA":80,"gatewayB":20}}`,
+			callSet:        true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"routing weights updated successfully","data":{"name":"usd-cards","weights":{"gatewayA":80,"gatewayB":20}}}`,
+		},
+		{
+			name:           "Unknown rule",
+			body:           `{"weights":{"gatewayA":80,"gatewayB":20}}`,
+			mockError:      service.ErrRoutingRuleNotFound,
+			callSet:        true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":404,"message":"routing rule not found"}`,
+		},
+		{
+			name:           "Invalid weights",
+			body:           `{"weights":{"gatewayA":80,"gatewayB":20}}`,
+			mockError:      service.ErrInvalidRoutingWeights,
+			callSet:        true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid routing weights"}`,
+		},
+		{
+			name:           "Malformed body",
+			body:           `{"weights":{"gatewayA":"80"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to decode request"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRoutingService)
+			handler := NewRoutingHandler(mockService)
+			if tt.callSet {
+				weights := map[string]int{"gatewayA": 80, "gatewayB": 20}
+				mockService.On("SetRoutingWeights", mock.Anything, "usd-cards", weights).Return(tt.mockError)
+			}
+
+			req, _ := http.NewRequest("PUT", "/api/v1/admin/routing/rules/usd-cards/weights", strings.NewReader(tt.body))
+			req.SetPathValue("name", "usd-cards")
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleSetRoutingWeights(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
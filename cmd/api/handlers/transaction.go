@@ -1,36 +1,51 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 
-	"github.com/rauf/payment-service/internal/consts"
 	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
 	"github.com/rauf/payment-service/internal/serde"
 	"github.com/rauf/payment-service/internal/service"
+	"github.com/rauf/payment-service/internal/validation"
 )
 
+// idempotencyKeyHeader is the request header that makes transaction creation safe to retry.
+const idempotencyKeyHeader = "Idempotency-Key"
+
 // PaymentHandler is a struct that handles payment transactions
 type PaymentHandler struct {
 	paymentService paymentService
 	jsonSerde      serde.Serde
-	xmlSerde       serde.Serde
 }
 
 // interface on consumer side
 type paymentService interface {
 	CreateTransaction(ctx context.Context, req models.TransactionRequest) (models.TransactionResponse, error)
 	UpdateStatus(ctx context.Context, req models.UpdateStatusRequest) error
+	AuthorizeTransaction(ctx context.Context, req models.TransactionRequest) (models.TransactionResponse, error)
+	CaptureTransaction(ctx context.Context, req models.CaptureTransactionRequest) (models.TransactionResponse, error)
+	VoidTransaction(ctx context.Context, req models.VoidRequest) (models.TransactionResponse, error)
+	CreateRefund(ctx context.Context, req models.CreateRefundRequest) (models.RefundResponse, error)
+	GetTransaction(ctx context.Context, gateway, refID string) (models.TransactionDetails, error)
+	GetStatusHistory(ctx context.Context, gateway, refID string) ([]models.StatusChange, error)
+	ListTransactions(ctx context.Context, filter models.TransactionFilter) (models.TransactionPage, error)
+	ResolveTransaction(ctx context.Context, req models.ResolveTransactionRequest) (models.TransactionDetails, error)
 }
 
 func NewPaymentHandler(paymentService paymentService) *PaymentHandler {
 	return &PaymentHandler{
 		paymentService: paymentService,
 		jsonSerde:      serde.NewJSONSerde(),
-		xmlSerde:       serde.NewXMLSerde(),
 	}
 }
 
@@ -41,27 +56,248 @@ func (h *PaymentHandler) HandleCreateTransaction(_ http.ResponseWriter, r *http.
 	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
 		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
 	}
+	apiRequest.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
 	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
 		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
 	}
 
+	req := models.TransactionRequest{
+		Type:                  apiRequest.Type,
+		Amount:                apiRequest.amount,
+		PaymentMethod:         apiRequest.PaymentMethod,
+		Description:           apiRequest.Description,
+		CustomerID:            apiRequest.CustomerID,
+		PreferredGateway:      apiRequest.PreferredGateway,
+		Metadata:              apiRequest.Metadata,
+		MerchantID:            apiRequest.MerchantID,
+		IdempotencyKey:        apiRequest.IdempotencyKey,
+		DestinationCustomerID: apiRequest.DestinationCustomerID,
+	}
+
+	res, err := h.paymentService.CreateTransaction(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyInUse):
+			return NewResponse(http.StatusConflict, "a request with the same idempotency key is in progress", nil, err)
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			return NewResponse(http.StatusUnprocessableEntity, "idempotency key was already used for a different request", nil, err)
+		case errors.Is(err, gateway.ErrGatewayUnavailable):
+			return NewResponse(http.StatusServiceUnavailable, "all payment gateways are currently unavailable", nil, err)
+		case errors.Is(err, gatewayerr.ErrTimeout), errors.Is(err, gatewayerr.ErrUnknownOutcome):
+			// The transaction stays initiated; its reference lets the client look it up later.
+			return NewResponse(http.StatusGatewayTimeout, "payment gateway did not confirm the transaction", toTransactionApiResponse(res), err)
+		case errors.Is(err, service.ErrInsufficientBalance):
+			return NewResponse(http.StatusUnprocessableEntity, "insufficient balance", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to process transaction", nil, err)
+	}
+	return NewResponse(http.StatusOK, "transaction sent to gateway successfully", toTransactionApiResponse(res), nil)
+}
+
+func toTransactionApiResponse(res models.TransactionResponse) transactionApiResponse {
+	return transactionApiResponse{
+		Reference:     res.Reference,
+		RefID:         res.RefID,
+		Status:        res.Status,
+		DeclineReason: res.DeclineReason,
+		CreatedAt:     res.CreatedAt,
+		Gateway:       res.Gateway,
+	}
+}
+
+func (h *PaymentHandler) HandleGetTransaction(_ http.ResponseWriter, r *http.Request) Response {
+	transactionRefID := r.PathValue("id")
+	if transactionRefID == "" {
+		return NewResponse(http.StatusBadRequest, "missing transaction ID", nil, nil)
+	}
+	gatewayName := r.URL.Query().Get("gateway")
+	if gatewayName == "" {
+		var validationErrs validation.Errors
+		validationErrs.Add("gateway", "cannot be empty")
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	res, err := h.paymentService.GetTransaction(r.Context(), gatewayName, transactionRefID)
+	if err != nil {
+		if errors.Is(err, service.ErrTransactionNotFound) {
+			return NewResponse(http.StatusNotFound, "transaction not found", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to get transaction", nil, err)
+	}
+	return NewResponse(http.StatusOK, "transaction found", toTransactionDetailsApiResponse(res), nil)
+}
+
+func (h *PaymentHandler) HandleGetStatusHistory(_ http.ResponseWriter, r *http.Request) Response {
+	transactionRefID := r.PathValue("id")
+	if transactionRefID == "" {
+		return NewResponse(http.StatusBadRequest, "missing transaction ID", nil, nil)
+	}
+	gatewayName := r.URL.Query().Get("gateway")
+	if gatewayName == "" {
+		var validationErrs validation.Errors
+		validationErrs.Add("gateway", "cannot be empty")
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	history, err := h.paymentService.GetStatusHistory(r.Context(), gatewayName, transactionRefID)
+	if err != nil {
+		if errors.Is(err, service.ErrTransactionNotFound) {
+			return NewResponse(http.StatusNotFound, "transaction not found", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to get status history", nil, err)
+	}
+
+	apiResponse := make([]statusChangeApiResponse, 0, len(history))
+	for _, change := range history {
+		apiResponse = append(apiResponse, statusChangeApiResponse{
+			From:      change.From,
+			To:        change.To,
+			Source:    change.Source,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+	return NewResponse(http.StatusOK, "status history found", apiResponse, nil)
+}
+
+func (h *PaymentHandler) HandleListTransactions(_ http.ResponseWriter, r *http.Request) Response {
+	apiRequest := newListTransactionsApiRequest(r.URL.Query())
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	filter := models.TransactionFilter{
+		CustomerID:  apiRequest.CustomerID,
+		Reference:   apiRequest.Reference,
+		Status:      apiRequest.Status,
+		Gateway:     apiRequest.Gateway,
+		Type:        apiRequest.Type,
+		Currency:    apiRequest.Currency,
+		MinAmount:   apiRequest.MinAmount,
+		MaxAmount:   apiRequest.MaxAmount,
+		CreatedFrom: apiRequest.CreatedFrom,
+		CreatedTo:   apiRequest.CreatedTo,
+		Cursor:      apiRequest.Cursor,
+		Limit:       apiRequest.Limit,
+	}
+
+	page, err := h.paymentService.ListTransactions(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return NewResponse(http.StatusBadRequest, "invalid cursor", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to list transactions", nil, err)
+	}
+
+	apiResponse := listTransactionsApiResponse{
+		Transactions: make([]transactionDetailsApiResponse, 0, len(page.Transactions)),
+		NextCursor:   page.NextCursor,
+	}
+	for _, transaction := range page.Transactions {
+		apiResponse.Transactions = append(apiResponse.Transactions, toTransactionDetailsApiResponse(transaction))
+	}
+	return NewResponse(http.StatusOK, "transactions listed successfully", apiResponse, nil)
+}
+
+func toTransactionDetailsApiResponse(t models.TransactionDetails) transactionDetailsApiResponse {
+	res := transactionDetailsApiResponse{
+		Reference:             t.Reference,
+		RefID:                 t.RefID,
+		Gateway:               t.Gateway,
+		Type:                  t.Type,
+		Status:                t.Status,
+		GatewayStatusCode:     t.GatewayStatusCode,
+		DeclineReason:         t.DeclineReason,
+		Amount:                json.Number(t.Amount.Decimal()),
+		RefundedAmount:        json.Number(t.RefundedAmount.Decimal()),
+		Currency:              t.Amount.Currency().String(),
+		PaymentMethod:         t.PaymentMethod,
+		Description:           t.Description,
+		CustomerID:            t.CustomerID,
+		MerchantID:            t.MerchantID,
+		DestinationCustomerID: t.DestinationCustomerID,
+		PreferredGateway:      t.PreferredGateway,
+		RoutingRule:           t.RoutingRule,
+		Metadata:              t.Metadata,
+		CreatedAt:             t.CreatedAt,
+		UpdatedAt:             t.UpdatedAt,
+	}
+	if !t.CapturedAmount.IsZero() {
+		res.CapturedAmount = json.Number(t.CapturedAmount.Decimal())
+	}
+	if t.ExpectedFee != (money.Money{}) {
+		res.ExpectedFee = json.Number(t.ExpectedFee.Decimal())
+	}
+	if !t.AuthorizationExpiresAt.IsZero() {
+		res.AuthorizationExpiresAt = &t.AuthorizationExpiresAt
+	}
+	return res
+}
+
+func (h *PaymentHandler) HandleAuthorizeTransaction(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Authorization request received", "method", r.Method, "url", r.URL.Path)
+
+	var apiRequest transactionApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+	validationErrs := apiRequest.validate()
+	if strings.EqualFold(apiRequest.Type, transactionTypeTransfer) {
+		validationErrs.Add("type", "transfers cannot be authorized")
+	}
+	if !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
 	req := models.TransactionRequest{
 		Type:             apiRequest.Type,
-		Amount:           apiRequest.Amount,
-		Currency:         apiRequest.Currency,
+		Amount:           apiRequest.amount,
 		PaymentMethod:    apiRequest.PaymentMethod,
 		Description:      apiRequest.Description,
 		CustomerID:       apiRequest.CustomerID,
 		PreferredGateway: apiRequest.PreferredGateway,
 		Metadata:         apiRequest.Metadata,
+		MerchantID:       apiRequest.MerchantID,
 	}
 
-	res, err := h.paymentService.CreateTransaction(r.Context(), req)
+	res, err := h.paymentService.AuthorizeTransaction(r.Context(), req)
 	if err != nil {
-		if errors.Is(err, gateway.ErrGatewayUnavailable) {
+		switch {
+		case errors.Is(err, gateway.ErrGatewayUnavailable):
 			return NewResponse(http.StatusServiceUnavailable, "all payment gateways are currently unavailable", nil, err)
+		case errors.Is(err, gatewayerr.ErrTimeout), errors.Is(err, gatewayerr.ErrUnknownOutcome):
+			// The transaction stays initiated; its reference lets the client look it up later.
+			return NewResponse(http.StatusGatewayTimeout, "payment gateway did not confirm the authorization", toTransactionApiResponse(res), err)
 		}
-		return NewResponse(http.StatusInternalServerError, "failed to process transaction", nil, err)
+		return NewResponse(http.StatusInternalServerError, "failed to authorize transaction", nil, err)
+	}
+	return NewResponse(http.StatusOK, "transaction authorized successfully", toTransactionApiResponse(res), nil)
+}
+
+func (h *PaymentHandler) HandleCaptureTransaction(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Capture request received", "method", r.Method, "url", r.URL.Path)
+
+	transactionRefID := r.PathValue("id")
+	if transactionRefID == "" {
+		return NewResponse(http.StatusBadRequest, "missing transaction ID", nil, nil)
+	}
+
+	var apiRequest captureApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	req := models.CaptureTransactionRequest{
+		Gateway:          apiRequest.Gateway,
+		TransactionRefID: transactionRefID,
+		Amount:           apiRequest.Amount.String(),
+	}
+
+	res, err := h.paymentService.CaptureTransaction(r.Context(), req)
+	if err != nil {
+		return authorizationErrorResponse(err, "failed to capture transaction")
 	}
 	apiResponse := transactionApiResponse{
 		RefID:     res.RefID,
@@ -69,7 +305,62 @@ func (h *PaymentHandler) HandleCreateTransaction(_ http.ResponseWriter, r *http.
 		CreatedAt: res.CreatedAt,
 		Gateway:   res.Gateway,
 	}
-	return NewResponse(http.StatusOK, "transaction sent to gateway successfully", apiResponse, nil)
+	return NewResponse(http.StatusOK, "transaction captured successfully", apiResponse, nil)
+}
+
+func (h *PaymentHandler) HandleVoidTransaction(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Void request received", "method", r.Method, "url", r.URL.Path)
+
+	transactionRefID := r.PathValue("id")
+	if transactionRefID == "" {
+		return NewResponse(http.StatusBadRequest, "missing transaction ID", nil, nil)
+	}
+
+	var apiRequest voidApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	req := models.VoidRequest{
+		Gateway:          apiRequest.Gateway,
+		TransactionRefID: transactionRefID,
+	}
+
+	res, err := h.paymentService.VoidTransaction(r.Context(), req)
+	if err != nil {
+		return authorizationErrorResponse(err, "failed to void transaction")
+	}
+	apiResponse := transactionApiResponse{
+		RefID:     res.RefID,
+		Status:    res.Status,
+		CreatedAt: res.CreatedAt,
+		Gateway:   res.Gateway,
+	}
+	return NewResponse(http.StatusOK, "transaction voided successfully", apiResponse, nil)
+}
+
+// authorizationErrorResponse maps the errors of capture and void operations to a response.
+func authorizationErrorResponse(err error, message string) Response {
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return NewResponse(http.StatusNotFound, "transaction not found", nil, err)
+	case errors.Is(err, service.ErrTransactionNotAuthorized):
+		return NewResponse(http.StatusConflict, "transaction is not authorized", nil, err)
+	case errors.Is(err, service.ErrAuthorizationExpired):
+		return NewResponse(http.StatusConflict, "authorization has expired", nil, err)
+	case errors.Is(err, service.ErrInvalidAmount):
+		return NewResponse(http.StatusBadRequest, "invalid amount", nil, err)
+	case errors.Is(err, service.ErrCaptureAmountExceeded):
+		return NewResponse(http.StatusUnprocessableEntity, "capture amount exceeds authorized amount", nil, err)
+	case errors.Is(err, service.ErrCaptureDeclined):
+		return NewResponse(http.StatusPaymentRequired, "capture declined by gateway", nil, err)
+	case errors.Is(err, gateway.ErrGatewayUnavailable):
+		return NewResponse(http.StatusServiceUnavailable, "payment gateway is currently unavailable", nil, err)
+	}
+	return NewResponse(http.StatusInternalServerError, message, nil, err)
 }
 
 func (h *PaymentHandler) HandleUpdateStatus(_ http.ResponseWriter, r *http.Request) Response {
@@ -80,8 +371,12 @@ func (h *PaymentHandler) HandleUpdateStatus(_ http.ResponseWriter, r *http.Reque
 		return NewResponse(http.StatusBadRequest, "missing transaction ID", nil, nil)
 	}
 
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to read request", nil, err)
+	}
 	var apiRequest updateStatusApiRequest
-	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+	if err := h.jsonSerde.Deserialize(bytes.NewReader(body), &apiRequest); err != nil {
 		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
 	}
 	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
@@ -89,25 +384,28 @@ func (h *PaymentHandler) HandleUpdateStatus(_ http.ResponseWriter, r *http.Reque
 	}
 
 	req := models.UpdateStatusRequest{
-		Gateway: apiRequest.Gateway,
-		RefID:   transactionRefID,
-		Status:  apiRequest.Status,
+		Gateway:    apiRequest.Gateway,
+		RefID:      transactionRefID,
+		Status:     apiRequest.Status,
+		Source:     models.StatusChangeSourceAPI,
+		RawPayload: string(body),
 	}
 
-	err := h.paymentService.UpdateStatus(r.Context(), req)
-	if err != nil {
-		if errors.Is(err, service.ErrTransactionNotFound) {
-			return NewResponse(http.StatusNotFound, "transaction not found", nil, err)
-		}
-		return NewResponse(http.StatusInternalServerError, "failed to process update status", nil, err)
+	if err := h.paymentService.UpdateStatus(r.Context(), req); err != nil {
+		return updateStatusErrorResponse(err)
 	}
 	return NewResponse(http.StatusOK, "status updated successfully", nil, nil)
 }
 
-func (h *PaymentHandler) HandleGatewayACallback(_ http.ResponseWriter, r *http.Request) Response {
-	slog.InfoContext(r.Context(), "Gateway A callback request received", "method", r.Method, "url", r.URL.Path)
+// HandleResolveTransaction completes a transaction parked for review with the outcome an operator looked up
+// at the gateway.
+func (h *PaymentHandler) HandleResolveTransaction(_ http.ResponseWriter, r *http.Request) Response {
+	reference := r.PathValue("reference")
+	if reference == "" {
+		return NewResponse(http.StatusBadRequest, "missing transaction reference", nil, nil)
+	}
 
-	var apiRequest gatewayACallbackRequest
+	var apiRequest resolveTransactionApiRequest
 	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
 		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
 	}
@@ -115,45 +413,86 @@ func (h *PaymentHandler) HandleGatewayACallback(_ http.ResponseWriter, r *http.R
 		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
 	}
 
-	req := models.UpdateStatusRequest{
-		Gateway: consts.GatewayA,
-		RefID:   apiRequest.RefID,
-		Status:  apiRequest.Status,
-	}
-
-	err := h.paymentService.UpdateStatus(r.Context(), req)
+	res, err := h.paymentService.ResolveTransaction(r.Context(), models.ResolveTransactionRequest{
+		Reference:     reference,
+		Gateway:       apiRequest.Gateway,
+		RefID:         apiRequest.RefID,
+		Status:        apiRequest.Status,
+		DeclineReason: apiRequest.DeclineReason,
+	})
 	if err != nil {
-		if errors.Is(err, service.ErrTransactionNotFound) {
+		switch {
+		case errors.Is(err, service.ErrTransactionNotFound):
 			return NewResponse(http.StatusNotFound, "transaction not found", nil, err)
+		case errors.Is(err, service.ErrIllegalTransition):
+			return NewResponse(http.StatusConflict, "transaction is not awaiting review", nil, err)
 		}
-		return NewResponse(http.StatusInternalServerError, "failed to process update status", nil, err)
+		return NewResponse(http.StatusInternalServerError, "failed to resolve transaction", nil, err)
 	}
-	return NewResponse(http.StatusOK, "status updated successfully", nil, nil)
+	return NewResponse(http.StatusOK, "transaction resolved successfully", toTransactionDetailsApiResponse(res), nil)
 }
 
-func (h *PaymentHandler) HandleGatewayBCallback(_ http.ResponseWriter, r *http.Request) Response {
-	slog.InfoContext(r.Context(), "Gateway B callback request received", "method", r.Method, "url", r.URL.Path)
+// updateStatusErrorResponse maps the errors of status updates to a response.
+func updateStatusErrorResponse(err error) Response {
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return NewResponse(http.StatusNotFound, "transaction not found", nil, err)
+	case errors.Is(err, service.ErrIllegalTransition):
+		return NewResponse(http.StatusConflict, "illegal status transition", nil, err)
+	}
+	return NewResponse(http.StatusInternalServerError, "failed to process update status", nil, err)
+}
+
+func (h *PaymentHandler) HandleCreateRefund(_ http.ResponseWriter, r *http.Request) Response {
+	slog.InfoContext(r.Context(), "Refund request received", "method", r.Method, "url", r.URL.Path)
+
+	transactionRefID := r.PathValue("id")
+	if transactionRefID == "" {
+		return NewResponse(http.StatusBadRequest, "missing transaction ID", nil, nil)
+	}
 
-	var apiRequest gatewayBCallbackRequest
-	if err := h.xmlSerde.Deserialize(r.Body, &apiRequest); err != nil {
-		return NewResponse(http.StatusBadRequest, "failed to decode XML request", nil, err)
+	var apiRequest refundApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
 	}
 	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
 		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
 	}
 
-	req := models.UpdateStatusRequest{
-		Gateway: consts.GatewayB,
-		RefID:   apiRequest.RefID,
-		Status:  apiRequest.Status,
+	req := models.CreateRefundRequest{
+		Gateway:          apiRequest.Gateway,
+		TransactionRefID: transactionRefID,
+		Amount:           apiRequest.Amount.String(),
+		Reason:           apiRequest.Reason,
 	}
 
-	err := h.paymentService.UpdateStatus(r.Context(), req)
+	res, err := h.paymentService.CreateRefund(r.Context(), req)
 	if err != nil {
-		if errors.Is(err, service.ErrTransactionNotFound) {
+		switch {
+		case errors.Is(err, service.ErrTransactionNotFound):
 			return NewResponse(http.StatusNotFound, "transaction not found", nil, err)
+		case errors.Is(err, service.ErrTransactionNotRefundable):
+			return NewResponse(http.StatusConflict, "transaction cannot be refunded", nil, err)
+		case errors.Is(err, service.ErrInvalidAmount):
+			return NewResponse(http.StatusBadRequest, "invalid amount", nil, err)
+		case errors.Is(err, service.ErrRefundAmountExceeded):
+			return NewResponse(http.StatusUnprocessableEntity, "refund amount exceeds refundable amount", nil, err)
+		case errors.Is(err, gateway.ErrGatewayUnavailable):
+			return NewResponse(http.StatusServiceUnavailable, "payment gateway is currently unavailable", nil, err)
+		case errors.Is(err, gatewayerr.ErrTimeout), errors.Is(err, gatewayerr.ErrUnknownOutcome):
+			return NewResponse(http.StatusGatewayTimeout, "payment gateway did not confirm the refund", nil, err)
 		}
-		return NewResponse(http.StatusInternalServerError, "failed to process update status", nil, err)
+		return NewResponse(http.StatusInternalServerError, "failed to process refund", nil, err)
 	}
-	return NewResponse(http.StatusOK, "status updated successfully", nil, nil)
+	apiResponse := refundApiResponse{
+		ID:               res.ID,
+		RefID:            res.RefID,
+		TransactionRefID: res.TransactionRefID,
+		Amount:           json.Number(res.Amount.Decimal()),
+		Currency:         res.Amount.Currency().String(),
+		Status:           res.Status,
+		CreatedAt:        res.CreatedAt,
+		Gateway:          res.Gateway,
+	}
+	return NewResponse(http.StatusOK, "refund sent to gateway successfully", apiResponse, nil)
 }
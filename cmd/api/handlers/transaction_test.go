@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
 	"github.com/rauf/payment-service/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -29,10 +34,51 @@ func (m *MockPaymentService) UpdateStatus(ctx context.Context, req models.Update
 	return args.Error(0)
 }
 
+func (m *MockPaymentService) AuthorizeTransaction(ctx context.Context, req models.TransactionRequest) (models.TransactionResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.TransactionResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) CaptureTransaction(ctx context.Context, req models.CaptureTransactionRequest) (models.TransactionResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.TransactionResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) VoidTransaction(ctx context.Context, req models.VoidRequest) (models.TransactionResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.TransactionResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) CreateRefund(ctx context.Context, req models.CreateRefundRequest) (models.RefundResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.RefundResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) GetTransaction(ctx context.Context, gateway, refID string) (models.TransactionDetails, error) {
+	args := m.Called(ctx, gateway, refID)
+	return args.Get(0).(models.TransactionDetails), args.Error(1)
+}
+
+func (m *MockPaymentService) GetStatusHistory(ctx context.Context, gateway, refID string) ([]models.StatusChange, error) {
+	args := m.Called(ctx, gateway, refID)
+	return args.Get(0).([]models.StatusChange), args.Error(1)
+}
+
+func (m *MockPaymentService) ListTransactions(ctx context.Context, filter models.TransactionFilter) (models.TransactionPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(models.TransactionPage), args.Error(1)
+}
+
+func (m *MockPaymentService) ResolveTransaction(ctx context.Context, req models.ResolveTransactionRequest) (models.TransactionDetails, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(models.TransactionDetails), args.Error(1)
+}
+
 func TestHandleTransaction(t *testing.T) {
 	tests := []struct {
 		name               string
 		input              transactionApiRequest
+		idempotencyKey     string
 		mockResponse       models.TransactionResponse
 		mockError          error
 		callTransactMethod bool
@@ -42,7 +88,7 @@ func TestHandleTransaction(t *testing.T) {
 		{
 			name: "Successful transaction",
 			input: transactionApiRequest{
-				Amount:           100.0,
+				Amount:           json.Number("100"),
 				Type:             "deposit",
 				Currency:         "USD",
 				PaymentMethod:    "card",
@@ -50,28 +96,55 @@ func TestHandleTransaction(t *testing.T) {
 				PreferredGateway: "stripe",
 			},
 			mockResponse: models.TransactionResponse{
-				RefID:   "ref123",
-				Status:  "pending",
-				Gateway: "stripe",
+				Reference: "txn_1",
+				RefID:     "ref123",
+				Status:    "pending",
+				Gateway:   "stripe",
 			},
 			mockError:          nil,
 			expectedStatus:     http.StatusOK,
 			callTransactMethod: true,
-			expectedBody:       `{"code":200,"message":"transaction sent to gateway successfully","data":{"ref_id":"ref123","status":"pending","created_at":"0001-01-01T00:00:00Z","gateway":"stripe"}}`,
+			expectedBody:       `{"code":200,"message":"transaction sent to gateway successfully","data":{"reference":"txn_1","ref_id":"ref123","status":"pending","created_at":"0001-01-01T00:00:00Z","gateway":"stripe"}}`,
 		},
 		{
 			name: "Invalid request",
 			input: transactionApiRequest{
-				Amount: -100.0,
+				Amount: json.Number("-100"),
 			},
 			expectedStatus:     http.StatusBadRequest,
 			callTransactMethod: false,
 			expectedBody:       `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"amount","message":"must be greater than 0"},{"field":"currency","message":"must be 3 characters long"},{"field":"payment_method","message":"cannot be empty"},{"field":"customer_id","message":"cannot be empty"},{"field":"type","message":"cannot be empty"}]}}`,
 		},
+		{
+			name: "Amount too precise for currency",
+			input: transactionApiRequest{
+				Amount:        json.Number("100.5"),
+				Type:          "deposit",
+				Currency:      "JPY",
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+			},
+			expectedStatus:     http.StatusBadRequest,
+			callTransactMethod: false,
+			expectedBody:       `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"amount","message":"must have at most 0 decimal places for JPY"}]}}`,
+		},
+		{
+			name: "Unsupported currency",
+			input: transactionApiRequest{
+				Amount:        json.Number("100"),
+				Type:          "deposit",
+				Currency:      "ABC",
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+			},
+			expectedStatus:     http.StatusBadRequest,
+			callTransactMethod: false,
+			expectedBody:       `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"currency","message":"not a supported ISO 4217 currency"}]}}`,
+		},
 		{
 			name: "Gateway unavailable",
 			input: transactionApiRequest{
-				Amount:           100.0,
+				Amount:           json.Number("100"),
 				Type:             "deposit",
 				Currency:         "USD",
 				PaymentMethod:    "card",
@@ -83,6 +156,162 @@ func TestHandleTransaction(t *testing.T) {
 			callTransactMethod: true,
 			expectedBody:       `{"code":503,"message":"all payment gateways are currently unavailable"}`,
 		},
+		{
+			name: "Gateway timeout",
+			input: transactionApiRequest{
+				Amount:        json.Number("100"),
+				Type:          "deposit",
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+			},
+			mockResponse: models.TransactionResponse{
+				Reference: "txn_1",
+				Status:    "initiated",
+				Gateway:   "stripe",
+			},
+			mockError:          fmt.Errorf("transaction failed: %w", gatewayerr.ErrTimeout),
+			expectedStatus:     http.StatusGatewayTimeout,
+			callTransactMethod: true,
+			expectedBody:       `{"code":504,"message":"payment gateway did not confirm the transaction","data":{"reference":"txn_1","ref_id":"","status":"initiated","created_at":"0001-01-01T00:00:00Z","gateway":"stripe"}}`,
+		},
+		{
+			name: "Successful transfer",
+			input: transactionApiRequest{
+				Amount:                json.Number("25.50"),
+				Type:                  "transfer",
+				Currency:              "USD",
+				CustomerID:            "cust123",
+				DestinationCustomerID: "cust456",
+			},
+			mockResponse: models.TransactionResponse{
+				Reference: "txn_2",
+				RefID:     "txn_2",
+				Status:    "success",
+				Gateway:   "internal",
+			},
+			expectedStatus:     http.StatusOK,
+			callTransactMethod: true,
+			expectedBody:       `{"code":200,"message":"transaction sent to gateway successfully","data":{"reference":"txn_2","ref_id":"txn_2","status":"success","created_at":"0001-01-01T00:00:00Z","gateway":"internal"}}`,
+		},
+		{
+			name: "Transfer exceeding balance",
+			input: transactionApiRequest{
+				Amount:                json.Number("25.50"),
+				Type:                  "transfer",
+				Currency:              "USD",
+				CustomerID:            "cust123",
+				DestinationCustomerID: "cust456",
+			},
+			mockError:          service.ErrInsufficientBalance,
+			expectedStatus:     http.StatusUnprocessableEntity,
+			callTransactMethod: true,
+			expectedBody:       `{"code":422,"message":"insufficient balance"}`,
+		},
+		{
+			name: "Transfer to the same customer",
+			input: transactionApiRequest{
+				Amount:                json.Number("25.50"),
+				Type:                  "transfer",
+				Currency:              "USD",
+				CustomerID:            "cust123",
+				DestinationCustomerID: "cust123",
+				PreferredGateway:      "stripe",
+			},
+			expectedStatus:     http.StatusBadRequest,
+			callTransactMethod: false,
+			expectedBody: `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"destination_customer_id","message":"must differ from customer_id"},
+				{"field":"preferred_gateway","message":"not allowed for transfers"}]}}`,
+		},
+		{
+			name: "Transfer without destination",
+			input: transactionApiRequest{
+				Amount:     json.Number("25.50"),
+				Type:       "transfer",
+				Currency:   "USD",
+				CustomerID: "cust123",
+			},
+			expectedStatus:     http.StatusBadRequest,
+			callTransactMethod: false,
+			expectedBody:       `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"destination_customer_id","message":"cannot be empty"}]}}`,
+		},
+		{
+			name: "Destination on a deposit",
+			input: transactionApiRequest{
+				Amount:                json.Number("100"),
+				Type:                  "deposit",
+				Currency:              "USD",
+				PaymentMethod:         "card",
+				CustomerID:            "cust123",
+				DestinationCustomerID: "cust456",
+			},
+			expectedStatus:     http.StatusBadRequest,
+			callTransactMethod: false,
+			expectedBody:       `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"destination_customer_id","message":"only allowed for transfers"}]}}`,
+		},
+		{
+			name: "Idempotent replay",
+			input: transactionApiRequest{
+				Amount:        json.Number("100"),
+				Type:          "deposit",
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+			},
+			idempotencyKey: "key-1",
+			mockResponse: models.TransactionResponse{
+				RefID:   "ref123",
+				Status:  "pending",
+				Gateway: "stripe",
+			},
+			expectedStatus:     http.StatusOK,
+			callTransactMethod: true,
+			expectedBody:       `{"code":200,"message":"transaction sent to gateway successfully","data":{"ref_id":"ref123","status":"pending","created_at":"0001-01-01T00:00:00Z","gateway":"stripe"}}`,
+		},
+		{
+			name: "Idempotency key in use",
+			input: transactionApiRequest{
+				Amount:        json.Number("100"),
+				Type:          "deposit",
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+			},
+			idempotencyKey:     "key-1",
+			mockError:          service.ErrIdempotencyKeyInUse,
+			expectedStatus:     http.StatusConflict,
+			callTransactMethod: true,
+			expectedBody:       `{"code":409,"message":"a request with the same idempotency key is in progress"}`,
+		},
+		{
+			name: "Idempotency key reused with different payload",
+			input: transactionApiRequest{
+				Amount:        json.Number("200"),
+				Type:          "deposit",
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+			},
+			idempotencyKey:     "key-1",
+			mockError:          service.ErrIdempotencyKeyReused,
+			expectedStatus:     http.StatusUnprocessableEntity,
+			callTransactMethod: true,
+			expectedBody:       `{"code":422,"message":"idempotency key was already used for a different request"}`,
+		},
+		{
+			name: "Idempotency key too long",
+			input: transactionApiRequest{
+				Amount:        json.Number("100"),
+				Type:          "deposit",
+				Currency:      "USD",
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+			},
+			idempotencyKey:     strings.Repeat("k", 256),
+			expectedStatus:     http.StatusBadRequest,
+			callTransactMethod: false,
+			expectedBody:       `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"idempotency_key","message":"must be at most 255 characters long"}]}}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -91,11 +320,19 @@ func TestHandleTransaction(t *testing.T) {
 
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.callTransactMethod {
-				mockService.On("CreateTransaction", mock.Anything, mock.Anything).Return(tt.mockResponse, tt.mockError)
+				amount := money.MustParse(tt.input.Amount.String(), tt.input.Currency)
+				matchesKey := mock.MatchedBy(func(req models.TransactionRequest) bool {
+					return req.IdempotencyKey == tt.idempotencyKey && req.Amount == amount &&
+						req.DestinationCustomerID == tt.input.DestinationCustomerID
+				})
+				mockService.On("CreateTransaction", mock.Anything, matchesKey).Return(tt.mockResponse, tt.mockError)
 			}
 
 			body, _ := json.Marshal(tt.input)
 			req, _ := http.NewRequest("POST", "/transaction", bytes.NewBuffer(body))
+			if tt.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", tt.idempotencyKey)
+			}
 			rr := httptest.NewRecorder()
 
 			res := handler.HandleCreateTransaction(rr, req)
@@ -156,6 +393,18 @@ func TestHandleUpdateStatus(t *testing.T) {
 			expectedStatus:   http.StatusNotFound,
 			expectedBody:     `{"code":404,"message":"transaction not found"}`,
 		},
+		{
+			name:             "Illegal transition",
+			transactionRefID: "ref123",
+			input: updateStatusApiRequest{
+				Gateway: "stripe",
+				Status:  "pending",
+			},
+			mockError:        service.ErrIllegalTransition,
+			callUpdateMethod: true,
+			expectedStatus:   http.StatusConflict,
+			expectedBody:     `{"code":409,"message":"illegal status transition"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -163,10 +412,13 @@ func TestHandleUpdateStatus(t *testing.T) {
 		handler := NewPaymentHandler(mockService)
 
 		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.input)
 			if tt.callUpdateMethod {
-				mockService.On("UpdateStatus", mock.Anything, mock.Anything).Return(tt.mockError)
+				matchesRequest := mock.MatchedBy(func(req models.UpdateStatusRequest) bool {
+					return req.Source == models.StatusChangeSourceAPI && req.RawPayload == string(body)
+				})
+				mockService.On("UpdateStatus", mock.Anything, matchesRequest).Return(tt.mockError)
 			}
-			body, _ := json.Marshal(tt.input)
 			req, _ := http.NewRequest("PATCH", "/api/v1/transactions/"+tt.transactionRefID+"/status", bytes.NewBuffer(body))
 			req.SetPathValue("id", tt.transactionRefID)
 			rr := httptest.NewRecorder()
@@ -181,3 +433,547 @@ func TestHandleUpdateStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleResolveTransaction(t *testing.T) {
+	createdAt := time.Date(2024, 9, 16, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		input             resolveTransactionApiRequest
+		mockResponse      models.TransactionDetails
+		mockError         error
+		callResolveMethod bool
+		expectedStatus    int
+		expectedBody      string
+	}{
+		{
+			name: "Resolved with the gateway status",
+			input: resolveTransactionApiRequest{
+				Gateway: "gatewayA",
+				RefID:   "ref123",
+				Status:  "success",
+			},
+			mockResponse: models.TransactionDetails{
+				Reference:     "txn_1",
+				Gateway:       "gatewayA",
+				RefID:         "ref123",
+				Type:          "deposit",
+				Amount:        money.MustParse("100", "USD"),
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+				Status:        "success",
+				CreatedAt:     createdAt,
+				UpdatedAt:     createdAt,
+			},
+			callResolveMethod: true,
+			expectedStatus:    http.StatusOK,
+			expectedBody:      `{"code":200,"message":"transaction resolved successfully","data":{"reference":"txn_1","ref_id":"ref123","gateway":"gatewayA","type":"deposit","status":"success","amount":100,"refunded_amount":0,"currency":"USD","payment_method":"card","customer_id":"cust123","created_at":"2024-09-16T10:00:00Z","updated_at":"2024-09-16T10:00:00Z"}}`,
+		},
+		{
+			name: "Failed without a gateway",
+			input: resolveTransactionApiRequest{
+				Status:        "failed",
+				DeclineReason: "technical",
+			},
+			mockResponse: models.TransactionDetails{
+				Reference:     "txn_1",
+				Gateway:       "gatewayA",
+				Type:          "deposit",
+				Amount:        money.MustParse("100", "USD"),
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+				Status:        "failed",
+				DeclineReason: "technical",
+				CreatedAt:     createdAt,
+				UpdatedAt:     createdAt,
+			},
+			callResolveMethod: true,
+			expectedStatus:    http.StatusOK,
+			expectedBody:      `{"code":200,"message":"transaction resolved successfully","data":{"reference":"txn_1","ref_id":"","gateway":"gatewayA","type":"deposit","status":"failed","decline_reason":"technical","amount":100,"refunded_amount":0,"currency":"USD","payment_method":"card","customer_id":"cust123","created_at":"2024-09-16T10:00:00Z","updated_at":"2024-09-16T10:00:00Z"}}`,
+		},
+		{
+			name: "Missing gateway reference",
+			input: resolveTransactionApiRequest{
+				Status: "success",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"gateway","message":"cannot be empty"},{"field":"ref_id","message":"cannot be empty"}]}}`,
+		},
+		{
+			name: "Invalid decline reason",
+			input: resolveTransactionApiRequest{
+				Status:        "failed",
+				DeclineReason: "unknown",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"decline_reason","message":"not valid decline reason"}]}}`,
+		},
+		{
+			name: "Transaction not awaiting review",
+			input: resolveTransactionApiRequest{
+				Status: "failed",
+			},
+			mockError:         service.ErrIllegalTransition,
+			callResolveMethod: true,
+			expectedStatus:    http.StatusConflict,
+			expectedBody:      `{"code":409,"message":"transaction is not awaiting review"}`,
+		},
+		{
+			name: "Transaction not found",
+			input: resolveTransactionApiRequest{
+				Status: "failed",
+			},
+			mockError:         service.ErrTransactionNotFound,
+			callResolveMethod: true,
+			expectedStatus:    http.StatusNotFound,
+			expectedBody:      `{"code":404,"message":"transaction not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		mockService := new(MockPaymentService)
+		handler := NewPaymentHandler(mockService)
+
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.callResolveMethod {
+				mockService.On("ResolveTransaction", mock.Anything, models.ResolveTransactionRequest{
+					Reference:     "txn_1",
+					Gateway:       tt.input.Gateway,
+					RefID:         tt.input.RefID,
+					Status:        tt.input.Status,
+					DeclineReason: tt.input.DeclineReason,
+				}).Return(tt.mockResponse, tt.mockError)
+			}
+			body, _ := json.Marshal(tt.input)
+			req, _ := http.NewRequest("POST", "/api/v1/admin/transactions/txn_1/resolve", bytes.NewBuffer(body))
+			req.SetPathValue("reference", "txn_1")
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleResolveTransaction(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetStatusHistory(t *testing.T) {
+	changedAt := time.Date(2024, 9, 18, 9, 0, 0, 0, time.UTC)
+	mockService := new(MockPaymentService)
+	handler := NewPaymentHandler(mockService)
+
+	mockService.On("GetStatusHistory", mock.Anything, "gatewayA", "ref123").Return([]models.StatusChange{
+		{From: "authorized", To: "captured", Source: "api", CreatedAt: changedAt},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/transactions/ref123/history?gateway=gatewayA", nil)
+	req.SetPathValue("id", "ref123")
+	rr := httptest.NewRecorder()
+
+	res := handler.HandleGetStatusHistory(rr, req)
+	writeResponse(rr, req, res)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"code":200,"message":"status history found","data":[{"from":"authorized","to":"captured","source":"api","created_at":"2024-09-18T09:00:00Z"}]}`, rr.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleCaptureTransaction(t *testing.T) {
+	tests := []struct {
+		name              string
+		transactionRefID  string
+		input             captureApiRequest
+		mockResponse      models.TransactionResponse
+		mockError         error
+		callCaptureMethod bool
+		expectedStatus    int
+		expectedBody      string
+	}{
+		{
+			name:             "Successful capture",
+			transactionRefID: "ref123",
+			input: captureApiRequest{
+				Gateway: "stripe",
+				Amount:  json.Number("50"),
+			},
+			mockResponse: models.TransactionResponse{
+				RefID:   "ref123",
+				Status:  "captured",
+				Gateway: "stripe",
+			},
+			callCaptureMethod: true,
+			expectedStatus:    http.StatusOK,
+			expectedBody:      `{"code":200,"message":"transaction captured successfully","data":{"ref_id":"ref123","status":"captured","created_at":"0001-01-01T00:00:00Z","gateway":"stripe"}}`,
+		},
+		{
+			name:             "Invalid request",
+			transactionRefID: "ref123",
+			input: captureApiRequest{
+				Amount: json.Number("-1"),
+			},
+			callCaptureMethod: false,
+			expectedStatus:    http.StatusBadRequest,
+			expectedBody:      `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"gateway","message":"cannot be empty"},{"field":"amount","message":"cannot be negative"}]}}`,
+		},
+		{
+			name:             "Authorization expired",
+			transactionRefID: "ref123",
+			input: captureApiRequest{
+				Gateway: "stripe",
+			},
+			mockError:         service.ErrAuthorizationExpired,
+			callCaptureMethod: true,
+			expectedStatus:    http.StatusConflict,
+			expectedBody:      `{"code":409,"message":"authorization has expired"}`,
+		},
+		{
+			name:             "Capture exceeds authorized amount",
+			transactionRefID: "ref123",
+			input: captureApiRequest{
+				Gateway: "stripe",
+				Amount:  json.Number("1000"),
+			},
+			mockError:         service.ErrCaptureAmountExceeded,
+			callCaptureMethod: true,
+			expectedStatus:    http.StatusUnprocessableEntity,
+			expectedBody:      `{"code":422,"message":"capture amount exceeds authorized amount"}`,
+		},
+		{
+			name:             "Capture declined by gateway",
+			transactionRefID: "ref123",
+			input: captureApiRequest{
+				Gateway: "stripe",
+			},
+			mockError:         service.ErrCaptureDeclined,
+			callCaptureMethod: true,
+			expectedStatus:    http.StatusPaymentRequired,
+			expectedBody:      `{"code":402,"message":"capture declined by gateway"}`,
+		},
+		{
+			name:             "Amount too precise for currency",
+			transactionRefID: "ref123",
+			input: captureApiRequest{
+				Gateway: "stripe",
+				Amount:  json.Number("10.005"),
+			},
+			mockError:         service.ErrInvalidAmount,
+			callCaptureMethod: true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedBody:      `{"code":400,"message":"invalid amount"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		mockService := new(MockPaymentService)
+		handler := NewPaymentHandler(mockService)
+
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.callCaptureMethod {
+				mockService.On("CaptureTransaction", mock.Anything, mock.Anything).Return(tt.mockResponse, tt.mockError)
+			}
+			body, _ := json.Marshal(tt.input)
+			req, _ := http.NewRequest("POST", "/api/v1/transactions/"+tt.transactionRefID+"/capture", bytes.NewBuffer(body))
+			req.SetPathValue("id", tt.transactionRefID)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleCaptureTransaction(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleCreateRefund(t *testing.T) {
+	tests := []struct {
+		name             string
+		transactionRefID string
+		input            refundApiRequest
+		mockResponse     models.RefundResponse
+		mockError        error
+		callRefundMethod bool
+		expectedStatus   int
+		expectedBody     string
+	}{
+		{
+			name:             "Successful partial refund",
+			transactionRefID: "ref123",
+			input: refundApiRequest{
+				Gateway: "stripe",
+				Amount:  json.Number("25.5"),
+			},
+			mockResponse: models.RefundResponse{
+				ID:               1,
+				Gateway:          "stripe",
+				RefID:            "refund123",
+				TransactionRefID: "ref123",
+				Amount:           money.MustParse("25.5", "USD"),
+				Status:           "pending",
+			},
+			callRefundMethod: true,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"code":200,"message":"refund sent to gateway successfully","data":{"id":1,"ref_id":"refund123","transaction_ref_id":"ref123","amount":25.5,"currency":"USD","status":"pending","created_at":"0001-01-01T00:00:00Z","gateway":"stripe"}}`,
+		},
+		{
+			name:             "Invalid request",
+			transactionRefID: "ref123",
+			input: refundApiRequest{
+				Amount: json.Number("-1"),
+			},
+			callRefundMethod: false,
+			expectedStatus:   http.StatusBadRequest,
+			expectedBody:     `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"gateway","message":"cannot be empty"},{"field":"amount","message":"cannot be negative"}]}}`,
+		},
+		{
+			name:             "Refund exceeds refundable amount",
+			transactionRefID: "ref123",
+			input: refundApiRequest{
+				Gateway: "stripe",
+				Amount:  json.Number("1000"),
+			},
+			mockError:        service.ErrRefundAmountExceeded,
+			callRefundMethod: true,
+			expectedStatus:   http.StatusUnprocessableEntity,
+			expectedBody:     `{"code":422,"message":"refund amount exceeds refundable amount"}`,
+		},
+		{
+			name:             "Transaction not refundable",
+			transactionRefID: "ref123",
+			input: refundApiRequest{
+				Gateway: "stripe",
+			},
+			mockError:        service.ErrTransactionNotRefundable,
+			callRefundMethod: true,
+			expectedStatus:   http.StatusConflict,
+			expectedBody:     `{"code":409,"message":"transaction cannot be refunded"}`,
+		},
+		{
+			name:             "Refund not confirmed",
+			transactionRefID: "ref123",
+			input: refundApiRequest{
+				Gateway: "stripe",
+			},
+			mockError:        fmt.Errorf("refund not confirmed: %w", gatewayerr.ErrTimeout),
+			callRefundMethod: true,
+			expectedStatus:   http.StatusGatewayTimeout,
+			expectedBody:     `{"code":504,"message":"payment gateway did not confirm the refund"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		mockService := new(MockPaymentService)
+		handler := NewPaymentHandler(mockService)
+
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.callRefundMethod {
+				mockService.On("CreateRefund", mock.Anything, mock.Anything).Return(tt.mockResponse, tt.mockError)
+			}
+			body, _ := json.Marshal(tt.input)
+			req, _ := http.NewRequest("POST", "/api/v1/transactions/"+tt.transactionRefID+"/refunds", bytes.NewBuffer(body))
+			req.SetPathValue("id", tt.transactionRefID)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleCreateRefund(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetTransaction(t *testing.T) {
+	createdAt := time.Date(2024, 9, 16, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		transactionRefID string
+		gateway          string
+		mockResponse     models.TransactionDetails
+		mockError        error
+		callGetMethod    bool
+		expectedStatus   int
+		expectedBody     string
+	}{
+		{
+			name:             "Transaction found",
+			transactionRefID: "ref123",
+			gateway:          "gatewayA",
+			mockResponse: models.TransactionDetails{
+				Reference:     "txn_1",
+				Gateway:       "gatewayA",
+				RefID:         "ref123",
+				Type:          "deposit",
+				Amount:        money.MustParse("100", "USD"),
+				PaymentMethod: "card",
+				CustomerID:    "cust123",
+				Status:        "success",
+				CreatedAt:     createdAt,
+				UpdatedAt:     createdAt,
+			},
+			callGetMethod:  true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"transaction found","data":{"reference":"txn_1","ref_id":"ref123","gateway":"gatewayA","type":"deposit","status":"success","amount":100,"refunded_amount":0,"currency":"USD","payment_method":"card","customer_id":"cust123","created_at":"2024-09-16T10:00:00Z","updated_at":"2024-09-16T10:00:00Z"}}`,
+		},
+		{
+			name:             "Missing gateway",
+			transactionRefID: "ref123",
+			callGetMethod:    false,
+			expectedStatus:   http.StatusBadRequest,
+			expectedBody:     `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"gateway","message":"cannot be empty"}]}}`,
+		},
+		{
+			name:             "Transaction not found",
+			transactionRefID: "unknown",
+			gateway:          "gatewayA",
+			mockError:        service.ErrTransactionNotFound,
+			callGetMethod:    true,
+			expectedStatus:   http.StatusNotFound,
+			expectedBody:     `{"code":404,"message":"transaction not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		mockService := new(MockPaymentService)
+		handler := NewPaymentHandler(mockService)
+
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.callGetMethod {
+				mockService.On("GetTransaction", mock.Anything, tt.gateway, tt.transactionRefID).Return(tt.mockResponse, tt.mockError)
+			}
+			req, _ := http.NewRequest("GET", "/api/v1/transactions/"+tt.transactionRefID+"?gateway="+tt.gateway, nil)
+			req.SetPathValue("id", tt.transactionRefID)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleGetTransaction(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleListTransactions(t *testing.T) {
+	createdAt := time.Date(2024, 9, 16, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedFilter models.TransactionFilter
+		mockResponse   models.TransactionPage
+		mockError      error
+		callListMethod bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "By reference",
+			query: "reference=txn_1",
+			expectedFilter: models.TransactionFilter{
+				Reference: "txn_1",
+			},
+			mockResponse: models.TransactionPage{
+				Transactions: []models.TransactionDetails{{
+					Reference:     "txn_1",
+					Type:          "deposit",
+					Amount:        money.MustParse("100", "USD"),
+					PaymentMethod: "card",
+					CustomerID:    "cust123",
+					Status:        "initiated",
+					CreatedAt:     createdAt,
+					UpdatedAt:     createdAt,
+				}},
+			},
+			callListMethod: true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"transactions listed successfully","data":{"transactions":[{"reference":"txn_1","ref_id":"","gateway":"","type":"deposit","status":"initiated","amount":100,"refunded_amount":0,"currency":"USD","payment_method":"card","customer_id":"cust123","created_at":"2024-09-16T10:00:00Z","updated_at":"2024-09-16T10:00:00Z"}]}}`,
+		},
+		{
+			name:  "Filtered page with next cursor",
+			query: "customer_id=cust123&status=success&min_amount=10&max_amount=500.5&created_from=2024-09-01T00:00:00Z&limit=1",
+			expectedFilter: models.TransactionFilter{
+				CustomerID:  "cust123",
+				Status:      "success",
+				MinAmount:   "10",
+				MaxAmount:   "500.5",
+				CreatedFrom: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+				Limit:       1,
+			},
+			mockResponse: models.TransactionPage{
+				Transactions: []models.TransactionDetails{{
+					Reference:     "txn_1",
+					Gateway:       "gatewayA",
+					RefID:         "ref123",
+					Type:          "deposit",
+					Amount:        money.MustParse("100", "USD"),
+					PaymentMethod: "card",
+					CustomerID:    "cust123",
+					Status:        "success",
+					ExpectedFee:   money.MustParse("3.20", "USD"),
+					CreatedAt:     createdAt,
+					UpdatedAt:     createdAt,
+				}},
+				NextCursor: "abc",
+			},
+			callListMethod: true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"transactions listed successfully","data":{"transactions":[{"reference":"txn_1","ref_id":"ref123","gateway":"gatewayA","type":"deposit","status":"success","amount":100,"refunded_amount":0,"currency":"USD","payment_method":"card","customer_id":"cust123","expected_fee":3.20,"created_at":"2024-09-16T10:00:00Z","updated_at":"2024-09-16T10:00:00Z"}],"next_cursor":"abc"}}`,
+		},
+		{
+			name:           "Empty page",
+			query:          "",
+			expectedFilter: models.TransactionFilter{},
+			callListMethod: true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"transactions listed successfully","data":{"transactions":[]}}`,
+		},
+		{
+			name:           "Invalid filters",
+			query:          "status=unknown&min_amount=abc&created_to=yesterday&limit=500",
+			callListMethod: false,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"min_amount","message":"must be a number"},{"field":"created_to","message":"must be an RFC 3339 timestamp"},{"field":"status","message":"not valid transaction status"},{"field":"limit","message":"must be between 1 and 100"}]}}`,
+		},
+		{
+			name:           "Invalid cursor",
+			query:          "cursor=broken",
+			expectedFilter: models.TransactionFilter{Cursor: "broken"},
+			mockError:      service.ErrInvalidCursor,
+			callListMethod: true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid cursor"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		mockService := new(MockPaymentService)
+		handler := NewPaymentHandler(mockService)
+
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.callListMethod {
+				mockService.On("ListTransactions", mock.Anything, tt.expectedFilter).Return(tt.mockResponse, tt.mockError)
+			}
+			req, _ := http.NewRequest("GET", "/api/v1/transactions?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleListTransactions(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
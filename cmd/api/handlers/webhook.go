@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/serde"
+	"github.com/rauf/payment-service/internal/service"
+)
+
+// WebhookHandler manages the webhook endpoints of merchants and their delivery log.
+type WebhookHandler struct {
+	webhookService webhookService
+	jsonSerde      serde.Serde
+}
+
+// interface on consumer side
+type webhookService interface {
+	RegisterEndpoint(ctx context.Context, merchantID, url string) (models.WebhookEndpointDetails, error)
+	ListEndpoints(ctx context.Context, merchantID string) ([]models.WebhookEndpointDetails, error)
+	DeleteEndpoint(ctx context.Context, merchantID string, id int32) error
+	ListDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) (models.WebhookDeliveryPage, error)
+	Redeliver(ctx context.Context, merchantID string, id int64) (models.WebhookDeliveryDetails, error)
+}
+
+func NewWebhookHandler(webhookService webhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		jsonSerde:      serde.NewJSONSerde(),
+	}
+}
+
+func (h *WebhookHandler) HandleRegisterWebhook(_ http.ResponseWriter, r *http.Request) Response {
+	merchantID := r.PathValue("merchant_id")
+	if merchantID == "" {
+		return NewResponse(http.StatusBadRequest, "missing merchant ID", nil, nil)
+	}
+
+	var apiRequest webhookEndpointApiRequest
+	if err := h.jsonSerde.Deserialize(r.Body, &apiRequest); err != nil {
+		return NewResponse(http.StatusBadRequest, "failed to decode request", nil, err)
+	}
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	endpoint, err := h.webhookService.RegisterEndpoint(r.Context(), merchantID, apiRequest.URL)
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, "failed to register webhook", nil, err)
+	}
+	slog.InfoContext(r.Context(), "Webhook endpoint registered", "merchant_id", merchantID, "endpoint_id", endpoint.ID)
+	return NewResponse(http.StatusOK, "webhook registered successfully", toWebhookEndpointApiResponse(endpoint), nil)
+}
+
+func (h *WebhookHandler) HandleListWebhooks(_ http.ResponseWriter, r *http.Request) Response {
+	merchantID := r.PathValue("merchant_id")
+	if merchantID == "" {
+		return NewResponse(http.StatusBadRequest, "missing merchant ID", nil, nil)
+	}
+
+	endpoints, err := h.webhookService.ListEndpoints(r.Context(), merchantID)
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, "failed to list webhooks", nil, err)
+	}
+	apiResponse := make([]webhookEndpointApiResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		apiResponse = append(apiResponse, toWebhookEndpointApiResponse(endpoint))
+	}
+	return NewResponse(http.StatusOK, "webhooks listed successfully", apiResponse, nil)
+}
+
+func (h *WebhookHandler) HandleDeleteWebhook(_ http.ResponseWriter, r *http.Request) Response {
+	merchantID := r.PathValue("merchant_id")
+	if merchantID == "" {
+		return NewResponse(http.StatusBadRequest, "missing merchant ID", nil, nil)
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, "invalid webhook ID", nil, err)
+	}
+
+	if err := h.webhookService.DeleteEndpoint(r.Context(), merchantID, int32(id)); err != nil {
+		if errors.Is(err, service.ErrWebhookEndpointNotFound) {
+			return NewResponse(http.StatusNotFound, "webhook not found", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to delete webhook", nil, err)
+	}
+	return NewResponse(http.StatusOK, "webhook deleted successfully", nil, nil)
+}
+
+func (h *WebhookHandler) HandleListWebhookDeliveries(_ http.ResponseWriter, r *http.Request) Response {
+	merchantID := r.PathValue("merchant_id")
+	if merchantID == "" {
+		return NewResponse(http.StatusBadRequest, "missing merchant ID", nil, nil)
+	}
+	apiRequest := newListWebhookDeliveriesApiRequest(r.URL.Query())
+	if validationErrs := apiRequest.validate(); !validationErrs.IsValid() {
+		return NewResponse(http.StatusBadRequest, "failed to validate request", validationErrs, &validationErrs)
+	}
+
+	page, err := h.webhookService.ListDeliveries(r.Context(), models.WebhookDeliveryFilter{
+		MerchantID: merchantID,
+		Status:     apiRequest.Status,
+		Cursor:     apiRequest.Cursor,
+		Limit:      apiRequest.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return NewResponse(http.StatusBadRequest, "invalid cursor", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to list webhook deliveries", nil, err)
+	}
+
+	apiResponse := listWebhookDeliveriesApiResponse{
+		Deliveries: make([]webhookDeliveryApiResponse, 0, len(page.Deliveries)),
+		NextCursor: page.NextCursor,
+	}
+	for _, delivery := range page.Deliveries {
+		apiResponse.Deliveries = append(apiResponse.Deliveries, toWebhookDeliveryApiResponse(delivery))
+	}
+	return NewResponse(http.StatusOK, "webhook deliveries listed successfully", apiResponse, nil)
+}
+
+func (h *WebhookHandler) HandleRedeliverWebhook(_ http.ResponseWriter, r *http.Request) Response {
+	merchantID := r.PathValue("merchant_id")
+	if merchantID == "" {
+		return NewResponse(http.StatusBadRequest, "missing merchant ID", nil, nil)
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, "invalid delivery ID", nil, err)
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), merchantID, id)
+	if err != nil {
+		if errors.Is(err, service.ErrWebhookDeliveryNotFound) {
+			return NewResponse(http.StatusNotFound, "webhook delivery not found", nil, err)
+		}
+		return NewResponse(http.StatusInternalServerError, "failed to redeliver webhook", nil, err)
+	}
+	return NewResponse(http.StatusOK, "webhook scheduled for redelivery", toWebhookDeliveryApiResponse(delivery), nil)
+}
+
+func toWebhookEndpointApiResponse(e models.WebhookEndpointDetails) webhookEndpointApiResponse {
+	return webhookEndpointApiResponse{
+		ID:        e.ID,
+		URL:       e.URL,
+		Secret:    e.Secret,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryApiResponse(d models.WebhookDeliveryDetails) webhookDeliveryApiResponse {
+	return webhookDeliveryApiResponse{
+		ID:            d.ID,
+		EndpointID:    d.EndpointID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		ResponseCode:  d.ResponseCode,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) RegisterEndpoint(ctx context.Context, merchantID, url string) (models.WebhookEndpointDetails, error) {
+	args := m.Called(ctx, merchantID, url)
+	return args.Get(0).(models.WebhookEndpointDetails), args.Error(1)
+}
+
+func (m *MockWebhookService) ListEndpoints(ctx context.Context, merchantID string) ([]models.WebhookEndpointDetails, error) {
+	args := m.Called(ctx, merchantID)
+	return args.Get(0).([]models.WebhookEndpointDetails), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteEndpoint(ctx context.Context, merchantID string, id int32) error {
+	args := m.Called(ctx, merchantID, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) (models.WebhookDeliveryPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(models.WebhookDeliveryPage), args.Error(1)
+}
+
+func (m *MockWebhookService) Redeliver(ctx context.Context, merchantID string, id int64) (models.WebhookDeliveryDetails, error) {
+	args := m.Called(ctx, merchantID, id)
+	return args.Get(0).(models.WebhookDeliveryDetails), args.Error(1)
+}
+
+func TestHandleRegisterWebhook(t *testing.T) {
+	createdAt := time.Date(2024, 9, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		body           string
+		callRegister   bool
+		mockResponse   models.WebhookEndpointDetails
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:         "Registered",
+			body:         `{"url":"https://merchant.example.com/hooks"}`,
+			callRegister: true,
+			mockResponse: models.WebhookEndpointDetails{
+				ID:         1,
+				MerchantID: "merchant1",
+				URL:        "https://merchant.example.com/hooks",
+				Secret:     "whsec_abc",
+				CreatedAt:  createdAt,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"webhook registered successfully","data":{"id":1,"url":"https://merchant.example.com/hooks","secret":"whsec_abc","created_at":"2024-09-20T09:00:00Z"}}`,
+		},
+		{
+			name:           "Missing URL",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"url","message":"cannot be empty"}]}}`,
+		},
+		{
+			name:           "Unsupported scheme",
+			body:           `{"url":"ftp://merchant.example.com"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"url","message":"must be an absolute http or https URL"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockWebhookService)
+			handler := NewWebhookHandler(mockService)
+			if tt.callRegister {
+				mockService.On("RegisterEndpoint", mock.Anything, "merchant1", "https://merchant.example.com/hooks").Return(tt.mockResponse, nil)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/merchants/merchant1/webhooks", bytes.NewBufferString(tt.body))
+			req.SetPathValue("merchant_id", "merchant1")
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleRegisterWebhook(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleListWebhookDeliveries(t *testing.T) {
+	at := time.Date(2024, 9, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedFilter models.WebhookDeliveryFilter
+		mockResponse   models.WebhookDeliveryPage
+		mockError      error
+		callList       bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Dead deliveries",
+			query:          "status=dead&limit=1",
+			expectedFilter: models.WebhookDeliveryFilter{MerchantID: "merchant1", Status: "dead", Limit: 1},
+			mockResponse: models.WebhookDeliveryPage{
+				Deliveries: []models.WebhookDeliveryDetails{{
+					ID:            3,
+					EndpointID:    1,
+					EventID:       42,
+					EventType:     "transaction.succeeded",
+					Status:        "dead",
+					Attempts:      13,
+					NextAttemptAt: at,
+					ResponseCode:  500,
+					LastError:     "webhook endpoint responded with 500",
+					CreatedAt:     at,
+					UpdatedAt:     at,
+				}},
+				NextCursor: "Mw",
+			},
+			callList:       true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"webhook deliveries listed successfully","data":{"deliveries":[{"id":3,"endpoint_id":1,"event_id":42,"event_type":"transaction.succeeded","status":"dead","attempts":13,"next_attempt_at":"2024-09-20T09:00:00Z","response_code":500,"last_error":"webhook endpoint responded with 500","created_at":"2024-09-20T09:00:00Z","updated_at":"2024-09-20T09:00:00Z"}],"next_cursor":"Mw"}}`,
+		},
+		{
+			name:           "Invalid filters",
+			query:          "status=lost&limit=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"failed to validate request","data":{"errors":[{"field":"limit","message":"must be an integer"},{"field":"status","message":"not valid delivery status"}]}}`,
+		},
+		{
+			name:           "Invalid cursor",
+			query:          "cursor=broken",
+			expectedFilter: models.WebhookDeliveryFilter{MerchantID: "merchant1", Cursor: "broken"},
+			mockError:      service.ErrInvalidCursor,
+			callList:       true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid cursor"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockWebhookService)
+			handler := NewWebhookHandler(mockService)
+			if tt.callList {
+				mockService.On("ListDeliveries", mock.Anything, tt.expectedFilter).Return(tt.mockResponse, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("GET", "/api/v1/merchants/merchant1/webhooks/deliveries?"+tt.query, nil)
+			req.SetPathValue("merchant_id", "merchant1")
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleListWebhookDeliveries(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleRedeliverWebhook(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockError      error
+		callRedeliver  bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Scheduled",
+			id:             "3",
+			callRedeliver:  true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"code":200,"message":"webhook scheduled for redelivery","data":{"id":3,"endpoint_id":1,"event_id":42,"event_type":"transaction.succeeded","status":"pending","attempts":0,"next_attempt_at":"0001-01-01T00:00:00Z","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:           "Not found",
+			id:             "3",
+			mockError:      service.ErrWebhookDeliveryNotFound,
+			callRedeliver:  true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":404,"message":"webhook delivery not found"}`,
+		},
+		{
+			name:           "Invalid ID",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":400,"message":"invalid delivery ID"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockWebhookService)
+			handler := NewWebhookHandler(mockService)
+			if tt.callRedeliver {
+				mockService.On("Redeliver", mock.Anything, "merchant1", int64(3)).Return(models.WebhookDeliveryDetails{
+					ID:         3,
+					EndpointID: 1,
+					EventID:    42,
+					EventType:  "transaction.succeeded",
+					Status:     "pending",
+				}, tt.mockError)
+			}
+
+			req, _ := http.NewRequest("POST", "/api/v1/merchants/merchant1/webhooks/deliveries/"+tt.id+"/redeliver", nil)
+			req.SetPathValue("merchant_id", "merchant1")
+			req.SetPathValue("id", tt.id)
+			rr := httptest.NewRecorder()
+
+			res := handler.HandleRedeliverWebhook(rr, req)
+			writeResponse(rr, req, res)
+
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
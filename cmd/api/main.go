@@ -22,6 +22,8 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to setup application: %w", err)
 	}
 
+	app.StartWorkers(ctx)
+
 	mux := app.SetupRoutes()
 	slog.InfoContext(ctx, "starting server on :8080")
 	return http.ListenAndServe(":8080", mux)
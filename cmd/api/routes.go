@@ -9,12 +9,43 @@ import (
 func (a *Application) SetupRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 
+	mux.HandleFunc("GET /api/v1/transactions", handlers.MakeHandler(a.PaymentHandler.HandleListTransactions))
 	mux.HandleFunc("POST /api/v1/transactions", handlers.MakeHandler(a.PaymentHandler.HandleCreateTransaction))
+	mux.HandleFunc("GET /api/v1/transactions/{id}", handlers.MakeHandler(a.PaymentHandler.HandleGetTransaction))
+	mux.HandleFunc("GET /api/v1/transactions/{id}/history", handlers.MakeHandler(a.PaymentHandler.HandleGetStatusHistory))
+	mux.HandleFunc("POST /api/v1/transactions/authorize", handlers.MakeHandler(a.PaymentHandler.HandleAuthorizeTransaction))
+	mux.HandleFunc("POST /api/v1/transactions/{id}/capture", handlers.MakeHandler(a.PaymentHandler.HandleCaptureTransaction))
+	mux.HandleFunc("POST /api/v1/transactions/{id}/void", handlers.MakeHandler(a.PaymentHandler.HandleVoidTransaction))
 	mux.HandleFunc("PATCH /api/v1/transactions/{id}/status", handlers.MakeHandler(a.PaymentHandler.HandleUpdateStatus))
+	mux.HandleFunc("POST /api/v1/transactions/{id}/refunds", handlers.MakeHandler(a.PaymentHandler.HandleCreateRefund))
 
-	// Each gateway can have its own response and format
-	mux.HandleFunc("POST /api/v1/gateways/gatewayA/callback", handlers.MakeHandler(a.PaymentHandler.HandleGatewayACallback))
-	mux.HandleFunc("POST /api/v1/gateways/gatewayB/callback", handlers.MakeHandler(a.PaymentHandler.HandleGatewayBCallback))
+	mux.HandleFunc("GET /api/v1/merchants/{merchant_id}/webhooks", handlers.MakeHandler(a.WebhookHandler.HandleListWebhooks))
+	mux.HandleFunc("POST /api/v1/merchants/{merchant_id}/webhooks", handlers.MakeHandler(a.WebhookHandler.HandleRegisterWebhook))
+	mux.HandleFunc("DELETE /api/v1/merchants/{merchant_id}/webhooks/{id}", handlers.MakeHandler(a.WebhookHandler.HandleDeleteWebhook))
+	mux.HandleFunc("GET /api/v1/merchants/{merchant_id}/webhooks/deliveries", handlers.MakeHandler(a.WebhookHandler.HandleListWebhookDeliveries))
+	mux.HandleFunc("POST /api/v1/merchants/{merchant_id}/webhooks/deliveries/{id}/redeliver", handlers.MakeHandler(a.WebhookHandler.HandleRedeliverWebhook))
+
+	// Each gateway decodes and verifies its own callbacks
+	mux.HandleFunc("POST /api/v1/gateways/{name}/callback", handlers.MakeHandler(a.CallbackHandler.HandleGatewayCallback))
+
+	mux.HandleFunc("GET /api/v1/admin/callbacks", handlers.MakeHandler(a.CallbackHandler.HandleListCallbackInbox))
+	mux.HandleFunc("POST /api/v1/admin/callbacks/{id}/replay", handlers.MakeHandler(a.CallbackHandler.HandleReplayCallback))
+
+	mux.HandleFunc("POST /api/v1/admin/transactions/{reference}/resolve", handlers.MakeHandler(a.PaymentHandler.HandleResolveTransaction))
+
+	mux.HandleFunc("GET /api/v1/admin/reconciliations", handlers.MakeHandler(a.ReconciliationHandler.HandleListReconciliations))
+	mux.HandleFunc("POST /api/v1/admin/reconciliations", handlers.MakeHandler(a.ReconciliationHandler.HandleCreateReconciliation))
+	mux.HandleFunc("GET /api/v1/admin/reconciliations/{id}", handlers.MakeHandler(a.ReconciliationHandler.HandleGetReconciliation))
+
+	mux.HandleFunc("GET /api/v1/customers/{customer_id}/balances", handlers.MakeHandler(a.LedgerHandler.HandleGetCustomerBalances))
+	mux.HandleFunc("GET /api/v1/admin/ledger/accounts", handlers.MakeHandler(a.LedgerHandler.HandleListLedgerAccounts))
+	mux.HandleFunc("POST /api/v1/admin/ledger/fees", handlers.MakeHandler(a.LedgerHandler.HandlePostFee))
+	mux.HandleFunc("POST /api/v1/admin/ledger/adjustments", handlers.MakeHandler(a.LedgerHandler.HandlePostAdjustment))
+	mux.HandleFunc("GET /api/v1/admin/ledger/check", handlers.MakeHandler(a.LedgerHandler.HandleCheckLedger))
+
+	mux.HandleFunc("POST /api/v1/admin/routing/dry-run", handlers.MakeHandler(a.RoutingHandler.HandleDryRunRouting))
+	mux.HandleFunc("GET /api/v1/admin/routing/rules", handlers.MakeHandler(a.RoutingHandler.HandleListRoutingRules))
+	mux.HandleFunc("PUT /api/v1/admin/routing/rules/{name}/weights", handlers.MakeHandler(a.RoutingHandler.HandleSetRoutingWeights))
 
 	return mux
 }
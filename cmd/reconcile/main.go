@@ -0,0 +1,118 @@
+// Command reconcile reconciles a gateway settlement report file with the transactions in the database and
+// prints the discrepancies.
+//
+//	go run ./cmd/reconcile -gateway gatewayA -file settlement.csv -from 2024-09-01
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rauf/payment-service/internal/config"
+	"github.com/rauf/payment-service/internal/database"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/reconcile"
+	"github.com/rauf/payment-service/internal/repo"
+	"github.com/rauf/payment-service/internal/service"
+)
+
+// dateLayout is the layout of the -from and -to flags.
+const dateLayout = time.DateOnly
+
+func main() {
+	ctx := context.Background()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		slog.ErrorContext(ctx, "failed to reconcile", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	gateway := flags.String("gateway", "", "gateway that sent the settlement report")
+	file := flags.String("file", "", "path of the settlement report")
+	from := flags.String("from", "", "first day of the settlement period, as YYYY-MM-DD")
+	to := flags.String("to", "", "day after the settlement period, as YYYY-MM-DD (default the day after -from)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *gateway == "" || *file == "" || *from == "" {
+		flags.Usage()
+		return errors.New("-gateway, -file and -from are required")
+	}
+
+	periodFrom, err := time.Parse(dateLayout, *from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	periodTo := periodFrom.AddDate(0, 0, 1)
+	if *to != "" {
+		if periodTo, err = time.Parse(dateLayout, *to); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+	if !periodFrom.Before(periodTo) {
+		return errors.New("-to must be after -from")
+	}
+
+	report, err := os.Open(*file)
+	if err != nil {
+		return fmt.Errorf("failed to open settlement report: %w", err)
+	}
+	defer report.Close()
+
+	conf := config.NewConfig()
+	db, err := database.NewDatabase(conf.Database)
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer db.Close()
+	parsers, err := reconcile.NewParsers()
+	if err != nil {
+		return fmt.Errorf("failed to create settlement report parsers: %w", err)
+	}
+	reconciliationService := service.NewReconciliationService(repo.NewPaymentRepo(db.DB), parsers)
+
+	reconciliation, err := reconciliationService.Reconcile(ctx, models.ReconcileRequest{
+		Gateway:    *gateway,
+		PeriodFrom: periodFrom,
+		PeriodTo:   periodTo,
+		Report:     report,
+	})
+	if err != nil {
+		return err
+	}
+	return printReconciliation(out, reconciliation)
+}
+
+func printReconciliation(out io.Writer, r models.Reconciliation) error {
+	fmt.Fprintf(out, "Reconciliation %d of %s from %s to %s\n", r.ID, r.Gateway, r.PeriodFrom.Format(dateLayout), r.PeriodTo.Format(dateLayout))
+	fmt.Fprintf(out, "Records: %d, matched: %d, discrepancies: %d\n", r.Records, r.Matched, r.DiscrepancyCount)
+	if len(r.Discrepancies) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tREF ID\tRECORDED\tSETTLED")
+	for _, d := range r.Discrepancies {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Kind, d.RefID, side(d.RecordedAmount.String(), d.RecordedStatus), side(d.SettledAmount.String(), d.SettledStatus))
+	}
+	return w.Flush()
+}
+
+// side formats the amount and status of one side of a discrepancy, which has neither when the transaction
+// is missing from it.
+func side(amount, status string) string {
+	if status == "" {
+		return "-"
+	}
+	return amount + " " + status
+}
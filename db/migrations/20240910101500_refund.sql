@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transaction
+    ADD COLUMN refunded_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT transaction_refunded_amount_check CHECK (refunded_amount >= 0 AND refunded_amount <= amount);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS refund
+(
+    id             SERIAL PRIMARY KEY,
+    transaction_id INTEGER            NOT NULL REFERENCES transaction (id),
+    amount         NUMERIC(15, 2)     NOT NULL CHECK (amount > 0),
+    currency       VARCHAR(10)        NOT NULL,
+    reason         TEXT,
+    gateway        VARCHAR(50)        NOT NULL,
+    gateway_ref_id VARCHAR(50),
+    status         transaction_status NOT NULL DEFAULT 'PENDING',
+    created_at     TIMESTAMP          NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP          NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS refund_transaction_id_idx ON refund (transaction_id);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refund;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction
+    DROP CONSTRAINT IF EXISTS transaction_refunded_amount_check,
+    DROP COLUMN IF EXISTS refunded_amount;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'AUTHORIZED';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'CAPTURED';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'VOIDED';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction
+    ADD COLUMN captured_amount          NUMERIC(15, 2),
+    ADD COLUMN authorization_expires_at TIMESTAMP,
+    ADD CONSTRAINT transaction_captured_amount_check CHECK (captured_amount > 0 AND captured_amount <= amount),
+    DROP CONSTRAINT IF EXISTS transaction_refunded_amount_check,
+    ADD CONSTRAINT transaction_refunded_amount_check CHECK (refunded_amount >= 0 AND refunded_amount <= COALESCE(captured_amount, amount));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS transaction_authorization_expires_at_idx ON transaction (authorization_expires_at) WHERE status = 'AUTHORIZED';
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- Enum values cannot be removed from transaction_status, so only the columns are dropped.
+-- +goose StatementBegin
+DROP INDEX IF EXISTS transaction_authorization_expires_at_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction
+    DROP CONSTRAINT IF EXISTS transaction_refunded_amount_check,
+    DROP CONSTRAINT IF EXISTS transaction_captured_amount_check,
+    DROP COLUMN IF EXISTS authorization_expires_at,
+    DROP COLUMN IF EXISTS captured_amount,
+    ADD CONSTRAINT transaction_refunded_amount_check CHECK (refunded_amount >= 0 AND refunded_amount <= amount);
+-- +goose StatementEnd
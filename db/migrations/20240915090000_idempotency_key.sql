@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE idempotency_status AS ENUM ('IN_PROGRESS', 'COMPLETED');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_key
+(
+    id           SERIAL PRIMARY KEY,
+    key          VARCHAR(255)       NOT NULL UNIQUE,
+    request_hash VARCHAR(64)        NOT NULL,
+    status       idempotency_status NOT NULL DEFAULT 'IN_PROGRESS',
+    response     JSONB,
+    created_at   TIMESTAMP          NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP          NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idempotency_key_created_at_idx ON idempotency_key (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS idempotency_status;
+-- +goose StatementEnd
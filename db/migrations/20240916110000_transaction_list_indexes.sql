@@ -0,0 +1,30 @@
+-- +goose NO TRANSACTION
+
+-- Indexes are built concurrently so that listing can be rolled out without locking the transaction table.
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS transaction_created_at_id_idx ON transaction (created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS transaction_customer_id_created_at_idx ON transaction (customer_id, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS transaction_status_created_at_idx ON transaction (status, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS transaction_status_created_at_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS transaction_customer_id_created_at_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS transaction_created_at_id_idx;
+-- +goose StatementEnd
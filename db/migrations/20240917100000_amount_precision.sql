@@ -0,0 +1,27 @@
+-- +goose Up
+-- Amounts are stored in major units. Four decimal places fit every ISO 4217 currency exponent.
+-- +goose StatementBegin
+ALTER TABLE transaction
+    ALTER COLUMN amount TYPE NUMERIC(19, 4),
+    ALTER COLUMN captured_amount TYPE NUMERIC(19, 4),
+    ALTER COLUMN refunded_amount TYPE NUMERIC(19, 4);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE refund
+    ALTER COLUMN amount TYPE NUMERIC(19, 4);
+-- +goose StatementEnd
+
+-- +goose Down
+-- Amounts of currencies with more than two decimal places are rounded.
+-- +goose StatementBegin
+ALTER TABLE refund
+    ALTER COLUMN amount TYPE NUMERIC(15, 2);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction
+    ALTER COLUMN amount TYPE NUMERIC(15, 2),
+    ALTER COLUMN captured_amount TYPE NUMERIC(15, 2),
+    ALTER COLUMN refunded_amount TYPE NUMERIC(15, 2);
+-- +goose StatementEnd
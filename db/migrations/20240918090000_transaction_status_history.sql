@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE status_change_source AS ENUM ('API', 'CALLBACK', 'RECONCILIATION', 'SYSTEM');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS transaction_status_history
+(
+    id             SERIAL PRIMARY KEY,
+    transaction_id INTEGER              NOT NULL REFERENCES transaction (id),
+    from_status    transaction_status   NOT NULL,
+    to_status      transaction_status   NOT NULL,
+    source         status_change_source NOT NULL,
+    raw_payload    TEXT,
+    created_at     TIMESTAMP            NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS transaction_status_history_transaction_id_idx ON transaction_status_history (transaction_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transaction_status_history;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS status_change_source;
+-- +goose StatementEnd
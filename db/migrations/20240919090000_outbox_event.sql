@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS outbox_event
+(
+    id           BIGSERIAL PRIMARY KEY,
+    event_type   VARCHAR(100) NOT NULL,
+    payload      JSONB        NOT NULL,
+    attempts     INTEGER      NOT NULL DEFAULT 0,
+    last_error   TEXT,
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS outbox_event_unpublished_idx ON outbox_event (id) WHERE published_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_event;
+-- +goose StatementEnd
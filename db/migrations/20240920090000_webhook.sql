@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transaction ADD COLUMN IF NOT EXISTS merchant_id VARCHAR(100);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TYPE webhook_delivery_status AS ENUM ('PENDING', 'DELIVERED', 'DEAD');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_endpoint
+(
+    id          SERIAL PRIMARY KEY,
+    merchant_id VARCHAR(100) NOT NULL,
+    url         TEXT         NOT NULL,
+    secret      VARCHAR(100) NOT NULL,
+    active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS webhook_endpoint_merchant_idx ON webhook_endpoint (merchant_id) WHERE active;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_delivery
+(
+    id              BIGSERIAL PRIMARY KEY,
+    endpoint_id     INTEGER                 NOT NULL REFERENCES webhook_endpoint (id),
+    event_id        BIGINT                  NOT NULL,
+    event_type      VARCHAR(100)            NOT NULL,
+    payload         JSONB                   NOT NULL,
+    status          webhook_delivery_status NOT NULL,
+    attempts        INTEGER                 NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP               NOT NULL,
+    response_code   INTEGER,
+    last_error      TEXT,
+    created_at      TIMESTAMP               NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP               NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (endpoint_id, event_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON webhook_delivery (next_attempt_at) WHERE status = 'PENDING';
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_delivery;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_endpoint;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS webhook_delivery_status;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction DROP COLUMN IF EXISTS merchant_id;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE decline_reason AS ENUM ('INSUFFICIENT_FUNDS', 'DO_NOT_HONOR', 'FRAUD', 'EXPIRED_CARD', 'TECHNICAL');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction ADD COLUMN IF NOT EXISTS gateway_status_code VARCHAR(50);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction ADD COLUMN IF NOT EXISTS decline_reason decline_reason;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE transaction DROP COLUMN IF EXISTS decline_reason;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction DROP COLUMN IF EXISTS gateway_status_code;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS decline_reason;
+-- +goose StatementEnd
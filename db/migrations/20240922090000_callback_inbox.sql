@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE callback_inbox_status AS ENUM ('RECEIVED', 'APPLIED', 'PARKED', 'REJECTED');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS callback_inbox
+(
+    id                  BIGSERIAL PRIMARY KEY,
+    gateway             VARCHAR(50)           NOT NULL,
+    ref_id              VARCHAR(50)           NOT NULL,
+    dedupe_key          VARCHAR(255)          NOT NULL,
+    status              callback_inbox_status NOT NULL DEFAULT 'RECEIVED',
+    reported_status     transaction_status    NOT NULL,
+    gateway_status_code VARCHAR(50),
+    decline_reason      decline_reason,
+    raw_payload         TEXT                  NOT NULL,
+    attempts            INTEGER               NOT NULL DEFAULT 0,
+    last_error          TEXT,
+    created_at          TIMESTAMP             NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at          TIMESTAMP             NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (gateway, dedupe_key)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS callback_inbox_parked_idx ON callback_inbox (id) WHERE status = 'PARKED';
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS callback_inbox;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS callback_inbox_status;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'INITIATED';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction ADD COLUMN IF NOT EXISTS reference VARCHAR(50);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE transaction SET reference = 'txn_' || id WHERE reference IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction ALTER COLUMN reference SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS transaction_reference_idx ON transaction (reference);
+-- +goose StatementEnd
+
+-- The gateway reference is only known once the gateway has answered.
+-- +goose StatementBegin
+ALTER TABLE transaction ALTER COLUMN gateway_ref_id DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS transaction_initiated_idx ON transaction (created_at) WHERE status = 'INITIATED';
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- Enum values cannot be removed from transaction_status. Transactions that never reached a gateway are removed
+-- so that the gateway reference can be required again.
+-- +goose StatementBegin
+DROP INDEX IF EXISTS transaction_initiated_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM transaction_status_history
+WHERE transaction_id IN (SELECT id FROM transaction WHERE gateway_ref_id IS NULL);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM transaction WHERE gateway_ref_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction ALTER COLUMN gateway_ref_id SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS transaction_reference_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction DROP COLUMN IF EXISTS reference;
+-- +goose StatementEnd
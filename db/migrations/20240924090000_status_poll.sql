@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transaction ADD COLUMN IF NOT EXISTS status_poll_attempts INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction ADD COLUMN IF NOT EXISTS next_status_poll_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS transaction_pending_poll_idx ON transaction (created_at) WHERE status = 'PENDING';
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS transaction_pending_poll_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction DROP COLUMN IF EXISTS next_status_poll_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transaction DROP COLUMN IF EXISTS status_poll_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE reconciliation_discrepancy_kind AS ENUM ('MISSING', 'EXTRA', 'AMOUNT_MISMATCH', 'STATUS_MISMATCH');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS reconciliation_run
+(
+    id            BIGSERIAL PRIMARY KEY,
+    gateway       VARCHAR(50) NOT NULL,
+    period_from   TIMESTAMP   NOT NULL,
+    period_to     TIMESTAMP   NOT NULL,
+    records       INTEGER     NOT NULL,
+    matched       INTEGER     NOT NULL,
+    discrepancies INTEGER     NOT NULL,
+    created_at    TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancy
+(
+    id                BIGSERIAL PRIMARY KEY,
+    run_id            BIGINT                          NOT NULL REFERENCES reconciliation_run (id) ON DELETE CASCADE,
+    kind              reconciliation_discrepancy_kind NOT NULL,
+    ref_id            VARCHAR(50)                     NOT NULL,
+    recorded_amount   NUMERIC(19, 4),
+    recorded_currency VARCHAR(3),
+    recorded_status   VARCHAR(20),
+    settled_amount    NUMERIC(19, 4),
+    settled_currency  VARCHAR(3),
+    settled_status    VARCHAR(20)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS reconciliation_discrepancy_run_idx ON reconciliation_discrepancy (run_id);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reconciliation_discrepancy;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reconciliation_run;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS reconciliation_discrepancy_kind;
+-- +goose StatementEnd
@@ -0,0 +1,85 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE ledger_account_type AS ENUM ('CUSTOMER', 'GATEWAY_CLEARING', 'FEES', 'SUSPENSE');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TYPE ledger_entry_kind AS ENUM ('DEPOSIT', 'WITHDRAWAL', 'REFUND', 'FEE', 'ADJUSTMENT');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledger_account
+(
+    id         BIGSERIAL PRIMARY KEY,
+    type       ledger_account_type NOT NULL,
+    -- customer ID of customer accounts, gateway of gateway clearing and fee accounts, empty for suspense
+    owner      VARCHAR(100)        NOT NULL,
+    currency   VARCHAR(3)          NOT NULL,
+    created_at TIMESTAMP           NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (type, owner, currency)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledger_entry
+(
+    id             BIGSERIAL PRIMARY KEY,
+    kind           ledger_entry_kind NOT NULL,
+    transaction_id INTEGER REFERENCES transaction (id),
+    refund_id      INTEGER REFERENCES refund (id),
+    description    TEXT,
+    created_at     TIMESTAMP         NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- A transaction or refund moves money at most once
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_entry_transaction_idx ON ledger_entry (transaction_id, kind) WHERE transaction_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_entry_refund_idx ON ledger_entry (refund_id) WHERE refund_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledger_posting
+(
+    id         BIGSERIAL PRIMARY KEY,
+    entry_id   BIGINT         NOT NULL REFERENCES ledger_entry (id),
+    account_id BIGINT         NOT NULL REFERENCES ledger_account (id),
+    -- debits are positive and credits negative
+    amount     NUMERIC(19, 4) NOT NULL CHECK (amount <> 0),
+    currency   VARCHAR(3)     NOT NULL,
+    created_at TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS ledger_posting_entry_idx ON ledger_posting (entry_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS ledger_posting_account_idx ON ledger_posting (account_id);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_posting;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_entry;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_account;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS ledger_entry_kind;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TYPE IF EXISTS ledger_account_type;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transaction
+    ADD COLUMN destination_customer_id VARCHAR(100),
+    ADD CONSTRAINT transaction_destination_customer_id_check
+        CHECK ((type = 'TRANSFER') = (destination_customer_id IS NOT NULL));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TYPE ledger_entry_kind ADD VALUE IF NOT EXISTS 'TRANSFER';
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- Enum values cannot be removed from ledger_entry_kind, so only the column is dropped.
+-- +goose StatementBegin
+ALTER TABLE transaction
+    DROP CONSTRAINT IF EXISTS transaction_destination_customer_id_check,
+    DROP COLUMN IF EXISTS destination_customer_id;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transaction
+    ADD COLUMN routing_rule VARCHAR(100);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transaction
+    DROP COLUMN IF EXISTS routing_rule;
+-- +goose StatementEnd
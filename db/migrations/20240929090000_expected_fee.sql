@@ -0,0 +1,12 @@
+-- +goose Up
+-- The fee the gateway is expected to charge, in the currency of the transaction, from its fee schedule.
+-- +goose StatementBegin
+ALTER TABLE transaction
+    ADD COLUMN expected_fee NUMERIC(19, 4);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transaction
+    DROP COLUMN IF EXISTS expected_fee;
+-- +goose StatementEnd
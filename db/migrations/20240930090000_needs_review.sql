@@ -0,0 +1,14 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+-- Initiated transactions the sweeper could not resolve with the gateways are parked for manual review.
+-- +goose StatementBegin
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'NEEDS_REVIEW';
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- Enum values cannot be removed from transaction_status. Parked transactions are handed back to the sweeper.
+-- +goose StatementBegin
+UPDATE transaction SET status = 'INITIATED' WHERE status = 'NEEDS_REVIEW';
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refund ADD COLUMN IF NOT EXISTS status_poll_attempts INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE refund ADD COLUMN IF NOT EXISTS next_status_poll_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS refund_pending_poll_idx ON refund (created_at) WHERE status = 'PENDING';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS refund_gateway_ref_id_idx ON refund (gateway, gateway_ref_id);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS refund_gateway_ref_id_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS refund_pending_poll_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE refund DROP COLUMN IF EXISTS next_status_poll_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE refund DROP COLUMN IF EXISTS status_poll_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE idempotency_key ADD COLUMN IF NOT EXISTS reference VARCHAR(50);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE idempotency_key DROP COLUMN IF EXISTS reference;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- name: CreateCallbackInboxEntry :one
+INSERT INTO callback_inbox (gateway, ref_id, dedupe_key, reported_status, gateway_status_code, decline_reason,
+                            raw_payload, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+ON CONFLICT (gateway, dedupe_key) DO NOTHING
+RETURNING *;
+
+-- name: GetCallbackInboxEntry :one
+SELECT *
+FROM callback_inbox
+WHERE id = $1;
+
+-- name: GetCallbackInboxEntryByDedupeKey :one
+SELECT *
+FROM callback_inbox
+WHERE gateway = $1 AND dedupe_key = $2;
+
+-- name: UpdateCallbackInboxEntry :one
+UPDATE callback_inbox
+SET status = $2, attempts = attempts + 1, last_error = $3, updated_at = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: ListCallbackInboxEntries :many
+SELECT *
+FROM callback_inbox
+WHERE (sqlc.narg(gateway)::varchar IS NULL OR gateway = sqlc.narg(gateway))
+  AND (sqlc.narg(ref_id)::varchar IS NULL OR ref_id = sqlc.narg(ref_id))
+  AND (sqlc.narg(status)::callback_inbox_status IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(cursor_id)::bigint IS NULL OR id < sqlc.narg(cursor_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListUnparkableCallbacks :many
+SELECT i.*
+FROM callback_inbox i
+WHERE i.status = 'PARKED'
+  AND (EXISTS (SELECT 1 FROM transaction t WHERE t.gateway = i.gateway AND t.gateway_ref_id = i.ref_id)
+    OR EXISTS (SELECT 1 FROM refund r WHERE r.gateway = i.gateway AND r.gateway_ref_id = i.ref_id))
+ORDER BY i.id
+LIMIT $1;
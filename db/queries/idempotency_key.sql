@@ -0,0 +1,29 @@
+-- name: CreateIdempotencyKey :execrows
+INSERT INTO idempotency_key (key, request_hash, created_at, updated_at)
+VALUES ($1, $2, $3, $3)
+ON CONFLICT (key) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+SELECT *
+FROM idempotency_key
+WHERE key = $1;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_key
+SET status = 'COMPLETED', response = $2, updated_at = $3
+WHERE key = $1;
+
+-- name: SetIdempotencyKeyReference :exec
+UPDATE idempotency_key
+SET reference = $2, updated_at = $3
+WHERE key = $1;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE
+FROM idempotency_key
+WHERE key = $1 AND status = 'IN_PROGRESS';
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE
+FROM idempotency_key
+WHERE created_at < $1;
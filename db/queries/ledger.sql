@@ -0,0 +1,44 @@
+-- name: UpsertLedgerAccount :one
+INSERT INTO ledger_account (type, owner, currency, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (type, owner, currency) DO UPDATE SET type = EXCLUDED.type
+RETURNING *;
+
+-- name: CreateLedgerEntry :one
+INSERT INTO ledger_entry (kind, transaction_id, refund_id, description, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreateLedgerPosting :exec
+INSERT INTO ledger_posting (entry_id, account_id, amount, currency, created_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListLedgerBalances :many
+SELECT a.id, a.type, a.owner, a.currency, COALESCE(SUM(p.amount), 0)::numeric AS balance
+FROM ledger_account a
+         LEFT JOIN ledger_posting p ON p.account_id = a.id
+WHERE (sqlc.narg(type)::ledger_account_type IS NULL OR a.type = sqlc.narg(type))
+  AND (sqlc.narg(owner)::varchar IS NULL OR a.owner = sqlc.narg(owner))
+  AND (sqlc.narg(cursor_id)::bigint IS NULL OR a.id > sqlc.narg(cursor_id))
+GROUP BY a.id
+ORDER BY a.id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetLedgerAccountBalance :one
+SELECT COALESCE(SUM(amount), 0)::numeric AS balance
+FROM ledger_posting
+WHERE account_id = $1;
+
+-- name: SumLedgerPostings :many
+SELECT currency, SUM(amount)::numeric AS total, COUNT(*) AS postings
+FROM ledger_posting
+GROUP BY currency
+ORDER BY currency;
+
+-- name: ListUnbalancedLedgerEntries :many
+SELECT entry_id, currency, SUM(amount)::numeric AS total
+FROM ledger_posting
+GROUP BY entry_id, currency
+HAVING SUM(amount) <> 0
+ORDER BY entry_id
+LIMIT $1;
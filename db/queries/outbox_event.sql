@@ -0,0 +1,20 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_event (event_type, payload, created_at)
+VALUES ($1, $2, $3);
+
+-- name: ListPendingOutboxEvents :many
+SELECT *
+FROM outbox_event
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1 FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_event
+SET published_at = $2
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_event
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1;
@@ -0,0 +1,28 @@
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_run (gateway, period_from, period_to, records, matched, discrepancies, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: CreateReconciliationDiscrepancy :exec
+INSERT INTO reconciliation_discrepancy (run_id, kind, ref_id, recorded_amount, recorded_currency, recorded_status,
+                                        settled_amount, settled_currency, settled_status)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetReconciliationRun :one
+SELECT *
+FROM reconciliation_run
+WHERE id = $1;
+
+-- name: ListReconciliationRuns :many
+SELECT *
+FROM reconciliation_run
+WHERE (sqlc.narg(gateway)::varchar IS NULL OR gateway = sqlc.narg(gateway))
+  AND (sqlc.narg(cursor_id)::bigint IS NULL OR id < sqlc.narg(cursor_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListReconciliationDiscrepancies :many
+SELECT *
+FROM reconciliation_discrepancy
+WHERE run_id = $1
+ORDER BY id;
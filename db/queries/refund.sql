@@ -0,0 +1,51 @@
+-- name: ReserveRefundAmount :execrows
+UPDATE transaction
+SET refunded_amount = refunded_amount + sqlc.arg(amount)::numeric, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND refunded_amount + sqlc.arg(amount)::numeric <= COALESCE(captured_amount, amount);
+
+-- name: ReleaseRefundAmount :exec
+UPDATE transaction
+SET refunded_amount = refunded_amount - sqlc.arg(amount)::numeric, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id);
+
+-- name: CreateRefund :one
+INSERT INTO refund (transaction_id,
+                    amount,
+                    currency,
+                    reason,
+                    gateway,
+                    status)
+VALUES ($1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6)
+RETURNING *;
+
+-- name: UpdateRefund :one
+UPDATE refund
+SET gateway_ref_id = COALESCE($2, gateway_ref_id), status = $3, updated_at = $4
+WHERE id = $1 AND status = 'PENDING'
+RETURNING *;
+
+-- name: GetRefundByGatewayRefID :one
+SELECT *
+FROM refund
+WHERE gateway = $1 AND gateway_ref_id = $2;
+
+-- name: ListPendingRefundsToPoll :many
+SELECT *
+FROM refund
+WHERE status = 'PENDING'
+  AND created_at < sqlc.arg(created_before)
+  AND status_poll_attempts < sqlc.arg(max_attempts)
+  AND (next_status_poll_at IS NULL OR next_status_poll_at <= sqlc.arg(now))
+ORDER BY created_at
+LIMIT sqlc.arg(page_size);
+
+-- name: ScheduleRefundStatusPoll :exec
+UPDATE refund
+SET status_poll_attempts = status_poll_attempts + 1,
+    next_status_poll_at  = $2
+WHERE id = $1;
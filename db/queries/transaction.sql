@@ -1,8 +1,22 @@
--- name: GetAll :one
+-- name: ListTransactions :many
 SELECT *
-FROM transaction;
+FROM transaction
+WHERE (sqlc.narg(customer_id)::varchar IS NULL OR customer_id = sqlc.narg(customer_id))
+  AND (sqlc.narg(reference)::varchar IS NULL OR reference = sqlc.narg(reference))
+  AND (sqlc.narg(status)::transaction_status IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(gateway)::varchar IS NULL OR gateway = sqlc.narg(gateway))
+  AND (sqlc.narg(type)::transaction_type IS NULL OR type = sqlc.narg(type))
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND (sqlc.narg(min_amount)::numeric IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::numeric IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamp IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR
+       (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::integer))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
 
--- name: CreateTransaction :exec
+-- name: CreateTransaction :one
 INSERT INTO transaction (type,
                      amount,
                      currency,
@@ -13,7 +27,13 @@ INSERT INTO transaction (type,
                      gateway_ref_id,
                      status,
                      preferred_gateway,
-                     metadata)
+                     metadata,
+                     authorization_expires_at,
+                     merchant_id,
+                     gateway_status_code,
+                     decline_reason,
+                     reference,
+                     destination_customer_id)
 VALUES ($1,
         $2,
         $3,
@@ -24,15 +44,119 @@ VALUES ($1,
         $8,
         $9,
         $10,
-        $11);
+        $11,
+        $12,
+        $13,
+        $14,
+        $15,
+        $16,
+        $17)
+RETURNING *;
+
+-- name: CompleteInitiatedTransaction :one
+UPDATE transaction
+SET gateway                  = sqlc.arg(gateway),
+    gateway_ref_id           = sqlc.arg(gateway_ref_id),
+    status                   = sqlc.arg(status),
+    gateway_status_code      = sqlc.narg(gateway_status_code),
+    decline_reason           = sqlc.narg(decline_reason),
+    authorization_expires_at = sqlc.narg(authorization_expires_at),
+    routing_rule             = sqlc.narg(routing_rule),
+    expected_fee             = sqlc.narg(expected_fee),
+    status_poll_attempts     = 0,
+    next_status_poll_at      = NULL,
+    updated_at               = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING *;
+
+-- name: ListStaleInitiatedTransactions :many
+SELECT *
+FROM transaction
+WHERE status = 'INITIATED'
+  AND created_at < sqlc.arg(created_before)
+  AND (next_status_poll_at IS NULL OR next_status_poll_at <= sqlc.arg(now))
+ORDER BY created_at
+LIMIT sqlc.arg(page_size);
 
+-- name: GetTransactionByReference :one
+SELECT *
+FROM transaction
+WHERE reference = $1;
 
--- name: UpdateTransactionStatus :exec
+-- name: TransitionTransactionStatus :one
 UPDATE transaction
-SET status = $1, updated_at = $4
-WHERE gateway_ref_id = $2 AND gateway = $3;
+SET status              = sqlc.arg(to_status),
+    gateway_status_code = COALESCE(sqlc.narg(gateway_status_code), gateway_status_code),
+    decline_reason      = COALESCE(sqlc.narg(decline_reason), decline_reason),
+    updated_at          = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING *;
 
 -- name: GetTransactionByGatewayRefId :one
 SELECT *
 FROM transaction
-WHERE gateway_ref_id = $1 AND gateway = $2;
\ No newline at end of file
+WHERE gateway_ref_id = $1 AND gateway = $2;
+
+-- name: CaptureTransaction :one
+UPDATE transaction
+SET status = 'CAPTURED', captured_amount = sqlc.arg(captured_amount)::numeric, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND status = 'AUTHORIZED'
+RETURNING *;
+
+-- name: VoidTransaction :one
+UPDATE transaction
+SET status = 'VOIDED', updated_at = $2
+WHERE id = $1 AND status = 'AUTHORIZED'
+RETURNING *;
+
+-- name: ListPendingTransactionsToPoll :many
+SELECT *
+FROM transaction
+WHERE status = 'PENDING'
+  AND created_at < sqlc.arg(created_before)
+  AND status_poll_attempts < sqlc.arg(max_attempts)
+  AND (next_status_poll_at IS NULL OR next_status_poll_at <= sqlc.arg(now))
+ORDER BY created_at
+LIMIT sqlc.arg(page_size);
+
+-- name: ScheduleStatusPoll :exec
+UPDATE transaction
+SET status_poll_attempts = status_poll_attempts + 1,
+    next_status_poll_at  = $2
+WHERE id = $1;
+
+-- name: ListTransactionsByGatewayRefIDs :many
+SELECT *
+FROM transaction
+WHERE gateway = sqlc.arg(gateway)
+  AND gateway_ref_id = ANY (sqlc.arg(ref_ids)::varchar[]);
+
+-- name: ListSettledTransactions :many
+SELECT *
+FROM transaction
+WHERE gateway = sqlc.arg(gateway)
+  AND status IN ('SUCCESS', 'CAPTURED')
+  AND created_at >= sqlc.arg(created_from)
+  AND created_at < sqlc.arg(created_to)
+ORDER BY id;
+
+-- name: ListGatewayVolumes :many
+SELECT gateway, currency, SUM(COALESCE(captured_amount, amount))::numeric AS volume
+FROM transaction
+WHERE status IN ('SUCCESS', 'CAPTURED')
+  AND type <> 'TRANSFER'
+  AND created_at >= sqlc.arg(created_from)
+GROUP BY gateway, currency
+ORDER BY gateway, currency;
+
+-- name: ListExpiredAuthorizations :many
+SELECT *
+FROM transaction
+WHERE status = 'AUTHORIZED' AND authorization_expires_at < $1
+ORDER BY authorization_expires_at
+LIMIT $2;
+
+-- name: GetTransaction :one
+SELECT *
+FROM transaction
+WHERE id = $1;
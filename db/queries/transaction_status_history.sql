@@ -0,0 +1,9 @@
+-- name: CreateTransactionStatusHistory :exec
+INSERT INTO transaction_status_history (transaction_id, from_status, to_status, source, raw_payload, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListTransactionStatusHistory :many
+SELECT *
+FROM transaction_status_history
+WHERE transaction_id = $1
+ORDER BY created_at, id;
@@ -0,0 +1,56 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoint (merchant_id, url, secret, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $4)
+RETURNING *;
+
+-- name: ListWebhookEndpoints :many
+SELECT *
+FROM webhook_endpoint
+WHERE merchant_id = $1 AND active
+ORDER BY id;
+
+-- name: DeactivateWebhookEndpoint :execrows
+UPDATE webhook_endpoint
+SET active = FALSE, updated_at = $3
+WHERE id = $1 AND merchant_id = $2 AND active;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_delivery (endpoint_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, 'PENDING', $5, $5, $5)
+ON CONFLICT (endpoint_id, event_id) DO NOTHING;
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_delivery d
+SET next_attempt_at = sqlc.arg(lease_until)
+FROM webhook_endpoint e
+WHERE e.id = d.endpoint_id
+  AND d.id IN (SELECT pending.id
+               FROM webhook_delivery pending
+                        JOIN webhook_endpoint endpoint ON endpoint.id = pending.endpoint_id
+               WHERE pending.status = 'PENDING' AND pending.next_attempt_at <= sqlc.arg(now) AND endpoint.active
+               ORDER BY pending.next_attempt_at
+               LIMIT sqlc.arg(batch_size) FOR UPDATE OF pending SKIP LOCKED)
+RETURNING d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.attempts, d.created_at, e.url, e.secret;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_delivery
+SET status = $2, attempts = $3, next_attempt_at = $4, response_code = $5, last_error = $6, updated_at = $7
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT d.*
+FROM webhook_delivery d
+         JOIN webhook_endpoint e ON e.id = d.endpoint_id
+WHERE e.merchant_id = sqlc.arg(merchant_id)
+  AND (sqlc.narg(status)::webhook_delivery_status IS NULL OR d.status = sqlc.narg(status))
+  AND (sqlc.narg(cursor_id)::bigint IS NULL OR d.id < sqlc.narg(cursor_id))
+ORDER BY d.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_delivery d
+SET status = 'PENDING', attempts = 0, next_attempt_at = sqlc.arg(now), last_error = NULL, updated_at = sqlc.arg(now)
+FROM webhook_endpoint e
+WHERE e.id = d.endpoint_id AND d.id = sqlc.arg(id) AND e.merchant_id = sqlc.arg(merchant_id) AND e.active
+RETURNING d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+    d.response_code, d.last_error, d.created_at, d.updated_at;
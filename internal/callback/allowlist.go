@@ -0,0 +1,58 @@
+package callback
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// IPAllowlist accepts callbacks only from the configured addresses and networks. The address is taken from
+// the connection, so forwarded headers set by clients cannot spoof it.
+type IPAllowlist struct {
+	prefixes []netip.Prefix
+}
+
+// NewIPAllowlist parses IP addresses and CIDR networks such as "203.0.113.7" or "198.51.100.0/24".
+func NewIPAllowlist(entries []string) (*IPAllowlist, error) {
+	a := &IPAllowlist{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed IP %q: %w", entry, err)
+			}
+			a.prefixes = append(a.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed network %q: %w", entry, err)
+		}
+		a.prefixes = append(a.prefixes, prefix.Masked())
+	}
+	return a, nil
+}
+
+func (a *IPAllowlist) Verify(r *http.Request, _ []byte) error {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: invalid remote address %q", ErrUnverified, r.RemoteAddr)
+	}
+	addr = addr.Unmap()
+	for _, prefix := range a.prefixes {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not an allowed IP", ErrUnverified, addr)
+}
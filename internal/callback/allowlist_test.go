@@ -0,0 +1,48 @@
+package callback
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIPAllowlist_Verify(t *testing.T) {
+	allowlist, err := NewIPAllowlist([]string{"203.0.113.7", " 198.51.100.0/24", "", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		remoteAddr string
+		wantErr    bool
+	}{
+		{remoteAddr: "203.0.113.7:4321"},
+		{remoteAddr: "203.0.113.8:4321", wantErr: true},
+		{remoteAddr: "198.51.100.200:80"},
+		{remoteAddr: "198.51.101.1:80", wantErr: true},
+		{remoteAddr: "[::ffff:203.0.113.7]:4321"},
+		{remoteAddr: "[2001:db8::1]:443"},
+		{remoteAddr: "[2001:db9::1]:443", wantErr: true},
+		{remoteAddr: "garbage", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodPost, "/callback", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+			err := allowlist.Verify(r, nil)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewIPAllowlist_Invalid(t *testing.T) {
+	for _, entry := range []string{"203.0.113", "198.51.100.0/33", "localhost"} {
+		if _, err := NewIPAllowlist([]string{entry}); err == nil {
+			t.Errorf("Expected error for %q", entry)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package callback
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// TimestampHeader is the unix time at which the gateway signed the callback.
+	TimestampHeader = "X-Callback-Timestamp"
+	// SignatureHeader is the hex HMAC-SHA256 of "<timestamp>.<body>" with the gateway secret.
+	SignatureHeader = "X-Callback-Signature"
+)
+
+// HMACVerifier checks the HMAC-SHA256 signature of the body sent in the callback headers.
+type HMACVerifier struct {
+	secret    string
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func NewHMACVerifier(secret string, tolerance time.Duration) *HMACVerifier {
+	return &HMACVerifier{
+		secret:    secret,
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+}
+
+func (v *HMACVerifier) Verify(r *http.Request, body []byte) error {
+	signature := strings.ToLower(strings.TrimSpace(r.Header.Get(SignatureHeader)))
+	return verifySignature(v.secret, r.Header.Get(TimestampHeader), signature, body, v.now(), v.tolerance)
+}
@@ -0,0 +1,110 @@
+package callback
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "s3cret"
+
+func TestHMACVerifier_Verify(t *testing.T) {
+	now := time.Unix(1726736400, 0)
+	body := []byte(`{"ref_id":"ref123","status":"success"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      []byte
+		wantErr   bool
+	}{
+		{name: "valid", timestamp: timestamp, signature: Sign(testSecret, timestamp, body), body: body},
+		{name: "upper case signature", timestamp: timestamp, signature: strings.ToUpper(Sign(testSecret, timestamp, body)), body: body},
+		{name: "tampered body", timestamp: timestamp, signature: Sign(testSecret, timestamp, body), body: []byte(`{"ref_id":"ref123","status":"failed"}`), wantErr: true},
+		{name: "wrong secret", timestamp: timestamp, signature: Sign("other", timestamp, body), body: body, wantErr: true},
+		{name: "replayed", timestamp: "1726736000", signature: Sign(testSecret, "1726736000", body), body: body, wantErr: true},
+		{name: "from the future", timestamp: "1726736800", signature: Sign(testSecret, "1726736800", body), body: body, wantErr: true},
+		{name: "within tolerance", timestamp: "1726736200", signature: Sign(testSecret, "1726736200", body), body: body},
+		{name: "invalid timestamp", timestamp: "yesterday", signature: Sign(testSecret, "yesterday", body), body: body, wantErr: true},
+		{name: "missing signature", timestamp: timestamp, body: body, wantErr: true},
+		{name: "missing timestamp", signature: Sign(testSecret, "", body), body: body, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewHMACVerifier(testSecret, 5*time.Minute)
+			v.now = func() time.Time { return now }
+
+			r, _ := http.NewRequest(http.MethodPost, "/callback", nil)
+			if tt.timestamp != "" {
+				r.Header.Set(TimestampHeader, tt.timestamp)
+			}
+			if tt.signature != "" {
+				r.Header.Set(SignatureHeader, tt.signature)
+			}
+
+			err := v.Verify(r, tt.body)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrUnverified) {
+				t.Errorf("Expected ErrUnverified, got %v", err)
+			}
+		})
+	}
+}
+
+func TestXMLSignatureVerifier_Verify(t *testing.T) {
+	now := time.Unix(1726736400, 0)
+	document := `<callback><ref_id>ref123</ref_id><status>success</status><timestamp>1726736400</timestamp></callback>`
+	signature := Sign(testSecret, "1726736400", []byte(document))
+	signed := strings.Replace(document, "</callback>", "<signature>"+signature+"</signature></callback>", 1)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "valid", body: signed},
+		{name: "tampered", body: strings.Replace(signed, "success", "failed", 1), wantErr: true},
+		{name: "unsigned", body: document, wantErr: true},
+		{name: "two signatures", body: strings.Replace(signed, "<ref_id>", "<signature>x</signature><ref_id>", 1), wantErr: true},
+		{name: "not XML", body: `{"ref_id":"ref123"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewXMLSignatureVerifier(testSecret, 5*time.Minute)
+			v.now = func() time.Time { return now }
+
+			err := v.Verify(nil, []byte(tt.body))
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrUnverified) {
+				t.Errorf("Expected ErrUnverified, got %v", err)
+			}
+		})
+	}
+
+	v := NewXMLSignatureVerifier(testSecret, 5*time.Minute)
+	v.now = func() time.Time { return now.Add(time.Hour) }
+	if err := v.Verify(nil, []byte(signed)); !errors.Is(err, ErrUnverified) {
+		t.Errorf("Expected replayed callback to be rejected, got %v", err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "/callback", nil)
+	if err := Chain().Verify(r, nil); err != nil {
+		t.Errorf("Expected empty chain to pass, got %v", err)
+	}
+	if err := Chain(Chain(), Reject("closed")).Verify(r, nil); !errors.Is(err, ErrUnverified) {
+		t.Errorf("Expected ErrUnverified, got %v", err)
+	}
+}
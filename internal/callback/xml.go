@@ -0,0 +1,63 @@
+package callback
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	signatureStart = []byte("<signature>")
+	signatureEnd   = []byte("</signature>")
+)
+
+// XMLSignatureVerifier checks a signature embedded in an XML callback. The document carries a <timestamp>
+// and a <signature> element; the signature is computed over "<timestamp>.<document>", where the document is
+// the body with the <signature> element removed.
+type XMLSignatureVerifier struct {
+	secret    string
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func NewXMLSignatureVerifier(secret string, tolerance time.Duration) *XMLSignatureVerifier {
+	return &XMLSignatureVerifier{
+		secret:    secret,
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+}
+
+func (v *XMLSignatureVerifier) Verify(_ *http.Request, body []byte) error {
+	var signed struct {
+		Timestamp string `xml:"timestamp"`
+		Signature string `xml:"signature"`
+	}
+	if err := xml.Unmarshal(body, &signed); err != nil {
+		return fmt.Errorf("%w: failed to decode XML: %w", ErrUnverified, err)
+	}
+	document, ok := withoutSignature(body)
+	if !ok {
+		return fmt.Errorf("%w: missing signature", ErrUnverified)
+	}
+	signature := strings.ToLower(strings.TrimSpace(signed.Signature))
+	return verifySignature(v.secret, strings.TrimSpace(signed.Timestamp), signature, document, v.now(), v.tolerance)
+}
+
+// withoutSignature removes the only <signature> element from the body. Documents with more than one are
+// rejected, so the signed and the decoded content cannot differ.
+func withoutSignature(body []byte) ([]byte, bool) {
+	start := bytes.Index(body, signatureStart)
+	if start < 0 || bytes.Count(body, signatureStart) != 1 {
+		return nil, false
+	}
+	end := bytes.Index(body[start:], signatureEnd)
+	if end < 0 {
+		return nil, false
+	}
+	end += start + len(signatureEnd)
+	return append(append([]byte(nil), body[:start]...), body[end:]...), true
+}
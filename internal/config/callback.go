@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type CallbackConfig struct {
+	// Secret authenticates the callbacks of a gateway. Callbacks of gateways without a secret are rejected.
+	Secret string
+	// Tolerance is how far the signed timestamp of a callback may be from now.
+	Tolerance time.Duration
+	// AllowedIPs optionally restricts the callbacks to these IP addresses and CIDR networks.
+	AllowedIPs []string
+}
+
+type CallbackInboxConfig struct {
+	// ReplayInterval is how often parked callbacks are checked for transactions that were created since.
+	ReplayInterval time.Duration
+	BatchSize      int32
+}
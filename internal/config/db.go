@@ -3,12 +3,26 @@ package config
 import (
 	"cmp"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/consts"
 	"github.com/rauf/payment-service/internal/database"
 )
 
 type Config struct {
 	Database database.Config
+	Payment  PaymentConfig
+	Outbox   OutboxConfig
+	Webhook  WebhookConfig
+	// Callbacks holds the callback authentication of each gateway, by gateway name.
+	Callbacks     map[string]CallbackConfig
+	CallbackInbox CallbackInboxConfig
+	Ledger        LedgerConfig
+	Routing       RoutingConfig
+	Fee           FeeConfig
 }
 
 func NewConfig() *Config {
@@ -21,9 +35,104 @@ func NewConfig() *Config {
 			Password:     "postgres",
 			DatabaseName: "payment",
 		},
+		Payment: PaymentConfig{
+			AuthorizationTTL:              getEnvDuration("AUTHORIZATION_TTL", 7*24*time.Hour),
+			AuthorizationExpiryInterval:   getEnvDuration("AUTHORIZATION_EXPIRY_INTERVAL", 5*time.Minute),
+			IdempotencyKeyTTL:             getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+			IdempotencyKeyCleanupInterval: getEnvDuration("IDEMPOTENCY_KEY_CLEANUP_INTERVAL", time.Hour),
+			InitiatedTimeout:              getEnvDuration("INITIATED_TIMEOUT", 10*time.Minute),
+			InitiatedSweepInterval:        getEnvDuration("INITIATED_SWEEP_INTERVAL", time.Minute),
+			StatusPollThreshold:           getEnvDuration("STATUS_POLL_THRESHOLD", 15*time.Minute),
+			StatusPollInterval:            getEnvDuration("STATUS_POLL_INTERVAL", time.Minute),
+			StatusPollRetry: backoff.RetryConfig{
+				// 1m, 2m, 4m, ... up to 6h between queries, about 20 hours in total.
+				MaxRetries: 12,
+				Backoff:    backoff.NewExponentialBackoff(time.Minute, 2, 6*time.Hour),
+			},
+		},
+		Outbox: OutboxConfig{
+			RelayInterval: getEnvDuration("OUTBOX_RELAY_INTERVAL", time.Second),
+			BatchSize:     100,
+			Publisher:     getEnv("OUTBOX_PUBLISHER", "log"),
+			FilePath:      getEnv("OUTBOX_FILE", "events.jsonl"),
+		},
+		Webhook: WebhookConfig{
+			DeliveryInterval: getEnvDuration("WEBHOOK_DELIVERY_INTERVAL", 5*time.Second),
+			BatchSize:        50,
+			Timeout:          getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			Lease:            time.Minute,
+			Retry: backoff.RetryConfig{
+				// 30s, 1m, 2m, ... up to 6h between attempts, about 20 hours in total.
+				MaxRetries: 12,
+				Backoff:    backoff.NewExponentialBackoff(30*time.Second, 2, 6*time.Hour),
+			},
+		},
+		Callbacks: map[string]CallbackConfig{
+			consts.GatewayA: {
+				Secret:     os.Getenv("GATEWAY_A_CALLBACK_SECRET"),
+				Tolerance:  getEnvDuration("CALLBACK_TOLERANCE", 5*time.Minute),
+				AllowedIPs: getEnvList("GATEWAY_A_CALLBACK_ALLOWED_IPS"),
+			},
+			consts.GatewayB: {
+				Secret:     os.Getenv("GATEWAY_B_CALLBACK_SECRET"),
+				Tolerance:  getEnvDuration("CALLBACK_TOLERANCE", 5*time.Minute),
+				AllowedIPs: getEnvList("GATEWAY_B_CALLBACK_ALLOWED_IPS"),
+			},
+		},
+		CallbackInbox: CallbackInboxConfig{
+			ReplayInterval: getEnvDuration("CALLBACK_INBOX_REPLAY_INTERVAL", 5*time.Second),
+			BatchSize:      100,
+		},
+		Ledger: LedgerConfig{
+			CheckInterval: getEnvDuration("LEDGER_CHECK_INTERVAL", time.Hour),
+		},
+		Routing: RoutingConfig{
+			RulesFile: os.Getenv("ROUTING_RULES_FILE"),
+			Adaptive:  os.Getenv("ROUTING_ADAPTIVE") == "true",
+			Scorer: ScorerConfig{
+				Window:        getEnvDuration("ROUTING_SCORE_WINDOW", 15*time.Minute),
+				Buckets:       15,
+				LatencyTarget: getEnvDuration("ROUTING_LATENCY_TARGET", time.Second),
+				Exploration:   getEnvFloat("ROUTING_EXPLORATION", 0.05),
+			},
+			CostBased: os.Getenv("ROUTING_COST_BASED") == "true",
+		},
+		Fee: FeeConfig{
+			SchedulesFile:         os.Getenv("FEE_SCHEDULES_FILE"),
+			VolumeRefreshInterval: getEnvDuration("FEE_VOLUME_REFRESH_INTERVAL", 10*time.Minute),
+		},
 	}
 }
 
 func getEnv(key, fallback string) string {
 	return cmp.Or(os.Getenv(key), fallback)
 }
+
+// getEnvList reads a comma-separated list. Empty items are dropped.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvDuration reads a positive duration. Invalid, zero and negative values fall back, since the worker
+// tickers cannot run at such intervals.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type FeeConfig struct {
+	// SchedulesFile is the JSON file of the fee schedules of the gateways. Without it, expected fees are not
+	// recorded and cost-based routing is not available.
+	SchedulesFile string
+	// VolumeRefreshInterval is how often the monthly volumes of the gateways, which pick the fee tiers, are
+	// recomputed.
+	VolumeRefreshInterval time.Duration
+}
@@ -0,0 +1,8 @@
+package config
+
+import "time"
+
+type LedgerConfig struct {
+	// CheckInterval is how often the ledger is checked for postings that do not sum to zero.
+	CheckInterval time.Duration
+}
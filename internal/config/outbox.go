@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+type OutboxConfig struct {
+	// RelayInterval is how often pending outbox events are published.
+	RelayInterval time.Duration
+	// BatchSize is how many events are published per database transaction.
+	BatchSize int32
+	// Publisher selects where events are published: "log" or "file".
+	Publisher string
+	// FilePath is the file the "file" publisher appends events to.
+	FilePath string
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+)
+
+type PaymentConfig struct {
+	// AuthorizationTTL is how long an authorization can be captured before it expires and is voided.
+	AuthorizationTTL time.Duration
+	// AuthorizationExpiryInterval is how often stale authorizations are looked up and voided.
+	AuthorizationExpiryInterval time.Duration
+	// IdempotencyKeyTTL is how long idempotency keys are kept before they can be reused.
+	IdempotencyKeyTTL time.Duration
+	// IdempotencyKeyCleanupInterval is how often expired idempotency keys are deleted.
+	IdempotencyKeyCleanupInterval time.Duration
+	// InitiatedTimeout is how long a transaction can wait for the gateway answer before the sweeper resolves it.
+	InitiatedTimeout time.Duration
+	// InitiatedSweepInterval is how often transactions stuck in the initiated status are looked up.
+	InitiatedSweepInterval time.Duration
+	// StatusPollThreshold is how long a transaction can stay pending before its status is queried from the gateway.
+	StatusPollThreshold time.Duration
+	// StatusPollInterval is how often pending transactions are looked up for status queries.
+	StatusPollInterval time.Duration
+	// StatusPollRetry schedules the status queries of a transaction that stays pending, or that stays initiated
+	// because the gateways could not be asked about it. A pending transaction is not queried anymore after
+	// MaxRetries queries, and an initiated one is parked for review.
+	StatusPollRetry backoff.RetryConfig
+}
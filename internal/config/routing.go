@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+type RoutingConfig struct {
+	// RulesFile is the JSON file of the routing rules. Without it, transactions go to the preferred gateway
+	// first and then to the others in registration order.
+	RulesFile string
+	// Adaptive ranks the gateways of transactions no rule matches by their recent success rate and latency.
+	Adaptive bool
+	Scorer   ScorerConfig
+	// CostBased orders the healthy gateways of transactions no rule matches by the fee they are expected to
+	// charge. It needs fee schedules.
+	CostBased bool
+}
+
+// ScorerConfig tunes the adaptive ranking of the gateways.
+type ScorerConfig struct {
+	// Window is how far back outcomes are counted, split into Buckets slots that expire one at a time.
+	Window  time.Duration
+	Buckets int
+	// LatencyTarget is the p95 latency that halves the score of a gateway.
+	LatencyTarget time.Duration
+	// Exploration is the fraction of transactions sent first to a random gateway other than the best one.
+	Exploration float64
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+)
+
+type WebhookConfig struct {
+	// DeliveryInterval is how often due webhook deliveries are sent.
+	DeliveryInterval time.Duration
+	// BatchSize is how many deliveries are sent per run.
+	BatchSize int32
+	// Timeout bounds a single delivery request.
+	Timeout time.Duration
+	// Lease is how long a claimed delivery is hidden from other workers while it is sent. It has to be
+	// longer than Timeout.
+	Lease time.Duration
+	// Retry schedules the attempts of failed deliveries. A delivery is dead-lettered after MaxRetries retries.
+	Retry backoff.RetryConfig
+}
@@ -3,4 +3,6 @@ package consts
 const (
 	GatewayA = "gatewayA"
 	GatewayB = "gatewayB"
+	// GatewayInternal is the gateway of transfers between customers, which never leave the ledger.
+	GatewayInternal = "internal"
 )
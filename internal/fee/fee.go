@@ -0,0 +1,281 @@
+// Package fee computes the fees the gateways are expected to charge for a transaction, from declarative fee
+// schedules: a percentage of the amount plus a fixed fee, capped, with cheaper tiers once the monthly volume
+// of the gateway reaches them.
+package fee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+)
+
+var ErrInvalidSchedule = errors.New("invalid fee schedule")
+
+// Config is the JSON fee configuration, one schedule per gateway.
+type Config struct {
+	Schedules []Schedule `json:"schedules"`
+}
+
+// Schedule lists the rates of a gateway. The first rate matching a transaction prices it.
+type Schedule struct {
+	Gateway string `json:"gateway"`
+	Rates   []Rate `json:"rates"`
+}
+
+// Rate prices the transactions of a currency, optionally only those of some payment methods. Amounts are in
+// the major units of the currency.
+type Rate struct {
+	Currency       string   `json:"currency"`
+	PaymentMethods []string `json:"payment_methods,omitempty"`
+	// Percentage of the amount, e.g. 2.9 for 2.9%, and Fixed fee per transaction.
+	Percentage json.Number `json:"percentage,omitempty"`
+	Fixed      json.Number `json:"fixed,omitempty"`
+	// MinFee and MaxFee cap the fee, tiers included.
+	MinFee json.Number `json:"min_fee,omitempty"`
+	MaxFee json.Number `json:"max_fee,omitempty"`
+	// Tiers replace Percentage and Fixed once the volume of the gateway in the currency this month reaches
+	// their FromVolume.
+	Tiers []Tier `json:"tiers,omitempty"`
+}
+
+type Tier struct {
+	FromVolume json.Number `json:"from_volume"`
+	Percentage json.Number `json:"percentage,omitempty"`
+	Fixed      json.Number `json:"fixed,omitempty"`
+}
+
+// Schedules are validated fee schedules, ready to price transactions. The monthly volumes the tiers depend
+// on can be updated while they are in use.
+type Schedules struct {
+	mu       sync.RWMutex
+	gateways map[string][]rate
+	// volumes are the monthly volumes of each gateway, by currency.
+	volumes map[string]map[money.Currency]money.Money
+}
+
+// rate is a Rate with its amounts parsed. Tiers are sorted by volume, with the base price first.
+type rate struct {
+	currency       string
+	paymentMethods []string
+	tiers          []tier
+	minFee, maxFee money.Money
+	hasMin, hasMax bool
+}
+
+type tier struct {
+	fromVolume money.Money
+	percentage *big.Rat
+	fixed      money.Money
+}
+
+// LoadSchedules reads a JSON fee configuration and validates it.
+func LoadSchedules(r io.Reader) (*Schedules, error) {
+	var config Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode fee schedules: %w", err)
+	}
+	return NewSchedules(config.Schedules)
+}
+
+// NewSchedules validates the schedules. Every gateway has at most one schedule, and every rate a currency.
+// Amounts and percentages cannot be negative.
+func NewSchedules(schedules []Schedule) (*Schedules, error) {
+	s := &Schedules{
+		gateways: make(map[string][]rate, len(schedules)),
+		volumes:  make(map[string]map[money.Currency]money.Money),
+	}
+	for _, schedule := range schedules {
+		if schedule.Gateway == "" {
+			return nil, fmt.Errorf("%w: schedule without gateway", ErrInvalidSchedule)
+		}
+		if _, ok := s.gateways[schedule.Gateway]; ok {
+			return nil, fmt.Errorf("%w: duplicate schedule of %s", ErrInvalidSchedule, schedule.Gateway)
+		}
+		rates := make([]rate, 0, len(schedule.Rates))
+		for i, r := range schedule.Rates {
+			parsed, err := parseRate(r)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rate %d of %s: %w", ErrInvalidSchedule, i, schedule.Gateway, err)
+			}
+			rates = append(rates, parsed)
+		}
+		s.gateways[schedule.Gateway] = rates
+	}
+	return s, nil
+}
+
+func parseRate(r Rate) (rate, error) {
+	currency := strings.ToUpper(r.Currency)
+	if _, err := money.ParseCurrency(currency); err != nil {
+		return rate{}, err
+	}
+	parsed := rate{currency: currency, paymentMethods: r.PaymentMethods}
+
+	base, err := parseTier(Tier{Percentage: r.Percentage, Fixed: r.Fixed}, currency)
+	if err != nil {
+		return rate{}, err
+	}
+	parsed.tiers = append(parsed.tiers, base)
+	for _, t := range r.Tiers {
+		parsedTier, err := parseTier(t, currency)
+		if err != nil {
+			return rate{}, err
+		}
+		if !parsedTier.fromVolume.IsPositive() {
+			return rate{}, fmt.Errorf("tier from volume %s is not positive", t.FromVolume)
+		}
+		parsed.tiers = append(parsed.tiers, parsedTier)
+	}
+	slices.SortStableFunc(parsed.tiers, func(a, b tier) int {
+		c, _ := a.fromVolume.Cmp(b.fromVolume)
+		return c
+	})
+
+	if parsed.hasMin = r.MinFee != ""; parsed.hasMin {
+		if parsed.minFee, err = parseAmount(r.MinFee, currency); err != nil {
+			return rate{}, fmt.Errorf("min_fee: %w", err)
+		}
+	}
+	if parsed.hasMax = r.MaxFee != ""; parsed.hasMax {
+		if parsed.maxFee, err = parseAmount(r.MaxFee, currency); err != nil {
+			return rate{}, fmt.Errorf("max_fee: %w", err)
+		}
+	}
+	if parsed.hasMin && parsed.hasMax {
+		if c, _ := parsed.minFee.Cmp(parsed.maxFee); c > 0 {
+			return rate{}, errors.New("min_fee is above max_fee")
+		}
+	}
+	return parsed, nil
+}
+
+func parseTier(t Tier, currency string) (tier, error) {
+	parsed := tier{percentage: new(big.Rat)}
+	if t.Percentage != "" {
+		if _, ok := parsed.percentage.SetString(t.Percentage.String()); !ok || parsed.percentage.Sign() < 0 {
+			return tier{}, fmt.Errorf("invalid percentage %s", t.Percentage)
+		}
+	}
+	var err error
+	if parsed.fixed, err = parseAmount(t.Fixed, currency); err != nil {
+		return tier{}, fmt.Errorf("fixed: %w", err)
+	}
+	if parsed.fromVolume, err = parseAmount(t.FromVolume, currency); err != nil {
+		return tier{}, fmt.Errorf("from_volume: %w", err)
+	}
+	return parsed, nil
+}
+
+// parseAmount parses a non-negative amount. An empty amount is zero.
+func parseAmount(amount json.Number, currency string) (money.Money, error) {
+	if amount == "" {
+		amount = "0"
+	}
+	m, err := money.Parse(amount.String(), currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if m.IsNegative() {
+		return money.Money{}, fmt.Errorf("negative amount %s", amount)
+	}
+	return m, nil
+}
+
+// Gateways returns the gateways that have a schedule.
+func (s *Schedules) Gateways() []string {
+	if s == nil {
+		return nil
+	}
+	gateways := make([]string, 0, len(s.gateways))
+	for gateway := range s.gateways {
+		gateways = append(gateways, gateway)
+	}
+	slices.Sort(gateways)
+	return gateways
+}
+
+// SetVolumes replaces the monthly volumes the tiers are picked by. Gateways and currencies without a volume
+// are at the base price.
+func (s *Schedules) SetVolumes(volumes []models.GatewayVolume) {
+	if s == nil {
+		return
+	}
+	byGateway := make(map[string]map[money.Currency]money.Money)
+	for _, v := range volumes {
+		if byGateway[v.Gateway] == nil {
+			byGateway[v.Gateway] = make(map[money.Currency]money.Money)
+		}
+		byGateway[v.Gateway][v.Volume.Currency()] = v.Volume
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.volumes = byGateway
+}
+
+// Expected returns the fee the gateway is expected to charge for the transaction. It returns false if the
+// gateway has no rate for the transaction, and an error if the fee cannot be computed.
+func (s *Schedules) Expected(gateway string, transaction models.TransactionRequest) (money.Money, bool, error) {
+	if s == nil {
+		return money.Money{}, false, nil
+	}
+	currency := transaction.Amount.Currency()
+	i := slices.IndexFunc(s.gateways[gateway], func(r rate) bool {
+		return r.currency == currency.String() && (len(r.paymentMethods) == 0 ||
+			slices.ContainsFunc(r.paymentMethods, func(m string) bool { return strings.EqualFold(m, transaction.PaymentMethod) }))
+	})
+	if i < 0 {
+		return money.Money{}, false, nil
+	}
+	r := s.gateways[gateway][i]
+
+	s.mu.RLock()
+	volume, ok := s.volumes[gateway][currency]
+	s.mu.RUnlock()
+	t := r.tiers[0]
+	if ok {
+		for _, candidate := range r.tiers[1:] {
+			if c, _ := volume.Cmp(candidate.fromVolume); c < 0 {
+				break
+			}
+			t = candidate
+		}
+	}
+
+	fee, err := percentageOf(transaction.Amount, t.percentage)
+	if err != nil {
+		return money.Money{}, false, fmt.Errorf("failed to compute percentage fee: %w", err)
+	}
+	if fee, err = fee.Add(t.fixed); err != nil {
+		return money.Money{}, false, fmt.Errorf("failed to add fixed fee: %w", err)
+	}
+	if c, _ := fee.Cmp(r.minFee); r.hasMin && c < 0 {
+		fee = r.minFee
+	}
+	if c, _ := fee.Cmp(r.maxFee); r.hasMax && c > 0 {
+		fee = r.maxFee
+	}
+	return fee, true, nil
+}
+
+// percentageOf returns the percentage of the amount, rounded half up to the minor unit.
+func percentageOf(amount money.Money, percentage *big.Rat) (money.Money, error) {
+	fee := new(big.Rat).Mul(new(big.Rat).SetInt64(amount.Minor()), percentage)
+	fee.Quo(fee, big.NewRat(100, 1))
+	fee.Add(fee, big.NewRat(1, 2))
+	minor := new(big.Int).Quo(fee.Num(), fee.Denom())
+	if !minor.IsInt64() {
+		return money.Money{}, money.ErrOverflow
+	}
+	return money.New(minor.Int64(), amount.Currency().String())
+}
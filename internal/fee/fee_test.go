@@ -0,0 +1,170 @@
+package fee
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+)
+
+const testSchedules = `{
+	"schedules": [
+		{"gateway": "gatewayA", "rates": [
+			{"currency": "USD", "payment_methods": ["card"], "percentage": 2.9, "fixed": 0.30, "max_fee": 25,
+				"tiers": [{"from_volume": 100000, "percentage": 2.5, "fixed": 0.25}, {"from_volume": 10000, "percentage": 2.7, "fixed": 0.30}]},
+			{"currency": "usd", "percentage": 1, "min_fee": 0.50},
+			{"currency": "JPY", "percentage": 3.6}
+		]},
+		{"gateway": "gatewayB", "rates": [{"currency": "USD", "fixed": 0.99}]}
+	]
+}`
+
+func TestSchedules_Expected(t *testing.T) {
+	schedules, err := LoadSchedules(strings.NewReader(testSchedules))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		gateway       string
+		transaction   models.TransactionRequest
+		volumes       []models.GatewayVolume
+		expectedFee   money.Money
+		expectedFound bool
+	}{
+		{
+			name:          "Percentage and fixed fee",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("100", "USD"), PaymentMethod: "CARD"},
+			expectedFee:   money.MustParse("3.20", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:          "Rounded half up",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("0.50", "USD"), PaymentMethod: "card"},
+			expectedFee:   money.MustParse("0.31", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:          "Maximum fee",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("5000", "USD"), PaymentMethod: "card"},
+			expectedFee:   money.MustParse("25", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:          "Minimum fee of the next rate",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("10", "USD"), PaymentMethod: "wallet"},
+			expectedFee:   money.MustParse("0.50", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:          "Volume tier",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("100", "USD"), PaymentMethod: "card"},
+			volumes:       []models.GatewayVolume{{Gateway: "gatewayA", Volume: money.MustParse("10000", "USD")}},
+			expectedFee:   money.MustParse("3.00", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:          "Highest volume tier",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("100", "USD"), PaymentMethod: "card"},
+			volumes:       []models.GatewayVolume{{Gateway: "gatewayA", Volume: money.MustParse("250000", "USD")}},
+			expectedFee:   money.MustParse("2.75", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:          "Volume of another currency",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("100", "USD"), PaymentMethod: "card"},
+			volumes:       []models.GatewayVolume{{Gateway: "gatewayA", Volume: money.MustParse("250000", "EUR")}},
+			expectedFee:   money.MustParse("3.20", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:          "Currency without minor units",
+			gateway:       "gatewayA",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("1000", "JPY"), PaymentMethod: "card"},
+			expectedFee:   money.MustParse("36", "JPY"),
+			expectedFound: true,
+		},
+		{
+			name:          "Fixed fee only",
+			gateway:       "gatewayB",
+			transaction:   models.TransactionRequest{Amount: money.MustParse("100", "USD"), PaymentMethod: "card"},
+			expectedFee:   money.MustParse("0.99", "USD"),
+			expectedFound: true,
+		},
+		{
+			name:        "No rate for the currency",
+			gateway:     "gatewayB",
+			transaction: models.TransactionRequest{Amount: money.MustParse("100", "EUR"), PaymentMethod: "card"},
+		},
+		{
+			name:        "Gateway without schedule",
+			gateway:     "gatewayC",
+			transaction: models.TransactionRequest{Amount: money.MustParse("100", "USD"), PaymentMethod: "card"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedules.SetVolumes(tt.volumes)
+			fee, found, err := schedules.Expected(tt.gateway, tt.transaction)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if found != tt.expectedFound {
+				t.Errorf("Expected found %v, got %v", tt.expectedFound, found)
+			}
+			if fee != tt.expectedFee {
+				t.Errorf("Expected fee %v, got %v", tt.expectedFee, fee)
+			}
+		})
+	}
+}
+
+func TestNewSchedules_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules []Schedule
+	}{
+		{name: "No gateway", schedules: []Schedule{{Rates: []Rate{{Currency: "USD"}}}}},
+		{name: "Duplicate gateway", schedules: []Schedule{{Gateway: "gatewayA"}, {Gateway: "gatewayA"}}},
+		{name: "Unknown currency", schedules: []Schedule{{Gateway: "gatewayA", Rates: []Rate{{Currency: "XXX"}}}}},
+		{name: "Negative percentage", schedules: []Schedule{{Gateway: "gatewayA", Rates: []Rate{{Currency: "USD", Percentage: "-1"}}}}},
+		{name: "Too precise fixed fee", schedules: []Schedule{{Gateway: "gatewayA", Rates: []Rate{{Currency: "USD", Fixed: "0.305"}}}}},
+		{name: "Minimum above maximum", schedules: []Schedule{{Gateway: "gatewayA", Rates: []Rate{{Currency: "USD", MinFee: "2", MaxFee: "1"}}}}},
+		{name: "Tier without volume", schedules: []Schedule{{Gateway: "gatewayA", Rates: []Rate{{Currency: "USD", Tiers: []Tier{{Percentage: "1"}}}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSchedules(tt.schedules)
+			if !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSchedules_ExpectedOverflow(t *testing.T) {
+	schedules, err := NewSchedules([]Schedule{{Gateway: "gatewayA", Rates: []Rate{{Currency: "USD", Percentage: "200"}}}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, found, err := schedules.Expected("gatewayA", models.TransactionRequest{Amount: money.MustParse("90000000000000000", "USD")})
+	if !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	if found {
+		t.Errorf("Expected no fee to be found")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/rauf/payment-service/internal/protocol"
 	"github.com/rauf/payment-service/internal/serde"
 )
@@ -35,6 +36,9 @@ func newBaseGateway[Req, Res any](
 	}
 }
 
+// sendWithRetry sends the data until the gateway answers. Only errors that leave the request unprocessed
+// are retried, along with the errors that are not classified; a declined, invalid or possibly processed
+// request is returned at once.
 func (g *baseGateway[Req, Res]) sendWithRetry(ctx context.Context, data Req) (Res, error) {
 	var zero Res
 	var err error
@@ -46,10 +50,13 @@ func (g *baseGateway[Req, Res]) sendWithRetry(ctx context.Context, data Req) (Re
 			return response, nil
 		}
 
-		if errors.Is(err, ErrGatewayUnavailable) {
+		kind := gatewayerr.KindOf(err)
+		if kind == gatewayerr.KindUnavailable {
 			slog.WarnContext(ctx, "Gateway unavailable, retrying", "attempt", attempt+1, "maxRetries", g.retryConfig.MaxRetries)
 		} else if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
-			return zero, fmt.Errorf("operation cancelled or timed out: %w", err)
+			return zero, fmt.Errorf("operation cancelled or timed out: %w", withContextKind(err))
+		} else if kind != "" {
+			return zero, fmt.Errorf("not retrying %s error: %w", kind, err)
 		}
 		if attempt == g.retryConfig.MaxRetries {
 			return zero, fmt.Errorf("max retries reached, last error: %w", err)
@@ -64,6 +71,18 @@ func (g *baseGateway[Req, Res]) sendWithRetry(ctx context.Context, data Req) (Re
 	return zero, fmt.Errorf("all retries failed, last error: %w", err)
 }
 
+// withContextKind classifies a context error that the protocol did not classify. The request may have been
+// processed before the deadline passed or the caller gave up.
+func withContextKind(err error) error {
+	switch {
+	case gatewayerr.KindOf(err) != "":
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", gatewayerr.ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", gatewayerr.ErrUnknownOutcome, err)
+}
+
 func (g *baseGateway[Req, Res]) send(ctx context.Context, data Req) (Res, error) {
 	var zero Res
 	if g.serde == nil {
@@ -75,20 +94,21 @@ func (g *baseGateway[Req, Res]) send(ctx context.Context, data Req) (Res, error)
 	var buf bytes.Buffer
 	err := g.serde.Serialize(&buf, data)
 	if err != nil {
-		return zero, fmt.Errorf("error marshaling data: %w", err)
+		return zero, fmt.Errorf("error marshaling data: %w: %w", gatewayerr.ErrInvalidRequest, err)
 	}
 
 	response, err := g.protocolHandler.Send(ctx, buf.Bytes())
 	if err != nil {
 		return zero, fmt.Errorf("error sending data: %w", err)
 	}
+	// The gateway answered, so a response that cannot be read may still mean the request was processed.
 	if response == nil {
-		return zero, fmt.Errorf("received nil response")
+		return zero, fmt.Errorf("received nil response: %w", gatewayerr.ErrUnknownOutcome)
 	}
 
 	var result Res
 	if err := g.serde.Deserialize(bytes.NewReader(response), &result); err != nil {
-		return zero, fmt.Errorf("error unmarshaling response: %w", err)
+		return zero, fmt.Errorf("error unmarshaling response: %w: %w", gatewayerr.ErrUnknownOutcome, err)
 	}
 
 	return result, nil
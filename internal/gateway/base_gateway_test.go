@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -238,6 +239,52 @@ func TestBaseGateway_SendWithRetry_GatewayUnavailable(t *testing.T) {
 	mockProto.AssertNumberOfCalls(t, "Send", 4) // Initial attempt + 3 retries
 }
 
+func TestBaseGateway_SendWithRetry_NotRetried(t *testing.T) {
+	tests := []struct {
+		name        string
+		protocolErr error
+	}{
+		{name: "Decline", protocolErr: gatewayerr.ErrDecline},
+		{name: "Invalid request", protocolErr: gatewayerr.ErrInvalidRequest},
+		{name: "Timeout", protocolErr: gatewayerr.ErrTimeout},
+		{name: "Unknown outcome", protocolErr: gatewayerr.ErrUnknownOutcome},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSerde := &mockSerde{}
+			mockProto := &mockProtocol{}
+			retryConfig := backoff.RetryConfig{
+				MaxRetries: 3,
+				Backoff:    backoff.NewExponentialBackoff(100*time.Millisecond, 1.2, 1*time.Second),
+			}
+			bg := newBaseGateway[string, int]("test", mockSerde, mockProto, retryConfig)
+
+			mockSerde.On("Serialize", mock.Anything, mock.Anything).Return(nil)
+			mockProto.On("Send", mock.Anything, mock.Anything).Return([]byte{}, tt.protocolErr).Once()
+
+			_, err := bg.sendWithRetry(context.Background(), "test_data")
+
+			assert.ErrorIs(t, err, tt.protocolErr)
+			mockProto.AssertNumberOfCalls(t, "Send", 1)
+		})
+	}
+}
+
+func TestBaseGateway_Send_DeserializeErrorIsUnknownOutcome(t *testing.T) {
+	mockSerde := &mockSerde{}
+	mockProto := &mockProtocol{}
+	bg := newBaseGateway[string, int]("test", mockSerde, mockProto, backoff.RetryConfig{})
+
+	mockSerde.On("Serialize", mock.Anything, mock.Anything).Return(nil)
+	mockProto.On("Send", mock.Anything, mock.Anything).Return([]byte("data"), nil)
+	mockSerde.On("Deserialize", mock.Anything, mock.Anything).Return(errors.New("deserialize error"))
+
+	_, err := bg.send(context.Background(), "test_data")
+
+	assert.ErrorIs(t, err, gatewayerr.ErrUnknownOutcome)
+}
+
 func TestBaseGateway_Name(t *testing.T) {
 	bg := newBaseGateway[string, int]("test_gateway", nil, nil, backoff.RetryConfig{})
 	assert.Equal(t, "test_gateway", bg.Name())
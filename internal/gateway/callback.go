@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rauf/payment-service/internal/callback"
+	"github.com/rauf/payment-service/internal/serde"
+)
+
+// ErrInvalidCallback is returned for callbacks that were verified but cannot be decoded or are incomplete.
+var ErrInvalidCallback = errors.New("invalid callback")
+
+// CallbackParser is implemented by gateways that report status changes through callbacks. The gateway owns
+// the whole callback: it verifies the request, decodes it with its own serde and maps its statuses to ours.
+type CallbackParser interface {
+	// ParseCallback returns the status update of a callback request whose body was already read. Requests
+	// that fail verification return an error wrapping callback.ErrUnverified.
+	ParseCallback(r *http.Request, body []byte) (Callback, error)
+}
+
+// Callback is a status update reported by a gateway.
+type Callback struct {
+	RefID string
+	// Status is one of our transaction statuses, in lower case.
+	Status string
+	// GatewayStatusCode is the raw status code reported by the gateway.
+	GatewayStatusCode string
+	DeclineReason     string
+	// DedupeKey identifies redeliveries of the callback. A gateway that reports the same code twice for a
+	// transaction sends the same callback again.
+	DedupeKey string
+	CreatedAt time.Time
+}
+
+// parseCallback verifies the callback, decodes the body into payload and maps the reported status with codes.
+// Unlike request responses, callbacks with unknown codes are rejected.
+func parseCallback(verifier callback.Verifier, s serde.Serde, codes StatusCodes, r *http.Request, body []byte, payload *callbackPayload) (Callback, error) {
+	if verifier == nil {
+		return Callback{}, fmt.Errorf("%w: no verifier configured", callback.ErrUnverified)
+	}
+	if err := verifier.Verify(r, body); err != nil {
+		return Callback{}, err
+	}
+	if err := s.Deserialize(bytes.NewReader(body), payload); err != nil {
+		return Callback{}, fmt.Errorf("%w: %w", ErrInvalidCallback, err)
+	}
+	if payload.RefID == "" {
+		return Callback{}, fmt.Errorf("%w: missing ref_id", ErrInvalidCallback)
+	}
+	status, ok := codes.Map(payload.code())
+	if !ok {
+		return Callback{}, fmt.Errorf("%w: unknown status code %q", ErrInvalidCallback, payload.code())
+	}
+	return Callback{
+		RefID:             payload.RefID,
+		Status:            status.Status,
+		GatewayStatusCode: payload.code(),
+		DeclineReason:     status.DeclineReason,
+		DedupeKey:         payload.RefID + ":" + payload.code(),
+		CreatedAt:         payload.CreatedAt,
+	}, nil
+}
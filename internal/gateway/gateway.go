@@ -2,18 +2,35 @@ package gateway
 
 import (
 	"context"
-	"fmt"
+	"errors"
 
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/rauf/payment-service/internal/models"
 )
 
 var (
-	// ErrGatewayUnavailable is an error that is returned when the gateway is unavailable.
-	ErrGatewayUnavailable = fmt.Errorf("gateway unavailable")
+	// ErrGatewayUnavailable is an error that is returned when the gateway is unavailable. It is the
+	// gatewayerr.ErrUnavailable kind: the gateway did not process the request.
+	ErrGatewayUnavailable = gatewayerr.ErrUnavailable
+	// ErrUnknownReference is returned by status queries when the gateway does not know the transaction.
+	ErrUnknownReference = errors.New("transaction unknown to gateway")
 )
 
 // PaymentGateway is an interface that defines the methods that a payment gateway should implement.
 type PaymentGateway interface {
 	Name() string
 	Transact(context.Context, models.TransactionRequest) (models.TransactionResponse, error)
+	// Authorize places a hold on the funds without moving them. The hold is later captured or voided.
+	Authorize(context.Context, models.TransactionRequest) (models.TransactionResponse, error)
+	// Capture moves the funds of a previous authorization. The amount may be lower than the authorized amount.
+	Capture(context.Context, models.CaptureRequest) (models.TransactionResponse, error)
+	// Void releases the hold of a previous authorization.
+	Void(context.Context, models.VoidRequest) (models.TransactionResponse, error)
+	// Refund returns the given amount of a previously processed transaction. The amount may be
+	// lower than the original transaction amount for partial refunds.
+	Refund(context.Context, models.RefundRequest) (models.RefundResponse, error)
+	// QueryStatus asks the gateway for the current status of a previously processed transaction, for
+	// transactions whose callback never arrived or whose answer was lost. It fails with ErrUnknownReference
+	// if the gateway does not know the transaction.
+	QueryStatus(ctx context.Context, query models.StatusQuery) (models.TransactionResponse, error)
 }
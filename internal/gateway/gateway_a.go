@@ -2,10 +2,12 @@ package gateway
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/callback"
 	"github.com/rauf/payment-service/internal/models"
 	"github.com/rauf/payment-service/internal/protocol"
 	"github.com/rauf/payment-service/internal/serde"
@@ -14,9 +16,16 @@ import (
 // GatewayA is the gateway for service A. It uses HTTP protocol with JSON serde
 type GatewayA struct {
 	baseGateway[gatewayARequest, gatewayAResponse]
+	authorizations baseGateway[gatewayARequest, gatewayAResponse]
+	captures       baseGateway[gatewayACaptureRequest, gatewayAResponse]
+	voids          baseGateway[gatewayAVoidRequest, gatewayAResponse]
+	refunds        baseGateway[gatewayARefundRequest, gatewayAResponse]
+	statuses       baseGateway[gatewayAStatusRequest, gatewayAResponse]
+	// callbackVerifier authenticates the status callbacks of the gateway.
+	callbackVerifier callback.Verifier
 }
 
-func NewGatewayA(name, method, address string, httpClient *http.Client, retryConfig backoff.RetryConfig) *GatewayA {
+func NewGatewayA(name, method, address string, httpClient *http.Client, retryConfig backoff.RetryConfig, callbackVerifier callback.Verifier) *GatewayA {
 	return &GatewayA{
 		baseGateway: newBaseGateway[gatewayARequest, gatewayAResponse](
 			name,
@@ -24,22 +33,149 @@ func NewGatewayA(name, method, address string, httpClient *http.Client, retryCon
 			protocol.NewHTTPConnectionMock(httpClient, method, address, "json"),
 			retryConfig,
 		),
+		authorizations: newBaseGateway[gatewayARequest, gatewayAResponse](
+			name,
+			serde.NewJSONSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/authorizations", "json"),
+			retryConfig,
+		),
+		captures: newBaseGateway[gatewayACaptureRequest, gatewayAResponse](
+			name,
+			serde.NewJSONSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/captures", "json"),
+			retryConfig,
+		),
+		voids: newBaseGateway[gatewayAVoidRequest, gatewayAResponse](
+			name,
+			serde.NewJSONSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/voids", "json"),
+			retryConfig,
+		),
+		refunds: newBaseGateway[gatewayARefundRequest, gatewayAResponse](
+			name,
+			serde.NewJSONSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/refunds", "json"),
+			retryConfig,
+		),
+		statuses: newBaseGateway[gatewayAStatusRequest, gatewayAResponse](
+			name,
+			serde.NewJSONSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/status", "json"),
+			retryConfig,
+		),
+		callbackVerifier: callbackVerifier,
 	}
 }
 
 func (g *GatewayA) Transact(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
 	req := gatewayARequest{
-		Amount:   transaction.Amount,
-		Currency: transaction.Currency,
+		Amount:            json.Number(transaction.Amount.Decimal()),
+		Currency:          transaction.Amount.Currency().String(),
+		MerchantReference: transaction.Reference,
 	}
 	res, err := g.sendWithRetry(ctx, req)
 	if err != nil {
 		return models.TransactionResponse{}, fmt.Errorf("error sending transaction request: %w", err)
 	}
+	if err := notProcessed(gatewayANotProcessedCodes, res.code()); err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("transaction not processed: %w", err)
+	}
 
-	return models.TransactionResponse{
+	return toGatewayAResponse(res), nil
+}
+
+func (g *GatewayA) Authorize(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
+	req := gatewayARequest{
+		Amount:            json.Number(transaction.Amount.Decimal()),
+		Currency:          transaction.Amount.Currency().String(),
+		MerchantReference: transaction.Reference,
+	}
+	res, err := g.authorizations.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending authorization request: %w", err)
+	}
+	if err := notProcessed(gatewayANotProcessedCodes, res.code()); err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("authorization not processed: %w", err)
+	}
+
+	return toGatewayAResponse(res), nil
+}
+
+func (g *GatewayA) Capture(ctx context.Context, capture models.CaptureRequest) (models.TransactionResponse, error) {
+	req := gatewayACaptureRequest{
+		RefID:    capture.TransactionRefID,
+		Amount:   json.Number(capture.Amount.Decimal()),
+		Currency: capture.Amount.Currency().String(),
+	}
+	res, err := g.captures.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending capture request: %w", err)
+	}
+
+	return toGatewayAResponse(res), nil
+}
+
+func (g *GatewayA) Void(ctx context.Context, void models.VoidRequest) (models.TransactionResponse, error) {
+	req := gatewayAVoidRequest{
+		RefID: void.TransactionRefID,
+	}
+	res, err := g.voids.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending void request: %w", err)
+	}
+
+	return toGatewayAResponse(res), nil
+}
+
+func (g *GatewayA) Refund(ctx context.Context, refund models.RefundRequest) (models.RefundResponse, error) {
+	req := gatewayARefundRequest{
+		RefID:             refund.TransactionRefID,
+		Amount:            json.Number(refund.Amount.Decimal()),
+		Currency:          refund.Amount.Currency().String(),
+		Reason:            refund.Reason,
+		MerchantReference: refund.Reference,
+	}
+	res, err := g.refunds.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.RefundResponse{}, fmt.Errorf("error sending refund request: %w", err)
+	}
+
+	return models.RefundResponse{
 		RefID:     res.RefID,
-		Status:    res.Status,
+		Status:    mapResponseStatus(gatewayAStatusCodes, res.code()).Status,
 		CreatedAt: res.CreatedAt,
 	}, nil
 }
+
+func (g *GatewayA) QueryStatus(ctx context.Context, query models.StatusQuery) (models.TransactionResponse, error) {
+	res, err := g.statuses.sendWithRetry(ctx, gatewayAStatusRequest{
+		RefID:             query.RefID,
+		MerchantReference: query.MerchantReference,
+	})
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending status request: %w", err)
+	}
+	if isCode(res.code(), gatewayAUnknownReferenceCode) {
+		return models.TransactionResponse{}, fmt.Errorf("%w: %s", ErrUnknownReference, queryReference(query))
+	}
+
+	return toGatewayAResponse(res), nil
+}
+
+// ParseCallback verifies and decodes a JSON status callback of the gateway.
+func (g *GatewayA) ParseCallback(r *http.Request, body []byte) (Callback, error) {
+	var payload callbackPayload
+	return parseCallback(g.callbackVerifier, g.serde, gatewayAStatusCodes, r, body, &payload)
+}
+
+// toGatewayAResponse maps the response code of the gateway to our status.
+func toGatewayAResponse(res gatewayAResponse) models.TransactionResponse {
+	status := mapResponseStatus(gatewayAStatusCodes, res.code())
+	return models.TransactionResponse{
+		RefID:             res.RefID,
+		Status:            status.Status,
+		GatewayStatusCode: res.code(),
+		DeclineReason:     status.DeclineReason,
+		CreatedAt:         res.CreatedAt,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/callback"
 	"github.com/rauf/payment-service/internal/models"
 	"github.com/rauf/payment-service/internal/protocol"
 	"github.com/rauf/payment-service/internal/serde"
@@ -14,9 +15,16 @@ import (
 // GatewayB is the gateway for service B. It uses HTTP protocol with XML serde
 type GatewayB struct {
 	baseGateway[gatewayBRequest, gatewayBResponse]
+	authorizations baseGateway[gatewayBRequest, gatewayBResponse]
+	captures       baseGateway[gatewayBCaptureRequest, gatewayBResponse]
+	voids          baseGateway[gatewayBVoidRequest, gatewayBResponse]
+	refunds        baseGateway[gatewayBRefundRequest, gatewayBResponse]
+	statuses       baseGateway[gatewayBStatusRequest, gatewayBResponse]
+	// callbackVerifier authenticates the status callbacks of the gateway.
+	callbackVerifier callback.Verifier
 }
 
-func NewGatewayB(name, method, address string, httpClient *http.Client, retryConfig backoff.RetryConfig) *GatewayB {
+func NewGatewayB(name, method, address string, httpClient *http.Client, retryConfig backoff.RetryConfig, callbackVerifier callback.Verifier) *GatewayB {
 	return &GatewayB{
 		baseGateway: newBaseGateway[gatewayBRequest, gatewayBResponse](
 			name,
@@ -24,22 +32,149 @@ func NewGatewayB(name, method, address string, httpClient *http.Client, retryCon
 			protocol.NewHTTPConnectionMock(httpClient, method, address, "xml"),
 			retryConfig,
 		),
+		authorizations: newBaseGateway[gatewayBRequest, gatewayBResponse](
+			name,
+			serde.NewXMLSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/authorizations", "xml"),
+			retryConfig,
+		),
+		captures: newBaseGateway[gatewayBCaptureRequest, gatewayBResponse](
+			name,
+			serde.NewXMLSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/captures", "xml"),
+			retryConfig,
+		),
+		voids: newBaseGateway[gatewayBVoidRequest, gatewayBResponse](
+			name,
+			serde.NewXMLSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/voids", "xml"),
+			retryConfig,
+		),
+		refunds: newBaseGateway[gatewayBRefundRequest, gatewayBResponse](
+			name,
+			serde.NewXMLSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/refunds", "xml"),
+			retryConfig,
+		),
+		statuses: newBaseGateway[gatewayBStatusRequest, gatewayBResponse](
+			name,
+			serde.NewXMLSerde(),
+			protocol.NewHTTPConnectionMock(httpClient, http.MethodPost, address+"/status", "xml"),
+			retryConfig,
+		),
+		callbackVerifier: callbackVerifier,
 	}
 }
 
 func (g *GatewayB) Transact(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
 	req := gatewayBRequest{
-		Amount:   transaction.Amount,
-		Currency: transaction.Currency,
+		Amount:            transaction.Amount.Decimal(),
+		Currency:          transaction.Amount.Currency().String(),
+		MerchantReference: transaction.Reference,
 	}
 	res, err := g.sendWithRetry(ctx, req)
 	if err != nil {
 		return models.TransactionResponse{}, fmt.Errorf("error sending transaction request: %w", err)
 	}
+	if err := notProcessed(gatewayBNotProcessedStatuses, res.Status); err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("transaction not processed: %w", err)
+	}
 
-	return models.TransactionResponse{
+	return toGatewayBResponse(res), nil
+}
+
+func (g *GatewayB) Authorize(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
+	req := gatewayBRequest{
+		Amount:            transaction.Amount.Decimal(),
+		Currency:          transaction.Amount.Currency().String(),
+		MerchantReference: transaction.Reference,
+	}
+	res, err := g.authorizations.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending authorization request: %w", err)
+	}
+	if err := notProcessed(gatewayBNotProcessedStatuses, res.Status); err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("authorization not processed: %w", err)
+	}
+
+	return toGatewayBResponse(res), nil
+}
+
+func (g *GatewayB) Capture(ctx context.Context, capture models.CaptureRequest) (models.TransactionResponse, error) {
+	req := gatewayBCaptureRequest{
+		RefID:    capture.TransactionRefID,
+		Amount:   capture.Amount.Decimal(),
+		Currency: capture.Amount.Currency().String(),
+	}
+	res, err := g.captures.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending capture request: %w", err)
+	}
+
+	return toGatewayBResponse(res), nil
+}
+
+func (g *GatewayB) Void(ctx context.Context, void models.VoidRequest) (models.TransactionResponse, error) {
+	req := gatewayBVoidRequest{
+		RefID: void.TransactionRefID,
+	}
+	res, err := g.voids.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending void request: %w", err)
+	}
+
+	return toGatewayBResponse(res), nil
+}
+
+func (g *GatewayB) Refund(ctx context.Context, refund models.RefundRequest) (models.RefundResponse, error) {
+	req := gatewayBRefundRequest{
+		RefID:             refund.TransactionRefID,
+		Amount:            refund.Amount.Decimal(),
+		Currency:          refund.Amount.Currency().String(),
+		Reason:            refund.Reason,
+		MerchantReference: refund.Reference,
+	}
+	res, err := g.refunds.sendWithRetry(ctx, req)
+	if err != nil {
+		return models.RefundResponse{}, fmt.Errorf("error sending refund request: %w", err)
+	}
+
+	return models.RefundResponse{
 		RefID:     res.RefID,
-		Status:    res.Status,
+		Status:    mapResponseStatus(gatewayBStatusCodes, res.Status).Status,
 		CreatedAt: res.CreatedAt,
 	}, nil
 }
+
+func (g *GatewayB) QueryStatus(ctx context.Context, query models.StatusQuery) (models.TransactionResponse, error) {
+	res, err := g.statuses.sendWithRetry(ctx, gatewayBStatusRequest{
+		RefID:             query.RefID,
+		MerchantReference: query.MerchantReference,
+	})
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("error sending status request: %w", err)
+	}
+	if isCode(res.Status, gatewayBUnknownReferenceStatus) {
+		return models.TransactionResponse{}, fmt.Errorf("%w: %s", ErrUnknownReference, queryReference(query))
+	}
+
+	return toGatewayBResponse(res), nil
+}
+
+// ParseCallback verifies and decodes a XML status callback of the gateway.
+func (g *GatewayB) ParseCallback(r *http.Request, body []byte) (Callback, error) {
+	var payload callbackPayload
+	return parseCallback(g.callbackVerifier, g.serde, gatewayBStatusCodes, r, body, &payload)
+}
+
+// toGatewayBResponse maps the status of the gateway to ours.
+func toGatewayBResponse(res gatewayBResponse) models.TransactionResponse {
+	status := mapResponseStatus(gatewayBStatusCodes, res.Status)
+	return models.TransactionResponse{
+		RefID:             res.RefID,
+		Status:            status.Status,
+		GatewayStatusCode: res.Status,
+		DeclineReason:     status.DeclineReason,
+		CreatedAt:         res.CreatedAt,
+	}
+}
@@ -1,21 +1,35 @@
 package gateway
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type gatewayARequest struct {
-	Amount   float64 `json:"amount"`
-	Currency string  `json:"currency"`
+	Amount            json.Number `json:"amount"`
+	Currency          string      `json:"currency"`
+	MerchantReference string      `json:"merchant_reference,omitempty"`
 }
 
 type gatewayAResponse struct {
-	RefID     string    `json:"ref_id"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	RefID        string    `json:"ref_id"`
+	Status       string    `json:"status"`
+	ResponseCode string    `json:"response_code,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// code returns the raw status code of the response, preferring the ISO 8583 response code.
+func (r gatewayAResponse) code() string {
+	if r.ResponseCode != "" {
+		return r.ResponseCode
+	}
+	return r.Status
 }
 
 type gatewayBRequest struct {
-	Amount   float64 `xml:"amount"`
-	Currency string  `xml:"currency"`
+	Amount            string `xml:"amount"`
+	Currency          string `xml:"currency"`
+	MerchantReference string `xml:"merchant_reference,omitempty"`
 }
 
 type gatewayBResponse struct {
@@ -23,3 +37,65 @@ type gatewayBResponse struct {
 	Status    string    `xml:"status"`
 	CreatedAt time.Time `xml:"created_at"`
 }
+
+type gatewayARefundRequest struct {
+	RefID             string      `json:"ref_id"`
+	Amount            json.Number `json:"amount"`
+	Currency          string      `json:"currency"`
+	Reason            string      `json:"reason,omitempty"`
+	MerchantReference string      `json:"merchant_reference,omitempty"`
+}
+
+type gatewayBRefundRequest struct {
+	RefID             string `xml:"ref_id"`
+	Amount            string `xml:"amount"`
+	Currency          string `xml:"currency"`
+	Reason            string `xml:"reason,omitempty"`
+	MerchantReference string `xml:"merchant_reference,omitempty"`
+}
+
+type gatewayACaptureRequest struct {
+	RefID    string      `json:"ref_id"`
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+type gatewayAVoidRequest struct {
+	RefID string `json:"ref_id"`
+}
+
+type gatewayBCaptureRequest struct {
+	RefID    string `xml:"ref_id"`
+	Amount   string `xml:"amount"`
+	Currency string `xml:"currency"`
+}
+
+type gatewayBVoidRequest struct {
+	RefID string `xml:"ref_id"`
+}
+
+type gatewayAStatusRequest struct {
+	RefID             string `json:"ref_id,omitempty"`
+	MerchantReference string `json:"merchant_reference,omitempty"`
+}
+
+type gatewayBStatusRequest struct {
+	RefID             string `xml:"ref_id,omitempty"`
+	MerchantReference string `xml:"merchant_reference,omitempty"`
+}
+
+// callbackPayload is the callback body of gateways A (JSON) and B (XML).
+type callbackPayload struct {
+	RefID        string    `json:"ref_id" xml:"ref_id"`
+	Status       string    `json:"status" xml:"status"`
+	ResponseCode string    `json:"response_code,omitempty" xml:"response_code,omitempty"`
+	CreatedAt    time.Time `json:"created_at" xml:"created_at"`
+}
+
+// code returns the raw status code of the callback, preferring the response code when there is one.
+func (p callbackPayload) code() string {
+	if p.ResponseCode != "" {
+		return p.ResponseCode
+	}
+	return p.Status
+}
@@ -0,0 +1,130 @@
+package gateway
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rauf/payment-service/internal/gatewayerr"
+	"github.com/rauf/payment-service/internal/models"
+)
+
+// Decline reasons of failed transactions, in lower case like our statuses.
+const (
+	DeclineReasonInsufficientFunds = "insufficient_funds"
+	DeclineReasonDoNotHonor        = "do_not_honor"
+	DeclineReasonFraud             = "fraud"
+	DeclineReasonExpiredCard       = "expired_card"
+	DeclineReasonTechnical         = "technical"
+)
+
+// StatusCode is one of our transaction statuses, in lower case, with the decline reason of failures.
+type StatusCode struct {
+	Status        string
+	DeclineReason string
+}
+
+// StatusCodes maps the raw status or response codes of a gateway to our statuses. Codes are matched
+// case-insensitively.
+type StatusCodes map[string]StatusCode
+
+// Map returns the status of the raw code. Unknown codes return false.
+func (c StatusCodes) Map(code string) (StatusCode, bool) {
+	status, ok := c[strings.ToLower(strings.TrimSpace(code))]
+	return status, ok
+}
+
+// mapResponseStatus maps the raw code of a request response. Unknown codes are reported as pending, so that
+// the transaction is neither settled nor failed until the gateway confirms it.
+func mapResponseStatus(codes StatusCodes, code string) StatusCode {
+	if status, ok := codes.Map(code); ok {
+		return status
+	}
+	return StatusCode{Status: "pending"}
+}
+
+// The codes the gateways answer status queries with when they do not know the transaction.
+const (
+	gatewayAUnknownReferenceCode   = "25" // unable to locate record
+	gatewayBUnknownReferenceStatus = "not_found"
+)
+
+// queryReference returns the reference a status query asks about.
+func queryReference(query models.StatusQuery) string {
+	if query.RefID != "" {
+		return query.RefID
+	}
+	return query.MerchantReference
+}
+
+// The codes the gateways answer payment requests with when they did not process them, e.g. because the
+// issuer could not be reached. Callbacks and status queries still map them to a technical decline.
+var (
+	gatewayANotProcessedCodes    = []string{"91", "96"}
+	gatewayBNotProcessedStatuses = []string{"error", "timeout"}
+)
+
+// notProcessed returns an error wrapping gatewayerr.ErrUnavailable when the raw code of a payment response is
+// one of the given codes, so that the router sends the payment to another gateway and counts the failure
+// against the circuit breaker.
+func notProcessed(codes []string, raw string) error {
+	if slices.ContainsFunc(codes, func(code string) bool { return isCode(raw, code) }) {
+		return fmt.Errorf("%w: response code %s", gatewayerr.ErrUnavailable, strings.TrimSpace(raw))
+	}
+	return nil
+}
+
+// isCode reports whether the raw code is the given code, matched like StatusCodes.
+func isCode(raw, code string) bool {
+	return strings.EqualFold(strings.TrimSpace(raw), code)
+}
+
+var (
+	pending = StatusCode{Status: "pending"}
+	success = StatusCode{Status: "success"}
+	failed  = StatusCode{Status: "failed"}
+)
+
+func declined(reason string) StatusCode {
+	return StatusCode{Status: "failed", DeclineReason: reason}
+}
+
+// gatewayAStatusCodes are the ISO 8583 response codes of gateway A. Older responses and callbacks only carry
+// the status, so the plain statuses are accepted as well.
+var gatewayAStatusCodes = StatusCodes{
+	"00": success,
+	"10": success, // partial approval
+	"09": pending, // request in progress
+	"05": declined(DeclineReasonDoNotHonor),
+	"57": declined(DeclineReasonDoNotHonor), // transaction not permitted to cardholder
+	"51": declined(DeclineReasonInsufficientFunds),
+	"61": declined(DeclineReasonInsufficientFunds), // exceeds withdrawal limit
+	"54": declined(DeclineReasonExpiredCard),
+	"33": declined(DeclineReasonExpiredCard),
+	"59": declined(DeclineReasonFraud), // suspected fraud
+	"34": declined(DeclineReasonFraud),
+	"41": declined(DeclineReasonFraud),     // lost card
+	"43": declined(DeclineReasonFraud),     // stolen card
+	"91": declined(DeclineReasonTechnical), // issuer unavailable
+	"96": declined(DeclineReasonTechnical), // system malfunction
+
+	"pending": pending,
+	"success": success,
+	"failed":  failed,
+}
+
+// gatewayBStatusCodes are the statuses of gateway B.
+var gatewayBStatusCodes = StatusCodes{
+	"pending":            pending,
+	"processing":         pending,
+	"success":            success,
+	"approved":           success,
+	"failed":             failed,
+	"declined":           declined(DeclineReasonDoNotHonor),
+	"insufficient_funds": declined(DeclineReasonInsufficientFunds),
+	"card_expired":       declined(DeclineReasonExpiredCard),
+	"fraud_suspected":    declined(DeclineReasonFraud),
+	"risk_rejected":      declined(DeclineReasonFraud),
+	"error":              declined(DeclineReasonTechnical),
+	"timeout":            declined(DeclineReasonTechnical),
+}
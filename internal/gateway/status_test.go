@@ -0,0 +1,337 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/gatewayerr"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToGatewayAResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response gatewayAResponse
+		expected StatusCode
+		code     string
+	}{
+		{"Approved", gatewayAResponse{Status: "approved", ResponseCode: "00"}, success, "00"},
+		{"Insufficient funds", gatewayAResponse{Status: "declined", ResponseCode: "51"}, declined(DeclineReasonInsufficientFunds), "51"},
+		{"Suspected fraud", gatewayAResponse{Status: "declined", ResponseCode: "59"}, declined(DeclineReasonFraud), "59"},
+		{"Issuer unavailable", gatewayAResponse{Status: "error", ResponseCode: "91"}, declined(DeclineReasonTechnical), "91"},
+		{"Status without response code", gatewayAResponse{Status: "SUCCESS"}, success, "SUCCESS"},
+		{"Unknown response code", gatewayAResponse{Status: "declined", ResponseCode: "N7"}, pending, "N7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := toGatewayAResponse(tt.response)
+			assert.Equal(t, tt.expected.Status, res.Status)
+			assert.Equal(t, tt.expected.DeclineReason, res.DeclineReason)
+			assert.Equal(t, tt.code, res.GatewayStatusCode)
+		})
+	}
+}
+
+func TestToGatewayBResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		expected StatusCode
+	}{
+		{"Approved", "APPROVED", success},
+		{"Processing", "PROCESSING", pending},
+		{"Expired card", "CARD_EXPIRED", declined(DeclineReasonExpiredCard)},
+		{"Do not honor", "DECLINED", declined(DeclineReasonDoNotHonor)},
+		{"Unknown status", "ON_HOLD", pending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := toGatewayBResponse(gatewayBResponse{Status: tt.status})
+			assert.Equal(t, tt.expected.Status, res.Status)
+			assert.Equal(t, tt.expected.DeclineReason, res.DeclineReason)
+			assert.Equal(t, tt.status, res.GatewayStatusCode)
+		})
+	}
+}
+
+func TestParseCallback_StatusCodes(t *testing.T) {
+	verified := verifierFunc(func(*http.Request, []byte) error { return nil })
+
+	tests := []struct {
+		name        string
+		serde       serde.Serde
+		codes       StatusCodes
+		body        string
+		expected    Callback
+		expectedErr error
+	}{
+		{
+			name:     "Gateway A response code",
+			serde:    serde.NewJSONSerde(),
+			codes:    gatewayAStatusCodes,
+			body:     `{"ref_id":"ref123","status":"declined","response_code":"54"}`,
+			expected: Callback{RefID: "ref123", Status: "failed", GatewayStatusCode: "54", DeclineReason: DeclineReasonExpiredCard, DedupeKey: "ref123:54"},
+		},
+		{
+			name:     "Gateway B status",
+			serde:    serde.NewXMLSerde(),
+			codes:    gatewayBStatusCodes,
+			body:     `<callback><ref_id>ref123</ref_id><status>INSUFFICIENT_FUNDS</status></callback>`,
+			expected: Callback{RefID: "ref123", Status: "failed", GatewayStatusCode: "INSUFFICIENT_FUNDS", DeclineReason: DeclineReasonInsufficientFunds, DedupeKey: "ref123:INSUFFICIENT_FUNDS"},
+		},
+		{
+			name:        "Unknown code",
+			serde:       serde.NewXMLSerde(),
+			codes:       gatewayBStatusCodes,
+			body:        `<callback><ref_id>ref123</ref_id><status>ON_HOLD</status></callback>`,
+			expectedErr: ErrInvalidCallback,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/callback", strings.NewReader(tt.body))
+			var payload callbackPayload
+			cb, err := parseCallback(verified, tt.serde, tt.codes, req, []byte(tt.body), &payload)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			cb.CreatedAt = time.Time{}
+			assert.Equal(t, tt.expected, cb)
+		})
+	}
+}
+
+type verifierFunc func(r *http.Request, body []byte) error
+
+func (f verifierFunc) Verify(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+func TestGatewayA_QueryStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expected    StatusCode
+		expectedErr error
+	}{
+		{
+			name:     "Approved",
+			response: `{"ref_id":"ref123","status":"approved","response_code":"00"}`,
+			expected: success,
+		},
+		{
+			name:     "Still pending",
+			response: `{"ref_id":"ref123","status":"pending","response_code":"09"}`,
+			expected: pending,
+		},
+		{
+			name:     "Declined",
+			response: `{"ref_id":"ref123","status":"declined","response_code":"51"}`,
+			expected: declined(DeclineReasonInsufficientFunds),
+		},
+		{
+			name:        "Unknown reference",
+			response:    `{"status":"error","response_code":"25"}`,
+			expectedErr: ErrUnknownReference,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProto := &mockProtocol{}
+			mockProto.On("Send", mock.Anything, mock.MatchedBy(func(data []byte) bool {
+				return strings.Contains(string(data), `"ref_id":"ref123"`)
+			})).Return([]byte(tt.response), nil).Once()
+			g := &GatewayA{statuses: newBaseGateway[gatewayAStatusRequest, gatewayAResponse]("gatewayA", serde.NewJSONSerde(), mockProto, backoff.RetryConfig{})}
+
+			res, err := g.QueryStatus(context.Background(), models.StatusQuery{RefID: "ref123"})
+
+			mockProto.AssertExpectations(t)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ref123", res.RefID)
+			assert.Equal(t, tt.expected.Status, res.Status)
+			assert.Equal(t, tt.expected.DeclineReason, res.DeclineReason)
+		})
+	}
+}
+
+func TestGatewayB_QueryStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expected    StatusCode
+		expectedErr error
+	}{
+		{
+			name:     "Approved",
+			response: `<response><ref_id>ref123</ref_id><status>APPROVED</status></response>`,
+			expected: success,
+		},
+		{
+			name:     "Fraud suspected",
+			response: `<response><ref_id>ref123</ref_id><status>FRAUD_SUSPECTED</status></response>`,
+			expected: declined(DeclineReasonFraud),
+		},
+		{
+			name:        "Unknown reference",
+			response:    `<response><status>NOT_FOUND</status></response>`,
+			expectedErr: ErrUnknownReference,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProto := &mockProtocol{}
+			mockProto.On("Send", mock.Anything, mock.MatchedBy(func(data []byte) bool {
+				return strings.Contains(string(data), "<ref_id>ref123</ref_id>")
+			})).Return([]byte(tt.response), nil).Once()
+			g := &GatewayB{statuses: newBaseGateway[gatewayBStatusRequest, gatewayBResponse]("gatewayB", serde.NewXMLSerde(), mockProto, backoff.RetryConfig{})}
+
+			res, err := g.QueryStatus(context.Background(), models.StatusQuery{RefID: "ref123"})
+
+			mockProto.AssertExpectations(t)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ref123", res.RefID)
+			assert.Equal(t, tt.expected.Status, res.Status)
+			assert.Equal(t, tt.expected.DeclineReason, res.DeclineReason)
+		})
+	}
+}
+
+func TestGatewayA_QueryStatusByMerchantReference(t *testing.T) {
+	mockProto := &mockProtocol{}
+	mockProto.On("Send", mock.Anything, mock.MatchedBy(func(data []byte) bool {
+		return strings.Contains(string(data), `"merchant_reference":"txn_123"`) && !strings.Contains(string(data), "ref_id")
+	})).Return([]byte(`{"ref_id":"ref123","status":"approved","response_code":"00"}`), nil).Once()
+	g := &GatewayA{statuses: newBaseGateway[gatewayAStatusRequest, gatewayAResponse]("gatewayA", serde.NewJSONSerde(), mockProto, backoff.RetryConfig{})}
+
+	res, err := g.QueryStatus(context.Background(), models.StatusQuery{MerchantReference: "txn_123"})
+
+	mockProto.AssertExpectations(t)
+	require.NoError(t, err)
+	assert.Equal(t, "ref123", res.RefID)
+	assert.Equal(t, success.Status, res.Status)
+}
+
+func TestGatewayA_QueryStatusFailure(t *testing.T) {
+	mockProto := &mockProtocol{}
+	mockProto.On("Send", mock.Anything, mock.Anything).Return([]byte{}, gatewayerr.ErrTimeout).Once()
+	g := &GatewayA{statuses: newBaseGateway[gatewayAStatusRequest, gatewayAResponse]("gatewayA", serde.NewJSONSerde(), mockProto, backoff.RetryConfig{})}
+
+	_, err := g.QueryStatus(context.Background(), models.StatusQuery{RefID: "ref123"})
+
+	assert.ErrorIs(t, err, gatewayerr.ErrTimeout)
+	assert.NotErrorIs(t, err, ErrUnknownReference)
+}
+
+func TestGatewayA_TransactNotProcessed(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expected    StatusCode
+		expectedErr error
+	}{
+		{
+			name:     "Insufficient funds",
+			response: `{"ref_id":"ref123","status":"declined","response_code":"51"}`,
+			expected: declined(DeclineReasonInsufficientFunds),
+		},
+		{
+			name:        "Issuer unavailable",
+			response:    `{"ref_id":"ref123","status":"error","response_code":"91"}`,
+			expectedErr: gatewayerr.ErrUnavailable,
+		},
+		{
+			name:        "System malfunction",
+			response:    `{"status":"error","response_code":"96"}`,
+			expectedErr: gatewayerr.ErrUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProto := &mockProtocol{}
+			mockProto.On("Send", mock.Anything, mock.Anything).Return([]byte(tt.response), nil).Once()
+			g := &GatewayA{baseGateway: newBaseGateway[gatewayARequest, gatewayAResponse]("gatewayA", serde.NewJSONSerde(), mockProto, backoff.RetryConfig{})}
+
+			res, err := g.Transact(context.Background(), models.TransactionRequest{Amount: money.MustParse("100", "USD"), Reference: "txn_123"})
+
+			mockProto.AssertExpectations(t)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.True(t, gatewayerr.CanFailover(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.Status, res.Status)
+			assert.Equal(t, tt.expected.DeclineReason, res.DeclineReason)
+		})
+	}
+}
+
+func TestGatewayB_AuthorizeNotProcessed(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expected    StatusCode
+		expectedErr error
+	}{
+		{
+			name:     "Fraud suspected",
+			response: `<response><ref_id>ref123</ref_id><status>FRAUD_SUSPECTED</status></response>`,
+			expected: declined(DeclineReasonFraud),
+		},
+		{
+			name:        "Error",
+			response:    `<response><status>ERROR</status></response>`,
+			expectedErr: gatewayerr.ErrUnavailable,
+		},
+		{
+			name:        "Timeout",
+			response:    `<response><status>TIMEOUT</status></response>`,
+			expectedErr: gatewayerr.ErrUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProto := &mockProtocol{}
+			mockProto.On("Send", mock.Anything, mock.Anything).Return([]byte(tt.response), nil).Once()
+			g := &GatewayB{authorizations: newBaseGateway[gatewayBRequest, gatewayBResponse]("gatewayB", serde.NewXMLSerde(), mockProto, backoff.RetryConfig{})}
+
+			res, err := g.Authorize(context.Background(), models.TransactionRequest{Amount: money.MustParse("100", "USD"), Reference: "txn_123"})
+
+			mockProto.AssertExpectations(t)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.True(t, gatewayerr.CanFailover(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.Status, res.Status)
+			assert.Equal(t, tt.expected.DeclineReason, res.DeclineReason)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package gatewayerr classifies the errors of requests sent to payment gateways by what they tell about the
+// request: whether the gateway refused it, never processed it, or may have processed it without answering.
+// Protocols and gateways wrap their errors with one of the sentinel errors, and the router uses the kind to
+// decide whether the request may go to another gateway and whether it counts against the circuit breaker.
+package gatewayerr
+
+import "errors"
+
+var (
+	// ErrDecline is returned when the gateway or the issuer refused the payment. Sending it again, to the
+	// same gateway or another one, would be refused as well.
+	ErrDecline = errors.New("payment declined")
+	// ErrInvalidRequest is returned when the gateway rejected the request without processing it, e.g.
+	// because it is malformed or not supported by the gateway.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrTimeout is returned when the gateway did not answer in time. It may have processed the request.
+	ErrTimeout = errors.New("gateway timed out")
+	// ErrUnavailable is returned when the gateway did not process the request, e.g. because it could not
+	// be reached or shed the load. The request is safe to send again.
+	ErrUnavailable = errors.New("gateway unavailable")
+	// ErrUnknownOutcome is returned when the gateway may or may not have processed the request, e.g.
+	// because the connection dropped before the response arrived.
+	ErrUnknownOutcome = errors.New("unknown outcome")
+)
+
+// Kind is the class of a gateway error.
+type Kind string
+
+const (
+	KindDecline        Kind = "decline"
+	KindInvalidRequest Kind = "invalid_request"
+	KindTimeout        Kind = "timeout"
+	KindUnavailable    Kind = "unavailable"
+	KindUnknownOutcome Kind = "unknown_outcome"
+)
+
+var kinds = []struct {
+	kind Kind
+	err  error
+}{
+	{KindDecline, ErrDecline},
+	{KindInvalidRequest, ErrInvalidRequest},
+	{KindTimeout, ErrTimeout},
+	{KindUnavailable, ErrUnavailable},
+	{KindUnknownOutcome, ErrUnknownOutcome},
+}
+
+// KindOf returns the kind of the error, or an empty kind if it is not classified.
+func KindOf(err error) Kind {
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return k.kind
+		}
+	}
+	return ""
+}
+
+// CanFailover reports whether the request may be sent to another gateway after the error: the failed
+// gateway did not process it, and another gateway may accept it.
+func CanFailover(err error) bool {
+	kind := KindOf(err)
+	return kind == KindUnavailable || kind == KindInvalidRequest
+}
+
+// IsTechnical reports whether the error is a failure of the gateway, which counts against its circuit
+// breaker. Declines and invalid requests are answers of a working gateway.
+func IsTechnical(err error) bool {
+	kind := KindOf(err)
+	return kind != KindDecline && kind != KindInvalidRequest
+}
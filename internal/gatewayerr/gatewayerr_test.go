@@ -0,0 +1,74 @@
+package gatewayerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		expectedKind      Kind
+		expectedFailover  bool
+		expectedTechnical bool
+	}{
+		{
+			name:              "Decline",
+			err:               fmt.Errorf("gateway A: %w", ErrDecline),
+			expectedKind:      KindDecline,
+			expectedFailover:  false,
+			expectedTechnical: false,
+		},
+		{
+			name:              "Invalid request",
+			err:               fmt.Errorf("unexpected HTTP status code: 400: %w", ErrInvalidRequest),
+			expectedKind:      KindInvalidRequest,
+			expectedFailover:  true,
+			expectedTechnical: false,
+		},
+		{
+			name:              "Timeout",
+			err:               ErrTimeout,
+			expectedKind:      KindTimeout,
+			expectedFailover:  false,
+			expectedTechnical: true,
+		},
+		{
+			name:              "Unavailable",
+			err:               fmt.Errorf("failed to establish TCP connection: %w", ErrUnavailable),
+			expectedKind:      KindUnavailable,
+			expectedFailover:  true,
+			expectedTechnical: true,
+		},
+		{
+			name:              "Unknown outcome",
+			err:               ErrUnknownOutcome,
+			expectedKind:      KindUnknownOutcome,
+			expectedFailover:  false,
+			expectedTechnical: true,
+		},
+		{
+			name:              "Not classified",
+			err:               errors.New("some error"),
+			expectedKind:      "",
+			expectedFailover:  false,
+			expectedTechnical: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kind := KindOf(tt.err); kind != tt.expectedKind {
+				t.Errorf("Expected kind %q, got %q", tt.expectedKind, kind)
+			}
+			if failover := CanFailover(tt.err); failover != tt.expectedFailover {
+				t.Errorf("Expected failover %v, got %v", tt.expectedFailover, failover)
+			}
+			if technical := IsTechnical(tt.err); technical != tt.expectedTechnical {
+				t.Errorf("Expected technical %v, got %v", tt.expectedTechnical, technical)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package ledger builds the double-entry journal entries of the money movements. Postings are signed:
+// debits are positive and credits negative, so the postings of a balanced entry sum to zero in every currency.
+package ledger
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rauf/payment-service/internal/money"
+)
+
+var ErrUnbalanced = errors.New("unbalanced journal entry")
+
+type AccountType string
+
+const (
+	// AccountCustomer holds the money we owe a customer.
+	AccountCustomer AccountType = "customer"
+	// AccountGatewayClearing holds the money a gateway collected or paid out for us and has not settled yet.
+	AccountGatewayClearing AccountType = "gateway_clearing"
+	// AccountFees holds the fees charged by a gateway.
+	AccountFees AccountType = "fees"
+	// AccountSuspense holds the money that cannot be attributed yet, until it is adjusted to the right account.
+	AccountSuspense AccountType = "suspense"
+)
+
+// CreditNormal reports whether the balance of accounts of the type is their credits minus their debits.
+// Customer accounts are liabilities, so a customer holding money has a positive balance.
+func (t AccountType) CreditNormal() bool {
+	return t == AccountCustomer
+}
+
+// Account is identified by its type, owner and currency. The owner is the customer ID of customer accounts
+// and the gateway of gateway clearing and fee accounts. Suspense accounts have no owner.
+type Account struct {
+	Type     AccountType
+	Owner    string
+	Currency string
+}
+
+func CustomerAccount(customerID, currency string) Account {
+	return Account{Type: AccountCustomer, Owner: customerID, Currency: currency}
+}
+
+func GatewayClearingAccount(gateway, currency string) Account {
+	return Account{Type: AccountGatewayClearing, Owner: gateway, Currency: currency}
+}
+
+func FeesAccount(gateway, currency string) Account {
+	return Account{Type: AccountFees, Owner: gateway, Currency: currency}
+}
+
+func SuspenseAccount(currency string) Account {
+	return Account{Type: AccountSuspense, Currency: currency}
+}
+
+// CompareAccounts orders accounts by type, owner and currency. Database transactions lock accounts in this
+// order, so that two of them posting to the same accounts cannot deadlock.
+func CompareAccounts(a, b Account) int {
+	return cmp.Or(
+		strings.Compare(string(a.Type), string(b.Type)),
+		strings.Compare(a.Owner, b.Owner),
+		strings.Compare(a.Currency, b.Currency),
+	)
+}
+
+// Balance returns the balance of an account of the type from the sum of its postings.
+func (t AccountType) Balance(sum money.Money) money.Money {
+	if t.CreditNormal() {
+		return sum.Neg()
+	}
+	return sum
+}
+
+type Kind string
+
+const (
+	KindDeposit    Kind = "deposit"
+	KindWithdrawal Kind = "withdrawal"
+	KindRefund     Kind = "refund"
+	KindFee        Kind = "fee"
+	KindAdjustment Kind = "adjustment"
+	KindTransfer   Kind = "transfer"
+)
+
+// Posting debits the account with a positive amount or credits it with a negative one.
+type Posting struct {
+	Account Account
+	Amount  money.Money
+}
+
+type Entry struct {
+	Kind        Kind
+	Description string
+	Postings    []Posting
+}
+
+// Accounts returns the distinct accounts of the postings, in the order of CompareAccounts.
+func (e Entry) Accounts() []Account {
+	accounts := make([]Account, 0, len(e.Postings))
+	for _, p := range e.Postings {
+		accounts = append(accounts, p.Account)
+	}
+	slices.SortFunc(accounts, CompareAccounts)
+	return slices.Compact(accounts)
+}
+
+// Validate checks that the entry has at least two postings, that no posting is zero or in another currency
+// than its account, and that the postings sum to zero in every currency.
+func (e Entry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: %d postings", ErrUnbalanced, len(e.Postings))
+	}
+	sums := make(map[money.Currency]money.Money)
+	for _, p := range e.Postings {
+		if p.Amount.IsZero() {
+			return fmt.Errorf("%w: zero posting to %s account %s", ErrUnbalanced, p.Account.Type, p.Account.Owner)
+		}
+		currency := p.Amount.Currency()
+		if currency.String() != p.Account.Currency {
+			return fmt.Errorf("%w: %s posting to %s account", ErrUnbalanced, currency, p.Account.Currency)
+		}
+		sum, ok := sums[currency]
+		if !ok {
+			sums[currency] = p.Amount
+			continue
+		}
+		sum, err := sum.Add(p.Amount)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrUnbalanced, err)
+		}
+		sums[currency] = sum
+	}
+	for currency, sum := range sums {
+		if !sum.IsZero() {
+			return fmt.Errorf("%w: postings in %s sum to %s", ErrUnbalanced, currency, sum.Decimal())
+		}
+	}
+	return nil
+}
+
+// transfer is an entry debiting one account and crediting another with the amount.
+func transfer(kind Kind, debit, credit Account, amount money.Money) Entry {
+	return Entry{
+		Kind: kind,
+		Postings: []Posting{
+			{Account: debit, Amount: amount},
+			{Account: credit, Amount: amount.Neg()},
+		},
+	}
+}
+
+// Deposit records the money a gateway collected for a customer: the gateway owes it to us and we owe it to
+// the customer.
+func Deposit(gateway, customerID string, amount money.Money) Entry {
+	currency := amount.Currency().String()
+	return transfer(KindDeposit, GatewayClearingAccount(gateway, currency), CustomerAccount(customerID, currency), amount)
+}
+
+// Withdrawal records the money a gateway paid out to a customer.
+func Withdrawal(gateway, customerID string, amount money.Money) Entry {
+	currency := amount.Currency().String()
+	return transfer(KindWithdrawal, CustomerAccount(customerID, currency), GatewayClearingAccount(gateway, currency), amount)
+}
+
+// Refund records the money a gateway returned to a customer for a deposit, or to us for a withdrawal.
+func Refund(gateway, customerID string, amount money.Money, original Kind) Entry {
+	currency := amount.Currency().String()
+	customer, clearing := CustomerAccount(customerID, currency), GatewayClearingAccount(gateway, currency)
+	if original == KindWithdrawal {
+		return transfer(KindRefund, clearing, customer, amount)
+	}
+	return transfer(KindRefund, customer, clearing, amount)
+}
+
+// Fee records a fee the gateway charged, which it deducts from the money it settles.
+func Fee(gateway string, amount money.Money) Entry {
+	currency := amount.Currency().String()
+	return transfer(KindFee, FeesAccount(gateway, currency), GatewayClearingAccount(gateway, currency), amount)
+}
+
+// Transfer records money a customer sent to another customer, which never leaves the ledger.
+func Transfer(sourceCustomerID, destinationCustomerID string, amount money.Money) Entry {
+	currency := amount.Currency().String()
+	return transfer(KindTransfer, CustomerAccount(sourceCustomerID, currency), CustomerAccount(destinationCustomerID, currency), amount)
+}
+
+// Adjustment debits the account with the amount, or credits it with a negative amount, against suspense.
+func Adjustment(account Account, amount money.Money) Entry {
+	return transfer(KindAdjustment, account, SuspenseAccount(account.Currency), amount)
+}
@@ -0,0 +1,180 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/money"
+)
+
+func TestEntries(t *testing.T) {
+	amount := money.MustParse("10.50", "USD")
+
+	tests := []struct {
+		name   string
+		entry  Entry
+		debit  Account
+		credit Account
+	}{
+		{
+			name:   "Deposit",
+			entry:  Deposit("gatewayA", "cust1", amount),
+			debit:  GatewayClearingAccount("gatewayA", "USD"),
+			credit: CustomerAccount("cust1", "USD"),
+		},
+		{
+			name:   "Withdrawal",
+			entry:  Withdrawal("gatewayA", "cust1", amount),
+			debit:  CustomerAccount("cust1", "USD"),
+			credit: GatewayClearingAccount("gatewayA", "USD"),
+		},
+		{
+			name:   "Refund of a deposit",
+			entry:  Refund("gatewayB", "cust1", amount, KindDeposit),
+			debit:  CustomerAccount("cust1", "USD"),
+			credit: GatewayClearingAccount("gatewayB", "USD"),
+		},
+		{
+			name:   "Refund of a withdrawal",
+			entry:  Refund("gatewayB", "cust1", amount, KindWithdrawal),
+			debit:  GatewayClearingAccount("gatewayB", "USD"),
+			credit: CustomerAccount("cust1", "USD"),
+		},
+		{
+			name:   "Fee",
+			entry:  Fee("gatewayB", amount),
+			debit:  FeesAccount("gatewayB", "USD"),
+			credit: GatewayClearingAccount("gatewayB", "USD"),
+		},
+		{
+			name:   "Transfer",
+			entry:  Transfer("cust1", "cust2", amount),
+			debit:  CustomerAccount("cust1", "USD"),
+			credit: CustomerAccount("cust2", "USD"),
+		},
+		{
+			name:   "Adjustment",
+			entry:  Adjustment(CustomerAccount("cust1", "USD"), amount),
+			debit:  CustomerAccount("cust1", "USD"),
+			credit: SuspenseAccount("USD"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.entry.Validate(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			want := []Posting{{Account: tt.debit, Amount: amount}, {Account: tt.credit, Amount: amount.Neg()}}
+			if len(tt.entry.Postings) != len(want) || tt.entry.Postings[0] != want[0] || tt.entry.Postings[1] != want[1] {
+				t.Errorf("Expected postings %+v, got %+v", want, tt.entry.Postings)
+			}
+		})
+	}
+}
+
+func TestEntry_Validate(t *testing.T) {
+	usd := money.MustParse("10.00", "USD")
+	eur := money.MustParse("10.00", "EUR")
+	customer := CustomerAccount("cust1", "USD")
+	clearing := GatewayClearingAccount("gatewayA", "USD")
+
+	tests := []struct {
+		name     string
+		postings []Posting
+		wantErr  bool
+	}{
+		{
+			name:     "Balanced",
+			postings: []Posting{{Account: clearing, Amount: usd}, {Account: customer, Amount: usd.Neg()}},
+		},
+		{
+			name: "Balanced in every currency",
+			postings: []Posting{
+				{Account: clearing, Amount: usd}, {Account: customer, Amount: usd.Neg()},
+				{Account: GatewayClearingAccount("gatewayA", "EUR"), Amount: eur}, {Account: CustomerAccount("cust1", "EUR"), Amount: eur.Neg()},
+			},
+		},
+		{
+			name:     "Single posting",
+			postings: []Posting{{Account: clearing, Amount: usd}},
+			wantErr:  true,
+		},
+		{
+			name:     "Unbalanced",
+			postings: []Posting{{Account: clearing, Amount: usd}, {Account: customer, Amount: money.MustParse("-9.99", "USD")}},
+			wantErr:  true,
+		},
+		{
+			name:     "Zero posting",
+			postings: []Posting{{Account: clearing, Amount: money.MustParse("0", "USD")}, {Account: customer, Amount: money.MustParse("0", "USD")}},
+			wantErr:  true,
+		},
+		{
+			name:     "Balanced across currencies",
+			postings: []Posting{{Account: clearing, Amount: usd}, {Account: CustomerAccount("cust1", "EUR"), Amount: eur.Neg()}},
+			wantErr:  true,
+		},
+		{
+			name:     "Posting in another currency than its account",
+			postings: []Posting{{Account: clearing, Amount: eur}, {Account: CustomerAccount("cust1", "EUR"), Amount: eur.Neg()}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Entry{Kind: KindAdjustment, Postings: tt.postings}.Validate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnbalanced) {
+					t.Errorf("Expected ErrUnbalanced, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEntry_Accounts(t *testing.T) {
+	amount := money.MustParse("10.00", "USD")
+	entry := Entry{
+		Kind: KindAdjustment,
+		Postings: []Posting{
+			{Account: GatewayClearingAccount("gatewayB", "USD"), Amount: amount},
+			{Account: CustomerAccount("cust2", "USD"), Amount: amount.Neg()},
+			{Account: GatewayClearingAccount("gatewayA", "USD"), Amount: amount},
+			{Account: CustomerAccount("cust1", "USD"), Amount: amount.Neg()},
+			{Account: GatewayClearingAccount("gatewayA", "USD"), Amount: amount.Neg()},
+		},
+	}
+
+	expected := []Account{
+		CustomerAccount("cust1", "USD"),
+		CustomerAccount("cust2", "USD"),
+		GatewayClearingAccount("gatewayA", "USD"),
+		GatewayClearingAccount("gatewayB", "USD"),
+	}
+	got := entry.Accounts()
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d accounts, got %v", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected account %d to be %v, got %v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestAccountType_Balance(t *testing.T) {
+	debits := money.MustParse("25.00", "USD")
+
+	if got := AccountCustomer.Balance(debits.Neg()); got != debits {
+		t.Errorf("Expected customer balance %s, got %s", debits, got)
+	}
+	if got := AccountGatewayClearing.Balance(debits); got != debits {
+		t.Errorf("Expected clearing balance %s, got %s", debits, got)
+	}
+}
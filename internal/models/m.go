@@ -2,34 +2,425 @@ package models
 
 import (
 	"encoding/json"
+	"io"
 	"time"
+
+	"github.com/rauf/payment-service/internal/money"
 )
 
 type TransactionRequest struct {
 	Type             string
-	Amount           float64
-	Currency         string
+	Amount           money.Money
 	PaymentMethod    string
 	Description      string
 	CustomerID       string
 	PreferredGateway string
 	Metadata         json.RawMessage
+	// MerchantID is the merchant the transaction is made for. Its webhook endpoints receive the transaction events.
+	MerchantID string
+	// DestinationCustomerID is the customer a transfer is sent to. It is empty for other transaction types.
+	DestinationCustomerID string
+	// IdempotencyKey makes retries of the request safe. It is not part of the request fingerprint.
+	IdempotencyKey string `json:"-"`
+	// Reference is our ID of the transaction, sent to the gateway as the merchant reference. It is generated
+	// for every request, so it is not part of the request fingerprint either.
+	Reference string `json:"-"`
 }
 
 type TransactionResponse struct {
+	Reference string
 	Gateway   string
 	RefID     string
 	Status    string
-	CreatedAt time.Time
+	// GatewayStatusCode is the raw status or response code of the gateway that Status was mapped from.
+	GatewayStatusCode string
+	// DeclineReason is the lower-case reason category of failed transactions, if the gateway gave one.
+	DeclineReason string
+	CreatedAt     time.Time
 }
 
 type UpdateStatusRequest struct {
-	Gateway string
-	RefID   string
-	Status  string
+	Gateway           string
+	RefID             string
+	Status            string
+	GatewayStatusCode string
+	DeclineReason     string
+	Source            StatusChangeSource
+	// RawPayload is the request body that reported the status. It is kept in the status history.
+	RawPayload string
+}
+
+// ResolveTransactionRequest is the outcome an operator found for a transaction parked for review.
+type ResolveTransactionRequest struct {
+	Reference     string
+	Gateway       string
+	RefID         string
+	Status        string
+	DeclineReason string
 }
 
 type UpdateStatusResponse struct {
 	RefID  string
 	Status string
 }
+
+// RefundRequest is a refund sent to a gateway.
+type RefundRequest struct {
+	Gateway          string
+	TransactionRefID string
+	Amount           money.Money
+	Reason           string
+	// Reference is our reference of the refund, sent as the merchant reference so that a refund the gateway
+	// never answered can be queried.
+	Reference string
+}
+
+// CreateRefundRequest asks for a refund of a transaction.
+type CreateRefundRequest struct {
+	Gateway          string
+	TransactionRefID string
+	// Amount is a decimal in the currency of the transaction. Empty or zero refunds the remaining amount.
+	Amount string
+	Reason string
+}
+
+type RefundResponse struct {
+	ID               int32
+	Gateway          string
+	RefID            string
+	TransactionRefID string
+	Amount           money.Money
+	Status           string
+	CreatedAt        time.Time
+}
+
+// CaptureRequest is a capture sent to a gateway.
+type CaptureRequest struct {
+	Gateway          string
+	TransactionRefID string
+	Amount           money.Money
+}
+
+// CaptureTransactionRequest asks for the capture of an authorized transaction.
+type CaptureTransactionRequest struct {
+	Gateway          string
+	TransactionRefID string
+	// Amount is a decimal in the currency of the transaction. Empty or zero captures the full amount.
+	Amount string
+}
+
+type VoidRequest struct {
+	Gateway          string
+	TransactionRefID string
+}
+
+// StatusQuery identifies the transaction of a status query, by the reference of the gateway or, if the
+// gateway never answered, by our reference sent as the merchant reference.
+type StatusQuery struct {
+	RefID             string
+	MerchantReference string
+}
+
+// TransactionDetails is the stored state of a transaction.
+type TransactionDetails struct {
+	Reference     string
+	Gateway       string
+	RefID         string
+	Type          string
+	Amount        money.Money
+	PaymentMethod string
+	Description   string
+	CustomerID    string
+	MerchantID    string
+	// DestinationCustomerID is the customer a transfer was sent to.
+	DestinationCustomerID string
+	Status                string
+	// GatewayStatusCode is the raw status code of the gateway that Status was last mapped from.
+	GatewayStatusCode string
+	DeclineReason     string
+	PreferredGateway  string
+	// RoutingRule is the routing rule that picked the gateway, empty if no rule matched.
+	RoutingRule string
+	// ExpectedFee is the fee the gateway is expected to charge, the zero Money if its fee schedule does not
+	// price the transaction.
+	ExpectedFee money.Money
+	// CapturedAmount is zero unless the transaction was captured.
+	CapturedAmount         money.Money
+	RefundedAmount         money.Money
+	AuthorizationExpiresAt time.Time
+	Metadata               json.RawMessage
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+}
+
+// StatusChange is a recorded status transition of a transaction.
+type StatusChange struct {
+	From      string
+	To        string
+	Source    string
+	CreatedAt time.Time
+}
+
+// TransactionFilter selects transactions to list. Zero values are not applied.
+type TransactionFilter struct {
+	CustomerID string
+	// Reference is our reference of the transaction, returned even when the gateway never answered.
+	Reference string
+	Status    string
+	Gateway   string
+	Type      string
+	Currency  string
+	// MinAmount and MaxAmount are decimal bounds of the amount, in the currency of each transaction.
+	MinAmount   string
+	MaxAmount   string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	Limit  int
+}
+
+type TransactionPage struct {
+	Transactions []TransactionDetails
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// WebhookEndpointDetails is a URL that receives the events of a merchant's transactions.
+type WebhookEndpointDetails struct {
+	ID         int32
+	MerchantID string
+	URL        string
+	// Secret signs the deliveries. It is only returned when the endpoint is registered.
+	Secret    string
+	CreatedAt time.Time
+}
+
+// WebhookDeliveryDetails is the delivery of an event to a webhook endpoint.
+type WebhookDeliveryDetails struct {
+	ID            int64
+	EndpointID    int32
+	EventID       int64
+	EventType     string
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	// ResponseCode is the status code of the last response, 0 if there was none.
+	ResponseCode int32
+	LastError    string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// WebhookDeliveryFilter selects the webhook deliveries of a merchant to list.
+type WebhookDeliveryFilter struct {
+	MerchantID string
+	Status     string
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	Limit  int
+}
+
+type WebhookDeliveryPage struct {
+	Deliveries []WebhookDeliveryDetails
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// GatewayCallback is a verified status callback of a gateway.
+type GatewayCallback struct {
+	Gateway           string
+	RefID             string
+	Status            string
+	GatewayStatusCode string
+	DeclineReason     string
+	// DedupeKey identifies redeliveries of the callback by the gateway.
+	DedupeKey  string
+	RawPayload string
+}
+
+// CallbackInboxEntry is a received gateway callback and the outcome of applying it.
+type CallbackInboxEntry struct {
+	ID        int64
+	Gateway   string
+	RefID     string
+	DedupeKey string
+	// Status is received, applied, parked (the transaction does not exist yet) or rejected.
+	Status            string
+	ReportedStatus    string
+	GatewayStatusCode string
+	DeclineReason     string
+	RawPayload        string
+	Attempts          int32
+	LastError         string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// CallbackInboxFilter selects the callback inbox entries to list.
+type CallbackInboxFilter struct {
+	Gateway string
+	RefID   string
+	Status  string
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	Limit  int
+}
+
+type CallbackInboxPage struct {
+	Entries []CallbackInboxEntry
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// ReconcileRequest asks for the reconciliation of a settlement report of a gateway.
+type ReconcileRequest struct {
+	Gateway string
+	// PeriodFrom and PeriodTo bound the creation time of the transactions the report should settle. Settled
+	// transactions of the period that are not in the report are missing.
+	PeriodFrom time.Time
+	PeriodTo   time.Time
+	Report     io.Reader
+}
+
+// Reconciliation is a reconciliation run. Discrepancies are only set when a single run is read.
+type Reconciliation struct {
+	ID         int64
+	Gateway    string
+	PeriodFrom time.Time
+	PeriodTo   time.Time
+	// Records is the number of records in the settlement report, and Matched the number of them that match ours.
+	Records          int32
+	Matched          int32
+	DiscrepancyCount int32
+	Discrepancies    []ReconciliationDiscrepancyDetails
+	CreatedAt        time.Time
+}
+
+// ReconciliationDiscrepancyDetails is a difference between a recorded transaction and the settlement report.
+// Recorded fields are zero for extra transactions, and settled fields are zero for missing ones.
+type ReconciliationDiscrepancyDetails struct {
+	// Kind is missing, extra, amount_mismatch or status_mismatch.
+	Kind           string
+	RefID          string
+	RecordedAmount money.Money
+	RecordedStatus string
+	SettledAmount  money.Money
+	SettledStatus  string
+}
+
+type ReconciliationFilter struct {
+	Gateway string
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	Limit  int
+}
+
+type ReconciliationPage struct {
+	Reconciliations []Reconciliation
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// LedgerBalance is the balance of a ledger account. Customer accounts have a positive balance when we owe the
+// customer money, and the other accounts when they hold money for us.
+type LedgerBalance struct {
+	AccountID int64
+	// AccountType is customer, gateway_clearing, fees or suspense.
+	AccountType string
+	Owner       string
+	Balance     money.Money
+}
+
+type LedgerAccountFilter struct {
+	AccountType string
+	Owner       string
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	Limit  int
+}
+
+type LedgerAccountPage struct {
+	Accounts []LedgerBalance
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// FeeRequest records a fee charged by a gateway.
+type FeeRequest struct {
+	Gateway     string
+	Amount      money.Money
+	Description string
+}
+
+// AdjustmentRequest moves money between an account and suspense. A positive amount debits the account and a
+// negative amount credits it.
+type AdjustmentRequest struct {
+	AccountType string
+	Owner       string
+	Amount      money.Money
+	Description string
+}
+
+// JournalEntry is a posted journal entry. Debits are positive and credits negative.
+type JournalEntry struct {
+	ID          int64
+	Kind        string
+	Description string
+	Postings    []LedgerPostingDetails
+	CreatedAt   time.Time
+}
+
+type LedgerPostingDetails struct {
+	AccountType string
+	Owner       string
+	Amount      money.Money
+}
+
+// LedgerCheck is the result of the ledger consistency check. The ledger is balanced when the postings sum to
+// zero in every currency and no entry is unbalanced.
+type LedgerCheck struct {
+	Balanced          bool
+	Totals            []LedgerTotal
+	UnbalancedEntries []UnbalancedLedgerEntry
+}
+
+// LedgerTotal is the sum of all postings in a currency.
+type LedgerTotal struct {
+	Total    money.Money
+	Postings int64
+}
+
+type UnbalancedLedgerEntry struct {
+	EntryID int64
+	Total   money.Money
+}
+
+// RoutingDecision is the routing of a transaction: the rule that matched it, if any, and the gateways it
+// would be sent to, in order.
+type RoutingDecision struct {
+	Rule     string
+	Gateways []RoutedGateway
+}
+
+// GatewayVolume is the amount a gateway processed in a currency over a period.
+type GatewayVolume struct {
+	Gateway string
+	Volume  money.Money
+}
+
+// RoutingRule is a routing rule with the gateways it picks and their current weights.
+type RoutingRule struct {
+	Name     string
+	Gateways []string
+	// Weights split the traffic of the rule between its gateways. Without weights, the gateways are tried
+	// in order.
+	Weights map[string]int
+}
+
+type RoutedGateway struct {
+	Name string
+	// Circuit is the state of the circuit breaker of the gateway: closed, half-open or open. Gateways with an
+	// open circuit are skipped.
+	Circuit string
+}
@@ -7,18 +7,333 @@ package models
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/sqlc-dev/pqtype"
 )
 
+type CallbackInboxStatus string
+
+const (
+	CallbackInboxStatusRECEIVED CallbackInboxStatus = "RECEIVED"
+	CallbackInboxStatusAPPLIED  CallbackInboxStatus = "APPLIED"
+	CallbackInboxStatusPARKED   CallbackInboxStatus = "PARKED"
+	CallbackInboxStatusREJECTED CallbackInboxStatus = "REJECTED"
+)
+
+func (e *CallbackInboxStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CallbackInboxStatus(s)
+	case string:
+		*e = CallbackInboxStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CallbackInboxStatus: %T", src)
+	}
+	return nil
+}
+
+type NullCallbackInboxStatus struct {
+	CallbackInboxStatus CallbackInboxStatus `json:"callbackInboxStatus"`
+	Valid               bool                `json:"valid"` // Valid is true if CallbackInboxStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCallbackInboxStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.CallbackInboxStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CallbackInboxStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCallbackInboxStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CallbackInboxStatus), nil
+}
+
+type DeclineReason string
+
+const (
+	DeclineReasonINSUFFICIENTFUNDS DeclineReason = "INSUFFICIENT_FUNDS"
+	DeclineReasonDONOTHONOR        DeclineReason = "DO_NOT_HONOR"
+	DeclineReasonFRAUD             DeclineReason = "FRAUD"
+	DeclineReasonEXPIREDCARD       DeclineReason = "EXPIRED_CARD"
+	DeclineReasonTECHNICAL         DeclineReason = "TECHNICAL"
+)
+
+func (e *DeclineReason) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DeclineReason(s)
+	case string:
+		*e = DeclineReason(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DeclineReason: %T", src)
+	}
+	return nil
+}
+
+type NullDeclineReason struct {
+	DeclineReason DeclineReason `json:"declineReason"`
+	Valid         bool          `json:"valid"` // Valid is true if DeclineReason is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDeclineReason) Scan(value interface{}) error {
+	if value == nil {
+		ns.DeclineReason, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DeclineReason.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDeclineReason) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DeclineReason), nil
+}
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusINPROGRESS IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyStatusCOMPLETED  IdempotencyStatus = "COMPLETED"
+)
+
+func (e *IdempotencyStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = IdempotencyStatus(s)
+	case string:
+		*e = IdempotencyStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for IdempotencyStatus: %T", src)
+	}
+	return nil
+}
+
+type NullIdempotencyStatus struct {
+	IdempotencyStatus IdempotencyStatus `json:"idempotencyStatus"`
+	Valid             bool              `json:"valid"` // Valid is true if IdempotencyStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullIdempotencyStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.IdempotencyStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.IdempotencyStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullIdempotencyStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.IdempotencyStatus), nil
+}
+
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeCUSTOMER        LedgerAccountType = "CUSTOMER"
+	LedgerAccountTypeGATEWAYCLEARING LedgerAccountType = "GATEWAY_CLEARING"
+	LedgerAccountTypeFEES            LedgerAccountType = "FEES"
+	LedgerAccountTypeSUSPENSE        LedgerAccountType = "SUSPENSE"
+)
+
+func (e *LedgerAccountType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerAccountType(s)
+	case string:
+		*e = LedgerAccountType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerAccountType: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerAccountType struct {
+	LedgerAccountType LedgerAccountType `json:"ledgerAccountType"`
+	Valid             bool              `json:"valid"` // Valid is true if LedgerAccountType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerAccountType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerAccountType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerAccountType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerAccountType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerAccountType), nil
+}
+
+type LedgerEntryKind string
+
+const (
+	LedgerEntryKindDEPOSIT    LedgerEntryKind = "DEPOSIT"
+	LedgerEntryKindWITHDRAWAL LedgerEntryKind = "WITHDRAWAL"
+	LedgerEntryKindREFUND     LedgerEntryKind = "REFUND"
+	LedgerEntryKindFEE        LedgerEntryKind = "FEE"
+	LedgerEntryKindADJUSTMENT LedgerEntryKind = "ADJUSTMENT"
+	LedgerEntryKindTRANSFER   LedgerEntryKind = "TRANSFER"
+)
+
+func (e *LedgerEntryKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerEntryKind(s)
+	case string:
+		*e = LedgerEntryKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerEntryKind: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerEntryKind struct {
+	LedgerEntryKind LedgerEntryKind `json:"ledgerEntryKind"`
+	Valid           bool            `json:"valid"` // Valid is true if LedgerEntryKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerEntryKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerEntryKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerEntryKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerEntryKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerEntryKind), nil
+}
+
+type ReconciliationDiscrepancyKind string
+
+const (
+	ReconciliationDiscrepancyKindMISSING        ReconciliationDiscrepancyKind = "MISSING"
+	ReconciliationDiscrepancyKindEXTRA          ReconciliationDiscrepancyKind = "EXTRA"
+	ReconciliationDiscrepancyKindAMOUNTMISMATCH ReconciliationDiscrepancyKind = "AMOUNT_MISMATCH"
+	ReconciliationDiscrepancyKindSTATUSMISMATCH ReconciliationDiscrepancyKind = "STATUS_MISMATCH"
+)
+
+func (e *ReconciliationDiscrepancyKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReconciliationDiscrepancyKind(s)
+	case string:
+		*e = ReconciliationDiscrepancyKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReconciliationDiscrepancyKind: %T", src)
+	}
+	return nil
+}
+
+type NullReconciliationDiscrepancyKind struct {
+	ReconciliationDiscrepancyKind ReconciliationDiscrepancyKind `json:"reconciliationDiscrepancyKind"`
+	Valid                         bool                          `json:"valid"` // Valid is true if ReconciliationDiscrepancyKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReconciliationDiscrepancyKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReconciliationDiscrepancyKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReconciliationDiscrepancyKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReconciliationDiscrepancyKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReconciliationDiscrepancyKind), nil
+}
+
+type StatusChangeSource string
+
+const (
+	StatusChangeSourceAPI            StatusChangeSource = "API"
+	StatusChangeSourceCALLBACK       StatusChangeSource = "CALLBACK"
+	StatusChangeSourceRECONCILIATION StatusChangeSource = "RECONCILIATION"
+	StatusChangeSourceSYSTEM         StatusChangeSource = "SYSTEM"
+)
+
+func (e *StatusChangeSource) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StatusChangeSource(s)
+	case string:
+		*e = StatusChangeSource(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StatusChangeSource: %T", src)
+	}
+	return nil
+}
+
+type NullStatusChangeSource struct {
+	StatusChangeSource StatusChangeSource `json:"statusChangeSource"`
+	Valid              bool               `json:"valid"` // Valid is true if StatusChangeSource is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStatusChangeSource) Scan(value interface{}) error {
+	if value == nil {
+		ns.StatusChangeSource, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StatusChangeSource.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStatusChangeSource) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StatusChangeSource), nil
+}
+
 type TransactionStatus string
 
 const (
-	TransactionStatusPENDING TransactionStatus = "PENDING"
-	TransactionStatusSUCCESS TransactionStatus = "SUCCESS"
-	TransactionStatusFAILED  TransactionStatus = "FAILED"
+	TransactionStatusPENDING     TransactionStatus = "PENDING"
+	TransactionStatusSUCCESS     TransactionStatus = "SUCCESS"
+	TransactionStatusFAILED      TransactionStatus = "FAILED"
+	TransactionStatusAUTHORIZED  TransactionStatus = "AUTHORIZED"
+	TransactionStatusCAPTURED    TransactionStatus = "CAPTURED"
+	TransactionStatusVOIDED      TransactionStatus = "VOIDED"
+	TransactionStatusINITIATED   TransactionStatus = "INITIATED"
+	TransactionStatusNEEDSREVIEW TransactionStatus = "NEEDS_REVIEW"
 )
 
 func (e *TransactionStatus) Scan(src interface{}) error {
@@ -99,19 +414,211 @@ func (ns NullTransactionType) Value() (driver.Value, error) {
 	return string(ns.TransactionType), nil
 }
 
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPENDING   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusDELIVERED WebhookDeliveryStatus = "DELIVERED"
+	WebhookDeliveryStatusDEAD      WebhookDeliveryStatus = "DEAD"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus `json:"webhookDeliveryStatus"`
+	Valid                 bool                  `json:"valid"` // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
+type CallbackInbox struct {
+	ID                int64               `json:"id"`
+	Gateway           string              `json:"gateway"`
+	RefID             string              `json:"refId"`
+	DedupeKey         string              `json:"dedupeKey"`
+	Status            CallbackInboxStatus `json:"status"`
+	ReportedStatus    TransactionStatus   `json:"reportedStatus"`
+	GatewayStatusCode sql.NullString      `json:"gatewayStatusCode"`
+	DeclineReason     NullDeclineReason   `json:"declineReason"`
+	RawPayload        string              `json:"rawPayload"`
+	Attempts          int32               `json:"attempts"`
+	LastError         sql.NullString      `json:"lastError"`
+	CreatedAt         time.Time           `json:"createdAt"`
+	UpdatedAt         time.Time           `json:"updatedAt"`
+}
+
+type IdempotencyKey struct {
+	ID          int32                 `json:"id"`
+	Key         string                `json:"key"`
+	RequestHash string                `json:"requestHash"`
+	Status      IdempotencyStatus     `json:"status"`
+	Response    pqtype.NullRawMessage `json:"response"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+	Reference   sql.NullString        `json:"reference"`
+}
+
+type LedgerAccount struct {
+	ID        int64             `json:"id"`
+	Type      LedgerAccountType `json:"type"`
+	Owner     string            `json:"owner"`
+	Currency  string            `json:"currency"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+type LedgerEntry struct {
+	ID            int64           `json:"id"`
+	Kind          LedgerEntryKind `json:"kind"`
+	TransactionID sql.NullInt32   `json:"transactionId"`
+	RefundID      sql.NullInt32   `json:"refundId"`
+	Description   sql.NullString  `json:"description"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+type LedgerPosting struct {
+	ID        int64     `json:"id"`
+	EntryID   int64     `json:"entryId"`
+	AccountID int64     `json:"accountId"`
+	Amount    string    `json:"amount"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type OutboxEvent struct {
+	ID          int64           `json:"id"`
+	EventType   string          `json:"eventType"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int32           `json:"attempts"`
+	LastError   sql.NullString  `json:"lastError"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	PublishedAt sql.NullTime    `json:"publishedAt"`
+}
+
+type ReconciliationDiscrepancy struct {
+	ID               int64                         `json:"id"`
+	RunID            int64                         `json:"runId"`
+	Kind             ReconciliationDiscrepancyKind `json:"kind"`
+	RefID            string                        `json:"refId"`
+	RecordedAmount   sql.NullString                `json:"recordedAmount"`
+	RecordedCurrency sql.NullString                `json:"recordedCurrency"`
+	RecordedStatus   sql.NullString                `json:"recordedStatus"`
+	SettledAmount    sql.NullString                `json:"settledAmount"`
+	SettledCurrency  sql.NullString                `json:"settledCurrency"`
+	SettledStatus    sql.NullString                `json:"settledStatus"`
+}
+
+type ReconciliationRun struct {
+	ID            int64     `json:"id"`
+	Gateway       string    `json:"gateway"`
+	PeriodFrom    time.Time `json:"periodFrom"`
+	PeriodTo      time.Time `json:"periodTo"`
+	Records       int32     `json:"records"`
+	Matched       int32     `json:"matched"`
+	Discrepancies int32     `json:"discrepancies"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type Refund struct {
+	ID                 int32             `json:"id"`
+	TransactionID      int32             `json:"transactionId"`
+	Amount             string            `json:"amount"`
+	Currency           string            `json:"currency"`
+	Reason             sql.NullString    `json:"reason"`
+	Gateway            string            `json:"gateway"`
+	GatewayRefID       sql.NullString    `json:"gatewayRefId"`
+	Status             TransactionStatus `json:"status"`
+	CreatedAt          time.Time         `json:"createdAt"`
+	UpdatedAt          time.Time         `json:"updatedAt"`
+	StatusPollAttempts int32             `json:"statusPollAttempts"`
+	NextStatusPollAt   sql.NullTime      `json:"nextStatusPollAt"`
+}
+
 type Transaction struct {
-	ID               int32                 `json:"id"`
-	Type             TransactionType       `json:"type"`
-	Amount           string                `json:"amount"`
-	Currency         string                `json:"currency"`
-	PaymentMethod    string                `json:"paymentMethod"`
-	Description      sql.NullString        `json:"description"`
-	CustomerID       string                `json:"customerId"`
-	Gateway          string                `json:"gateway"`
-	GatewayRefID     string                `json:"gatewayRefId"`
-	Status           TransactionStatus     `json:"status"`
-	PreferredGateway sql.NullString        `json:"preferredGateway"`
-	CreatedAt        time.Time             `json:"createdAt"`
-	UpdatedAt        time.Time             `json:"updatedAt"`
-	Metadata         pqtype.NullRawMessage `json:"metadata"`
+	ID                     int32                 `json:"id"`
+	Type                   TransactionType       `json:"type"`
+	Amount                 string                `json:"amount"`
+	Currency               string                `json:"currency"`
+	PaymentMethod          string                `json:"paymentMethod"`
+	Description            sql.NullString        `json:"description"`
+	CustomerID             string                `json:"customerId"`
+	Gateway                string                `json:"gateway"`
+	GatewayRefID           sql.NullString        `json:"gatewayRefId"`
+	Status                 TransactionStatus     `json:"status"`
+	PreferredGateway       sql.NullString        `json:"preferredGateway"`
+	CreatedAt              time.Time             `json:"createdAt"`
+	UpdatedAt              time.Time             `json:"updatedAt"`
+	Metadata               pqtype.NullRawMessage `json:"metadata"`
+	RefundedAmount         string                `json:"refundedAmount"`
+	CapturedAmount         sql.NullString        `json:"capturedAmount"`
+	AuthorizationExpiresAt sql.NullTime          `json:"authorizationExpiresAt"`
+	MerchantID             sql.NullString        `json:"merchantId"`
+	GatewayStatusCode      sql.NullString        `json:"gatewayStatusCode"`
+	DeclineReason          NullDeclineReason     `json:"declineReason"`
+	Reference              string                `json:"reference"`
+	StatusPollAttempts     int32                 `json:"statusPollAttempts"`
+	NextStatusPollAt       sql.NullTime          `json:"nextStatusPollAt"`
+	DestinationCustomerID  sql.NullString        `json:"destinationCustomerId"`
+	RoutingRule            sql.NullString        `json:"routingRule"`
+	ExpectedFee            sql.NullString        `json:"expectedFee"`
+}
+
+type TransactionStatusHistory struct {
+	ID            int32              `json:"id"`
+	TransactionID int32              `json:"transactionId"`
+	FromStatus    TransactionStatus  `json:"fromStatus"`
+	ToStatus      TransactionStatus  `json:"toStatus"`
+	Source        StatusChangeSource `json:"source"`
+	RawPayload    sql.NullString     `json:"rawPayload"`
+	CreatedAt     time.Time          `json:"createdAt"`
+}
+
+type WebhookDelivery struct {
+	ID            int64                 `json:"id"`
+	EndpointID    int32                 `json:"endpointId"`
+	EventID       int64                 `json:"eventId"`
+	EventType     string                `json:"eventType"`
+	Payload       json.RawMessage       `json:"payload"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int32                 `json:"attempts"`
+	NextAttemptAt time.Time             `json:"nextAttemptAt"`
+	ResponseCode  sql.NullInt32         `json:"responseCode"`
+	LastError     sql.NullString        `json:"lastError"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	UpdatedAt     time.Time             `json:"updatedAt"`
+}
+
+type WebhookEndpoint struct {
+	ID         int32     `json:"id"`
+	MerchantID string    `json:"merchantId"`
+	Url        string    `json:"url"`
+	Secret     string    `json:"secret"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
 }
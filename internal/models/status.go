@@ -0,0 +1,18 @@
+package models
+
+import "slices"
+
+// transitions lists the statuses a transaction can move to from each status. SUCCESS, FAILED, CAPTURED
+// and VOIDED are final. NEEDS_REVIEW parks an initiated transaction the gateways could not be asked about,
+// until it is resolved manually.
+var transitions = map[TransactionStatus][]TransactionStatus{
+	TransactionStatusINITIATED:   {TransactionStatusPENDING, TransactionStatusSUCCESS, TransactionStatusFAILED, TransactionStatusAUTHORIZED, TransactionStatusNEEDSREVIEW},
+	TransactionStatusNEEDSREVIEW: {TransactionStatusPENDING, TransactionStatusSUCCESS, TransactionStatusFAILED, TransactionStatusAUTHORIZED},
+	TransactionStatusPENDING:     {TransactionStatusSUCCESS, TransactionStatusFAILED},
+	TransactionStatusAUTHORIZED:  {TransactionStatusCAPTURED, TransactionStatusVOIDED, TransactionStatusFAILED},
+}
+
+// CanTransitionTo reports whether a transaction in status e may move to the given status.
+func (e TransactionStatus) CanTransitionTo(to TransactionStatus) bool {
+	return slices.Contains(transitions[e], to)
+}
@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestTransactionStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from TransactionStatus
+		to   TransactionStatus
+		want bool
+	}{
+		{from: TransactionStatusINITIATED, to: TransactionStatusPENDING, want: true},
+		{from: TransactionStatusINITIATED, to: TransactionStatusSUCCESS, want: true},
+		{from: TransactionStatusINITIATED, to: TransactionStatusFAILED, want: true},
+		{from: TransactionStatusINITIATED, to: TransactionStatusAUTHORIZED, want: true},
+		{from: TransactionStatusINITIATED, to: TransactionStatusNEEDSREVIEW, want: true},
+		{from: TransactionStatusINITIATED, to: TransactionStatusCAPTURED, want: false},
+		{from: TransactionStatusNEEDSREVIEW, to: TransactionStatusSUCCESS, want: true},
+		{from: TransactionStatusNEEDSREVIEW, to: TransactionStatusFAILED, want: true},
+		{from: TransactionStatusNEEDSREVIEW, to: TransactionStatusINITIATED, want: false},
+		{from: TransactionStatusPENDING, to: TransactionStatusINITIATED, want: false},
+		{from: TransactionStatusPENDING, to: TransactionStatusSUCCESS, want: true},
+		{from: TransactionStatusPENDING, to: TransactionStatusFAILED, want: true},
+		{from: TransactionStatusPENDING, to: TransactionStatusCAPTURED, want: false},
+		{from: TransactionStatusAUTHORIZED, to: TransactionStatusCAPTURED, want: true},
+		{from: TransactionStatusAUTHORIZED, to: TransactionStatusVOIDED, want: true},
+		{from: TransactionStatusAUTHORIZED, to: TransactionStatusFAILED, want: true},
+		{from: TransactionStatusAUTHORIZED, to: TransactionStatusSUCCESS, want: false},
+		{from: TransactionStatusSUCCESS, to: TransactionStatusPENDING, want: false},
+		{from: TransactionStatusSUCCESS, to: TransactionStatusFAILED, want: false},
+		{from: TransactionStatusFAILED, to: TransactionStatusSUCCESS, want: false},
+		{from: TransactionStatusCAPTURED, to: TransactionStatusVOIDED, want: false},
+		{from: TransactionStatusVOIDED, to: TransactionStatusCAPTURED, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
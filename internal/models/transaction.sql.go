@@ -10,10 +10,135 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/sqlc-dev/pqtype"
 )
 
-const createTransaction = `-- name: CreateTransaction :exec
+const captureTransaction = `-- name: CaptureTransaction :one
+UPDATE transaction
+SET status = 'CAPTURED', captured_amount = $1::numeric, updated_at = $2
+WHERE id = $3 AND status = 'AUTHORIZED'
+RETURNING id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+`
+
+type CaptureTransactionParams struct {
+	CapturedAmount string    `json:"capturedAmount"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	ID             int32     `json:"id"`
+}
+
+func (q *Queries) CaptureTransaction(ctx context.Context, arg CaptureTransactionParams) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, captureTransaction, arg.CapturedAmount, arg.UpdatedAt, arg.ID)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Description,
+		&i.CustomerID,
+		&i.Gateway,
+		&i.GatewayRefID,
+		&i.Status,
+		&i.PreferredGateway,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
+	)
+	return i, err
+}
+
+const completeInitiatedTransaction = `-- name: CompleteInitiatedTransaction :one
+UPDATE transaction
+SET gateway                  = $1,
+    gateway_ref_id           = $2,
+    status                   = $3,
+    gateway_status_code      = $4,
+    decline_reason           = $5,
+    authorization_expires_at = $6,
+    routing_rule             = $7,
+    expected_fee             = $8,
+    status_poll_attempts     = 0,
+    next_status_poll_at      = NULL,
+    updated_at               = $9
+WHERE id = $10 AND status = $11
+RETURNING id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+`
+
+type CompleteInitiatedTransactionParams struct {
+	Gateway                string            `json:"gateway"`
+	GatewayRefID           sql.NullString    `json:"gatewayRefId"`
+	Status                 TransactionStatus `json:"status"`
+	GatewayStatusCode      sql.NullString    `json:"gatewayStatusCode"`
+	DeclineReason          NullDeclineReason `json:"declineReason"`
+	AuthorizationExpiresAt sql.NullTime      `json:"authorizationExpiresAt"`
+	RoutingRule            sql.NullString    `json:"routingRule"`
+	ExpectedFee            sql.NullString    `json:"expectedFee"`
+	UpdatedAt              time.Time         `json:"updatedAt"`
+	ID                     int32             `json:"id"`
+	FromStatus             TransactionStatus `json:"fromStatus"`
+}
+
+func (q *Queries) CompleteInitiatedTransaction(ctx context.Context, arg CompleteInitiatedTransactionParams) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, completeInitiatedTransaction,
+		arg.Gateway,
+		arg.GatewayRefID,
+		arg.Status,
+		arg.GatewayStatusCode,
+		arg.DeclineReason,
+		arg.AuthorizationExpiresAt,
+		arg.RoutingRule,
+		arg.ExpectedFee,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.FromStatus,
+	)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Description,
+		&i.CustomerID,
+		&i.Gateway,
+		&i.GatewayRefID,
+		&i.Status,
+		&i.PreferredGateway,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
+	)
+	return i, err
+}
+
+const createTransaction = `-- name: CreateTransaction :one
 INSERT INTO transaction (type,
                      amount,
                      currency,
@@ -24,7 +149,13 @@ INSERT INTO transaction (type,
                      gateway_ref_id,
                      status,
                      preferred_gateway,
-                     metadata)
+                     metadata,
+                     authorization_expires_at,
+                     merchant_id,
+                     gateway_status_code,
+                     decline_reason,
+                     reference,
+                     destination_customer_id)
 VALUES ($1,
         $2,
         $3,
@@ -35,25 +166,38 @@ VALUES ($1,
         $8,
         $9,
         $10,
-        $11)
+        $11,
+        $12,
+        $13,
+        $14,
+        $15,
+        $16,
+        $17)
+RETURNING id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
 `
 
 type CreateTransactionParams struct {
-	Type             TransactionType       `json:"type"`
-	Amount           string                `json:"amount"`
-	Currency         string                `json:"currency"`
-	PaymentMethod    string                `json:"paymentMethod"`
-	Description      sql.NullString        `json:"description"`
-	CustomerID       string                `json:"customerId"`
-	Gateway          string                `json:"gateway"`
-	GatewayRefID     string                `json:"gatewayRefId"`
-	Status           TransactionStatus     `json:"status"`
-	PreferredGateway sql.NullString        `json:"preferredGateway"`
-	Metadata         pqtype.NullRawMessage `json:"metadata"`
-}
-
-func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) error {
-	_, err := q.db.ExecContext(ctx, createTransaction,
+	Type                   TransactionType       `json:"type"`
+	Amount                 string                `json:"amount"`
+	Currency               string                `json:"currency"`
+	PaymentMethod          string                `json:"paymentMethod"`
+	Description            sql.NullString        `json:"description"`
+	CustomerID             string                `json:"customerId"`
+	Gateway                string                `json:"gateway"`
+	GatewayRefID           sql.NullString        `json:"gatewayRefId"`
+	Status                 TransactionStatus     `json:"status"`
+	PreferredGateway       sql.NullString        `json:"preferredGateway"`
+	Metadata               pqtype.NullRawMessage `json:"metadata"`
+	AuthorizationExpiresAt sql.NullTime          `json:"authorizationExpiresAt"`
+	MerchantID             sql.NullString        `json:"merchantId"`
+	GatewayStatusCode      sql.NullString        `json:"gatewayStatusCode"`
+	DeclineReason          NullDeclineReason     `json:"declineReason"`
+	Reference              string                `json:"reference"`
+	DestinationCustomerID  sql.NullString        `json:"destinationCustomerId"`
+}
+
+func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, createTransaction,
 		arg.Type,
 		arg.Amount,
 		arg.Currency,
@@ -65,17 +209,53 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		arg.Status,
 		arg.PreferredGateway,
 		arg.Metadata,
+		arg.AuthorizationExpiresAt,
+		arg.MerchantID,
+		arg.GatewayStatusCode,
+		arg.DeclineReason,
+		arg.Reference,
+		arg.DestinationCustomerID,
 	)
-	return err
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Description,
+		&i.CustomerID,
+		&i.Gateway,
+		&i.GatewayRefID,
+		&i.Status,
+		&i.PreferredGateway,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
+	)
+	return i, err
 }
 
-const getAll = `-- name: GetAll :one
-SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata
+const getTransaction = `-- name: GetTransaction :one
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
 FROM transaction
+WHERE id = $1
 `
 
-func (q *Queries) GetAll(ctx context.Context) (Transaction, error) {
-	row := q.db.QueryRowContext(ctx, getAll)
+func (q *Queries) GetTransaction(ctx context.Context, id int32) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getTransaction, id)
 	var i Transaction
 	err := row.Scan(
 		&i.ID,
@@ -92,19 +272,31 @@ func (q *Queries) GetAll(ctx context.Context) (Transaction, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
 	)
 	return i, err
 }
 
 const getTransactionByGatewayRefId = `-- name: GetTransactionByGatewayRefId :one
-SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
 FROM transaction
 WHERE gateway_ref_id = $1 AND gateway = $2
 `
 
 type GetTransactionByGatewayRefIdParams struct {
-	GatewayRefID string `json:"gatewayRefId"`
-	Gateway      string `json:"gateway"`
+	GatewayRefID sql.NullString `json:"gatewayRefId"`
+	Gateway      string         `json:"gateway"`
 }
 
 func (q *Queries) GetTransactionByGatewayRefId(ctx context.Context, arg GetTransactionByGatewayRefIdParams) (Transaction, error) {
@@ -125,29 +317,653 @@ func (q *Queries) GetTransactionByGatewayRefId(ctx context.Context, arg GetTrans
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
 	)
 	return i, err
 }
 
-const updateTransactionStatus = `-- name: UpdateTransactionStatus :exec
-UPDATE transaction
-SET status = $1, updated_at = $4
-WHERE gateway_ref_id = $2 AND gateway = $3
+const getTransactionByReference = `-- name: GetTransactionByReference :one
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+FROM transaction
+WHERE reference = $1
+`
+
+func (q *Queries) GetTransactionByReference(ctx context.Context, reference string) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getTransactionByReference, reference)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Description,
+		&i.CustomerID,
+		&i.Gateway,
+		&i.GatewayRefID,
+		&i.Status,
+		&i.PreferredGateway,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
+	)
+	return i, err
+}
+
+const listExpiredAuthorizations = `-- name: ListExpiredAuthorizations :many
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+FROM transaction
+WHERE status = 'AUTHORIZED' AND authorization_expires_at < $1
+ORDER BY authorization_expires_at
+LIMIT $2
+`
+
+type ListExpiredAuthorizationsParams struct {
+	AuthorizationExpiresAt sql.NullTime `json:"authorizationExpiresAt"`
+	Limit                  int32        `json:"limit"`
+}
+
+func (q *Queries) ListExpiredAuthorizations(ctx context.Context, arg ListExpiredAuthorizationsParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredAuthorizations, arg.AuthorizationExpiresAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Description,
+			&i.CustomerID,
+			&i.Gateway,
+			&i.GatewayRefID,
+			&i.Status,
+			&i.PreferredGateway,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Metadata,
+			&i.RefundedAmount,
+			&i.CapturedAmount,
+			&i.AuthorizationExpiresAt,
+			&i.MerchantID,
+			&i.GatewayStatusCode,
+			&i.DeclineReason,
+			&i.Reference,
+			&i.StatusPollAttempts,
+			&i.NextStatusPollAt,
+			&i.DestinationCustomerID,
+			&i.RoutingRule,
+			&i.ExpectedFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGatewayVolumes = `-- name: ListGatewayVolumes :many
+SELECT gateway, currency, SUM(COALESCE(captured_amount, amount))::numeric AS volume
+FROM transaction
+WHERE status IN ('SUCCESS', 'CAPTURED')
+  AND type <> 'TRANSFER'
+  AND created_at >= $1
+GROUP BY gateway, currency
+ORDER BY gateway, currency
+`
+
+type ListGatewayVolumesRow struct {
+	Gateway  string `json:"gateway"`
+	Currency string `json:"currency"`
+	Volume   string `json:"volume"`
+}
+
+func (q *Queries) ListGatewayVolumes(ctx context.Context, createdFrom time.Time) ([]ListGatewayVolumesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGatewayVolumes, createdFrom)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGatewayVolumesRow
+	for rows.Next() {
+		var i ListGatewayVolumesRow
+		if err := rows.Scan(
+			&i.Gateway,
+			&i.Currency,
+			&i.Volume,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingTransactionsToPoll = `-- name: ListPendingTransactionsToPoll :many
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+FROM transaction
+WHERE status = 'PENDING'
+  AND created_at < $1
+  AND status_poll_attempts < $2
+  AND (next_status_poll_at IS NULL OR next_status_poll_at <= $3)
+ORDER BY created_at
+LIMIT $4
+`
+
+type ListPendingTransactionsToPollParams struct {
+	CreatedBefore time.Time `json:"createdBefore"`
+	MaxAttempts   int32     `json:"maxAttempts"`
+	Now           time.Time `json:"now"`
+	PageSize      int32     `json:"pageSize"`
+}
+
+func (q *Queries) ListPendingTransactionsToPoll(ctx context.Context, arg ListPendingTransactionsToPollParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingTransactionsToPoll,
+		arg.CreatedBefore,
+		arg.MaxAttempts,
+		arg.Now,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Description,
+			&i.CustomerID,
+			&i.Gateway,
+			&i.GatewayRefID,
+			&i.Status,
+			&i.PreferredGateway,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Metadata,
+			&i.RefundedAmount,
+			&i.CapturedAmount,
+			&i.AuthorizationExpiresAt,
+			&i.MerchantID,
+			&i.GatewayStatusCode,
+			&i.DeclineReason,
+			&i.Reference,
+			&i.StatusPollAttempts,
+			&i.NextStatusPollAt,
+			&i.DestinationCustomerID,
+			&i.RoutingRule,
+			&i.ExpectedFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSettledTransactions = `-- name: ListSettledTransactions :many
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+FROM transaction
+WHERE gateway = $1
+  AND status IN ('SUCCESS', 'CAPTURED')
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY id
+`
+
+type ListSettledTransactionsParams struct {
+	Gateway     string    `json:"gateway"`
+	CreatedFrom time.Time `json:"createdFrom"`
+	CreatedTo   time.Time `json:"createdTo"`
+}
+
+func (q *Queries) ListSettledTransactions(ctx context.Context, arg ListSettledTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listSettledTransactions, arg.Gateway, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Description,
+			&i.CustomerID,
+			&i.Gateway,
+			&i.GatewayRefID,
+			&i.Status,
+			&i.PreferredGateway,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Metadata,
+			&i.RefundedAmount,
+			&i.CapturedAmount,
+			&i.AuthorizationExpiresAt,
+			&i.MerchantID,
+			&i.GatewayStatusCode,
+			&i.DeclineReason,
+			&i.Reference,
+			&i.StatusPollAttempts,
+			&i.NextStatusPollAt,
+			&i.DestinationCustomerID,
+			&i.RoutingRule,
+			&i.ExpectedFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStaleInitiatedTransactions = `-- name: ListStaleInitiatedTransactions :many
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+FROM transaction
+WHERE status = 'INITIATED'
+  AND created_at < $1
+  AND (next_status_poll_at IS NULL OR next_status_poll_at <= $2)
+ORDER BY created_at
+LIMIT $3
 `
 
-type UpdateTransactionStatusParams struct {
-	Status       TransactionStatus `json:"status"`
-	GatewayRefID string            `json:"gatewayRefId"`
-	Gateway      string            `json:"gateway"`
-	UpdatedAt    time.Time         `json:"updatedAt"`
+type ListStaleInitiatedTransactionsParams struct {
+	CreatedBefore time.Time `json:"createdBefore"`
+	Now           time.Time `json:"now"`
+	PageSize      int32     `json:"pageSize"`
 }
 
-func (q *Queries) UpdateTransactionStatus(ctx context.Context, arg UpdateTransactionStatusParams) error {
-	_, err := q.db.ExecContext(ctx, updateTransactionStatus,
+func (q *Queries) ListStaleInitiatedTransactions(ctx context.Context, arg ListStaleInitiatedTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listStaleInitiatedTransactions, arg.CreatedBefore, arg.Now, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Description,
+			&i.CustomerID,
+			&i.Gateway,
+			&i.GatewayRefID,
+			&i.Status,
+			&i.PreferredGateway,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Metadata,
+			&i.RefundedAmount,
+			&i.CapturedAmount,
+			&i.AuthorizationExpiresAt,
+			&i.MerchantID,
+			&i.GatewayStatusCode,
+			&i.DeclineReason,
+			&i.Reference,
+			&i.StatusPollAttempts,
+			&i.NextStatusPollAt,
+			&i.DestinationCustomerID,
+			&i.RoutingRule,
+			&i.ExpectedFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactions = `-- name: ListTransactions :many
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+FROM transaction
+WHERE ($1::varchar IS NULL OR customer_id = $1)
+  AND ($2::varchar IS NULL OR reference = $2)
+  AND ($3::transaction_status IS NULL OR status = $3)
+  AND ($4::varchar IS NULL OR gateway = $4)
+  AND ($5::transaction_type IS NULL OR type = $5)
+  AND ($6::varchar IS NULL OR currency = $6)
+  AND ($7::numeric IS NULL OR amount >= $7)
+  AND ($8::numeric IS NULL OR amount <= $8)
+  AND ($9::timestamp IS NULL OR created_at >= $9)
+  AND ($10::timestamp IS NULL OR created_at < $10)
+  AND ($11::timestamp IS NULL OR
+       (created_at, id) < ($11, $12::integer))
+ORDER BY created_at DESC, id DESC
+LIMIT $13
+`
+
+type ListTransactionsParams struct {
+	CustomerID      sql.NullString        `json:"customerId"`
+	Reference       sql.NullString        `json:"reference"`
+	Status          NullTransactionStatus `json:"status"`
+	Gateway         sql.NullString        `json:"gateway"`
+	Type            NullTransactionType   `json:"type"`
+	Currency        sql.NullString        `json:"currency"`
+	MinAmount       sql.NullString        `json:"minAmount"`
+	MaxAmount       sql.NullString        `json:"maxAmount"`
+	CreatedFrom     sql.NullTime          `json:"createdFrom"`
+	CreatedTo       sql.NullTime          `json:"createdTo"`
+	CursorCreatedAt sql.NullTime          `json:"cursorCreatedAt"`
+	CursorID        sql.NullInt32         `json:"cursorId"`
+	PageSize        int32                 `json:"pageSize"`
+}
+
+func (q *Queries) ListTransactions(ctx context.Context, arg ListTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactions,
+		arg.CustomerID,
+		arg.Reference,
 		arg.Status,
-		arg.GatewayRefID,
 		arg.Gateway,
-		arg.UpdatedAt,
+		arg.Type,
+		arg.Currency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Description,
+			&i.CustomerID,
+			&i.Gateway,
+			&i.GatewayRefID,
+			&i.Status,
+			&i.PreferredGateway,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Metadata,
+			&i.RefundedAmount,
+			&i.CapturedAmount,
+			&i.AuthorizationExpiresAt,
+			&i.MerchantID,
+			&i.GatewayStatusCode,
+			&i.DeclineReason,
+			&i.Reference,
+			&i.StatusPollAttempts,
+			&i.NextStatusPollAt,
+			&i.DestinationCustomerID,
+			&i.RoutingRule,
+			&i.ExpectedFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionsByGatewayRefIDs = `-- name: ListTransactionsByGatewayRefIDs :many
+SELECT id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+FROM transaction
+WHERE gateway = $1
+  AND gateway_ref_id = ANY ($2::varchar[])
+`
+
+type ListTransactionsByGatewayRefIDsParams struct {
+	Gateway string   `json:"gateway"`
+	RefIds  []string `json:"refIds"`
+}
+
+func (q *Queries) ListTransactionsByGatewayRefIDs(ctx context.Context, arg ListTransactionsByGatewayRefIDsParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionsByGatewayRefIDs, arg.Gateway, pq.Array(arg.RefIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Amount,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Description,
+			&i.CustomerID,
+			&i.Gateway,
+			&i.GatewayRefID,
+			&i.Status,
+			&i.PreferredGateway,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Metadata,
+			&i.RefundedAmount,
+			&i.CapturedAmount,
+			&i.AuthorizationExpiresAt,
+			&i.MerchantID,
+			&i.GatewayStatusCode,
+			&i.DeclineReason,
+			&i.Reference,
+			&i.StatusPollAttempts,
+			&i.NextStatusPollAt,
+			&i.DestinationCustomerID,
+			&i.RoutingRule,
+			&i.ExpectedFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const scheduleStatusPoll = `-- name: ScheduleStatusPoll :exec
+UPDATE transaction
+SET status_poll_attempts = status_poll_attempts + 1,
+    next_status_poll_at  = $2
+WHERE id = $1
+`
+
+type ScheduleStatusPollParams struct {
+	ID               int32        `json:"id"`
+	NextStatusPollAt sql.NullTime `json:"nextStatusPollAt"`
+}
+
+func (q *Queries) ScheduleStatusPoll(ctx context.Context, arg ScheduleStatusPollParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleStatusPoll, arg.ID, arg.NextStatusPollAt)
 	return err
 }
+
+const transitionTransactionStatus = `-- name: TransitionTransactionStatus :one
+UPDATE transaction
+SET status              = $1,
+    gateway_status_code = COALESCE($2, gateway_status_code),
+    decline_reason      = COALESCE($3, decline_reason),
+    updated_at          = $4
+WHERE id = $5 AND status = $6
+RETURNING id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+`
+
+type TransitionTransactionStatusParams struct {
+	ToStatus          TransactionStatus `json:"toStatus"`
+	GatewayStatusCode sql.NullString    `json:"gatewayStatusCode"`
+	DeclineReason     NullDeclineReason `json:"declineReason"`
+	UpdatedAt         time.Time         `json:"updatedAt"`
+	ID                int32             `json:"id"`
+	FromStatus        TransactionStatus `json:"fromStatus"`
+}
+
+func (q *Queries) TransitionTransactionStatus(ctx context.Context, arg TransitionTransactionStatusParams) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, transitionTransactionStatus,
+		arg.ToStatus,
+		arg.GatewayStatusCode,
+		arg.DeclineReason,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.FromStatus,
+	)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Description,
+		&i.CustomerID,
+		&i.Gateway,
+		&i.GatewayRefID,
+		&i.Status,
+		&i.PreferredGateway,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
+	)
+	return i, err
+}
+
+const voidTransaction = `-- name: VoidTransaction :one
+UPDATE transaction
+SET status = 'VOIDED', updated_at = $2
+WHERE id = $1 AND status = 'AUTHORIZED'
+RETURNING id, type, amount, currency, payment_method, description, customer_id, gateway, gateway_ref_id, status, preferred_gateway, created_at, updated_at, metadata, refunded_amount, captured_amount, authorization_expires_at, merchant_id, gateway_status_code, decline_reason, reference, status_poll_attempts, next_status_poll_at, destination_customer_id, routing_rule, expected_fee
+`
+
+type VoidTransactionParams struct {
+	ID        int32     `json:"id"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (q *Queries) VoidTransaction(ctx context.Context, arg VoidTransactionParams) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, voidTransaction, arg.ID, arg.UpdatedAt)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Amount,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Description,
+		&i.CustomerID,
+		&i.Gateway,
+		&i.GatewayRefID,
+		&i.Status,
+		&i.PreferredGateway,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Metadata,
+		&i.RefundedAmount,
+		&i.CapturedAmount,
+		&i.AuthorizationExpiresAt,
+		&i.MerchantID,
+		&i.GatewayStatusCode,
+		&i.DeclineReason,
+		&i.Reference,
+		&i.StatusPollAttempts,
+		&i.NextStatusPollAt,
+		&i.DestinationCustomerID,
+		&i.RoutingRule,
+		&i.ExpectedFee,
+	)
+	return i, err
+}
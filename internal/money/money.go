@@ -0,0 +1,216 @@
+// Package money represents monetary amounts as an integer number of minor units of an ISO 4217 currency,
+// so amounts are never rounded by floating point arithmetic.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooPrecise       = errors.New("amount has more decimal places than the currency allows")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("amount out of range")
+)
+
+// Money is an amount in the minor units of its currency, e.g. 1050 USD is $10.50 and 1050 JPY is ¥1050.
+// The zero value has no currency and is only useful as "no amount".
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New returns an amount of the given minor units of the currency.
+func New(minor int64, currency string) (Money, error) {
+	c, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{minor: minor, currency: c}, nil
+}
+
+// Parse parses a decimal amount such as "10.50" in the major units of the currency. Trailing zeros are
+// accepted beyond the currency exponent, so "10.5000" is valid for USD, but "10.505" is rejected with
+// ErrTooPrecise rather than rounded.
+func Parse(amount, currency string) (Money, error) {
+	c, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s := amount
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+
+	exponent := c.Exponent()
+	if len(fraction) > exponent {
+		if strings.Trim(fraction[exponent:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %s allows %d decimal places, got %q", ErrTooPrecise, c, exponent, amount)
+		}
+		fraction = fraction[:exponent]
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	var minor int64
+	for _, d := range whole + fraction {
+		digit := int64(d - '0')
+		if minor > (math.MaxInt64-digit)/10 {
+			return Money{}, fmt.Errorf("%w: %q", ErrOverflow, amount)
+		}
+		minor = minor*10 + digit
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{minor: minor, currency: c}, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants and tests.
+func MustParse(amount, currency string) Money {
+	m, err := Parse(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Add returns m + o. Both amounts must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	if (o.minor > 0 && m.minor > math.MaxInt64-o.minor) || (o.minor < 0 && m.minor < math.MinInt64-o.minor) {
+		return Money{}, ErrOverflow
+	}
+	return Money{minor: m.minor + o.minor, currency: m.currency}, nil
+}
+
+// Sub returns m - o. Both amounts must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.minor == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{minor: -o.minor, currency: o.currency})
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether m is less than, equal to or greater than o. Both amounts
+// must be in the same currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.minor < o.minor:
+		return -1, nil
+	case m.minor > o.minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.currency != o.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	return nil
+}
+
+// Decimal formats the amount in major units with exactly as many decimal places as the currency
+// exponent, e.g. "10.50" for USD, "1050" for JPY and "10.500" for BHD.
+func (m Money) Decimal() string {
+	var b strings.Builder
+	// Formatting the absolute value through uint64 keeps math.MinInt64 intact.
+	abs := uint64(m.minor)
+	if m.minor < 0 {
+		b.WriteByte('-')
+		abs = -abs
+	}
+	digits := fmt.Sprintf("%d", abs)
+
+	exponent := m.currency.Exponent()
+	if exponent == 0 {
+		b.WriteString(digits)
+		return b.String()
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	b.WriteString(digits[:len(digits)-exponent])
+	b.WriteByte('.')
+	b.WriteString(digits[len(digits)-exponent:])
+	return b.String()
+}
+
+// String formats the amount with its currency, e.g. "10.50 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.currency)
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as {"amount": 10.50, "currency": "USD"} with the exact decimal.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: json.Number(m.Decimal()), Currency: string(m.currency)})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := Parse(v.Amount.String(), v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
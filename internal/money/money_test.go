@@ -0,0 +1,166 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    string
+		currency  string
+		wantMinor int64
+		wantErr   error
+	}{
+		{name: "two decimals", amount: "10.50", currency: "USD", wantMinor: 1050},
+		{name: "fewer decimals than exponent", amount: "10.5", currency: "USD", wantMinor: 1050},
+		{name: "whole number", amount: "10", currency: "USD", wantMinor: 1000},
+		{name: "trailing dot", amount: "10.", currency: "USD", wantMinor: 1000},
+		{name: "trailing zeros beyond exponent", amount: "10.5000", currency: "USD", wantMinor: 1050},
+		{name: "zero exponent", amount: "1050", currency: "JPY", wantMinor: 1050},
+		{name: "zero exponent with zero fraction", amount: "1050.00", currency: "JPY", wantMinor: 1050},
+		{name: "three exponent", amount: "1.005", currency: "BHD", wantMinor: 1005},
+		{name: "negative", amount: "-0.01", currency: "EUR", wantMinor: -1},
+		{name: "lower case currency", amount: "1", currency: "usd", wantMinor: 100},
+		{name: "too precise", amount: "10.505", currency: "USD", wantErr: ErrTooPrecise},
+		{name: "too precise zero exponent", amount: "1.5", currency: "JPY", wantErr: ErrTooPrecise},
+		{name: "empty", amount: "", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "missing whole part", amount: ".5", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "exponent notation", amount: "1e2", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "not a number", amount: "ten", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "overflow", amount: "92233720368547758.08", currency: "USD", wantErr: ErrOverflow},
+		{name: "unknown currency", amount: "1", currency: "XYZ", wantErr: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.amount, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if m.Minor() != tt.wantMinor {
+				t.Errorf("Expected %d minor units, got %d", tt.wantMinor, m.Minor())
+			}
+		})
+	}
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{minor: 1050, currency: "USD", want: "10.50"},
+		{minor: 5, currency: "USD", want: "0.05"},
+		{minor: 0, currency: "USD", want: "0.00"},
+		{minor: -1, currency: "USD", want: "-0.01"},
+		{minor: 1050, currency: "JPY", want: "1050"},
+		{minor: 1005, currency: "BHD", want: "1.005"},
+		{minor: 1, currency: "CLF", want: "0.0001"},
+		{minor: math.MinInt64, currency: "USD", want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			m, err := New(tt.minor, tt.currency)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := m.Decimal(); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+			parsed, err := Parse(m.Decimal(), tt.currency)
+			if err != nil {
+				if !errors.Is(err, ErrOverflow) {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if parsed != m {
+				t.Errorf("Expected %v to round-trip, got %v", m, parsed)
+			}
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := MustParse("10.50", "USD")
+	b := MustParse("0.25", "USD")
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sum.Decimal() != "10.75" {
+		t.Errorf("Expected 10.75, got %s", sum.Decimal())
+	}
+
+	diff, err := b.Sub(a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff.Decimal() != "-10.25" || !diff.IsNegative() {
+		t.Errorf("Expected -10.25, got %s", diff.Decimal())
+	}
+
+	if neg := a.Neg(); neg.Decimal() != "-10.50" || neg.Currency() != a.Currency() {
+		t.Errorf("Expected -10.50 USD, got %s", neg)
+	}
+
+	if c, err := a.Cmp(b); err != nil || c != 1 {
+		t.Errorf("Expected 1, got %d (%v)", c, err)
+	}
+	if c, err := b.Cmp(a); err != nil || c != -1 {
+		t.Errorf("Expected -1, got %d (%v)", c, err)
+	}
+	if c, err := a.Cmp(a); err != nil || c != 0 {
+		t.Errorf("Expected 0, got %d (%v)", c, err)
+	}
+
+	eur := MustParse("1", "EUR")
+	if _, err := a.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := a.Cmp(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+
+	largest, _ := New(math.MaxInt64, "USD")
+	if _, err := largest.Add(MustParse("0.01", "USD")); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	m := MustParse("1.005", "BHD")
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != `{"amount":1.005,"currency":"BHD"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	var decoded Money
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded != m {
+		t.Errorf("Expected %v, got %v", m, decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":1.0005,"currency":"BHD"}`), &decoded); !errors.Is(err, ErrTooPrecise) {
+		t.Errorf("Expected ErrTooPrecise, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+// Package outbox publishes payment events that were stored in the outbox table together with the state
+// change they describe, so an event is published if and only if its change was committed.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+)
+
+const (
+	TransactionCreated   = "transaction.created"
+	TransactionSucceeded = "transaction.succeeded"
+	TransactionFailed    = "transaction.failed"
+	TransactionCaptured  = "transaction.captured"
+	TransactionVoided    = "transaction.voided"
+	RefundCreated        = "refund.created"
+	RefundSucceeded      = "refund.succeeded"
+	RefundFailed         = "refund.failed"
+)
+
+// Event is a payment event. The ID increases in the order the events were written.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TransactionPayload is the payload of transaction events.
+type TransactionPayload struct {
+	TransactionID int32       `json:"transaction_id"`
+	Reference     string      `json:"reference"`
+	Gateway       string      `json:"gateway"`
+	RefID         string      `json:"ref_id"`
+	Type          string      `json:"type"`
+	Status        string      `json:"status"`
+	Amount        json.Number `json:"amount"`
+	Currency      string      `json:"currency"`
+	CustomerID    string      `json:"customer_id"`
+	MerchantID    string      `json:"merchant_id,omitempty"`
+	// DestinationCustomerID is the customer a transfer was sent to.
+	DestinationCustomerID string      `json:"destination_customer_id,omitempty"`
+	DeclineReason         string      `json:"decline_reason,omitempty"`
+	CapturedAmount        json.Number `json:"captured_amount,omitempty"`
+	UpdatedAt             time.Time   `json:"updated_at"`
+}
+
+// RefundPayload is the payload of refund events.
+type RefundPayload struct {
+	RefundID      int32       `json:"refund_id"`
+	TransactionID int32       `json:"transaction_id"`
+	Gateway       string      `json:"gateway"`
+	RefID         string      `json:"ref_id,omitempty"`
+	Status        string      `json:"status"`
+	Amount        json.Number `json:"amount"`
+	Currency      string      `json:"currency"`
+	Reason        string      `json:"reason,omitempty"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// NewTransactionEvent returns an event of the given type describing the transaction.
+func NewTransactionEvent(eventType string, transaction models.Transaction) (Event, error) {
+	amount, err := money.Parse(transaction.Amount, transaction.Currency)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to parse transaction amount: %w", err)
+	}
+	payload := TransactionPayload{
+		TransactionID:         transaction.ID,
+		Reference:             transaction.Reference,
+		Gateway:               transaction.Gateway,
+		RefID:                 transaction.GatewayRefID.String,
+		Type:                  strings.ToLower(string(transaction.Type)),
+		Status:                strings.ToLower(string(transaction.Status)),
+		Amount:                json.Number(amount.Decimal()),
+		Currency:              amount.Currency().String(),
+		CustomerID:            transaction.CustomerID,
+		MerchantID:            transaction.MerchantID.String,
+		DestinationCustomerID: transaction.DestinationCustomerID.String,
+		DeclineReason:         strings.ToLower(string(transaction.DeclineReason.DeclineReason)),
+		UpdatedAt:             transaction.UpdatedAt,
+	}
+	if transaction.CapturedAmount.Valid {
+		captured, err := money.Parse(transaction.CapturedAmount.String, transaction.Currency)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to parse captured amount: %w", err)
+		}
+		payload.CapturedAmount = json.Number(captured.Decimal())
+	}
+	return newEvent(eventType, payload)
+}
+
+// NewRefundEvent returns an event of the given type describing the refund.
+func NewRefundEvent(eventType string, refund models.Refund) (Event, error) {
+	amount, err := money.Parse(refund.Amount, refund.Currency)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to parse refund amount: %w", err)
+	}
+	return newEvent(eventType, RefundPayload{
+		RefundID:      refund.ID,
+		TransactionID: refund.TransactionID,
+		Gateway:       refund.Gateway,
+		RefID:         refund.GatewayRefID.String,
+		Status:        strings.ToLower(string(refund.Status)),
+		Amount:        json.Number(amount.Decimal()),
+		Currency:      amount.Currency().String(),
+		Reason:        refund.Reason.String,
+		UpdatedAt:     refund.UpdatedAt,
+	})
+}
+
+// TransactionStatusEventType returns the event type announcing that a transaction reached the status.
+// Statuses without an event, such as PENDING, return false.
+func TransactionStatusEventType(status models.TransactionStatus) (string, bool) {
+	switch status {
+	case models.TransactionStatusSUCCESS:
+		return TransactionSucceeded, true
+	case models.TransactionStatusFAILED:
+		return TransactionFailed, true
+	case models.TransactionStatusCAPTURED:
+		return TransactionCaptured, true
+	case models.TransactionStatusVOIDED:
+		return TransactionVoided, true
+	}
+	return "", false
+}
+
+// RefundStatusEventType returns the event type announcing that a refund reached the status.
+func RefundStatusEventType(status models.TransactionStatus) (string, bool) {
+	switch status {
+	case models.TransactionStatusSUCCESS:
+		return RefundSucceeded, true
+	case models.TransactionStatusFAILED:
+		return RefundFailed, true
+	}
+	return "", false
+}
+
+func newEvent(eventType string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+	return Event{Type: eventType, Payload: data}, nil
+}
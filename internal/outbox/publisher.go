@@ -0,0 +1,100 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Publisher delivers events to their consumers. Events are delivered at least once, so consumers have to
+// tolerate duplicates, e.g. by the event ID.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// LogPublisher logs the events. It is meant for local development.
+type LogPublisher struct{}
+
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event Event) error {
+	slog.InfoContext(ctx, "Published event", "id", event.ID, "type", event.Type, "payload", string(event.Payload))
+	return nil
+}
+
+// FilePublisher appends the events to a file as JSON lines.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// Handler consumes an event in process.
+type Handler func(ctx context.Context, event Event) error
+
+// InProcessPublisher hands the events to handlers subscribed in the same process.
+type InProcessPublisher struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewInProcessPublisher() *InProcessPublisher {
+	return &InProcessPublisher{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers the handler for the given event types. Without event types it receives all events.
+func (p *InProcessPublisher) Subscribe(handler Handler, eventTypes ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(eventTypes) == 0 {
+		eventTypes = []string{""}
+	}
+	for _, eventType := range eventTypes {
+		p.handlers[eventType] = append(p.handlers[eventType], handler)
+	}
+}
+
+// Publish runs the handlers of the event in order and stops at the first error. The event is published
+// again later, so the handlers that succeeded see it again.
+func (p *InProcessPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.RLock()
+	handlers := append(append([]Handler(nil), p.handlers[""]...), p.handlers[event.Type]...)
+	p.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInProcessPublisher_Publish(t *testing.T) {
+	p := NewInProcessPublisher()
+
+	var received []string
+	p.Subscribe(func(_ context.Context, event Event) error {
+		received = append(received, "all:"+event.Type)
+		return nil
+	})
+	p.Subscribe(func(_ context.Context, event Event) error {
+		received = append(received, "refund:"+event.Type)
+		return nil
+	}, RefundCreated, RefundFailed)
+
+	for _, eventType := range []string{TransactionCreated, RefundCreated} {
+		if err := p.Publish(context.Background(), Event{Type: eventType}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	want := []string{"all:transaction.created", "all:refund.created", "refund:refund.created"}
+	if !slices.Equal(received, want) {
+		t.Errorf("Expected %v, got %v", want, received)
+	}
+}
+
+func TestInProcessPublisher_StopsAtFirstError(t *testing.T) {
+	p := NewInProcessPublisher()
+	errHandler := errors.New("handler failed")
+
+	calls := 0
+	p.Subscribe(func(context.Context, Event) error {
+		calls++
+		return errHandler
+	})
+	p.Subscribe(func(context.Context, Event) error {
+		calls++
+		return nil
+	})
+
+	if err := p.Publish(context.Background(), Event{Type: TransactionCreated}); !errors.Is(err, errHandler) {
+		t.Errorf("Expected %v, got %v", errHandler, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
+
+func TestFilePublisher_Publish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	p, err := NewFilePublisher(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	events := []Event{
+		{ID: 1, Type: TransactionCreated, Payload: json.RawMessage(`{"transaction_id":1}`)},
+		{ID: 2, Type: TransactionSucceeded, Payload: json.RawMessage(`{"transaction_id":1}`)},
+	}
+	for _, event := range events {
+		if err := p.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer file.Close()
+
+	var got []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, event)
+	}
+	if len(got) != len(events) {
+		t.Fatalf("Expected %d events, got %d", len(events), len(got))
+	}
+	for i := range events {
+		if got[i].ID != events[i].ID || got[i].Type != events[i].Type || string(got[i].Payload) != string(events[i].Payload) {
+			t.Errorf("Expected %+v, got %+v", events[i], got[i])
+		}
+	}
+}
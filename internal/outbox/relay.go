@@ -0,0 +1,48 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Store gives the relay access to the events that have not been published yet.
+type Store interface {
+	// ProcessPendingEvents calls fn for up to limit unpublished events in order. Events are marked as
+	// published when fn succeeds. Processing stops at the first error, which is recorded on the event.
+	ProcessPendingEvents(ctx context.Context, limit int32, fn func(Event) error) (int, error)
+}
+
+// Relay publishes the events stored in the outbox.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	batchSize int32
+}
+
+func NewRelay(store Store, publisher Publisher, batchSize int32) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		batchSize: batchSize,
+	}
+}
+
+// Run publishes pending events in batches until none are left. An event that fails to publish stops the
+// run, so events are published in the order they were written.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		published, err := r.store.ProcessPendingEvents(ctx, r.batchSize, func(event Event) error {
+			return r.publisher.Publish(ctx, event)
+		})
+		if published > 0 {
+			slog.InfoContext(ctx, "Published outbox events", "count", published)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to publish outbox events: %w", err)
+		}
+		if published < int(r.batchSize) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeStore hands out its events in batches and drops them once they are processed.
+type fakeStore struct {
+	pending []Event
+	batches int
+}
+
+func (s *fakeStore) ProcessPendingEvents(_ context.Context, limit int32, fn func(Event) error) (int, error) {
+	s.batches++
+	processed := 0
+	for processed < len(s.pending) && processed < int(limit) {
+		if err := fn(s.pending[processed]); err != nil {
+			s.pending = s.pending[processed:]
+			return processed, err
+		}
+		processed++
+	}
+	s.pending = s.pending[processed:]
+	return processed, nil
+}
+
+type publisherFunc func(ctx context.Context, event Event) error
+
+func (f publisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+func TestRelay_Run(t *testing.T) {
+	errPublish := errors.New("publish failed")
+
+	tests := []struct {
+		name        string
+		events      int
+		failAt      int64
+		wantErr     bool
+		wantIDs     int
+		wantBatches int
+		wantPending int
+	}{
+		{name: "no events", events: 0, wantBatches: 1},
+		{name: "partial batch", events: 1, wantIDs: 1, wantBatches: 1},
+		{name: "full batches", events: 4, wantIDs: 4, wantBatches: 3},
+		{name: "several batches", events: 5, wantIDs: 5, wantBatches: 3},
+		{name: "stops at failure", events: 5, failAt: 4, wantErr: true, wantIDs: 3, wantBatches: 2, wantPending: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			for i := range tt.events {
+				store.pending = append(store.pending, Event{ID: int64(i + 1), Type: TransactionCreated})
+			}
+
+			var published []int64
+			relay := NewRelay(store, publisherFunc(func(_ context.Context, event Event) error {
+				if event.ID == tt.failAt {
+					return errPublish
+				}
+				published = append(published, event.ID)
+				return nil
+			}), 2)
+
+			err := relay.Run(context.Background())
+			if tt.wantErr != errors.Is(err, errPublish) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(published) != tt.wantIDs {
+				t.Errorf("Expected %d published events, got %d", tt.wantIDs, len(published))
+			}
+			for i, id := range published {
+				if id != int64(i+1) {
+					t.Errorf("Expected event %d at position %d, got %d", i+1, i, id)
+				}
+			}
+			if store.batches != tt.wantBatches {
+				t.Errorf("Expected %d batches, got %d", tt.wantBatches, store.batches)
+			}
+			if len(store.pending) != tt.wantPending {
+				t.Errorf("Expected %d pending events, got %d", tt.wantPending, len(store.pending))
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrFrameTooLarge is returned when a message does not fit in the frame limits.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// Framer delimits messages on a stream connection.
+type Framer interface {
+	WriteFrame(w io.Writer, msg []byte) error
+	ReadFrame(r *bufio.Reader) ([]byte, error)
+}
+
+// LengthPrefixFramer prefixes every message with its length as a big-endian
+// 2-byte or 4-byte header. The header does not count itself.
+type LengthPrefixFramer struct {
+	HeaderSize int
+	MaxLength  int
+}
+
+func NewLengthPrefixFramer(headerSize int) LengthPrefixFramer {
+	maxLength := 1<<16 - 1
+	if headerSize == 4 {
+		maxLength = 1 << 24
+	}
+	return LengthPrefixFramer{
+		HeaderSize: headerSize,
+		MaxLength:  maxLength,
+	}
+}
+
+func (f LengthPrefixFramer) WriteFrame(w io.Writer, msg []byte) error {
+	if len(msg) > f.MaxLength {
+		return fmt.Errorf("%w: %d bytes exceeds %d", ErrFrameTooLarge, len(msg), f.MaxLength)
+	}
+
+	frame := make([]byte, f.HeaderSize, f.HeaderSize+len(msg))
+	switch f.HeaderSize {
+	case 2:
+		binary.BigEndian.PutUint16(frame, uint16(len(msg)))
+	case 4:
+		binary.BigEndian.PutUint32(frame, uint32(len(msg)))
+	default:
+		return fmt.Errorf("unsupported length header size: %d", f.HeaderSize)
+	}
+	frame = append(frame, msg...)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+func (f LengthPrefixFramer) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	header := make([]byte, f.HeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	var length int
+	switch f.HeaderSize {
+	case 2:
+		length = int(binary.BigEndian.Uint16(header))
+	case 4:
+		length = int(binary.BigEndian.Uint32(header))
+	default:
+		return nil, fmt.Errorf("unsupported length header size: %d", f.HeaderSize)
+	}
+	if length > f.MaxLength {
+		return nil, fmt.Errorf("%w: %d bytes exceeds %d", ErrFrameTooLarge, length, f.MaxLength)
+	}
+
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, fmt.Errorf("failed to read %d byte frame: %w", length, err)
+	}
+	return msg, nil
+}
+
+// DelimiterFramer terminates every message with a delimiter byte, e.g. ETX or newline.
+// Messages must not contain the delimiter.
+type DelimiterFramer struct {
+	Delimiter byte
+	MaxLength int
+}
+
+func NewDelimiterFramer(delimiter byte, maxLength int) DelimiterFramer {
+	return DelimiterFramer{
+		Delimiter: delimiter,
+		MaxLength: maxLength,
+	}
+}
+
+func (f DelimiterFramer) WriteFrame(w io.Writer, msg []byte) error {
+	if bytes.IndexByte(msg, f.Delimiter) >= 0 {
+		return fmt.Errorf("message contains frame delimiter 0x%02x", f.Delimiter)
+	}
+	if f.MaxLength > 0 && len(msg) > f.MaxLength {
+		return fmt.Errorf("%w: %d bytes exceeds %d", ErrFrameTooLarge, len(msg), f.MaxLength)
+	}
+
+	_, err := w.Write(append(msg[:len(msg):len(msg)], f.Delimiter))
+	return err
+}
+
+func (f DelimiterFramer) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	var msg []byte
+	for {
+		chunk, err := r.ReadSlice(f.Delimiter)
+		msg = append(msg, chunk...)
+		if f.MaxLength > 0 && len(msg) > f.MaxLength+1 {
+			return nil, fmt.Errorf("%w: no delimiter within %d bytes", ErrFrameTooLarge, f.MaxLength)
+		}
+		if err == nil {
+			return msg[:len(msg)-1], nil
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			if len(msg) > 0 && errors.Is(err, io.EOF) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+}
+
+// FixedFramer exchanges messages of a fixed size. Shorter messages are rejected
+// rather than padded, since padding is part of the message format.
+type FixedFramer struct {
+	Size int
+}
+
+func NewFixedFramer(size int) FixedFramer {
+	return FixedFramer{Size: size}
+}
+
+func (f FixedFramer) WriteFrame(w io.Writer, msg []byte) error {
+	if len(msg) != f.Size {
+		return fmt.Errorf("message is %d bytes, expected %d", len(msg), f.Size)
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+func (f FixedFramer) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	msg := make([]byte, f.Size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,121 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFramer_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		framer   Framer
+		message  []byte
+		expected []byte
+	}{
+		{
+			name:     "2-byte length header",
+			framer:   NewLengthPrefixFramer(2),
+			message:  []byte("hello"),
+			expected: []byte("\x00\x05hello"),
+		},
+		{
+			name:     "4-byte length header",
+			framer:   NewLengthPrefixFramer(4),
+			message:  []byte("hello"),
+			expected: []byte("\x00\x00\x00\x05hello"),
+		},
+		{
+			name:     "Empty message",
+			framer:   NewLengthPrefixFramer(2),
+			message:  []byte{},
+			expected: []byte("\x00\x00"),
+		},
+		{
+			name:     "Delimiter",
+			framer:   NewDelimiterFramer('\x03', 0),
+			message:  []byte("hello"),
+			expected: []byte("hello\x03"),
+		},
+		{
+			name:     "Fixed size",
+			framer:   NewFixedFramer(5),
+			message:  []byte("hello"),
+			expected: []byte("hello"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.framer.WriteFrame(&buf, tt.message); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.expected) {
+				t.Errorf("Expected frame %q, got %q", tt.expected, buf.Bytes())
+			}
+
+			// A second frame right behind the first must be left untouched.
+			buf.WriteString("trailing")
+			reader := bufio.NewReader(&buf)
+			msg, err := tt.framer.ReadFrame(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(msg, tt.message) {
+				t.Errorf("Expected message %q, got %q", tt.message, msg)
+			}
+			rest, _ := reader.Peek(8)
+			if string(rest) != "trailing" {
+				t.Errorf("Expected remaining bytes to be untouched, got %q", rest)
+			}
+		})
+	}
+}
+
+func TestFramer_Errors(t *testing.T) {
+	t.Run("Message too large for 2-byte header", func(t *testing.T) {
+		err := NewLengthPrefixFramer(2).WriteFrame(&bytes.Buffer{}, make([]byte, 70000))
+		if !errors.Is(err, ErrFrameTooLarge) {
+			t.Errorf("Expected ErrFrameTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Incoming frame above maximum length", func(t *testing.T) {
+		framer := LengthPrefixFramer{HeaderSize: 4, MaxLength: 10}
+		_, err := framer.ReadFrame(bufio.NewReader(strings.NewReader("\x00\x00\x01\x00")))
+		if !errors.Is(err, ErrFrameTooLarge) {
+			t.Errorf("Expected ErrFrameTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Truncated frame", func(t *testing.T) {
+		_, err := NewLengthPrefixFramer(2).ReadFrame(bufio.NewReader(strings.NewReader("\x00\x05hel")))
+		if err == nil {
+			t.Errorf("Expected an error, but got none")
+		}
+	})
+
+	t.Run("Message contains delimiter", func(t *testing.T) {
+		err := NewDelimiterFramer('\n', 0).WriteFrame(&bytes.Buffer{}, []byte("a\nb"))
+		if err == nil {
+			t.Errorf("Expected an error, but got none")
+		}
+	})
+
+	t.Run("Delimiter missing within maximum length", func(t *testing.T) {
+		_, err := NewDelimiterFramer('\n', 4).ReadFrame(bufio.NewReaderSize(strings.NewReader(strings.Repeat("a", 64)), 16))
+		if !errors.Is(err, ErrFrameTooLarge) {
+			t.Errorf("Expected ErrFrameTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Fixed size mismatch", func(t *testing.T) {
+		err := NewFixedFramer(8).WriteFrame(&bytes.Buffer{}, []byte("short"))
+		if err == nil {
+			t.Errorf("Expected an error, but got none")
+		}
+	})
+}
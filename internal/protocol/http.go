@@ -3,10 +3,14 @@ package protocol
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+
+	"github.com/rauf/payment-service/internal/gatewayerr"
 )
 
 // HTTPProtocol is a protocol handler for HTTP connections.
@@ -32,7 +36,7 @@ func (h *HTTPProtocol) Send(ctx context.Context, data []byte) ([]byte, error) {
 
 	resp, err := h.Client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to send HTTP request: %w: %w", transportErrorKind(err), err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -42,13 +46,45 @@ func (h *HTTPProtocol) Send(ctx context.Context, data []byte) ([]byte, error) {
 	}(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected HTTP status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("%w: unexpected HTTP status code: %d", statusErrorKind(resp.StatusCode), resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read HTTP response body: %w", err)
+		return nil, fmt.Errorf("failed to read HTTP response body: %w: %w", transportErrorKind(err), err)
 	}
 
 	return body, nil
 }
+
+// statusErrorKind classifies a non-2xx status. Only statuses that guarantee the request was not processed
+// make it unavailable or invalid; others, such as a 409 or 422 for a reference the host already recorded, or
+// a 500 or 502, may come after the host processed it.
+func statusErrorKind(code int) error {
+	switch code {
+	case http.StatusPaymentRequired:
+		return gatewayerr.ErrDecline
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return gatewayerr.ErrInvalidRequest
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return gatewayerr.ErrUnavailable
+	case http.StatusGatewayTimeout:
+		return gatewayerr.ErrTimeout
+	}
+	return gatewayerr.ErrUnknownOutcome
+}
+
+// transportErrorKind classifies an error of the HTTP client. The request was not sent if the connection
+// could not be established; otherwise the host may have processed it.
+func transportErrorKind(err error) error {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if (errors.As(err, &opErr) && opErr.Op == "dial") || errors.As(err, &dnsErr) {
+		return gatewayerr.ErrUnavailable
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return gatewayerr.ErrTimeout
+	}
+	return gatewayerr.ErrUnknownOutcome
+}
@@ -2,10 +2,13 @@ package protocol
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/rauf/payment-service/internal/gatewayerr"
 )
 
 func TestHTTPProtocol_Send(t *testing.T) {
@@ -117,6 +120,57 @@ func TestHTTPProtocol_SendWithTimeout(t *testing.T) {
 	if err == nil {
 		t.Errorf("Expected a timeout error, but got none")
 	}
+	if !errors.Is(err, gatewayerr.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestHTTPProtocol_SendErrorKinds(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverStatus int
+		expectedErr  error
+	}{
+		{name: "Payment required", serverStatus: http.StatusPaymentRequired, expectedErr: gatewayerr.ErrDecline},
+		{name: "Bad request", serverStatus: http.StatusBadRequest, expectedErr: gatewayerr.ErrInvalidRequest},
+		{name: "Forbidden", serverStatus: http.StatusForbidden, expectedErr: gatewayerr.ErrInvalidRequest},
+		{name: "Conflict", serverStatus: http.StatusConflict, expectedErr: gatewayerr.ErrUnknownOutcome},
+		{name: "Unprocessable entity", serverStatus: http.StatusUnprocessableEntity, expectedErr: gatewayerr.ErrUnknownOutcome},
+		{name: "Too many requests", serverStatus: http.StatusTooManyRequests, expectedErr: gatewayerr.ErrUnavailable},
+		{name: "Service unavailable", serverStatus: http.StatusServiceUnavailable, expectedErr: gatewayerr.ErrUnavailable},
+		{name: "Gateway timeout", serverStatus: http.StatusGatewayTimeout, expectedErr: gatewayerr.ErrTimeout},
+		{name: "Internal server error", serverStatus: http.StatusInternalServerError, expectedErr: gatewayerr.ErrUnknownOutcome},
+		{name: "Bad gateway", serverStatus: http.StatusBadGateway, expectedErr: gatewayerr.ErrUnknownOutcome},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.serverStatus)
+			}))
+			defer server.Close()
+
+			httpProtocol := NewHTTPConnection(&http.Client{Timeout: 5 * time.Second}, http.MethodPost, server.URL)
+			_, err := httpProtocol.Send(context.Background(), []byte("test data"))
+
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestHTTPProtocol_SendConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	httpProtocol := NewHTTPConnection(&http.Client{Timeout: 5 * time.Second}, http.MethodPost, url)
+	_, err := httpProtocol.Send(context.Background(), []byte("test data"))
+
+	if !errors.Is(err, gatewayerr.ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable, got %v", err)
+	}
 }
 
 func TestHTTPProtocol_SendWithInvalidURL(t *testing.T) {
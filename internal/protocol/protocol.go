@@ -2,7 +2,8 @@ package protocol
 
 import "context"
 
-// Handler is the interface for all protocol handlers.
+// Handler is the interface for all protocol handlers. Send classifies its errors with the gatewayerr
+// sentinels, telling whether the host may have processed the data.
 type Handler interface {
 	Send(ctx context.Context, data []byte) ([]byte, error)
 }
@@ -1,50 +1,305 @@
 package protocol
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 )
 
+// ErrProtocolClosed is returned when sending on a protocol handler that has been closed.
+var ErrProtocolClosed = errors.New("protocol handler closed")
+
+// errNotSent marks a write that failed before any byte of the message left, so the host never saw it.
+var errNotSent = errors.New("message not sent")
+
+// TCPConfig controls framing and connection reuse of a TCPProtocol.
+type TCPConfig struct {
+	Framer      Framer
+	DialTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period. Zero uses the OS default, a negative value disables it.
+	KeepAlive time.Duration
+	// PoolSize is the number of long-lived connections kept open to the host. It also bounds
+	// the number of messages in flight. Zero dials a new connection for every message.
+	PoolSize int
+	// IdleTimeout closes pooled connections that have not been used for this long.
+	// Hosts usually drop idle clients, so this should be lower than the host's own timeout.
+	IdleTimeout time.Duration
+	// Reconnect controls redialing when the host cannot be reached.
+	Reconnect backoff.RetryConfig
+}
+
+// DefaultTCPConfig frames messages with a 2-byte length header and dials per message.
+func DefaultTCPConfig() TCPConfig {
+	return TCPConfig{
+		Framer:      NewLengthPrefixFramer(2),
+		DialTimeout: 5 * time.Second,
+		KeepAlive:   30 * time.Second,
+	}
+}
+
 // TCPProtocol is a protocol handler for TCP connections.
 type TCPProtocol struct {
 	Address string
+	Config  TCPConfig
+
+	dialer net.Dialer
+	// slots holds one token per message in flight when pooling is enabled.
+	slots chan struct{}
+	idle  chan *tcpConn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type tcpConn struct {
+	net.Conn
+	reader   *bufio.Reader
+	lastUsed time.Time
+	reused   bool
 }
 
 func NewTCPConnection(address string) *TCPProtocol {
-	return &TCPProtocol{
+	return NewTCPConnectionWithConfig(address, DefaultTCPConfig())
+}
+
+func NewTCPConnectionWithConfig(address string, config TCPConfig) *TCPProtocol {
+	if config.Framer == nil {
+		config.Framer = NewLengthPrefixFramer(2)
+	}
+	t := &TCPProtocol{
 		Address: address,
+		Config:  config,
+		dialer: net.Dialer{
+			Timeout:   config.DialTimeout,
+			KeepAlive: config.KeepAlive,
+		},
+	}
+	if config.PoolSize > 0 {
+		t.slots = make(chan struct{}, config.PoolSize)
+		t.idle = make(chan *tcpConn, config.PoolSize)
 	}
+	return t
 }
 
 func (t *TCPProtocol) Send(ctx context.Context, data []byte) ([]byte, error) {
-	var d net.Dialer
-	conn, err := d.DialContext(ctx, "tcp", t.Address)
+	conn, err := t.acquire(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to establish TCP connection: %w", err)
+		return nil, err
 	}
-	defer func(conn net.Conn) {
-		err := conn.Close()
+
+	response, err := t.roundTrip(ctx, conn, data)
+	if err != nil && conn.reused && errors.Is(err, errNotSent) {
+		// The host closed the pooled connection before any byte of the message was written, so it
+		// never saw the message and it is safe to send again on a fresh connection.
+		slog.DebugContext(ctx, "Pooled TCP connection was closed by remote host, reconnecting", "address", t.Address)
+		_ = conn.Close()
+		conn, err = t.dial(ctx)
 		if err != nil {
-			slog.ErrorContext(ctx, "Failed to close connection", "error", err)
+			t.releaseSlot()
+			return nil, err
 		}
-	}(conn)
+		response, err = t.roundTrip(ctx, conn, data)
+	}
 
-	_, err = conn.Write(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write data to TCP connection: %w", err)
+	t.release(conn, err)
+	return response, err
+}
+
+// Close closes all idle pooled connections. Messages in flight complete, after which
+// their connections are closed as well.
+func (t *TCPProtocol) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	if t.idle == nil {
+		return nil
+	}
+	for {
+		select {
+		case conn := <-t.idle:
+			_ = conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (t *TCPProtocol) roundTrip(ctx context.Context, conn *tcpConn, data []byte) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Time{})
 	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
 
-	response := make([]byte, 4096)
-	n, err := conn.Read(response)
+	// A partial frame may have reached the host, so failed writes leave the outcome unknown as well, unless
+	// the host had closed the connection before the first byte was written.
+	w := &countingWriter{w: conn}
+	if err := t.Config.Framer.WriteFrame(w, data); err != nil {
+		if ctx.Err() == nil && w.n == 0 && isStaleConnError(err) {
+			return nil, fmt.Errorf("failed to write data to TCP connection: %w: %w: %w", errNotSent, gatewayerr.ErrUnavailable, err)
+		}
+		return nil, t.wrapErr(ctx, "failed to write data to TCP connection", err)
+	}
+
+	response, err := t.Config.Framer.ReadFrame(conn.reader)
 	if err != nil {
-		if err == io.EOF {
-			return nil, fmt.Errorf("connection closed by remote host: %w", err)
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("connection closed by remote host: %w: %w", gatewayerr.ErrUnknownOutcome, err)
+		}
+		return nil, t.wrapErr(ctx, "failed to read response from TCP connection", err)
+	}
+	return response, nil
+}
+
+// wrapErr wraps an error of a message in flight, which the host may have processed. It times out if the
+// context deadline passed.
+func (t *TCPProtocol) wrapErr(ctx context.Context, msg string, err error) error {
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return fmt.Errorf("%s: %w: %w", msg, inFlightKind(err), err)
+}
+
+// inFlightKind classifies the error of a message that may have reached the host.
+func inFlightKind(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return gatewayerr.ErrTimeout
+	}
+	return gatewayerr.ErrUnknownOutcome
+}
+
+func (t *TCPProtocol) acquire(ctx context.Context) (*tcpConn, error) {
+	t.mu.Lock()
+	closed := t.closed
+	t.mu.Unlock()
+	if closed {
+		return nil, ErrProtocolClosed
+	}
+
+	if t.slots == nil {
+		return t.dial(ctx)
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for TCP connection: %w: %w", gatewayerr.ErrUnavailable, ctx.Err())
+	}
+
+	for {
+		select {
+		case conn := <-t.idle:
+			if t.Config.IdleTimeout > 0 && time.Since(conn.lastUsed) > t.Config.IdleTimeout {
+				_ = conn.Close()
+				continue
+			}
+			if conn.closedByRemote() {
+				slog.DebugContext(ctx, "Pooled TCP connection was closed by remote host, reconnecting", "address", t.Address)
+				_ = conn.Close()
+				continue
+			}
+			conn.reused = true
+			return conn, nil
+		default:
+			conn, err := t.dial(ctx)
+			if err != nil {
+				t.releaseSlot()
+				return nil, err
+			}
+			return conn, nil
 		}
-		return nil, fmt.Errorf("failed to read response from TCP connection: %w", err)
 	}
+}
+
+// release returns a healthy connection to the pool. Connections that saw an error may
+// hold a partial frame, so they are never reused.
+func (t *TCPProtocol) release(conn *tcpConn, err error) {
+	if t.slots == nil {
+		if closeErr := conn.Close(); closeErr != nil {
+			slog.Error("Failed to close connection", "error", closeErr)
+		}
+		return
+	}
+
+	t.mu.Lock()
+	if err != nil || t.closed {
+		_ = conn.Close()
+	} else {
+		conn.lastUsed = time.Now()
+		conn.reused = false
+		t.idle <- conn
+	}
+	t.mu.Unlock()
+	t.releaseSlot()
+}
+
+func (t *TCPProtocol) releaseSlot() {
+	if t.slots != nil {
+		<-t.slots
+	}
+}
+
+func (t *TCPProtocol) dial(ctx context.Context) (*tcpConn, error) {
+	for attempt := 0; ; attempt++ {
+		conn, err := t.dialer.DialContext(ctx, "tcp", t.Address)
+		if err == nil {
+			return &tcpConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+		}
+		if ctx.Err() != nil || attempt >= t.Config.Reconnect.MaxRetries || t.Config.Reconnect.Backoff == nil {
+			return nil, fmt.Errorf("failed to establish TCP connection: %w: %w", gatewayerr.ErrUnavailable, err)
+		}
+
+		slog.WarnContext(ctx, "Failed to connect, retrying", "address", t.Address, "attempt", attempt+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to establish TCP connection: %w: %w", gatewayerr.ErrUnavailable, ctx.Err())
+		case <-time.After(t.Config.Reconnect.Backoff.NextBackoff(attempt)):
+		}
+	}
+}
+
+// idleCheckTimeout bounds the read that checks whether an idle connection was closed by the host.
+const idleCheckTimeout = time.Millisecond
+
+// closedByRemote reports whether the host closed the idle connection, which shows as a pending EOF or reset.
+// An idle connection has nothing to read, so a healthy one only times out; unsolicited data makes it unusable
+// as well.
+func (c *tcpConn) closedByRemote() bool {
+	_ = c.SetReadDeadline(time.Now().Add(idleCheckTimeout))
+	_, err := c.reader.Peek(1)
+	_ = c.SetReadDeadline(time.Time{})
+	return !errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+// countingWriter counts the bytes written, so that a failed write tells whether any part of the frame left.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
 
-	return response[:n], nil
+// isStaleConnError reports whether the write error shows the remote host closed the connection.
+func isStaleConnError(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
 }
@@ -0,0 +1,260 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/gatewayerr"
+)
+
+var (
+	// ErrConnectionLost is returned to callers whose response was pending when the link dropped.
+	// The host may or may not have processed the request.
+	ErrConnectionLost = errors.New("connection lost")
+	// ErrDuplicateCorrelationKey is returned when a request reuses the key of a request still in flight.
+	ErrDuplicateCorrelationKey = errors.New("duplicate correlation key")
+)
+
+// CorrelationFunc extracts the key that pairs a response with its request, such as STAN and RRN.
+// It is applied to both outgoing requests and incoming responses.
+type CorrelationFunc func(msg []byte) (string, error)
+
+// MultiplexConfig controls the single shared link of a MultiplexTCPProtocol.
+type MultiplexConfig struct {
+	Framer      Framer
+	DialTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period. Zero uses the OS default, a negative value disables it.
+	KeepAlive time.Duration
+	// RequestTimeout bounds the wait for a response when the context has no earlier deadline.
+	RequestTimeout time.Duration
+	// Reconnect controls redialing when the host cannot be reached.
+	Reconnect backoff.RetryConfig
+}
+
+// DefaultMultiplexConfig frames messages with a 2-byte length header and waits up to 30 seconds for a response.
+func DefaultMultiplexConfig() MultiplexConfig {
+	return MultiplexConfig{
+		Framer:         NewLengthPrefixFramer(2),
+		DialTimeout:    5 * time.Second,
+		KeepAlive:      30 * time.Second,
+		RequestTimeout: 30 * time.Second,
+	}
+}
+
+// MultiplexTCPProtocol is a protocol handler that shares one TCP connection across concurrent Send calls.
+// Responses may arrive in any order and are matched to their callers by correlation key.
+type MultiplexTCPProtocol struct {
+	Address   string
+	Config    MultiplexConfig
+	correlate CorrelationFunc
+
+	// tcp only dials; its pool is never used.
+	tcp *TCPProtocol
+
+	mu     sync.Mutex
+	link   *muxLink
+	closed bool
+}
+
+// muxLink is one physical connection and the requests waiting on it.
+type muxLink struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan []byte
+	err     error
+	done    chan struct{}
+}
+
+func NewMultiplexTCPConnection(address string, correlate CorrelationFunc, config MultiplexConfig) *MultiplexTCPProtocol {
+	if config.Framer == nil {
+		config.Framer = NewLengthPrefixFramer(2)
+	}
+	return &MultiplexTCPProtocol{
+		Address:   address,
+		Config:    config,
+		correlate: correlate,
+		tcp: NewTCPConnectionWithConfig(address, TCPConfig{
+			Framer:      config.Framer,
+			DialTimeout: config.DialTimeout,
+			KeepAlive:   config.KeepAlive,
+			Reconnect:   config.Reconnect,
+		}),
+	}
+}
+
+func (m *MultiplexTCPProtocol) Send(ctx context.Context, data []byte) ([]byte, error) {
+	key, err := m.correlate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract correlation key from request: %w", err)
+	}
+
+	if m.Config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Config.RequestTimeout)
+		defer cancel()
+	}
+
+	link, err := m.getLink(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := link.register(key)
+	if err != nil {
+		if errors.Is(err, ErrDuplicateCorrelationKey) {
+			return nil, fmt.Errorf("%w: %w", gatewayerr.ErrInvalidRequest, err)
+		}
+		// The link failed before the request was written.
+		return nil, fmt.Errorf("%w: %w", gatewayerr.ErrUnavailable, err)
+	}
+
+	if err := link.write(ctx, m.Config.Framer, data); err != nil {
+		link.unregister(key)
+		link.fail(fmt.Errorf("%w: %w", ErrConnectionLost, err))
+		return nil, fmt.Errorf("failed to write data to TCP connection: %w: %w", inFlightKind(err), err)
+	}
+
+	select {
+	case res := <-response:
+		return res, nil
+	case <-ctx.Done():
+		link.unregister(key)
+		return nil, fmt.Errorf("waiting for response %s: %w: %w", key, inFlightKind(ctx.Err()), ctx.Err())
+	case <-link.done:
+		// The response may have been delivered just before the link failed.
+		select {
+		case res := <-response:
+			return res, nil
+		default:
+		}
+		return nil, fmt.Errorf("%w: %w", gatewayerr.ErrUnknownOutcome, link.err)
+	}
+}
+
+// Close drops the link and fails every pending request with ErrProtocolClosed.
+func (m *MultiplexTCPProtocol) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	link := m.link
+	m.link = nil
+	m.mu.Unlock()
+
+	if link != nil {
+		link.fail(ErrProtocolClosed)
+	}
+	return nil
+}
+
+// getLink returns the live link, dialing a new one if there is none or the last one failed.
+func (m *MultiplexTCPProtocol) getLink(ctx context.Context) (*muxLink, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrProtocolClosed
+	}
+	if m.link != nil {
+		select {
+		case <-m.link.done:
+		default:
+			return m.link, nil
+		}
+	}
+
+	conn, err := m.tcp.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.link = &muxLink{
+		conn:    conn.Conn,
+		pending: make(map[string]chan []byte),
+		done:    make(chan struct{}),
+	}
+	go m.readLoop(m.link, conn.reader)
+	return m.link, nil
+}
+
+func (m *MultiplexTCPProtocol) readLoop(link *muxLink, reader *bufio.Reader) {
+	for {
+		frame, err := m.Config.Framer.ReadFrame(reader)
+		if err != nil {
+			link.fail(fmt.Errorf("%w: %w", ErrConnectionLost, err))
+			return
+		}
+
+		key, err := m.correlate(frame)
+		if err != nil {
+			slog.Warn("Dropping response without correlation key", "address", m.Address, "error", err)
+			continue
+		}
+		if !link.deliver(key, frame) {
+			// Usually a late response for a request that already timed out.
+			slog.Warn("Dropping unmatched response", "address", m.Address, "key", key)
+		}
+	}
+}
+
+func (l *muxLink) register(key string) (chan []byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return nil, l.err
+	}
+	if _, ok := l.pending[key]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateCorrelationKey, key)
+	}
+	ch := make(chan []byte, 1)
+	l.pending[key] = ch
+	return ch, nil
+}
+
+func (l *muxLink) unregister(key string) {
+	l.mu.Lock()
+	delete(l.pending, key)
+	l.mu.Unlock()
+}
+
+func (l *muxLink) deliver(key string, frame []byte) bool {
+	l.mu.Lock()
+	ch, ok := l.pending[key]
+	delete(l.pending, key)
+	l.mu.Unlock()
+
+	if ok {
+		ch <- frame
+	}
+	return ok
+}
+
+func (l *muxLink) write(ctx context.Context, framer Framer, data []byte) error {
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	_ = l.conn.SetWriteDeadline(deadline)
+	return framer.WriteFrame(l.conn, data)
+}
+
+// fail closes the connection and wakes every pending caller. Only the first error is kept.
+func (l *muxLink) fail(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return
+	}
+	l.err = err
+	l.pending = nil
+	close(l.done)
+	_ = l.conn.Close()
+}
@@ -0,0 +1,256 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// keyBeforeColon correlates messages of the form "<key>:<payload>".
+func keyBeforeColon(msg []byte) (string, error) {
+	key, _, ok := strings.Cut(string(msg), ":")
+	if !ok {
+		return "", fmt.Errorf("no key in %q", msg)
+	}
+	return key, nil
+}
+
+// newMuxTestServer accepts connections and hands each one to handle.
+func newMuxTestServer(t *testing.T, handle func(conn net.Conn)) (string, *atomic.Int32) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go func() {
+				defer func() { _ = conn.Close() }()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String(), &accepted
+}
+
+func TestMultiplexTCPProtocol_OutOfOrderResponses(t *testing.T) {
+	const requests = 5
+	framer := NewLengthPrefixFramer(2)
+
+	// Collect all requests before answering them in reverse order.
+	addr, accepted := newMuxTestServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		var received [][]byte
+		for len(received) < requests {
+			req, err := framer.ReadFrame(reader)
+			if err != nil {
+				return
+			}
+			received = append(received, req)
+		}
+		for i := len(received) - 1; i >= 0; i-- {
+			key, _ := keyBeforeColon(received[i])
+			_ = framer.WriteFrame(conn, []byte(key+":response"))
+		}
+		_, _ = reader.ReadByte()
+	})
+
+	mux := NewMultiplexTCPConnection(addr, keyBeforeColon, DefaultMultiplexConfig())
+	defer func() { _ = mux.Close() }()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("%06d", i)
+			res, err := mux.Send(context.Background(), []byte(key+":request"))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if string(res) != key+":response" {
+				errs <- fmt.Errorf("request %s got response %q", key, res)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if got := accepted.Load(); got != 1 {
+		t.Errorf("Expected 1 connection, got %d", got)
+	}
+}
+
+func TestMultiplexTCPProtocol_RequestTimeout(t *testing.T) {
+	framer := NewLengthPrefixFramer(2)
+	addr, _ := newMuxTestServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		for {
+			req, err := framer.ReadFrame(reader)
+			if err != nil {
+				return
+			}
+			key, _ := keyBeforeColon(req)
+			if key == "slow" {
+				continue
+			}
+			_ = framer.WriteFrame(conn, []byte(key+":ok"))
+		}
+	})
+
+	config := DefaultMultiplexConfig()
+	config.RequestTimeout = 50 * time.Millisecond
+	mux := NewMultiplexTCPConnection(addr, keyBeforeColon, config)
+	defer func() { _ = mux.Close() }()
+
+	_, err := mux.Send(context.Background(), []byte("slow:request"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// A timed out request must not affect the link.
+	res, err := mux.Send(context.Background(), []byte("fast:request"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(res) != "fast:ok" {
+		t.Errorf("Expected response 'fast:ok', got '%s'", res)
+	}
+}
+
+func TestMultiplexTCPProtocol_LinkDrop(t *testing.T) {
+	framer := NewLengthPrefixFramer(2)
+	var dropped atomic.Bool
+	addr, accepted := newMuxTestServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		for {
+			req, err := framer.ReadFrame(reader)
+			if err != nil {
+				return
+			}
+			// Drop the first link as soon as a request arrives.
+			if dropped.CompareAndSwap(false, true) {
+				return
+			}
+			key, _ := keyBeforeColon(req)
+			_ = framer.WriteFrame(conn, []byte(key+":ok"))
+		}
+	})
+
+	mux := NewMultiplexTCPConnection(addr, keyBeforeColon, DefaultMultiplexConfig())
+	defer func() { _ = mux.Close() }()
+
+	_, err := mux.Send(context.Background(), []byte("first:request"))
+	if !errors.Is(err, ErrConnectionLost) {
+		t.Errorf("Expected ErrConnectionLost, got %v", err)
+	}
+
+	res, err := mux.Send(context.Background(), []byte("second:request"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(res) != "second:ok" {
+		t.Errorf("Expected response 'second:ok', got '%s'", res)
+	}
+	if got := accepted.Load(); got != 2 {
+		t.Errorf("Expected 2 connections, got %d", got)
+	}
+}
+
+func TestMultiplexTCPProtocol_DuplicateKey(t *testing.T) {
+	framer := NewLengthPrefixFramer(2)
+	received := make(chan struct{})
+	release := make(chan struct{})
+	addr, _ := newMuxTestServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		req, err := framer.ReadFrame(reader)
+		if err != nil {
+			return
+		}
+		close(received)
+		<-release
+		key, _ := keyBeforeColon(req)
+		_ = framer.WriteFrame(conn, []byte(key+":ok"))
+		_, _ = reader.ReadByte()
+	})
+
+	mux := NewMultiplexTCPConnection(addr, keyBeforeColon, DefaultMultiplexConfig())
+	defer func() { _ = mux.Close() }()
+
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := mux.Send(context.Background(), []byte("same:first"))
+		firstDone <- err
+	}()
+
+	<-received
+	_, err := mux.Send(context.Background(), []byte("same:second"))
+	if !errors.Is(err, ErrDuplicateCorrelationKey) {
+		t.Errorf("Expected ErrDuplicateCorrelationKey, got %v", err)
+	}
+
+	close(release)
+	if err := <-firstDone; err != nil {
+		t.Errorf("Unexpected error for first request: %v", err)
+	}
+}
+
+func TestMultiplexTCPProtocol_CloseFailsPending(t *testing.T) {
+	framer := NewLengthPrefixFramer(2)
+	received := make(chan struct{})
+	addr, _ := newMuxTestServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		if _, err := framer.ReadFrame(reader); err != nil {
+			return
+		}
+		close(received)
+		_, _ = reader.ReadByte()
+	})
+
+	mux := NewMultiplexTCPConnection(addr, keyBeforeColon, DefaultMultiplexConfig())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := mux.Send(context.Background(), []byte("pending:request"))
+		done <- err
+	}()
+
+	<-received
+	_ = mux.Close()
+
+	if err := <-done; !errors.Is(err, ErrProtocolClosed) {
+		t.Errorf("Expected ErrProtocolClosed, got %v", err)
+	}
+	if _, err := mux.Send(context.Background(), []byte("after:close")); !errors.Is(err, ErrProtocolClosed) {
+		t.Errorf("Expected ErrProtocolClosed, got %v", err)
+	}
+}
+
+func TestMultiplexTCPProtocol_InvalidRequestKey(t *testing.T) {
+	mux := NewMultiplexTCPConnection("127.0.0.1:0", keyBeforeColon, DefaultMultiplexConfig())
+
+	_, err := mux.Send(context.Background(), []byte("no key"))
+	if err == nil {
+		t.Errorf("Expected an error, but got none")
+	}
+}
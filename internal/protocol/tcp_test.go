@@ -0,0 +1,295 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+	"github.com/rauf/payment-service/internal/gatewayerr"
+)
+
+// tcpTestServer answers every framed request using respond. It closes the connection
+// after each response when closeAfterResponse is set, and instead of leaving a request
+// unanswered when closeUnanswered is set.
+type tcpTestServer struct {
+	listener           net.Listener
+	framer             Framer
+	respond            func(req []byte) []byte
+	closeAfterResponse bool
+	closeUnanswered    bool
+	fragment           bool
+	accepted           atomic.Int32
+}
+
+func newTCPTestServer(t *testing.T, framer Framer, respond func(req []byte) []byte) *tcpTestServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &tcpTestServer{listener: listener, framer: framer, respond: respond}
+	t.Cleanup(func() { _ = listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *tcpTestServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *tcpTestServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.accepted.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *tcpTestServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	for {
+		req, err := s.framer.ReadFrame(reader)
+		if err != nil {
+			return
+		}
+		res := s.respond(req)
+		if res == nil {
+			if s.closeUnanswered {
+				return
+			}
+			continue
+		}
+
+		var frame bytes.Buffer
+		if err := s.framer.WriteFrame(&frame, res); err != nil {
+			return
+		}
+		if s.fragment {
+			// Trickle the response out in small chunks to simulate TCP segmentation.
+			b := frame.Bytes()
+			for len(b) > 0 {
+				n := min(512, len(b))
+				_, _ = conn.Write(b[:n])
+				b = b[n:]
+				time.Sleep(time.Millisecond)
+			}
+		} else {
+			_, _ = conn.Write(frame.Bytes())
+		}
+		if s.closeAfterResponse {
+			return
+		}
+	}
+}
+
+func echo(req []byte) []byte {
+	return req
+}
+
+func TestTCPProtocol_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		framer  Framer
+		message []byte
+	}{
+		{
+			name:    "2-byte length header",
+			framer:  NewLengthPrefixFramer(2),
+			message: []byte("0200 authorization"),
+		},
+		{
+			name:    "4-byte length header with large message",
+			framer:  NewLengthPrefixFramer(4),
+			message: bytes.Repeat([]byte("x"), 100000),
+		},
+		{
+			name:    "Delimiter",
+			framer:  NewDelimiterFramer('\x03', 0),
+			message: bytes.Repeat([]byte("y"), 10000),
+		},
+		{
+			name:    "Fixed size",
+			framer:  NewFixedFramer(16),
+			message: []byte("0123456789ABCDEF"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTCPTestServer(t, tt.framer, echo)
+			server.fragment = true
+
+			config := DefaultTCPConfig()
+			config.Framer = tt.framer
+			tcp := NewTCPConnectionWithConfig(server.addr(), config)
+
+			response, err := tcp.Send(context.Background(), tt.message)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(response, tt.message) {
+				t.Errorf("Expected response of %d bytes, got %d bytes", len(tt.message), len(response))
+			}
+		})
+	}
+}
+
+func TestTCPProtocol_PooledConnectionsAreReused(t *testing.T) {
+	server := newTCPTestServer(t, NewLengthPrefixFramer(2), echo)
+
+	config := DefaultTCPConfig()
+	config.PoolSize = 2
+	tcp := NewTCPConnectionWithConfig(server.addr(), config)
+	defer func() { _ = tcp.Close() }()
+
+	for i := 0; i < 10; i++ {
+		if _, err := tcp.Send(context.Background(), []byte("ping")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if got := server.accepted.Load(); got != 1 {
+		t.Errorf("Expected 1 connection, got %d", got)
+	}
+}
+
+func TestTCPProtocol_ReconnectsAfterRemoteClose(t *testing.T) {
+	server := newTCPTestServer(t, NewLengthPrefixFramer(2), echo)
+	server.closeAfterResponse = true
+
+	config := DefaultTCPConfig()
+	config.PoolSize = 1
+	tcp := NewTCPConnectionWithConfig(server.addr(), config)
+	defer func() { _ = tcp.Close() }()
+
+	for i := 0; i < 3; i++ {
+		response, err := tcp.Send(context.Background(), []byte("ping"))
+		if err != nil {
+			t.Fatalf("Unexpected error on send %d: %v", i, err)
+		}
+		if string(response) != "ping" {
+			t.Errorf("Expected response 'ping', got '%s'", response)
+		}
+		// Give the server time to close so the pooled connection is stale.
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if got := server.accepted.Load(); got != 3 {
+		t.Errorf("Expected 3 connections, got %d", got)
+	}
+}
+
+func TestTCPProtocol_DoesNotResendAfterRemoteCloseOnRead(t *testing.T) {
+	var charges atomic.Int32
+	server := newTCPTestServer(t, NewLengthPrefixFramer(2), func(req []byte) []byte {
+		if string(req) == "charge" {
+			charges.Add(1)
+			return nil
+		}
+		return req
+	})
+	server.closeUnanswered = true
+
+	config := DefaultTCPConfig()
+	config.PoolSize = 1
+	tcp := NewTCPConnectionWithConfig(server.addr(), config)
+	defer func() { _ = tcp.Close() }()
+
+	// Pool the connection, so that the charge is sent on a reused one.
+	if _, err := tcp.Send(context.Background(), []byte("ping")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err := tcp.Send(context.Background(), []byte("charge"))
+	if !errors.Is(err, gatewayerr.ErrUnknownOutcome) {
+		t.Errorf("Expected unknown outcome, got %v", err)
+	}
+	// Give a resent message time to arrive.
+	time.Sleep(20 * time.Millisecond)
+	if got := charges.Load(); got != 1 {
+		t.Errorf("Expected the charge to be sent once, got %d", got)
+	}
+	if got := server.accepted.Load(); got != 1 {
+		t.Errorf("Expected 1 connection, got %d", got)
+	}
+}
+
+func TestTCPProtocol_IdleTimeout(t *testing.T) {
+	server := newTCPTestServer(t, NewLengthPrefixFramer(2), echo)
+
+	config := DefaultTCPConfig()
+	config.PoolSize = 1
+	config.IdleTimeout = 10 * time.Millisecond
+	tcp := NewTCPConnectionWithConfig(server.addr(), config)
+	defer func() { _ = tcp.Close() }()
+
+	for i := 0; i < 2; i++ {
+		if _, err := tcp.Send(context.Background(), []byte("ping")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	if got := server.accepted.Load(); got != 2 {
+		t.Errorf("Expected 2 connections, got %d", got)
+	}
+}
+
+func TestTCPProtocol_SendWithTimeout(t *testing.T) {
+	server := newTCPTestServer(t, NewLengthPrefixFramer(2), func([]byte) []byte { return nil })
+
+	tcp := NewTCPConnection(server.addr())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := tcp.Send(ctx, []byte("ping"))
+	if err == nil {
+		t.Errorf("Expected a timeout error, but got none")
+	}
+}
+
+func TestTCPProtocol_DialFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	config := DefaultTCPConfig()
+	config.Reconnect = backoff.RetryConfig{
+		MaxRetries: 2,
+		Backoff:    backoff.NewExponentialBackoff(time.Millisecond, 2, 10*time.Millisecond),
+	}
+	tcp := NewTCPConnectionWithConfig(address, config)
+
+	_, err = tcp.Send(context.Background(), []byte("ping"))
+	if err == nil {
+		t.Errorf("Expected an error for unreachable host, but got none")
+	}
+}
+
+func TestTCPProtocol_SendAfterClose(t *testing.T) {
+	server := newTCPTestServer(t, NewLengthPrefixFramer(2), echo)
+
+	config := DefaultTCPConfig()
+	config.PoolSize = 1
+	tcp := NewTCPConnectionWithConfig(server.addr(), config)
+	_ = tcp.Close()
+
+	_, err := tcp.Send(context.Background(), []byte("ping"))
+	if err != ErrProtocolClosed {
+		t.Errorf("Expected ErrProtocolClosed, got %v", err)
+	}
+}
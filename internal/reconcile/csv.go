@@ -0,0 +1,59 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvColumns are the columns a CSV settlement report has to have.
+var csvColumns = []string{"ref_id", "amount", "currency", "status"}
+
+// CSVParser reads CSV settlement reports, as sent by gateway A. The first row is the header; columns are
+// looked up by name, so their order does not matter and other columns are ignored.
+type CSVParser struct{}
+
+func NewCSVParser() *CSVParser {
+	return &CSVParser{}
+}
+
+func (p *CSVParser) Parse(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidReport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidReport, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, column := range csvColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("%w: missing column %s", ErrInvalidReport, column)
+		}
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidReport, err)
+		}
+		record, err := newRecord(row[index["ref_id"]], row[index["amount"]], row[index["currency"]], row[index["status"]])
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidReport, line, err)
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,116 @@
+package reconcile
+
+import "github.com/rauf/payment-service/internal/money"
+
+// Kind is the kind of a discrepancy between our records and a settlement report.
+type Kind string
+
+const (
+	// KindMissing is a transaction we recorded as settled that the gateway did not report.
+	KindMissing Kind = "missing"
+	// KindExtra is a reported transaction that we have no record of.
+	KindExtra Kind = "extra"
+	// KindAmountMismatch is a settled transaction whose reported amount differs from ours.
+	KindAmountMismatch Kind = "amount_mismatch"
+	// KindStatusMismatch is a transaction that the gateway reported with a status that does not match ours.
+	KindStatusMismatch Kind = "status_mismatch"
+)
+
+// settlementStatusOf maps our lower-case statuses to the settlement status a gateway should report. Transactions
+// in other statuses, e.g. pending, should not be in a settlement report at all.
+var settlementStatusOf = map[string]string{
+	"success":  StatusSettled,
+	"captured": StatusSettled,
+	"failed":   StatusFailed,
+	"voided":   StatusFailed,
+}
+
+// Recorded is a transaction as we recorded it.
+type Recorded struct {
+	RefID string
+	// Amount is the amount that should be settled, i.e. the captured amount of captured transactions.
+	Amount money.Money
+	// Status is our lower-case status.
+	Status string
+}
+
+// Discrepancy is a difference between a recorded transaction and the settlement report. Recorded fields are
+// zero for extra transactions, and settled fields are zero for missing ones.
+type Discrepancy struct {
+	Kind           Kind
+	RefID          string
+	RecordedAmount money.Money
+	SettledAmount  money.Money
+	RecordedStatus string
+	SettledStatus  string
+}
+
+// Result is the outcome of matching a settlement report.
+type Result struct {
+	// Matched is the number of reported transactions that match our records.
+	Matched       int
+	Discrepancies []Discrepancy
+}
+
+// Match matches the records of a settlement report to our records by gateway reference. Recorded transactions
+// that are not reported are only missing when we recorded them as settled.
+func Match(recorded []Recorded, settled []Record) Result {
+	byRefID := make(map[string]Recorded, len(recorded))
+	for _, r := range recorded {
+		byRefID[r.RefID] = r
+	}
+
+	var result Result
+	reported := make(map[string]bool, len(settled))
+	for _, s := range settled {
+		r, ok := byRefID[s.RefID]
+		// A record reported twice is as unexpected as one we never recorded.
+		if !ok || reported[s.RefID] {
+			result.Discrepancies = append(result.Discrepancies, Discrepancy{
+				Kind:          KindExtra,
+				RefID:         s.RefID,
+				SettledAmount: s.Amount,
+				SettledStatus: s.Status,
+			})
+			continue
+		}
+		reported[s.RefID] = true
+
+		matched := true
+		if settlementStatusOf[r.Status] != s.Status {
+			matched = false
+			result.Discrepancies = append(result.Discrepancies, newDiscrepancy(KindStatusMismatch, r, s))
+		}
+		if s.Status == StatusSettled && r.Amount != s.Amount {
+			matched = false
+			result.Discrepancies = append(result.Discrepancies, newDiscrepancy(KindAmountMismatch, r, s))
+		}
+		if matched {
+			result.Matched++
+		}
+	}
+
+	for _, r := range recorded {
+		if !reported[r.RefID] && settlementStatusOf[r.Status] == StatusSettled {
+			result.Discrepancies = append(result.Discrepancies, Discrepancy{
+				Kind:           KindMissing,
+				RefID:          r.RefID,
+				RecordedAmount: r.Amount,
+				RecordedStatus: r.Status,
+			})
+			reported[r.RefID] = true
+		}
+	}
+	return result
+}
+
+func newDiscrepancy(kind Kind, r Recorded, s Record) Discrepancy {
+	return Discrepancy{
+		Kind:           kind,
+		RefID:          r.RefID,
+		RecordedAmount: r.Amount,
+		SettledAmount:  s.Amount,
+		RecordedStatus: r.Status,
+		SettledStatus:  s.Status,
+	}
+}
@@ -0,0 +1,50 @@
+package reconcile
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/money"
+)
+
+func TestMatch(t *testing.T) {
+	usd := func(amount string) money.Money { return money.MustParse(amount, "USD") }
+
+	recorded := []Recorded{
+		{RefID: "matched", Amount: usd("10"), Status: "success"},
+		{RefID: "captured", Amount: usd("7.50"), Status: "captured"},
+		{RefID: "failed", Amount: usd("3"), Status: "failed"},
+		{RefID: "amount", Amount: usd("20"), Status: "success"},
+		{RefID: "pending", Amount: usd("5"), Status: "pending"},
+		{RefID: "missing", Amount: usd("8"), Status: "success"},
+		{RefID: "unsettled", Amount: usd("9"), Status: "failed"},
+	}
+	settled := []Record{
+		{RefID: "matched", Amount: usd("10"), Status: StatusSettled},
+		{RefID: "captured", Amount: usd("7.50"), Status: StatusSettled},
+		{RefID: "failed", Amount: usd("3"), Status: StatusFailed},
+		{RefID: "amount", Amount: usd("19.99"), Status: StatusSettled},
+		{RefID: "pending", Amount: usd("5"), Status: StatusSettled},
+		{RefID: "extra", Amount: usd("1"), Status: StatusSettled},
+		{RefID: "matched", Amount: usd("10"), Status: StatusSettled},
+	}
+
+	want := Result{
+		Matched: 3,
+		Discrepancies: []Discrepancy{
+			{Kind: KindAmountMismatch, RefID: "amount", RecordedAmount: usd("20"), SettledAmount: usd("19.99"), RecordedStatus: "success", SettledStatus: StatusSettled},
+			{Kind: KindStatusMismatch, RefID: "pending", RecordedAmount: usd("5"), SettledAmount: usd("5"), RecordedStatus: "pending", SettledStatus: StatusSettled},
+			{Kind: KindExtra, RefID: "extra", SettledAmount: usd("1"), SettledStatus: StatusSettled},
+			{Kind: KindExtra, RefID: "matched", SettledAmount: usd("10"), SettledStatus: StatusSettled},
+			{Kind: KindMissing, RefID: "missing", RecordedAmount: usd("8"), RecordedStatus: "success"},
+		},
+	}
+
+	got := Match(recorded, settled)
+	if got.Matched != want.Matched {
+		t.Errorf("Expected %d matched, got %d", want.Matched, got.Matched)
+	}
+	if !reflect.DeepEqual(got.Discrepancies, want.Discrepancies) {
+		t.Errorf("Expected discrepancies %+v, got %+v", want.Discrepancies, got.Discrepancies)
+	}
+}
@@ -0,0 +1,98 @@
+package reconcile
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/money"
+)
+
+func TestCSVParser_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  string
+		want    []Record
+		wantErr bool
+	}{
+		{
+			name:   "Columns in any order",
+			report: "settled_at,status,ref_id,currency,amount\n2024-09-24,SETTLED,ref1,USD,10.50\n2024-09-24,Declined,ref2,JPY,100\n",
+			want: []Record{
+				{RefID: "ref1", Amount: money.MustParse("10.50", "USD"), Status: StatusSettled},
+				{RefID: "ref2", Amount: money.MustParse("100", "JPY"), Status: StatusFailed},
+			},
+		},
+		{name: "Header only", report: "ref_id,amount,currency,status\n"},
+		{name: "Empty report", report: "", wantErr: true},
+		{name: "Missing column", report: "ref_id,amount,status\nref1,10,SETTLED\n", wantErr: true},
+		{name: "Unknown status", report: "ref_id,amount,currency,status\nref1,10,USD,ON_HOLD\n", wantErr: true},
+		{name: "Invalid amount", report: "ref_id,amount,currency,status\nref1,10.555,USD,SETTLED\n", wantErr: true},
+		{name: "Missing ref ID", report: "ref_id,amount,currency,status\n,10,USD,SETTLED\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCSVParser().Parse(strings.NewReader(tt.report))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidReport) {
+					t.Errorf("Expected ErrInvalidReport, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestXMLParser_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  string
+		want    []Record
+		wantErr bool
+	}{
+		{
+			name: "Settled and rejected",
+			report: `<settlement>
+				<transaction><ref_id>ref1</ref_id><amount>10.50</amount><currency>USD</currency><status>SETTLED</status></transaction>
+				<transaction><ref_id>ref2</ref_id><amount>5</amount><currency>EUR</currency><status>REJECTED</status></transaction>
+			</settlement>`,
+			want: []Record{
+				{RefID: "ref1", Amount: money.MustParse("10.50", "USD"), Status: StatusSettled},
+				{RefID: "ref2", Amount: money.MustParse("5", "EUR"), Status: StatusFailed},
+			},
+		},
+		{name: "Malformed XML", report: `<settlement><transaction>`, wantErr: true},
+		{name: "Wrong root element", report: `<report></report>`, wantErr: true},
+		{
+			name:    "Unknown currency",
+			report:  `<settlement><transaction><ref_id>ref1</ref_id><amount>1</amount><currency>XXX</currency><status>SETTLED</status></transaction></settlement>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewXMLParser().Parse(strings.NewReader(tt.report))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidReport) {
+					t.Errorf("Expected ErrInvalidReport, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package reconcile matches the transactions we recorded against the settlement reports of the gateways.
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rauf/payment-service/internal/consts"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/registry"
+)
+
+// ErrInvalidReport is returned when a settlement report cannot be parsed.
+var ErrInvalidReport = errors.New("invalid settlement report")
+
+// Settlement statuses of the records of a report.
+const (
+	StatusSettled = "settled"
+	StatusFailed  = "failed"
+)
+
+// settlementStatuses maps the raw statuses of settlement reports, in lower case, to ours.
+var settlementStatuses = map[string]string{
+	"settled":  StatusSettled,
+	"success":  StatusSettled,
+	"approved": StatusSettled,
+	"failed":   StatusFailed,
+	"declined": StatusFailed,
+	"rejected": StatusFailed,
+	"reversed": StatusFailed,
+}
+
+// Record is a transaction as a gateway reported it in its settlement report.
+type Record struct {
+	RefID  string
+	Amount money.Money
+	// Status is StatusSettled or StatusFailed.
+	Status string
+}
+
+// Parser reads the settlement report of a gateway.
+type Parser interface {
+	Parse(r io.Reader) ([]Record, error)
+}
+
+// NewParsers returns the settlement report parsers of the gateways, by gateway name.
+func NewParsers() (*registry.Registry[Parser], error) {
+	parsers := registry.NewRegistry[Parser]()
+	if err := parsers.Register(consts.GatewayA, NewCSVParser()); err != nil {
+		return nil, fmt.Errorf("failed to register parser of %s: %w", consts.GatewayA, err)
+	}
+	if err := parsers.Register(consts.GatewayB, NewXMLParser()); err != nil {
+		return nil, fmt.Errorf("failed to register parser of %s: %w", consts.GatewayB, err)
+	}
+	return parsers, nil
+}
+
+// newRecord validates and converts the raw fields of a report record.
+func newRecord(refID, amount, currency, status string) (Record, error) {
+	refID = strings.TrimSpace(refID)
+	if refID == "" {
+		return Record{}, errors.New("missing ref_id")
+	}
+	m, err := money.Parse(strings.TrimSpace(amount), currency)
+	if err != nil {
+		return Record{}, fmt.Errorf("invalid amount of %s: %w", refID, err)
+	}
+	settlementStatus, ok := settlementStatuses[strings.ToLower(strings.TrimSpace(status))]
+	if !ok {
+		return Record{}, fmt.Errorf("unknown status %q of %s", status, refID)
+	}
+	return Record{RefID: refID, Amount: m, Status: settlementStatus}, nil
+}
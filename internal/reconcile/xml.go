@@ -0,0 +1,47 @@
+package reconcile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// XMLParser reads XML settlement reports, as sent by gateway B.
+//
+//	<settlement>
+//	  <transaction><ref_id>...</ref_id><amount>10.50</amount><currency>USD</currency><status>SETTLED</status></transaction>
+//	</settlement>
+type XMLParser struct{}
+
+func NewXMLParser() *XMLParser {
+	return &XMLParser{}
+}
+
+type xmlReport struct {
+	XMLName      xml.Name    `xml:"settlement"`
+	Transactions []xmlRecord `xml:"transaction"`
+}
+
+type xmlRecord struct {
+	RefID    string `xml:"ref_id"`
+	Amount   string `xml:"amount"`
+	Currency string `xml:"currency"`
+	Status   string `xml:"status"`
+}
+
+func (p *XMLParser) Parse(r io.Reader) ([]Record, error) {
+	var report xmlReport
+	if err := xml.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidReport, err)
+	}
+
+	records := make([]Record, 0, len(report.Transactions))
+	for i, t := range report.Transactions {
+		record, err := newRecord(t.RefID, t.Amount, t.Currency, t.Status)
+		if err != nil {
+			return nil, fmt.Errorf("%w: transaction %d: %w", ErrInvalidReport, i+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
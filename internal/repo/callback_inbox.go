@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/utils/nullutil"
+)
+
+// ErrCallbackNotFound is returned when there is no callback inbox entry with the given ID.
+var ErrCallbackNotFound = errors.New("callback not found")
+
+// CreateCallbackInboxEntry stores a received callback. A callback with the dedupe key of a stored one is not
+// stored again; the stored entry is returned instead and created is false.
+func (r *PaymentRepo) CreateCallbackInboxEntry(ctx context.Context, entry CreateCallbackInboxEntry) (inbox models.CallbackInbox, created bool, err error) {
+	inbox, err = r.queries.CreateCallbackInboxEntry(ctx, models.CreateCallbackInboxEntryParams{
+		Gateway:           entry.Gateway,
+		RefID:             entry.RefID,
+		DedupeKey:         entry.DedupeKey,
+		ReportedStatus:    entry.ReportedStatus,
+		GatewayStatusCode: nullutil.NewNullString(entry.GatewayStatusCode),
+		DeclineReason:     newNullDeclineReason(entry.DeclineReason),
+		RawPayload:        entry.RawPayload,
+		CreatedAt:         time.Now().UTC(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		inbox, err = r.queries.GetCallbackInboxEntryByDedupeKey(ctx, models.GetCallbackInboxEntryByDedupeKeyParams{
+			Gateway:   entry.Gateway,
+			DedupeKey: entry.DedupeKey,
+		})
+		return inbox, false, err
+	}
+	return inbox, err == nil, err
+}
+
+func (r *PaymentRepo) GetCallbackInboxEntry(ctx context.Context, id int64) (models.CallbackInbox, error) {
+	inbox, err := r.queries.GetCallbackInboxEntry(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.CallbackInbox{}, ErrCallbackNotFound
+	}
+	return inbox, err
+}
+
+// UpdateCallbackInboxEntry records the outcome of an attempt to apply the callback.
+func (r *PaymentRepo) UpdateCallbackInboxEntry(ctx context.Context, id int64, status models.CallbackInboxStatus, lastError string) (models.CallbackInbox, error) {
+	return r.queries.UpdateCallbackInboxEntry(ctx, models.UpdateCallbackInboxEntryParams{
+		ID:        id,
+		Status:    status,
+		LastError: nullutil.NewNullString(lastError),
+		UpdatedAt: time.Now().UTC(),
+	})
+}
+
+// ListCallbackInboxEntries returns the inbox entries matching the filter, newest first.
+func (r *PaymentRepo) ListCallbackInboxEntries(ctx context.Context, list ListCallbackInboxEntries) ([]models.CallbackInbox, error) {
+	arg := models.ListCallbackInboxEntriesParams{
+		Gateway:  nullutil.NewNullString(list.Gateway),
+		RefID:    nullutil.NewNullString(list.RefID),
+		PageSize: list.Limit,
+	}
+	if list.Status != "" {
+		arg.Status = models.NullCallbackInboxStatus{CallbackInboxStatus: list.Status, Valid: true}
+	}
+	if list.CursorID != 0 {
+		arg.CursorID = sql.NullInt64{Int64: list.CursorID, Valid: true}
+	}
+	return r.queries.ListCallbackInboxEntries(ctx, arg)
+}
+
+// ListUnparkableCallbacks returns up to limit parked callbacks whose transaction or refund now has their
+// gateway reference, oldest first.
+func (r *PaymentRepo) ListUnparkableCallbacks(ctx context.Context, limit int32) ([]models.CallbackInbox, error) {
+	return r.queries.ListUnparkableCallbacks(ctx, limit)
+}
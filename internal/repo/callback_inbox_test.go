@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+)
+
+func TestListUnparkableCallbacks_Refund(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+
+	initiated, err := r.InitiateTransaction(ctx, models.TransactionRequest{
+		Type:          "deposit",
+		Amount:        money.MustParse("100", "USD"),
+		PaymentMethod: "card",
+		CustomerID:    "cust123",
+		Reference:     "txn_1",
+	}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error initiating the transaction, got %v", err)
+	}
+	err = r.CompleteInitiatedTransaction(ctx, CompleteInitiatedTransaction{
+		ID:           initiated.ID,
+		From:         models.TransactionStatusINITIATED,
+		Gateway:      "gatewayA",
+		GatewayRefID: "ref123",
+		Status:       "success",
+		Source:       models.StatusChangeSourceAPI,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error completing the transaction, got %v", err)
+	}
+	refund, err := r.CreateRefund(ctx, CreateRefund{
+		TransactionID: initiated.ID,
+		Amount:        money.MustParse("40", "USD"),
+		Gateway:       "gatewayA",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error creating the refund, got %v", err)
+	}
+
+	// The callback of the refund arrives before the refund request returned its gateway reference.
+	entry, _, err := r.CreateCallbackInboxEntry(ctx, CreateCallbackInboxEntry{
+		Gateway:        "gatewayA",
+		RefID:          "rfd-ref456",
+		DedupeKey:      "rfd-ref456:success",
+		ReportedStatus: models.TransactionStatusSUCCESS,
+		RawPayload:     "{}",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error storing the callback, got %v", err)
+	}
+	if _, err := r.UpdateCallbackInboxEntry(ctx, entry.ID, models.CallbackInboxStatusPARKED, "transaction not found"); err != nil {
+		t.Fatalf("Expected no error parking the callback, got %v", err)
+	}
+
+	unparkable, err := r.ListUnparkableCallbacks(ctx, 10)
+	if err != nil {
+		t.Fatalf("Expected no error listing unparkable callbacks, got %v", err)
+	}
+	if len(unparkable) != 0 {
+		t.Errorf("Expected no unparkable callbacks before the refund reference is stored, got %d", len(unparkable))
+	}
+
+	if _, err := r.CompleteRefund(ctx, CompleteRefund{ID: refund.ID, GatewayRefID: "rfd-ref456", Status: "pending"}); err != nil {
+		t.Fatalf("Expected no error storing the refund reference, got %v", err)
+	}
+
+	unparkable, err = r.ListUnparkableCallbacks(ctx, 10)
+	if err != nil {
+		t.Fatalf("Expected no error listing unparkable callbacks, got %v", err)
+	}
+	if len(unparkable) != 1 || unparkable[0].ID != entry.ID {
+		t.Errorf("Expected the parked refund callback %d to be unparkable, got %v", entry.ID, unparkable)
+	}
+}
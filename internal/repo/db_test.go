@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// testDatabaseURLEnv names the postgres:// URL of the database the repository tests run against. The tests
+// are skipped without it. Each test migrates a schema of its own and drops it afterwards.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+// newTestRepo returns a repository on a freshly migrated schema of the test database.
+func newTestRepo(t *testing.T) *PaymentRepo {
+	t.Helper()
+	databaseURL := os.Getenv(testDatabaseURLEnv)
+	if databaseURL == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	admin, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("Expected no error opening the database, got %v", err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("Expected no error creating schema %s, got %v", schema, err)
+	}
+	t.Cleanup(func() { _, _ = admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		t.Fatalf("Expected a postgres:// URL in %s, got %v", testDatabaseURLEnv, err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("Expected no error opening the database, got %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrate(t, db)
+	return NewPaymentRepo(db)
+}
+
+// migrate applies the up migrations of db/migrations in order, one statement at a time.
+func migrate(t *testing.T, db *sql.DB) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("..", "..", "db", "migrations", "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Expected migrations, got %d files and error %v", len(files), err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		for _, statement := range upStatements(t, file) {
+			if _, err := db.Exec(statement); err != nil {
+				t.Fatalf("Expected no error migrating %s, got %v in\n%s", filepath.Base(file), err, statement)
+			}
+		}
+	}
+}
+
+// upStatements splits the up section of a goose migration into its statements.
+func upStatements(t *testing.T, file string) []string {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("Expected no error opening %s, got %v", file, err)
+	}
+	defer f.Close()
+
+	var statements []string
+	var current strings.Builder
+	up, block := false, false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "-- +goose Up"):
+			up = true
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose Down"):
+			return statements
+		case strings.HasPrefix(trimmed, "-- +goose StatementBegin"):
+			block = true
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose StatementEnd"):
+			block = false
+		case strings.HasPrefix(trimmed, "-- +goose"), !up:
+			continue
+		default:
+			current.WriteString(line)
+			current.WriteString("\n")
+			if block || !strings.HasSuffix(trimmed, ";") {
+				continue
+			}
+		}
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Expected no error reading %s, got %v", file, err)
+	}
+	return statements
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/utils/nullutil"
+)
+
+// ReserveIdempotencyKey stores a new in-progress idempotency key for the request fingerprint. When the key
+// already exists, the stored key is returned and created is false.
+func (r *PaymentRepo) ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (models.IdempotencyKey, bool, error) {
+	// The existing key may be released between the insert and the lookup, in which case the insert is retried once.
+	for attempt := 0; ; attempt++ {
+		rows, err := r.queries.CreateIdempotencyKey(ctx, models.CreateIdempotencyKeyParams{
+			Key:         key,
+			RequestHash: requestHash,
+			CreatedAt:   time.Now().UTC(),
+		})
+		if err != nil {
+			return models.IdempotencyKey{}, false, err
+		}
+		if rows == 1 {
+			return models.IdempotencyKey{Key: key, RequestHash: requestHash, Status: models.IdempotencyStatusINPROGRESS}, true, nil
+		}
+
+		existing, err := r.queries.GetIdempotencyKey(ctx, key)
+		if errors.Is(err, sql.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		return existing, false, err
+	}
+}
+
+// CompleteIdempotencyKey stores the response of the request so that replays can return it.
+func (r *PaymentRepo) CompleteIdempotencyKey(ctx context.Context, key string, response json.RawMessage) error {
+	return r.queries.CompleteIdempotencyKey(ctx, models.CompleteIdempotencyKeyParams{
+		Key:       key,
+		Response:  nullutil.NewNullRawMessage(response),
+		UpdatedAt: time.Now().UTC(),
+	})
+}
+
+// setIdempotencyKeyReference stores the reference of the transaction on the idempotency key of its request,
+// if it has one.
+func setIdempotencyKeyReference(ctx context.Context, q *models.Queries, transaction models.TransactionRequest) error {
+	if transaction.IdempotencyKey == "" {
+		return nil
+	}
+	err := q.SetIdempotencyKeyReference(ctx, models.SetIdempotencyKeyReferenceParams{
+		Key:       transaction.IdempotencyKey,
+		Reference: nullutil.NewNullString(transaction.Reference),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store reference on idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes an in-progress key so that the request can be retried. Completed keys are kept.
+func (r *PaymentRepo) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return r.queries.DeleteIdempotencyKey(ctx, key)
+}
+
+// DeleteExpiredIdempotencyKeys deletes keys created before the given time and returns how many were deleted.
+func (r *PaymentRepo) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	return r.queries.DeleteExpiredIdempotencyKeys(ctx, before)
+}
@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+)
+
+func TestInitiateTransaction_StoresReferenceOnIdempotencyKey(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+
+	if _, created, err := r.ReserveIdempotencyKey(ctx, "key-1", "hash"); err != nil || !created {
+		t.Fatalf("Expected the key to be reserved, got created %v and error %v", created, err)
+	}
+	_, err := r.InitiateTransaction(ctx, models.TransactionRequest{
+		Type:           "deposit",
+		Amount:         money.MustParse("100", "USD"),
+		PaymentMethod:  "card",
+		CustomerID:     "cust123",
+		IdempotencyKey: "key-1",
+		Reference:      "txn_1",
+	}, time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error initiating the transaction, got %v", err)
+	}
+
+	stored, created, err := r.ReserveIdempotencyKey(ctx, "key-1", "hash")
+	if err != nil || created {
+		t.Fatalf("Expected the stored key, got created %v and error %v", created, err)
+	}
+	if stored.Status != models.IdempotencyStatusINPROGRESS {
+		t.Errorf("Expected status %s, got %s", models.IdempotencyStatusINPROGRESS, stored.Status)
+	}
+	if stored.Reference.String != "txn_1" {
+		t.Errorf("Expected reference txn_1, got %q", stored.Reference.String)
+	}
+}
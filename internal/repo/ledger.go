@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rauf/payment-service/internal/ledger"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/utils/nullutil"
+)
+
+// PostLedgerEntry posts a journal entry that is not caused by a transaction, such as a fee or an adjustment.
+func (r *PaymentRepo) PostLedgerEntry(ctx context.Context, entry ledger.Entry) (models.LedgerEntry, error) {
+	var posted models.LedgerEntry
+	err := withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		var err error
+		posted, err = postEntry(ctx, q, entry, sql.NullInt32{}, sql.NullInt32{})
+		return err
+	})
+	return posted, err
+}
+
+// ListLedgerBalances returns the accounts matching the filter with their balances, in creation order. The
+// balances are the sums of the postings, so credit-normal accounts have negative ones.
+func (r *PaymentRepo) ListLedgerBalances(ctx context.Context, list ListLedgerBalances) ([]models.ListLedgerBalancesRow, error) {
+	arg := models.ListLedgerBalancesParams{
+		Owner:    nullutil.NewNullString(list.Owner),
+		PageSize: list.Limit,
+	}
+	if list.Type != "" {
+		arg.Type = models.NullLedgerAccountType{LedgerAccountType: list.Type, Valid: true}
+	}
+	if list.CursorID != 0 {
+		arg.CursorID = sql.NullInt64{Int64: list.CursorID, Valid: true}
+	}
+	return r.queries.ListLedgerBalances(ctx, arg)
+}
+
+// SumLedgerPostings returns the sum of all postings per currency, which is zero when the ledger is consistent.
+func (r *PaymentRepo) SumLedgerPostings(ctx context.Context) ([]models.SumLedgerPostingsRow, error) {
+	return r.queries.SumLedgerPostings(ctx)
+}
+
+// ListUnbalancedLedgerEntries returns the entries whose postings do not sum to zero in a currency.
+func (r *PaymentRepo) ListUnbalancedLedgerEntries(ctx context.Context, limit int32) ([]models.ListUnbalancedLedgerEntriesRow, error) {
+	return r.queries.ListUnbalancedLedgerEntries(ctx, limit)
+}
+
+// postTransactionEntry posts the journal entry of a transaction that moved money: a successful deposit,
+// withdrawal or transfer, or a captured authorization. Other statuses post nothing. It has to be called in
+// the database transaction of the status change.
+func postTransactionEntry(ctx context.Context, q *models.Queries, transaction models.Transaction) error {
+	amount := transaction.Amount
+	switch {
+	case transaction.Status == models.TransactionStatusCAPTURED:
+		amount = transaction.CapturedAmount.String
+	case transaction.Status != models.TransactionStatusSUCCESS:
+		return nil
+	}
+	m, err := money.Parse(amount, transaction.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to parse amount of transaction %d: %w", transaction.ID, err)
+	}
+
+	var entry ledger.Entry
+	switch transaction.Type {
+	case models.TransactionTypeDEPOSIT:
+		entry = ledger.Deposit(transaction.Gateway, transaction.CustomerID, m)
+	case models.TransactionTypeWITHDRAWAL:
+		entry = ledger.Withdrawal(transaction.Gateway, transaction.CustomerID, m)
+	case models.TransactionTypeTRANSFER:
+		entry = ledger.Transfer(transaction.CustomerID, transaction.DestinationCustomerID.String, m)
+	default:
+		return nil
+	}
+	entry.Description = transaction.Reference
+	_, err = postEntry(ctx, q, entry, sql.NullInt32{Int32: transaction.ID, Valid: true}, sql.NullInt32{})
+	return err
+}
+
+// postRefundEntry posts the journal entry of a successful refund. It has to be called in the database
+// transaction of the refund update.
+func postRefundEntry(ctx context.Context, q *models.Queries, refund models.Refund) error {
+	if refund.Status != models.TransactionStatusSUCCESS {
+		return nil
+	}
+	transaction, err := q.GetTransaction(ctx, refund.TransactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get refunded transaction: %w", err)
+	}
+	m, err := money.Parse(refund.Amount, refund.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to parse amount of refund %d: %w", refund.ID, err)
+	}
+
+	entry := ledger.Refund(refund.Gateway, transaction.CustomerID, m, ledger.Kind(strings.ToLower(string(transaction.Type))))
+	entry.Description = transaction.Reference
+	_, err = postEntry(ctx, q, entry, sql.NullInt32{}, sql.NullInt32{Int32: refund.ID, Valid: true})
+	return err
+}
+
+// postEntry validates the entry and writes it with its postings, creating the accounts on their first posting.
+// The accounts are locked in the order of ledger.CompareAccounts before any posting is written, so that
+// concurrent entries on the same accounts cannot deadlock.
+func postEntry(ctx context.Context, q *models.Queries, entry ledger.Entry, transactionID, refundID sql.NullInt32) (models.LedgerEntry, error) {
+	if err := entry.Validate(); err != nil {
+		return models.LedgerEntry{}, err
+	}
+	now := time.Now().UTC()
+	accountIDs := make(map[ledger.Account]int64)
+	for _, account := range entry.Accounts() {
+		locked, err := upsertLedgerAccount(ctx, q, account, now)
+		if err != nil {
+			return models.LedgerEntry{}, err
+		}
+		accountIDs[account] = locked.ID
+	}
+
+	posted, err := q.CreateLedgerEntry(ctx, models.CreateLedgerEntryParams{
+		Kind:          models.LedgerEntryKind(strings.ToUpper(string(entry.Kind))),
+		TransactionID: transactionID,
+		RefundID:      refundID,
+		Description:   nullutil.NewNullString(entry.Description),
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return models.LedgerEntry{}, fmt.Errorf("failed to create %s ledger entry: %w", entry.Kind, err)
+	}
+	for _, p := range entry.Postings {
+		if err := q.CreateLedgerPosting(ctx, models.CreateLedgerPostingParams{
+			EntryID:   posted.ID,
+			AccountID: accountIDs[p.Account],
+			Amount:    p.Amount.Decimal(),
+			Currency:  p.Account.Currency,
+			CreatedAt: now,
+		}); err != nil {
+			return models.LedgerEntry{}, fmt.Errorf("failed to create ledger posting: %w", err)
+		}
+	}
+	return posted, nil
+}
+
+// upsertLedgerAccount returns the account, creating it if it has no postings yet. The account row stays
+// locked until the database transaction ends, so postings to an account are serialized.
+func upsertLedgerAccount(ctx context.Context, q *models.Queries, account ledger.Account, now time.Time) (models.LedgerAccount, error) {
+	upserted, err := q.UpsertLedgerAccount(ctx, models.UpsertLedgerAccountParams{
+		Type:      models.LedgerAccountType(strings.ToUpper(string(account.Type))),
+		Owner:     account.Owner,
+		Currency:  account.Currency,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return models.LedgerAccount{}, fmt.Errorf("failed to get %s ledger account: %w", account.Type, err)
+	}
+	return upserted, nil
+}
@@ -1,11 +1,31 @@
 package repo
 
-import "github.com/rauf/payment-service/internal/models"
+import (
+	"time"
 
-type CreateTransaction struct {
-	models.TransactionRequest
-	Gateway      string
-	GatewayRefID string
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/reconcile"
+)
+
+// CompleteInitiatedTransaction records the answer of the gateway on an initiated transaction.
+type CompleteInitiatedTransaction struct {
+	ID                     int32
+	Gateway                string
+	GatewayRefID           string
+	Status                 string
+	GatewayStatusCode      string
+	DeclineReason          string
+	AuthorizationExpiresAt time.Time
+	// RoutingRule is the routing rule that picked the gateway, empty if no rule matched.
+	RoutingRule string
+	// ExpectedFee is the fee the gateway is expected to charge, the zero Money if it is unknown.
+	ExpectedFee money.Money
+	// Source is where the answer came from: the response of the gateway, or a status query of the sweeper.
+	Source models.StatusChangeSource
+	// From is the status the transaction is completed from: initiated, or needs review when an operator
+	// resolves a parked transaction.
+	From models.TransactionStatus
 }
 
 type GetTransactionByRefID struct {
@@ -13,8 +33,142 @@ type GetTransactionByRefID struct {
 	RefID   string
 }
 
-type UpdateTransactionStatus struct {
+type TransitionTransactionStatus struct {
+	ID     int32
+	From   models.TransactionStatus
+	To     models.TransactionStatus
+	Source models.StatusChangeSource
+	// RawPayload is the request that caused the transition, e.g. the gateway callback body.
+	RawPayload string
+	// GatewayStatusCode and DeclineReason replace the stored ones when set.
+	GatewayStatusCode string
+	DeclineReason     string
+}
+
+type CreateRefund struct {
+	TransactionID int32
+	Amount        money.Money
+	Gateway       string
+	Reason        string
+}
+
+type CompleteRefund struct {
+	ID           int32
+	GatewayRefID string
+	Status       string
+}
+
+type FailRefund struct {
+	ID            int32
+	TransactionID int32
+	// Amount is the stored NUMERIC amount of the refund.
+	Amount string
+	// GatewayRefID is the reference of the refund at the gateway, empty if the gateway never answered.
+	GatewayRefID string
+}
+
+type CaptureTransaction struct {
+	ID     int32
+	Amount money.Money
+	Source models.StatusChangeSource
+}
+
+type VoidTransaction struct {
+	ID     int32
+	Source models.StatusChangeSource
+}
+
+// ListPendingTransactionsToPoll selects pending transactions whose status is due to be queried from the gateway.
+type ListPendingTransactionsToPoll struct {
+	// CreatedBefore skips transactions that are too recent for a callback to be overdue.
+	CreatedBefore time.Time
+	// MaxAttempts skips transactions that were queried that many times already.
+	MaxAttempts int32
+	Now         time.Time
+	Limit       int32
+}
+
+type ListTransactions struct {
+	CustomerID  string
+	Reference   string
+	Status      string
+	Gateway     string
+	Type        string
+	Currency    string
+	MinAmount   string
+	MaxAmount   string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// AfterCreatedAt and AfterID are the sort key of the last row of the previous page.
+	AfterCreatedAt time.Time
+	AfterID        int32
+	Limit          int32
+}
+
+type CreateWebhookEndpoint struct {
+	MerchantID string
+	URL        string
+	Secret     string
+}
+
+type UpdateWebhookDelivery struct {
+	ID            int64
+	Status        models.WebhookDeliveryStatus
+	Attempts      int32
+	NextAttemptAt time.Time
+	// ResponseCode is the status code of the endpoint response, 0 if there was none.
+	ResponseCode int
+	LastError    string
+}
+
+type ListWebhookDeliveries struct {
+	MerchantID string
+	Status     models.WebhookDeliveryStatus
+	// CursorID is the ID of the last delivery of the previous page.
+	CursorID int64
+	Limit    int32
+}
+
+type CreateCallbackInboxEntry struct {
+	Gateway           string
+	RefID             string
+	DedupeKey         string
+	ReportedStatus    models.TransactionStatus
+	GatewayStatusCode string
+	DeclineReason     string
+	RawPayload        string
+}
+
+type ListCallbackInboxEntries struct {
 	Gateway string
 	RefID   string
-	Status  string
+	Status  models.CallbackInboxStatus
+	// CursorID is the ID of the last entry of the previous page.
+	CursorID int64
+	Limit    int32
+}
+
+// CreateReconciliation is the result of matching a settlement report of a gateway for a period.
+type CreateReconciliation struct {
+	Gateway    string
+	PeriodFrom time.Time
+	PeriodTo   time.Time
+	// Records is the number of records in the settlement report.
+	Records int
+	Result  reconcile.Result
+}
+
+type ListReconciliationRuns struct {
+	Gateway string
+	// CursorID is the ID of the last run of the previous page.
+	CursorID int64
+	Limit    int32
+}
+
+type ListLedgerBalances struct {
+	Type  models.LedgerAccountType
+	Owner string
+	// CursorID is the ID of the last account of the previous page.
+	CursorID int64
+	Limit    int32
 }
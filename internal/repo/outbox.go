@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/outbox"
+)
+
+// ProcessPendingEvents calls fn for up to limit unpublished outbox events, oldest first, and marks the
+// events it succeeds for as published. The events are locked while they are processed, so concurrent
+// relays work on different events. Processing stops at the first failure, which is recorded on the event.
+func (r *PaymentRepo) ProcessPendingEvents(ctx context.Context, limit int32, fn func(outbox.Event) error) (int, error) {
+	var (
+		processed  int
+		publishErr error
+	)
+	err := withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		pending, err := q.ListPendingOutboxEvents(ctx, limit)
+		if err != nil {
+			return fmt.Errorf("failed to list pending events: %w", err)
+		}
+
+		for _, e := range pending {
+			event := outbox.Event{
+				ID:        e.ID,
+				Type:      e.EventType,
+				Payload:   e.Payload,
+				CreatedAt: e.CreatedAt,
+			}
+			if publishErr = fn(event); publishErr != nil {
+				// The failure is committed with the events published before it.
+				if err := q.MarkOutboxEventFailed(ctx, models.MarkOutboxEventFailedParams{
+					ID:        e.ID,
+					LastError: sql.NullString{String: publishErr.Error(), Valid: true},
+				}); err != nil {
+					return fmt.Errorf("failed to mark event %d as failed: %w", e.ID, err)
+				}
+				return nil
+			}
+			if err := q.MarkOutboxEventPublished(ctx, models.MarkOutboxEventPublishedParams{
+				ID:          e.ID,
+				PublishedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			}); err != nil {
+				return fmt.Errorf("failed to mark event %d as published: %w", e.ID, err)
+			}
+			processed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if publishErr != nil {
+		return processed, fmt.Errorf("failed to publish event: %w", publishErr)
+	}
+	return processed, nil
+}
+
+// addEvent writes an event to the outbox. It has to be called in the database transaction of the change
+// the event describes.
+func addEvent(ctx context.Context, q *models.Queries, event outbox.Event) error {
+	if err := q.CreateOutboxEvent(ctx, models.CreateOutboxEventParams{
+		EventType: event.Type,
+		Payload:   event.Payload,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+func addTransactionEvent(ctx context.Context, q *models.Queries, eventType string, transaction models.Transaction) error {
+	event, err := outbox.NewTransactionEvent(eventType, transaction)
+	if err != nil {
+		return err
+	}
+	return addEvent(ctx, q, event)
+}
+
+func addRefundEvent(ctx context.Context, q *models.Queries, eventType string, refund models.Refund) error {
+	event, err := outbox.NewRefundEvent(eventType, refund)
+	if err != nil {
+		return err
+	}
+	return addEvent(ctx, q, event)
+}
@@ -2,56 +2,532 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/rauf/payment-service/internal/consts"
+	"github.com/rauf/payment-service/internal/ledger"
 	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/outbox"
 	"github.com/rauf/payment-service/internal/utils/nullutil"
 )
 
+var (
+	// ErrRefundLimitExceeded is returned when a refund would take the refunded total above the captured amount.
+	ErrRefundLimitExceeded = errors.New("refund exceeds refundable amount")
+	// ErrNotAuthorized is returned when a capture or void targets a transaction that is no longer authorized.
+	ErrNotAuthorized = errors.New("transaction is not authorized")
+	// ErrStatusChanged is returned when the status of a transaction changed since it was read.
+	ErrStatusChanged = errors.New("transaction status changed concurrently")
+	// ErrInsufficientBalance is returned when a transfer exceeds the balance of its source customer.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
 type PaymentRepo struct {
+	db      *sql.DB
 	queries *models.Queries
 }
 
-func NewPaymentRepo(queries *models.Queries) *PaymentRepo {
+func NewPaymentRepo(db *sql.DB) *PaymentRepo {
 	return &PaymentRepo{
-		queries: queries,
+		db:      db,
+		queries: models.New(db),
 	}
 }
 
-func (r *PaymentRepo) CreateTransaction(ctx context.Context, transaction CreateTransaction) error {
-	arg := models.CreateTransactionParams{
-		Type:             models.TransactionType(strings.ToUpper(transaction.Type)),
-		Amount:           fmt.Sprintf("%.2f", transaction.Amount),
-		Currency:         transaction.Currency,
-		PaymentMethod:    transaction.PaymentMethod,
-		Description:      nullutil.NewNullString(transaction.Description),
-		CustomerID:       transaction.CustomerID,
-		Gateway:          transaction.Gateway,
-		GatewayRefID:     transaction.GatewayRefID,
-		Status:           models.TransactionStatusPENDING,
-		PreferredGateway: nullutil.NewNullString(transaction.PreferredGateway),
-		Metadata:         nullutil.NewNullRawMessage(transaction.Metadata),
+// InitiateTransaction stores the transaction in the INITIATED status before it is sent to a gateway. It has
+// no gateway yet, and no events are published until the gateway answered. Authorizations are stored with
+// their expiry, which tells them apart from other initiated transactions. The reference is stored on the
+// idempotency key of the request, so that a retry can look the transaction up.
+func (r *PaymentRepo) InitiateTransaction(ctx context.Context, transaction models.TransactionRequest, authorizationExpiresAt time.Time) (models.Transaction, error) {
+	var initiated models.Transaction
+	err := withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		var err error
+		initiated, err = q.CreateTransaction(ctx, models.CreateTransactionParams{
+			Type:                   models.TransactionType(strings.ToUpper(transaction.Type)),
+			Amount:                 transaction.Amount.Decimal(),
+			Currency:               transaction.Amount.Currency().String(),
+			PaymentMethod:          transaction.PaymentMethod,
+			Description:            nullutil.NewNullString(transaction.Description),
+			CustomerID:             transaction.CustomerID,
+			Status:                 models.TransactionStatusINITIATED,
+			PreferredGateway:       nullutil.NewNullString(transaction.PreferredGateway),
+			Metadata:               nullutil.NewNullRawMessage(transaction.Metadata),
+			AuthorizationExpiresAt: nullutil.NewNullTime(authorizationExpiresAt),
+			MerchantID:             nullutil.NewNullString(transaction.MerchantID),
+			Reference:              transaction.Reference,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		return setIdempotencyKeyReference(ctx, q, transaction)
+	})
+	return initiated, err
+}
+
+// CreateTransfer stores a successful transfer between two customers and posts it to the ledger, failing with
+// ErrInsufficientBalance if the source customer holds less than the amount. Both customer accounts are locked
+// while the balance is checked, so concurrent transfers cannot overdraw the source.
+func (r *PaymentRepo) CreateTransfer(ctx context.Context, transfer models.TransactionRequest) (models.Transaction, error) {
+	now := time.Now().UTC()
+	currency := transfer.Amount.Currency().String()
+	var created models.Transaction
+	err := withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		// Lock the accounts in the order postEntry locks them, so that opposite transfers between two customers
+		// cannot deadlock.
+		accounts := []ledger.Account{
+			ledger.CustomerAccount(transfer.CustomerID, currency),
+			ledger.CustomerAccount(transfer.DestinationCustomerID, currency),
+		}
+		slices.SortFunc(accounts, ledger.CompareAccounts)
+		var sourceID int64
+		for _, account := range accounts {
+			locked, err := upsertLedgerAccount(ctx, q, account, now)
+			if err != nil {
+				return err
+			}
+			if account.Owner == transfer.CustomerID {
+				sourceID = locked.ID
+			}
+		}
+
+		sum, err := q.GetLedgerAccountBalance(ctx, sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to get balance of %s: %w", transfer.CustomerID, err)
+		}
+		postings, err := money.Parse(sum, currency)
+		if err != nil {
+			return fmt.Errorf("failed to parse balance of %s: %w", transfer.CustomerID, err)
+		}
+		balance := ledger.AccountCustomer.Balance(postings)
+		cmp, err := balance.Cmp(transfer.Amount)
+		if err != nil {
+			return err
+		}
+		if cmp < 0 {
+			return fmt.Errorf("%w: %s holds %s %s", ErrInsufficientBalance, transfer.CustomerID, balance.Decimal(), currency)
+		}
+
+		created, err = q.CreateTransaction(ctx, models.CreateTransactionParams{
+			Type:                  models.TransactionTypeTRANSFER,
+			Amount:                transfer.Amount.Decimal(),
+			Currency:              currency,
+			PaymentMethod:         transfer.PaymentMethod,
+			Description:           nullutil.NewNullString(transfer.Description),
+			CustomerID:            transfer.CustomerID,
+			Gateway:               consts.GatewayInternal,
+			GatewayRefID:          nullutil.NewNullString(transfer.Reference),
+			Status:                models.TransactionStatusSUCCESS,
+			Metadata:              nullutil.NewNullRawMessage(transfer.Metadata),
+			MerchantID:            nullutil.NewNullString(transfer.MerchantID),
+			Reference:             transfer.Reference,
+			DestinationCustomerID: nullutil.NewNullString(transfer.DestinationCustomerID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create transfer: %w", err)
+		}
+		if err := setIdempotencyKeyReference(ctx, q, transfer); err != nil {
+			return err
+		}
+		if err := recordTransition(ctx, q, TransitionTransactionStatus{
+			ID:     created.ID,
+			From:   models.TransactionStatusINITIATED,
+			To:     models.TransactionStatusSUCCESS,
+			Source: models.StatusChangeSourceAPI,
+		}, now); err != nil {
+			return err
+		}
+		if err := postTransactionEntry(ctx, q, created); err != nil {
+			return err
+		}
+		if err := addTransactionEvent(ctx, q, outbox.TransactionCreated, created); err != nil {
+			return err
+		}
+		return addTransactionEvent(ctx, q, outbox.TransactionSucceeded, created)
+	})
+	return created, err
+}
+
+// CompleteInitiatedTransaction stores the gateway answer on an initiated transaction, records the transition,
+// posts a successful transaction to the ledger and publishes the creation and status events. It fails with
+// ErrStatusChanged if the transaction is no longer initiated, e.g. because the sweeper resolved it first.
+func (r *PaymentRepo) CompleteInitiatedTransaction(ctx context.Context, complete CompleteInitiatedTransaction) error {
+	now := time.Now().UTC()
+	status := models.TransactionStatus(strings.ToUpper(complete.Status))
+	// The fee is in the currency of the transaction, and an unknown fee is stored as NULL.
+	expectedFee, _ := newNullAmount(complete.ExpectedFee)
+	return withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		completed, err := q.CompleteInitiatedTransaction(ctx, models.CompleteInitiatedTransactionParams{
+			Gateway:                complete.Gateway,
+			GatewayRefID:           nullutil.NewNullString(complete.GatewayRefID),
+			Status:                 status,
+			GatewayStatusCode:      nullutil.NewNullString(complete.GatewayStatusCode),
+			DeclineReason:          newNullDeclineReason(complete.DeclineReason),
+			AuthorizationExpiresAt: nullutil.NewNullTime(complete.AuthorizationExpiresAt),
+			RoutingRule:            nullutil.NewNullString(complete.RoutingRule),
+			ExpectedFee:            expectedFee,
+			UpdatedAt:              now,
+			ID:                     complete.ID,
+			FromStatus:             complete.From,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrStatusChanged
+		}
+		if err != nil {
+			return fmt.Errorf("failed to complete transaction: %w", err)
+		}
+		if err := recordTransition(ctx, q, TransitionTransactionStatus{
+			ID:     complete.ID,
+			From:   complete.From,
+			To:     status,
+			Source: complete.Source,
+		}, now); err != nil {
+			return err
+		}
+		if err := postTransactionEntry(ctx, q, completed); err != nil {
+			return err
+		}
+		if err := addTransactionEvent(ctx, q, outbox.TransactionCreated, completed); err != nil {
+			return err
+		}
+		if eventType, ok := outbox.TransactionStatusEventType(completed.Status); ok {
+			return addTransactionEvent(ctx, q, eventType, completed)
+		}
+		return nil
+	})
+}
+
+// ListStaleInitiatedTransactions returns transactions that were initiated before the given time and never
+// completed, oldest first. Transactions whose next status query is scheduled after now are skipped.
+func (r *PaymentRepo) ListStaleInitiatedTransactions(ctx context.Context, before, now time.Time, limit int32) ([]models.Transaction, error) {
+	return r.queries.ListStaleInitiatedTransactions(ctx, models.ListStaleInitiatedTransactionsParams{
+		CreatedBefore: before,
+		Now:           now,
+		PageSize:      limit,
+	})
+}
+
+// GetTransactionByReference returns the transaction with our reference.
+func (r *PaymentRepo) GetTransactionByReference(ctx context.Context, reference string) (models.Transaction, error) {
+	return r.queries.GetTransactionByReference(ctx, reference)
+}
+
+// ListGatewayVolumes returns the amounts each gateway processed successfully since the given time, by
+// currency. Transfers are not processed by gateways and captured authorizations count with their captured
+// amount.
+func (r *PaymentRepo) ListGatewayVolumes(ctx context.Context, since time.Time) ([]models.GatewayVolume, error) {
+	rows, err := r.queries.ListGatewayVolumes(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gateway volumes: %w", err)
 	}
+	volumes := make([]models.GatewayVolume, 0, len(rows))
+	for _, row := range rows {
+		volume, err := money.Parse(row.Volume, row.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse volume of %s: %w", row.Gateway, err)
+		}
+		volumes = append(volumes, models.GatewayVolume{Gateway: row.Gateway, Volume: volume})
+	}
+	return volumes, nil
+}
 
-	return r.queries.CreateTransaction(ctx, arg)
+// newNullDeclineReason returns the decline reason of a lower-case reason, or null when there is none.
+func newNullDeclineReason(reason string) models.NullDeclineReason {
+	if reason == "" {
+		return models.NullDeclineReason{}
+	}
+	return models.NullDeclineReason{DeclineReason: models.DeclineReason(strings.ToUpper(reason)), Valid: true}
 }
 
 func (r *PaymentRepo) GetTransactionByRefID(ctx context.Context, g GetTransactionByRefID) (models.Transaction, error) {
 	return r.queries.GetTransactionByGatewayRefId(ctx, models.GetTransactionByGatewayRefIdParams{
-		GatewayRefID: g.RefID,
+		GatewayRefID: nullutil.NewNullString(g.RefID),
 		Gateway:      g.Gateway,
 	})
 }
 
-func (r *PaymentRepo) UpdateTransactionStatus(ctx context.Context, update UpdateTransactionStatus) error {
-	arg := models.UpdateTransactionStatusParams{
-		Gateway:      update.Gateway,
-		GatewayRefID: update.RefID,
-		Status:       models.TransactionStatus(strings.ToUpper(update.Status)),
-		UpdatedAt:    time.Now().UTC(),
+// ListTransactions returns transactions matching the filter, newest first.
+func (r *PaymentRepo) ListTransactions(ctx context.Context, list ListTransactions) ([]models.Transaction, error) {
+	arg := models.ListTransactionsParams{
+		CustomerID:  nullutil.NewNullString(list.CustomerID),
+		Reference:   nullutil.NewNullString(list.Reference),
+		Gateway:     nullutil.NewNullString(list.Gateway),
+		Currency:    nullutil.NewNullString(list.Currency),
+		MinAmount:   nullutil.NewNullString(list.MinAmount),
+		MaxAmount:   nullutil.NewNullString(list.MaxAmount),
+		CreatedFrom: nullutil.NewNullTime(list.CreatedFrom),
+		CreatedTo:   nullutil.NewNullTime(list.CreatedTo),
+		PageSize:    list.Limit,
+	}
+	if list.Status != "" {
+		arg.Status = models.NullTransactionStatus{TransactionStatus: models.TransactionStatus(strings.ToUpper(list.Status)), Valid: true}
+	}
+	if list.Type != "" {
+		arg.Type = models.NullTransactionType{TransactionType: models.TransactionType(strings.ToUpper(list.Type)), Valid: true}
 	}
+	if !list.AfterCreatedAt.IsZero() {
+		arg.CursorCreatedAt = nullutil.NewNullTime(list.AfterCreatedAt)
+		arg.CursorID = sql.NullInt32{Int32: list.AfterID, Valid: true}
+	}
+
+	return r.queries.ListTransactions(ctx, arg)
+}
+
+// TransitionTransactionStatus moves a transaction from one status to another, records the transition
+// in the status history and posts a successful transaction to the ledger. It fails with ErrStatusChanged if the transaction is no longer in the From status.
+func (r *PaymentRepo) TransitionTransactionStatus(ctx context.Context, transition TransitionTransactionStatus) error {
+	now := time.Now().UTC()
+	return withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		updated, err := q.TransitionTransactionStatus(ctx, models.TransitionTransactionStatusParams{
+			ToStatus:          transition.To,
+			GatewayStatusCode: nullutil.NewNullString(transition.GatewayStatusCode),
+			DeclineReason:     newNullDeclineReason(transition.DeclineReason),
+			UpdatedAt:         now,
+			ID:                transition.ID,
+			FromStatus:        transition.From,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrStatusChanged
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update transaction status: %w", err)
+		}
+		if err := recordTransition(ctx, q, transition, now); err != nil {
+			return err
+		}
+		if err := postTransactionEntry(ctx, q, updated); err != nil {
+			return err
+		}
+		if eventType, ok := outbox.TransactionStatusEventType(updated.Status); ok {
+			return addTransactionEvent(ctx, q, eventType, updated)
+		}
+		return nil
+	})
+}
+
+// CaptureTransaction marks an authorized transaction as captured for the given amount and posts the captured
+// amount to the ledger.
+func (r *PaymentRepo) CaptureTransaction(ctx context.Context, capture CaptureTransaction) error {
+	now := time.Now().UTC()
+	return withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		captured, err := q.CaptureTransaction(ctx, models.CaptureTransactionParams{
+			CapturedAmount: capture.Amount.Decimal(),
+			UpdatedAt:      now,
+			ID:             capture.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotAuthorized
+		}
+		if err != nil {
+			return fmt.Errorf("failed to capture transaction: %w", err)
+		}
+		if err := recordTransition(ctx, q, TransitionTransactionStatus{
+			ID:     capture.ID,
+			From:   models.TransactionStatusAUTHORIZED,
+			To:     models.TransactionStatusCAPTURED,
+			Source: capture.Source,
+		}, now); err != nil {
+			return err
+		}
+		if err := postTransactionEntry(ctx, q, captured); err != nil {
+			return err
+		}
+		return addTransactionEvent(ctx, q, outbox.TransactionCaptured, captured)
+	})
+}
+
+// VoidTransaction marks an authorized transaction as voided.
+func (r *PaymentRepo) VoidTransaction(ctx context.Context, void VoidTransaction) error {
+	now := time.Now().UTC()
+	return withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		voided, err := q.VoidTransaction(ctx, models.VoidTransactionParams{
+			ID:        void.ID,
+			UpdatedAt: now,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotAuthorized
+		}
+		if err != nil {
+			return fmt.Errorf("failed to void transaction: %w", err)
+		}
+		if err := recordTransition(ctx, q, TransitionTransactionStatus{
+			ID:     void.ID,
+			From:   models.TransactionStatusAUTHORIZED,
+			To:     models.TransactionStatusVOIDED,
+			Source: void.Source,
+		}, now); err != nil {
+			return err
+		}
+		return addTransactionEvent(ctx, q, outbox.TransactionVoided, voided)
+	})
+}
 
-	return r.queries.UpdateTransactionStatus(ctx, arg)
+// ListStatusHistory returns the status transitions of a transaction, oldest first.
+func (r *PaymentRepo) ListStatusHistory(ctx context.Context, transactionID int32) ([]models.TransactionStatusHistory, error) {
+	return r.queries.ListTransactionStatusHistory(ctx, transactionID)
+}
+
+func recordTransition(ctx context.Context, q *models.Queries, transition TransitionTransactionStatus, at time.Time) error {
+	err := q.CreateTransactionStatusHistory(ctx, models.CreateTransactionStatusHistoryParams{
+		TransactionID: transition.ID,
+		FromStatus:    transition.From,
+		ToStatus:      transition.To,
+		Source:        transition.Source,
+		RawPayload:    sql.NullString{String: transition.RawPayload, Valid: transition.RawPayload != ""},
+		CreatedAt:     at,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record status history: %w", err)
+	}
+	return nil
+}
+
+// ListExpiredAuthorizations returns authorized transactions whose authorization expired before the given time.
+func (r *PaymentRepo) ListExpiredAuthorizations(ctx context.Context, before time.Time, limit int32) ([]models.Transaction, error) {
+	return r.queries.ListExpiredAuthorizations(ctx, models.ListExpiredAuthorizationsParams{
+		AuthorizationExpiresAt: nullutil.NewNullTime(before),
+		Limit:                  limit,
+	})
+}
+
+// ListPendingTransactionsToPoll returns pending transactions whose next status query is due, oldest first.
+func (r *PaymentRepo) ListPendingTransactionsToPoll(ctx context.Context, list ListPendingTransactionsToPoll) ([]models.Transaction, error) {
+	return r.queries.ListPendingTransactionsToPoll(ctx, models.ListPendingTransactionsToPollParams{
+		CreatedBefore: list.CreatedBefore,
+		MaxAttempts:   list.MaxAttempts,
+		Now:           list.Now,
+		PageSize:      list.Limit,
+	})
+}
+
+// GetRefundByRefID returns the refund with the given gateway reference.
+func (r *PaymentRepo) GetRefundByRefID(ctx context.Context, g GetTransactionByRefID) (models.Refund, error) {
+	return r.queries.GetRefundByGatewayRefID(ctx, models.GetRefundByGatewayRefIDParams{
+		Gateway:      g.Gateway,
+		GatewayRefID: nullutil.NewNullString(g.RefID),
+	})
+}
+
+// ListPendingRefundsToPoll returns pending refunds whose next status query is due, oldest first.
+func (r *PaymentRepo) ListPendingRefundsToPoll(ctx context.Context, list ListPendingTransactionsToPoll) ([]models.Refund, error) {
+	return r.queries.ListPendingRefundsToPoll(ctx, models.ListPendingRefundsToPollParams{
+		CreatedBefore: list.CreatedBefore,
+		MaxAttempts:   list.MaxAttempts,
+		Now:           list.Now,
+		PageSize:      list.Limit,
+	})
+}
+
+// ScheduleRefundStatusPoll counts a status query of the refund and schedules the next one.
+func (r *PaymentRepo) ScheduleRefundStatusPoll(ctx context.Context, id int32, next time.Time) error {
+	return r.queries.ScheduleRefundStatusPoll(ctx, models.ScheduleRefundStatusPollParams{
+		ID:               id,
+		NextStatusPollAt: nullutil.NewNullTime(next),
+	})
+}
+
+// ScheduleStatusPoll counts a status query of the transaction and schedules the next one.
+func (r *PaymentRepo) ScheduleStatusPoll(ctx context.Context, id int32, next time.Time) error {
+	return r.queries.ScheduleStatusPoll(ctx, models.ScheduleStatusPollParams{
+		ID:               id,
+		NextStatusPollAt: nullutil.NewNullTime(next),
+	})
+}
+
+// CreateRefund reserves the refund amount on the original transaction and records a pending refund.
+// Both happen in a single database transaction so the refunded total can never exceed the captured amount.
+func (r *PaymentRepo) CreateRefund(ctx context.Context, refund CreateRefund) (models.Refund, error) {
+	amount := refund.Amount.Decimal()
+	now := time.Now().UTC()
+
+	var created models.Refund
+	err := withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		rows, err := q.ReserveRefundAmount(ctx, models.ReserveRefundAmountParams{
+			Amount:    amount,
+			UpdatedAt: now,
+			ID:        refund.TransactionID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reserve refund amount: %w", err)
+		}
+		if rows == 0 {
+			return ErrRefundLimitExceeded
+		}
+
+		created, err = q.CreateRefund(ctx, models.CreateRefundParams{
+			TransactionID: refund.TransactionID,
+			Amount:        amount,
+			Currency:      refund.Amount.Currency().String(),
+			Reason:        nullutil.NewNullString(refund.Reason),
+			Gateway:       refund.Gateway,
+			Status:        models.TransactionStatusPENDING,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create refund: %w", err)
+		}
+		return addRefundEvent(ctx, q, outbox.RefundCreated, created)
+	})
+	return created, err
+}
+
+// CompleteRefund stores the gateway result of a pending refund and posts a successful refund to the ledger.
+// It fails with ErrStatusChanged if the refund is no longer pending, e.g. because its callback settled it first.
+func (r *PaymentRepo) CompleteRefund(ctx context.Context, refund CompleteRefund) (models.Refund, error) {
+	var updated models.Refund
+	err := withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		var err error
+		updated, err = q.UpdateRefund(ctx, models.UpdateRefundParams{
+			ID:           refund.ID,
+			GatewayRefID: nullutil.NewNullString(refund.GatewayRefID),
+			Status:       models.TransactionStatus(strings.ToUpper(refund.Status)),
+			UpdatedAt:    time.Now().UTC(),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrStatusChanged
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update refund: %w", err)
+		}
+		if err := postRefundEntry(ctx, q, updated); err != nil {
+			return err
+		}
+		if eventType, ok := outbox.RefundStatusEventType(updated.Status); ok {
+			return addRefundEvent(ctx, q, eventType, updated)
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// FailRefund marks the pending refund as failed and releases its amount so it can be refunded again. It fails
+// with ErrStatusChanged if the refund is no longer pending, so an amount is never released twice.
+func (r *PaymentRepo) FailRefund(ctx context.Context, refund FailRefund) error {
+	now := time.Now().UTC()
+	return withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		failed, err := q.UpdateRefund(ctx, models.UpdateRefundParams{
+			ID:           refund.ID,
+			GatewayRefID: nullutil.NewNullString(refund.GatewayRefID),
+			Status:       models.TransactionStatusFAILED,
+			UpdatedAt:    now,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrStatusChanged
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update refund: %w", err)
+		}
+		if err := q.ReleaseRefundAmount(ctx, models.ReleaseRefundAmountParams{
+			Amount:    refund.Amount,
+			UpdatedAt: now,
+			ID:        refund.TransactionID,
+		}); err != nil {
+			return fmt.Errorf("failed to release refund amount: %w", err)
+		}
+		return addRefundEvent(ctx, q, outbox.RefundFailed, failed)
+	})
 }
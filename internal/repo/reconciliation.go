@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/utils/nullutil"
+)
+
+// ErrReconciliationNotFound is returned when there is no reconciliation run with the given ID.
+var ErrReconciliationNotFound = errors.New("reconciliation not found")
+
+// ListTransactionsByGatewayRefIDs returns the transactions of the gateway with the given gateway references.
+func (r *PaymentRepo) ListTransactionsByGatewayRefIDs(ctx context.Context, gateway string, refIDs []string) ([]models.Transaction, error) {
+	return r.queries.ListTransactionsByGatewayRefIDs(ctx, models.ListTransactionsByGatewayRefIDsParams{
+		Gateway: gateway,
+		RefIds:  refIDs,
+	})
+}
+
+// ListSettledTransactions returns the successful and captured transactions of the gateway created in [from, to).
+func (r *PaymentRepo) ListSettledTransactions(ctx context.Context, gateway string, from, to time.Time) ([]models.Transaction, error) {
+	return r.queries.ListSettledTransactions(ctx, models.ListSettledTransactionsParams{
+		Gateway:     gateway,
+		CreatedFrom: from,
+		CreatedTo:   to,
+	})
+}
+
+// CreateReconciliation stores a reconciliation run with its discrepancies.
+func (r *PaymentRepo) CreateReconciliation(ctx context.Context, reconciliation CreateReconciliation) (models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		var err error
+		run, err = q.CreateReconciliationRun(ctx, models.CreateReconciliationRunParams{
+			Gateway:       reconciliation.Gateway,
+			PeriodFrom:    reconciliation.PeriodFrom,
+			PeriodTo:      reconciliation.PeriodTo,
+			Records:       int32(reconciliation.Records),
+			Matched:       int32(reconciliation.Result.Matched),
+			Discrepancies: int32(len(reconciliation.Result.Discrepancies)),
+			CreatedAt:     time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create reconciliation run: %w", err)
+		}
+
+		for _, d := range reconciliation.Result.Discrepancies {
+			recordedAmount, recordedCurrency := newNullAmount(d.RecordedAmount)
+			settledAmount, settledCurrency := newNullAmount(d.SettledAmount)
+			err := q.CreateReconciliationDiscrepancy(ctx, models.CreateReconciliationDiscrepancyParams{
+				RunID:            run.ID,
+				Kind:             models.ReconciliationDiscrepancyKind(strings.ToUpper(string(d.Kind))),
+				RefID:            d.RefID,
+				RecordedAmount:   recordedAmount,
+				RecordedCurrency: recordedCurrency,
+				RecordedStatus:   nullutil.NewNullString(d.RecordedStatus),
+				SettledAmount:    settledAmount,
+				SettledCurrency:  settledCurrency,
+				SettledStatus:    nullutil.NewNullString(d.SettledStatus),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create reconciliation discrepancy: %w", err)
+			}
+		}
+		return nil
+	})
+	return run, err
+}
+
+// newNullAmount returns the decimal amount and the currency of m, or nulls when m is the zero value.
+func newNullAmount(m money.Money) (amount, currency sql.NullString) {
+	if m.Currency() == "" {
+		return sql.NullString{}, sql.NullString{}
+	}
+	return nullutil.NewNullString(m.Decimal()), nullutil.NewNullString(m.Currency().String())
+}
+
+func (r *PaymentRepo) GetReconciliationRun(ctx context.Context, id int64) (models.ReconciliationRun, error) {
+	run, err := r.queries.GetReconciliationRun(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ReconciliationRun{}, ErrReconciliationNotFound
+	}
+	return run, err
+}
+
+// ListReconciliationRuns returns the reconciliation runs matching the filter, newest first.
+func (r *PaymentRepo) ListReconciliationRuns(ctx context.Context, list ListReconciliationRuns) ([]models.ReconciliationRun, error) {
+	arg := models.ListReconciliationRunsParams{
+		Gateway:  nullutil.NewNullString(list.Gateway),
+		PageSize: list.Limit,
+	}
+	if list.CursorID != 0 {
+		arg.CursorID = sql.NullInt64{Int64: list.CursorID, Valid: true}
+	}
+	return r.queries.ListReconciliationRuns(ctx, arg)
+}
+
+// ListReconciliationDiscrepancies returns the discrepancies found by a reconciliation run.
+func (r *PaymentRepo) ListReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error) {
+	return r.queries.ListReconciliationDiscrepancies(ctx, runID)
+}
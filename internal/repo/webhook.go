@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/outbox"
+	"github.com/rauf/payment-service/internal/utils/nullutil"
+)
+
+var (
+	// ErrWebhookEndpointNotFound is returned when a merchant has no active webhook endpoint with the given ID.
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	// ErrWebhookDeliveryNotFound is returned when a merchant has no webhook delivery with the given ID.
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+func (r *PaymentRepo) CreateWebhookEndpoint(ctx context.Context, endpoint CreateWebhookEndpoint) (models.WebhookEndpoint, error) {
+	return r.queries.CreateWebhookEndpoint(ctx, models.CreateWebhookEndpointParams{
+		MerchantID: endpoint.MerchantID,
+		Url:        endpoint.URL,
+		Secret:     endpoint.Secret,
+		CreatedAt:  time.Now().UTC(),
+	})
+}
+
+// ListWebhookEndpoints returns the active webhook endpoints of the merchant.
+func (r *PaymentRepo) ListWebhookEndpoints(ctx context.Context, merchantID string) ([]models.WebhookEndpoint, error) {
+	return r.queries.ListWebhookEndpoints(ctx, merchantID)
+}
+
+// DeactivateWebhookEndpoint stops deliveries to the endpoint. Its delivery log is kept.
+func (r *PaymentRepo) DeactivateWebhookEndpoint(ctx context.Context, merchantID string, id int32) error {
+	rows, err := r.queries.DeactivateWebhookEndpoint(ctx, models.DeactivateWebhookEndpointParams{
+		ID:         id,
+		MerchantID: merchantID,
+		UpdatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// CreateWebhookDeliveries schedules the event for delivery to the endpoints. An event that is already
+// scheduled for an endpoint is skipped, so a republished event is delivered once.
+func (r *PaymentRepo) CreateWebhookDeliveries(ctx context.Context, endpoints []models.WebhookEndpoint, event outbox.Event) error {
+	now := time.Now().UTC()
+	return withTx(ctx, r.db, r.queries, func(q *models.Queries) error {
+		for _, endpoint := range endpoints {
+			if err := q.CreateWebhookDelivery(ctx, models.CreateWebhookDeliveryParams{
+				EndpointID:    endpoint.ID,
+				EventID:       event.ID,
+				EventType:     event.Type,
+				Payload:       event.Payload,
+				NextAttemptAt: now,
+			}); err != nil {
+				return fmt.Errorf("failed to create delivery for endpoint %d: %w", endpoint.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due and postpones them by the lease,
+// so other workers skip them while they are sent. A delivery whose worker dies is tried again after the lease.
+func (r *PaymentRepo) ClaimWebhookDeliveries(ctx context.Context, lease time.Duration, limit int32) ([]models.ClaimWebhookDeliveriesRow, error) {
+	now := time.Now().UTC()
+	return r.queries.ClaimWebhookDeliveries(ctx, models.ClaimWebhookDeliveriesParams{
+		LeaseUntil: now.Add(lease),
+		Now:        now,
+		BatchSize:  limit,
+	})
+}
+
+// UpdateWebhookDelivery stores the result of a delivery attempt.
+func (r *PaymentRepo) UpdateWebhookDelivery(ctx context.Context, delivery UpdateWebhookDelivery) error {
+	return r.queries.UpdateWebhookDelivery(ctx, models.UpdateWebhookDeliveryParams{
+		ID:            delivery.ID,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		ResponseCode:  sql.NullInt32{Int32: int32(delivery.ResponseCode), Valid: delivery.ResponseCode != 0},
+		LastError:     nullutil.NewNullString(delivery.LastError),
+		UpdatedAt:     time.Now().UTC(),
+	})
+}
+
+// ListWebhookDeliveries returns a page of the merchant's deliveries, newest first.
+func (r *PaymentRepo) ListWebhookDeliveries(ctx context.Context, filter ListWebhookDeliveries) ([]models.WebhookDelivery, error) {
+	return r.queries.ListWebhookDeliveries(ctx, models.ListWebhookDeliveriesParams{
+		MerchantID: filter.MerchantID,
+		Status: models.NullWebhookDeliveryStatus{
+			WebhookDeliveryStatus: filter.Status,
+			Valid:                 filter.Status != "",
+		},
+		CursorID: sql.NullInt64{Int64: filter.CursorID, Valid: filter.CursorID != 0},
+		PageSize: filter.Limit,
+	})
+}
+
+// RedeliverWebhookDelivery schedules the delivery again with a fresh set of attempts, whatever its status.
+func (r *PaymentRepo) RedeliverWebhookDelivery(ctx context.Context, merchantID string, id int64) (models.WebhookDelivery, error) {
+	delivery, err := r.queries.RedeliverWebhookDelivery(ctx, models.RedeliverWebhookDeliveryParams{
+		Now:        time.Now().UTC(),
+		ID:         id,
+		MerchantID: merchantID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.WebhookDelivery{}, ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
@@ -2,67 +2,284 @@ package router
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/rauf/payment-service/internal/fee"
 	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
 	"github.com/rauf/payment-service/internal/registry"
 	"github.com/sony/gobreaker/v2"
 )
 
 // Router is a struct that routes the request to the available gateways
 // It has a registry of all available gateways and uses circuit breakers to prevent cascading failures.
+// Routing rules pick the gateways of the transactions they match, in the order of the rule and its weights:
+// rules take precedence over scores and costs, which only rank the gateways of transactions no rule matches,
+// by how they have been performing and, in cost-based routing, by the fees they are expected to charge.
 type Router struct {
 	*circuitBreakers
 	registry *registry.Registry[gateway.PaymentGateway]
+	rules    *Rules
+	scorer   *Scorer
+	costs    *fee.Schedules
 }
 
+// NewRouter creates a router. Without rules, transactions are sent to the preferred gateway first and then
+// to the others, ranked by the scorer or, without one, in registration order. With fee schedules as costs,
+// the healthy gateways are then ordered by expected fee.
 func NewRouter(
 	registry *registry.Registry[gateway.PaymentGateway],
 	settings gobreaker.Settings,
+	rules *Rules,
+	scorer *Scorer,
+	costs *fee.Schedules,
 ) *Router {
 	return &Router{
 		registry:        registry,
 		circuitBreakers: newCircuitBreakers(settings),
+		rules:           rules,
+		scorer:          scorer,
+		costs:           costs,
 	}
 }
 
 type Response struct {
 	Gateway string
-	Data    models.TransactionResponse
+	// Rule is the routing rule that picked the gateway, empty if no rule matched.
+	Rule string
+	Data models.TransactionResponse
 }
 
-func (r *Router) SendMessage(ctx context.Context, preferredGateway string, operation func(gateway.PaymentGateway) (models.TransactionResponse, error)) (Response, error) {
-	allGateways, err := r.registry.ListWithPreference(preferredGateway)
+// Decision is the routing of a transaction: the gateways it is tried on, in order, and the rule that picked
+// them.
+type Decision struct {
+	Rule     string
+	Gateways []gateway.PaymentGateway
+}
+
+// Route picks the gateways of the transaction. The first matching rule picks them, with the gateway its
+// weights pick for the customer first and the preferred gateway before it if it is one of them. Without a
+// matching rule, all gateways are tried, preferred first and the others ranked. The gateways of a rule are
+// not ranked, so a rule always sends its traffic where it says.
+func (r *Router) Route(transaction models.TransactionRequest) (Decision, error) {
+	rule, ok := r.rules.Match(transaction)
+	if !ok {
+		gateways, err := r.registry.ListWithPreference(transaction.PreferredGateway)
+		if err != nil {
+			return Decision{}, fmt.Errorf("failed to get preferred gateways list: %w", err)
+		}
+		if len(gateways) > 0 && gateways[0].Name() == transaction.PreferredGateway {
+			gateways = append(gateways[:1:1], r.rank(gateways[1:], transaction)...)
+		} else {
+			gateways = r.rank(gateways, transaction)
+		}
+		return Decision{Gateways: gateways}, nil
+	}
+
+	names := moveToFront(rule.Gateways, rule.pickWeighted(transaction.CustomerID))
+	names = moveToFront(names, transaction.PreferredGateway)
+	decision := Decision{Rule: rule.Name}
+	for _, name := range names {
+		g, err := r.registry.Get(name)
+		if err != nil {
+			slog.Warn("Routing rule names an unknown gateway", "rule", rule.Name, "gateway", name)
+			continue
+		}
+		decision.Gateways = append(decision.Gateways, g)
+	}
+	if len(decision.Gateways) == 0 {
+		return Decision{}, fmt.Errorf("routing rule %s has no registered gateway", rule.Name)
+	}
+	return decision, nil
+}
+
+// rank orders the gateways by score and then, in cost-based routing, moves the gateways whose circuit is
+// open to the end and orders the others by expected fee. Gateways whose fee schedule does not price the
+// transaction come after the priced ones. A gateway the scorer picked to explore stays first.
+func (r *Router) rank(gateways []gateway.PaymentGateway, transaction models.TransactionRequest) []gateway.PaymentGateway {
+	ranked, explored := r.scorer.rank(gateways, transaction.Amount.Currency().String(), transaction.PaymentMethod)
+	if r.costs == nil {
+		return ranked
+	}
+
+	type cost struct {
+		unhealthy, unpriced bool
+		fee                 money.Money
+	}
+	costs := make(map[string]cost, len(ranked))
+	for _, g := range ranked {
+		expected, priced, err := r.costs.Expected(g.Name(), transaction)
+		if err != nil {
+			slog.Warn("Failed to compute expected fee, ranking the gateway as unpriced", "gateway", g.Name(), "error", err)
+		}
+		cb, err := r.getCircuitBreaker(g.Name())
+		costs[g.Name()] = cost{
+			unhealthy: err != nil || cb.State() == gobreaker.StateOpen,
+			unpriced:  !priced,
+			fee:       expected,
+		}
+	}
+	ranked = slices.Clone(ranked)
+	slices.SortStableFunc(ranked, func(a, b gateway.PaymentGateway) int {
+		ca, cb := costs[a.Name()], costs[b.Name()]
+		if ca.unhealthy != cb.unhealthy {
+			return compareBool(ca.unhealthy, cb.unhealthy)
+		}
+		if ca.unpriced || cb.unpriced {
+			return compareBool(ca.unpriced, cb.unpriced)
+		}
+		c, _ := ca.fee.Cmp(cb.fee)
+		return c
+	})
+	if i := slices.IndexFunc(ranked, func(g gateway.PaymentGateway) bool { return g.Name() == explored }); i > 0 {
+		first := ranked[i]
+		ranked = append([]gateway.PaymentGateway{first}, slices.Delete(ranked, i, i+1)...)
+	}
+	return ranked
+}
+
+// compareBool orders false before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// DryRun returns the routing of the transaction and the circuit state of its gateways, without sending it.
+func (r *Router) DryRun(transaction models.TransactionRequest) (models.RoutingDecision, error) {
+	decision, err := r.Route(transaction)
 	if err != nil {
-		return Response{}, fmt.Errorf("failed to get preferred gateways list: %w", err)
+		return models.RoutingDecision{}, err
+	}
+	dryRun := models.RoutingDecision{
+		Rule:     decision.Rule,
+		Gateways: make([]models.RoutedGateway, 0, len(decision.Gateways)),
 	}
+	for _, g := range decision.Gateways {
+		cb, err := r.getCircuitBreaker(g.Name())
+		if err != nil {
+			return models.RoutingDecision{}, err
+		}
+		dryRun.Gateways = append(dryRun.Gateways, models.RoutedGateway{
+			Name:    g.Name(),
+			Circuit: cb.State().String(),
+		})
+	}
+	return dryRun, nil
+}
 
-	for _, g := range allGateways {
+// SendMessage sends the transaction to the gateways picked by Route until one succeeds, skipping gateways
+// whose circuit is open. It only moves on to the next gateway if the failed one did not process the
+// transaction, i.e. it was unavailable or rejected the request; a decline, a timeout or an unknown outcome
+// ends the routing. Only technical failures count against the circuit breakers. The outcome and latency of
+// every attempt are recorded by the scorer. On failure, the response names the last gateway that was tried,
+// and it fails with gateway.ErrGatewayUnavailable if the circuits of all gateways are open.
+func (r *Router) SendMessage(ctx context.Context, transaction models.TransactionRequest, operation func(gateway.PaymentGateway) (models.TransactionResponse, error)) (Response, error) {
+	decision, err := r.Route(transaction)
+	if err != nil {
+		return Response{}, err
+	}
+	slog.InfoContext(ctx, "Routed transaction", "reference", transaction.Reference, "rule", decision.Rule, "gateways", gatewayNames(decision.Gateways))
+
+	var last string
+	for _, g := range decision.Gateways {
 		done, cbErr := r.isRequestAllowed(ctx, g.Name())
 		if cbErr != nil {
+			if err == nil {
+				err = fmt.Errorf("%w: %w", gateway.ErrGatewayUnavailable, cbErr)
+			}
 			continue
 		}
 
 		slog.InfoContext(ctx, "Sending request to gateway", "gateway", g.Name())
 
 		var result models.TransactionResponse
+		last = g.Name()
+		start := time.Now()
 		result, err = operation(g)
+		success := err == nil && !strings.EqualFold(result.Status, string(models.TransactionStatusFAILED))
+		r.scorer.Record(g.Name(), transaction.Amount.Currency().String(), transaction.PaymentMethod, success, time.Since(start))
 		if err == nil {
 			done(true)
 			return Response{
 				Gateway: g.Name(),
+				Rule:    decision.Rule,
 				Data:    result,
 			}, nil
 		}
 
-		slog.ErrorContext(ctx, "Gateway failed", "gateway", g.Name(), "error", err)
-		done(false)
-		if errors.Is(err, gateway.ErrGatewayUnavailable) {
-			continue
+		slog.ErrorContext(ctx, "Gateway failed", "gateway", g.Name(), "kind", gatewayerr.KindOf(err), "error", err)
+		done(!gatewayerr.IsTechnical(err))
+		if !gatewayerr.CanFailover(err) {
+			break
 		}
 	}
-	return Response{}, fmt.Errorf("all gateways failed: %w", err)
+	return Response{Gateway: last, Rule: decision.Rule}, fmt.Errorf("all gateways failed: %w", err)
+}
+
+// Gateways returns the names of the registered gateways.
+func (r *Router) Gateways() []string {
+	return gatewayNames(r.registry.List())
+}
+
+// SetWeights changes the weights of a routing rule, effective for the next transactions.
+func (r *Router) SetWeights(rule string, weights map[string]int) error {
+	return r.rules.SetWeights(rule, weights)
+}
+
+// Rules returns the routing rules with their current weights.
+func (r *Router) Rules() []Rule {
+	return r.rules.List()
+}
+
+// moveToFront returns the names with the name first, if it is one of them.
+func moveToFront(names []string, name string) []string {
+	i := slices.Index(names, name)
+	if i <= 0 {
+		return names
+	}
+	return append([]string{name}, slices.Delete(slices.Clone(names), i, i+1)...)
+}
+
+func gatewayNames(gateways []gateway.PaymentGateway) []string {
+	names := make([]string, 0, len(gateways))
+	for _, g := range gateways {
+		names = append(names, g.Name())
+	}
+	return names
+}
+
+// SendToGateway sends the request to the given gateway only, without falling back to other gateways.
+// It is used for follow-up operations (e.g. refunds) that must reach the gateway holding the original transaction.
+// As in SendMessage, only technical failures count against the circuit breaker.
+func (r *Router) SendToGateway(ctx context.Context, gatewayName string, operation func(gateway.PaymentGateway) error) error {
+	g, err := r.registry.Get(gatewayName)
+	if err != nil {
+		return fmt.Errorf("failed to get gateway %s: %w", gatewayName, err)
+	}
+
+	done, cbErr := r.isRequestAllowed(ctx, g.Name())
+	if cbErr != nil {
+		return fmt.Errorf("%w: %w", gateway.ErrGatewayUnavailable, cbErr)
+	}
+
+	slog.InfoContext(ctx, "Sending request to gateway", "gateway", g.Name())
+
+	if err = operation(g); err != nil {
+		slog.ErrorContext(ctx, "Gateway failed", "gateway", g.Name(), "kind", gatewayerr.KindOf(err), "error", err)
+		done(!gatewayerr.IsTechnical(err))
+		return fmt.Errorf("gateway %s failed: %w", g.Name(), err)
+	}
+	done(true)
+	return nil
 }
@@ -6,8 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rauf/payment-service/internal/fee"
 	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
 	"github.com/rauf/payment-service/internal/registry"
 	"github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +27,26 @@ func (m *mockGateway) Transact(ctx context.Context, request models.TransactionRe
 	return m.Called().Get(0).(models.TransactionResponse), m.Called().Error(1)
 }
 
+func (m *mockGateway) Authorize(ctx context.Context, request models.TransactionRequest) (models.TransactionResponse, error) {
+	return m.Called().Get(0).(models.TransactionResponse), m.Called().Error(1)
+}
+
+func (m *mockGateway) Capture(ctx context.Context, request models.CaptureRequest) (models.TransactionResponse, error) {
+	return m.Called().Get(0).(models.TransactionResponse), m.Called().Error(1)
+}
+
+func (m *mockGateway) Void(ctx context.Context, request models.VoidRequest) (models.TransactionResponse, error) {
+	return m.Called().Get(0).(models.TransactionResponse), m.Called().Error(1)
+}
+
+func (m *mockGateway) Refund(ctx context.Context, request models.RefundRequest) (models.RefundResponse, error) {
+	return m.Called().Get(0).(models.RefundResponse), m.Called().Error(1)
+}
+
+func (m *mockGateway) QueryStatus(ctx context.Context, query models.StatusQuery) (models.TransactionResponse, error) {
+	return m.Called().Get(0).(models.TransactionResponse), m.Called().Error(1)
+}
+
 func (m *mockGateway) Name() string {
 	return m.name
 }
@@ -82,7 +105,8 @@ func TestRouter_SendMessage(t *testing.T) {
 			operation: func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
 				return models.TransactionResponse{}, gateway.ErrGatewayUnavailable
 			},
-			expectedError: "all gateways failed: gateway unavailable",
+			expectedResponse: Response{Gateway: "gateway2"},
+			expectedError:    "all gateways failed: gateway unavailable",
 		},
 	}
 
@@ -93,16 +117,16 @@ func TestRouter_SendMessage(t *testing.T) {
 				_ = reg.Register(g.Name(), g)
 			}
 
-			r := NewRouter(reg, gobreaker.Settings{})
+			r := NewRouter(reg, gobreaker.Settings{}, nil, nil, nil)
 
-			response, err := r.SendMessage(ctx, tt.preferredGateway, tt.operation)
+			response, err := r.SendMessage(ctx, models.TransactionRequest{PreferredGateway: tt.preferredGateway}, tt.operation)
 
 			if tt.expectedError != "" {
 				assert.EqualError(t, err, tt.expectedError)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedResponse, response)
 			}
+			assert.Equal(t, tt.expectedResponse, response)
 		})
 	}
 }
@@ -117,7 +141,7 @@ func TestRouter_fallback(t *testing.T) {
 	router := NewRouter(reg, gobreaker.Settings{
 		Name:    "TestCircuitBreaker",
 		Timeout: 5 * time.Second,
-	})
+	}, nil, nil, nil)
 
 	testCases := []struct {
 		name             string
@@ -155,7 +179,7 @@ func TestRouter_fallback(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			ctx := context.Background()
 
-			response, err := router.SendMessage(ctx, tc.preferredGateway, tc.operation)
+			response, err := router.SendMessage(ctx, models.TransactionRequest{PreferredGateway: tc.preferredGateway}, tc.operation)
 
 			require.NoError(t, err)
 			assert.Equal(t, tc.expectedGateway, response.Gateway)
@@ -178,13 +202,13 @@ func TestRouterWithCircuitBreaker(t *testing.T) {
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
 			return counts.ConsecutiveFailures > uint32(failAfter)
 		},
-	})
+	}, nil, nil, nil)
 
 	ctx := context.Background()
 
 	// Test successful requests
 	for i := 0; i < failAfter; i++ {
-		response, err := router.SendMessage(ctx, "MockGateway", func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+		response, err := router.SendMessage(ctx, models.TransactionRequest{PreferredGateway: "MockGateway"}, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
 			return models.TransactionResponse{Gateway: "MockGateway", RefID: "mock-ref-id"}, nil
 		})
 		require.NoError(t, err)
@@ -194,7 +218,7 @@ func TestRouterWithCircuitBreaker(t *testing.T) {
 
 	// Test circuit breaker opening
 	for i := 0; i < 5; i++ {
-		_, err := router.SendMessage(ctx, "MockGateway", func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+		_, err := router.SendMessage(ctx, models.TransactionRequest{PreferredGateway: "MockGateway"}, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
 			return models.TransactionResponse{}, fmt.Errorf("error")
 		})
 		assert.Error(t, err)
@@ -205,10 +229,269 @@ func TestRouterWithCircuitBreaker(t *testing.T) {
 	time.Sleep(2 * time.Second)
 
 	// Test circuit breaker closing and successful request
-	response, err := router.SendMessage(ctx, "MockGateway", func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+	response, err := router.SendMessage(ctx, models.TransactionRequest{PreferredGateway: "MockGateway"}, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
 		return models.TransactionResponse{Gateway: "MockGateway", RefID: "mock-ref-id"}, nil
 	})
 	require.NoError(t, err)
 	assert.Equal(t, "MockGateway", response.Gateway)
 	assert.Equal(t, "mock-ref-id", response.Data.RefID)
 }
+
+func TestRouter_FailoverByErrorKind(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedCalled []string
+		expectedOpen   bool
+	}{
+		{
+			name:           "Unavailable fails over",
+			err:            fmt.Errorf("unexpected HTTP status code: 503: %w", gatewayerr.ErrUnavailable),
+			expectedCalled: []string{"GatewayA", "GatewayB"},
+			expectedOpen:   true,
+		},
+		{
+			name:           "Gateway A issuer unavailable code fails over",
+			err:            fmt.Errorf("transaction not processed: %w", fmt.Errorf("%w: response code 91", gatewayerr.ErrUnavailable)),
+			expectedCalled: []string{"GatewayA", "GatewayB"},
+			expectedOpen:   true,
+		},
+		{
+			name:           "Gateway B timeout status fails over",
+			err:            fmt.Errorf("authorization not processed: %w", fmt.Errorf("%w: response code TIMEOUT", gatewayerr.ErrUnavailable)),
+			expectedCalled: []string{"GatewayA", "GatewayB"},
+			expectedOpen:   true,
+		},
+		{
+			name:           "Invalid request fails over",
+			err:            gatewayerr.ErrInvalidRequest,
+			expectedCalled: []string{"GatewayA", "GatewayB"},
+			expectedOpen:   false,
+		},
+		{
+			name:           "Decline is final",
+			err:            gatewayerr.ErrDecline,
+			expectedCalled: []string{"GatewayA"},
+			expectedOpen:   false,
+		},
+		{
+			name:           "Timeout is final",
+			err:            gatewayerr.ErrTimeout,
+			expectedCalled: []string{"GatewayA"},
+			expectedOpen:   true,
+		},
+		{
+			name:           "Unknown outcome is final",
+			err:            gatewayerr.ErrUnknownOutcome,
+			expectedCalled: []string{"GatewayA"},
+			expectedOpen:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := registry.NewRegistry[gateway.PaymentGateway]()
+			require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+			require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+			router := NewRouter(reg, gobreaker.Settings{
+				Timeout: time.Minute,
+				ReadyToTrip: func(counts gobreaker.Counts) bool {
+					return counts.ConsecutiveFailures > 1
+				},
+			}, nil, nil, nil)
+
+			var called []string
+			for range 2 {
+				called = nil
+				_, err := router.SendMessage(context.Background(), models.TransactionRequest{PreferredGateway: "GatewayA"}, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+					called = append(called, g.Name())
+					if g.Name() == "GatewayA" {
+						return models.TransactionResponse{}, tt.err
+					}
+					return models.TransactionResponse{RefID: "123"}, nil
+				})
+				if len(tt.expectedCalled) == 1 {
+					assert.ErrorIs(t, err, tt.err)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+			assert.Equal(t, tt.expectedCalled, called)
+
+			cb, err := router.getCircuitBreaker("GatewayA")
+			require.NoError(t, err)
+			expectedState := gobreaker.StateClosed
+			if tt.expectedOpen {
+				expectedState = gobreaker.StateOpen
+			}
+			assert.Equal(t, expectedState, cb.State())
+		})
+	}
+}
+
+func TestRouter_IssuerDeclineIsFinal(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	router := NewRouter(reg, gobreaker.Settings{
+		Timeout: time.Minute,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures > 0
+		},
+	}, nil, nil, nil)
+
+	var called []string
+	res, err := router.SendMessage(context.Background(), models.TransactionRequest{PreferredGateway: "GatewayA"}, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+		called = append(called, g.Name())
+		return models.TransactionResponse{RefID: "123", Status: "failed", GatewayStatusCode: "51", DeclineReason: gateway.DeclineReasonInsufficientFunds}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GatewayA"}, called)
+	assert.Equal(t, "GatewayA", res.Gateway)
+	assert.Equal(t, gateway.DeclineReasonInsufficientFunds, res.Data.DeclineReason)
+	cb, err := router.getCircuitBreaker("GatewayA")
+	require.NoError(t, err)
+	assert.Equal(t, gobreaker.StateClosed, cb.State())
+}
+
+func TestRouter_SendMessageAllCircuitsOpen(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	router := NewRouter(reg, gobreaker.Settings{
+		Timeout: time.Minute,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures > 0
+		},
+	}, nil, nil, nil)
+
+	var calls int
+	operation := func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+		calls++
+		return models.TransactionResponse{}, gatewayerr.ErrTimeout
+	}
+	_, err := router.SendMessage(context.Background(), models.TransactionRequest{}, operation)
+	require.ErrorIs(t, err, gatewayerr.ErrTimeout)
+
+	response, err := router.SendMessage(context.Background(), models.TransactionRequest{}, operation)
+	assert.ErrorIs(t, err, gateway.ErrGatewayUnavailable)
+	assert.Empty(t, response.Gateway)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRouter_SendToGateway(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+
+	router := NewRouter(reg, gobreaker.Settings{
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures > 1
+		},
+	}, nil, nil, nil)
+	ctx := context.Background()
+
+	t.Run("sends to the named gateway only", func(t *testing.T) {
+		var called []string
+		err := router.SendToGateway(ctx, "GatewayB", func(g gateway.PaymentGateway) error {
+			called = append(called, g.Name())
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GatewayB"}, called)
+	})
+
+	t.Run("does not fall back on failure", func(t *testing.T) {
+		var called []string
+		err := router.SendToGateway(ctx, "GatewayA", func(g gateway.PaymentGateway) error {
+			called = append(called, g.Name())
+			return gateway.ErrGatewayUnavailable
+		})
+		assert.ErrorIs(t, err, gateway.ErrGatewayUnavailable)
+		assert.Equal(t, []string{"GatewayA"}, called)
+	})
+
+	t.Run("unknown gateway", func(t *testing.T) {
+		err := router.SendToGateway(ctx, "Unknown", func(g gateway.PaymentGateway) error {
+			return nil
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("declines do not open the circuit", func(t *testing.T) {
+		for range 3 {
+			err := router.SendToGateway(ctx, "GatewayB", func(g gateway.PaymentGateway) error {
+				return gatewayerr.ErrDecline
+			})
+			assert.ErrorIs(t, err, gatewayerr.ErrDecline)
+		}
+		err := router.SendToGateway(ctx, "GatewayB", func(g gateway.PaymentGateway) error {
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("circuit open", func(t *testing.T) {
+		_ = router.SendToGateway(ctx, "GatewayA", func(g gateway.PaymentGateway) error {
+			return fmt.Errorf("error")
+		})
+		err := router.SendToGateway(ctx, "GatewayA", func(g gateway.PaymentGateway) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, gateway.ErrGatewayUnavailable)
+	})
+}
+
+func TestRouter_RouteByCost(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	require.NoError(t, reg.Register("GatewayC", &mockGateway{name: "GatewayC"}))
+	require.NoError(t, reg.Register("GatewayD", &mockGateway{name: "GatewayD"}))
+	costs, err := fee.NewSchedules([]fee.Schedule{
+		{Gateway: "GatewayA", Rates: []fee.Rate{{Currency: "USD", Percentage: "3"}}},
+		{Gateway: "GatewayB", Rates: []fee.Rate{{Currency: "USD", Percentage: "2"}}},
+		{Gateway: "GatewayC", Rates: []fee.Rate{{Currency: "USD", Percentage: "1"}}},
+	})
+	require.NoError(t, err)
+	router := NewRouter(reg, gobreaker.Settings{
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures > 0
+		},
+	}, nil, nil, costs)
+
+	// Trip the circuit of gateway C.
+	done, err := router.isRequestAllowed(context.Background(), "GatewayC")
+	require.NoError(t, err)
+	done(false)
+
+	tests := []struct {
+		name             string
+		transaction      models.TransactionRequest
+		expectedGateways []string
+	}{
+		{
+			name:             "Cheapest healthy gateway first",
+			transaction:      models.TransactionRequest{Amount: money.MustParse("100", "USD")},
+			expectedGateways: []string{"GatewayB", "GatewayA", "GatewayD", "GatewayC"},
+		},
+		{
+			name:             "Preferred gateway stays first",
+			transaction:      models.TransactionRequest{Amount: money.MustParse("100", "USD"), PreferredGateway: "GatewayD"},
+			expectedGateways: []string{"GatewayD", "GatewayB", "GatewayA", "GatewayC"},
+		},
+		{
+			name:             "No gateway priced",
+			transaction:      models.TransactionRequest{Amount: money.MustParse("100", "EUR")},
+			expectedGateways: []string{"GatewayA", "GatewayB", "GatewayD", "GatewayC"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := router.Route(tt.transaction)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGateways, gatewayNames(decision.Gateways))
+		})
+	}
+}
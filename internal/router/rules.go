@@ -0,0 +1,290 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+)
+
+var (
+	ErrInvalidRule  = errors.New("invalid routing rule")
+	ErrRuleNotFound = errors.New("routing rule not found")
+)
+
+// RuleSet is the declarative routing configuration. Rules are evaluated in order and the first matching
+// rule picks the gateways of a transaction.
+type RuleSet struct {
+	Rules []Rule `json:"rules"`
+	// Segments lists the customer IDs of each customer segment.
+	Segments map[string][]string `json:"segments,omitempty"`
+}
+
+// Rule sends the transactions matching all its conditions to its gateways, in order. Empty conditions match
+// every transaction.
+type Rule struct {
+	Name           string   `json:"name"`
+	Currencies     []string `json:"currencies,omitempty"`
+	PaymentMethods []string `json:"payment_methods,omitempty"`
+	Types          []string `json:"types,omitempty"`
+	Segments       []string `json:"segments,omitempty"`
+	// MinAmount and MaxAmount bound the amount, including the minimum and excluding the maximum. They need
+	// exactly one currency.
+	MinAmount json.Number `json:"min_amount,omitempty"`
+	MaxAmount json.Number `json:"max_amount,omitempty"`
+	// Metadata requires the metadata of the transaction to have these keys. Keys with a non-empty value
+	// must also have that value.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Gateways []string          `json:"gateways"`
+	// Weights split the traffic of the rule between its gateways: a hash of the customer ID picks the
+	// gateway a customer is sent to first, with a probability proportional to its weight, and the other
+	// gateways follow in order. Gateways without a weight only get failover traffic.
+	Weights map[string]int `json:"weights,omitempty"`
+}
+
+// Rules is a validated rule set, ready to match transactions. The weights of its rules can be changed while
+// it is in use.
+type Rules struct {
+	mu    sync.RWMutex
+	rules []rule
+	// segments are the segments of each customer ID.
+	segments map[string][]string
+}
+
+// rule is a Rule with its amount bounds parsed.
+type rule struct {
+	Rule
+	minAmount, maxAmount money.Money
+}
+
+// LoadRules reads a JSON rule set and validates it.
+func LoadRules(r io.Reader) (*Rules, error) {
+	var set RuleSet
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode routing rules: %w", err)
+	}
+	return NewRules(set)
+}
+
+// NewRules validates the rule set. Every rule needs a unique name and at least one gateway.
+func NewRules(set RuleSet) (*Rules, error) {
+	rules := &Rules{
+		rules:    make([]rule, 0, len(set.Rules)),
+		segments: make(map[string][]string),
+	}
+	for segment, customerIDs := range set.Segments {
+		for _, customerID := range customerIDs {
+			rules.segments[customerID] = append(rules.segments[customerID], segment)
+		}
+	}
+
+	names := make(map[string]struct{}, len(set.Rules))
+	for i, r := range set.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("%w: rule %d has no name", ErrInvalidRule, i)
+		}
+		if _, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate rule %s", ErrInvalidRule, r.Name)
+		}
+		names[r.Name] = struct{}{}
+		if len(r.Gateways) == 0 {
+			return nil, fmt.Errorf("%w: rule %s has no gateways", ErrInvalidRule, r.Name)
+		}
+		if err := validateWeights(r.Gateways, r.Weights); err != nil {
+			return nil, fmt.Errorf("%w: rule %s: %w", ErrInvalidRule, r.Name, err)
+		}
+		for _, segment := range r.Segments {
+			if _, ok := set.Segments[segment]; !ok {
+				return nil, fmt.Errorf("%w: rule %s uses unknown segment %s", ErrInvalidRule, r.Name, segment)
+			}
+		}
+
+		parsed := rule{Rule: r}
+		if r.MinAmount != "" || r.MaxAmount != "" {
+			if len(r.Currencies) != 1 {
+				return nil, fmt.Errorf("%w: rule %s bounds the amount without exactly one currency", ErrInvalidRule, r.Name)
+			}
+			var err error
+			if parsed.minAmount, err = parseBound(r.MinAmount, r.Currencies[0]); err != nil {
+				return nil, fmt.Errorf("%w: min_amount of rule %s: %w", ErrInvalidRule, r.Name, err)
+			}
+			if parsed.maxAmount, err = parseBound(r.MaxAmount, r.Currencies[0]); err != nil {
+				return nil, fmt.Errorf("%w: max_amount of rule %s: %w", ErrInvalidRule, r.Name, err)
+			}
+			if r.MinAmount != "" && r.MaxAmount != "" {
+				if cmp, _ := parsed.minAmount.Cmp(parsed.maxAmount); cmp >= 0 {
+					return nil, fmt.Errorf("%w: rule %s has an empty amount range", ErrInvalidRule, r.Name)
+				}
+			}
+		}
+		rules.rules = append(rules.rules, parsed)
+	}
+	return rules, nil
+}
+
+// List returns the rules in evaluation order.
+func (r *Rules) List() []Rule {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		list = append(list, rule.Rule)
+	}
+	return list
+}
+
+// SetWeights replaces the weights of the rule. Empty weights stop splitting its traffic.
+func (r *Rules) SetWeights(name string, weights map[string]int) error {
+	if r == nil {
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.rules {
+		if r.rules[i].Name != name {
+			continue
+		}
+		if err := validateWeights(r.rules[i].Gateways, weights); err != nil {
+			return fmt.Errorf("%w: rule %s: %w", ErrInvalidRule, name, err)
+		}
+		// Matched rules share the weights map, so it is replaced instead of updated.
+		r.rules[i].Weights = maps.Clone(weights)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrRuleNotFound, name)
+}
+
+// validateWeights checks that the weights are not negative, belong to the gateways and do not sum to zero.
+func validateWeights(gateways []string, weights map[string]int) error {
+	if len(weights) == 0 {
+		return nil
+	}
+	total := 0
+	for gateway, weight := range weights {
+		if !slices.Contains(gateways, gateway) {
+			return fmt.Errorf("weight of %s, which is not one of the gateways", gateway)
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight of %s", gateway)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return errors.New("weights sum to zero")
+	}
+	return nil
+}
+
+// pickWeighted picks the gateway the customer is sent to first, with a probability proportional to its
+// weight. A customer gets the same gateway as long as the weights do not change. It returns an empty name
+// if the rule has no weights.
+func (r Rule) pickWeighted(customerID string) string {
+	total := 0
+	for _, g := range r.Gateways {
+		total += r.Weights[g]
+	}
+	if total == 0 {
+		return ""
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(r.Name + "/" + customerID))
+	n := int(h.Sum64() % uint64(total))
+	for _, g := range r.Gateways {
+		if n < r.Weights[g] {
+			return g
+		}
+		n -= r.Weights[g]
+	}
+	return ""
+}
+
+// parseBound parses an amount bound. An empty bound is the zero Money, i.e. unbounded.
+func parseBound(amount json.Number, currency string) (money.Money, error) {
+	if amount == "" {
+		return money.Money{}, nil
+	}
+	return money.Parse(amount.String(), strings.ToUpper(currency))
+}
+
+// Match returns the first rule matching the transaction.
+func (r *Rules) Match(transaction models.TransactionRequest) (Rule, bool) {
+	if r == nil {
+		return Rule{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var metadata map[string]json.RawMessage
+	// Metadata that is not an object has no keys to match.
+	_ = json.Unmarshal(transaction.Metadata, &metadata)
+
+	for _, rule := range r.rules {
+		if rule.matches(transaction, r.segments[transaction.CustomerID], metadata) {
+			return rule.Rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (r rule) matches(transaction models.TransactionRequest, segments []string, metadata map[string]json.RawMessage) bool {
+	if !containsFold(r.Currencies, transaction.Amount.Currency().String()) ||
+		!containsFold(r.PaymentMethods, transaction.PaymentMethod) ||
+		!containsFold(r.Types, transaction.Type) {
+		return false
+	}
+	if len(r.Segments) > 0 && !slices.ContainsFunc(r.Segments, func(s string) bool { return slices.Contains(segments, s) }) {
+		return false
+	}
+	if r.MinAmount != "" {
+		if cmp, err := transaction.Amount.Cmp(r.minAmount); err != nil || cmp < 0 {
+			return false
+		}
+	}
+	if r.MaxAmount != "" {
+		if cmp, err := transaction.Amount.Cmp(r.maxAmount); err != nil || cmp >= 0 {
+			return false
+		}
+	}
+	for key, want := range r.Metadata {
+		value, ok := metadata[key]
+		if !ok {
+			return false
+		}
+		if want != "" && metadataString(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether the value is in the list, ignoring case. An empty list contains every value.
+func containsFold(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(s, value) })
+}
+
+// metadataString returns a string metadata value without its quotes, and other values as JSON.
+func metadataString(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	return string(value)
+}
@@ -0,0 +1,273 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/registry"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `{
+	"segments": {"vip": ["cust-vip"]},
+	"rules": [
+		{"name": "vip", "segments": ["vip"], "gateways": ["GatewayB"]},
+		{"name": "large-usd-cards", "currencies": ["USD"], "payment_methods": ["card"], "min_amount": 1000, "gateways": ["GatewayB", "GatewayA"]},
+		{"name": "small-eur", "currencies": ["eur"], "max_amount": 50, "types": ["deposit"], "gateways": ["GatewayA"]},
+		{"name": "marketplace", "metadata": {"channel": "marketplace", "order_id": ""}, "gateways": ["GatewayA"]}
+	]
+}`
+
+func TestRules_Match(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(testRules))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		transaction  models.TransactionRequest
+		expectedRule string
+	}{
+		{
+			name:         "Customer segment",
+			transaction:  models.TransactionRequest{CustomerID: "cust-vip", Amount: money.MustParse("10", "USD")},
+			expectedRule: "vip",
+		},
+		{
+			name:         "Amount at the minimum",
+			transaction:  models.TransactionRequest{Amount: money.MustParse("1000", "USD"), PaymentMethod: "CARD"},
+			expectedRule: "large-usd-cards",
+		},
+		{
+			name:        "Amount below the minimum",
+			transaction: models.TransactionRequest{Amount: money.MustParse("999.99", "USD"), PaymentMethod: "card"},
+		},
+		{
+			name:        "Other payment method",
+			transaction: models.TransactionRequest{Amount: money.MustParse("5000", "USD"), PaymentMethod: "wallet"},
+		},
+		{
+			name:         "Amount below the maximum",
+			transaction:  models.TransactionRequest{Type: "deposit", Amount: money.MustParse("49.99", "EUR")},
+			expectedRule: "small-eur",
+		},
+		{
+			name:        "Amount at the maximum",
+			transaction: models.TransactionRequest{Type: "deposit", Amount: money.MustParse("50", "EUR")},
+		},
+		{
+			name:        "Other transaction type",
+			transaction: models.TransactionRequest{Type: "withdrawal", Amount: money.MustParse("10", "EUR")},
+		},
+		{
+			name: "Metadata keys and values",
+			transaction: models.TransactionRequest{
+				Amount:   money.MustParse("10", "GBP"),
+				Metadata: json.RawMessage(`{"channel": "marketplace", "order_id": 42}`),
+			},
+			expectedRule: "marketplace",
+		},
+		{
+			name: "Missing metadata key",
+			transaction: models.TransactionRequest{
+				Amount:   money.MustParse("10", "GBP"),
+				Metadata: json.RawMessage(`{"channel": "marketplace"}`),
+			},
+		},
+		{
+			name: "Other metadata value",
+			transaction: models.TransactionRequest{
+				Amount:   money.MustParse("10", "GBP"),
+				Metadata: json.RawMessage(`{"channel": "web", "order_id": 42}`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := rules.Match(tt.transaction)
+			assert.Equal(t, tt.expectedRule != "", ok)
+			assert.Equal(t, tt.expectedRule, rule.Name)
+		})
+	}
+}
+
+func TestNewRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "No name", rule: Rule{Gateways: []string{"GatewayA"}}},
+		{name: "No gateways", rule: Rule{Name: "r"}},
+		{name: "Unknown segment", rule: Rule{Name: "r", Segments: []string{"vip"}, Gateways: []string{"GatewayA"}}},
+		{name: "Amount without currency", rule: Rule{Name: "r", MinAmount: "10", Gateways: []string{"GatewayA"}}},
+		{name: "Too precise amount", rule: Rule{Name: "r", Currencies: []string{"JPY"}, MinAmount: "10.5", Gateways: []string{"GatewayA"}}},
+		{name: "Empty amount range", rule: Rule{Name: "r", Currencies: []string{"USD"}, MinAmount: "10", MaxAmount: "10", Gateways: []string{"GatewayA"}}},
+		{name: "Weight of another gateway", rule: Rule{Name: "r", Gateways: []string{"GatewayA"}, Weights: map[string]int{"GatewayB": 1}}},
+		{name: "Negative weight", rule: Rule{Name: "r", Gateways: []string{"GatewayA", "GatewayB"}, Weights: map[string]int{"GatewayA": 2, "GatewayB": -1}}},
+		{name: "Zero weights", rule: Rule{Name: "r", Gateways: []string{"GatewayA"}, Weights: map[string]int{"GatewayA": 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRules(RuleSet{Rules: []Rule{tt.rule}})
+			assert.ErrorIs(t, err, ErrInvalidRule)
+		})
+	}
+
+	t.Run("Duplicate name", func(t *testing.T) {
+		rule := Rule{Name: "r", Gateways: []string{"GatewayA"}}
+		_, err := NewRules(RuleSet{Rules: []Rule{rule, rule}})
+		assert.ErrorIs(t, err, ErrInvalidRule)
+	})
+}
+
+func TestRouter_Route(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	rules, err := LoadRules(strings.NewReader(testRules))
+	require.NoError(t, err)
+	router := NewRouter(reg, gobreaker.Settings{}, rules, nil, nil)
+
+	tests := []struct {
+		name             string
+		transaction      models.TransactionRequest
+		expectedRule     string
+		expectedGateways []string
+	}{
+		{
+			name:             "Rule gateways",
+			transaction:      models.TransactionRequest{Amount: money.MustParse("1000", "USD"), PaymentMethod: "card"},
+			expectedRule:     "large-usd-cards",
+			expectedGateways: []string{"GatewayB", "GatewayA"},
+		},
+		{
+			name:             "Preferred gateway first among the rule gateways",
+			transaction:      models.TransactionRequest{Amount: money.MustParse("1000", "USD"), PaymentMethod: "card", PreferredGateway: "GatewayA"},
+			expectedRule:     "large-usd-cards",
+			expectedGateways: []string{"GatewayA", "GatewayB"},
+		},
+		{
+			name:             "Preferred gateway outside the rule gateways",
+			transaction:      models.TransactionRequest{CustomerID: "cust-vip", Amount: money.MustParse("10", "USD"), PreferredGateway: "GatewayA"},
+			expectedRule:     "vip",
+			expectedGateways: []string{"GatewayB"},
+		},
+		{
+			name:             "No matching rule",
+			transaction:      models.TransactionRequest{Amount: money.MustParse("10", "USD"), PreferredGateway: "GatewayB"},
+			expectedGateways: []string{"GatewayB", "GatewayA"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := router.Route(tt.transaction)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedRule, decision.Rule)
+			assert.Equal(t, tt.expectedGateways, gatewayNames(decision.Gateways))
+		})
+	}
+}
+
+func TestRouter_DryRun(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	rules, err := LoadRules(strings.NewReader(testRules))
+	require.NoError(t, err)
+	router := NewRouter(reg, gobreaker.Settings{
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures > 0
+		},
+	}, rules, nil, nil)
+
+	// Trip the circuit of gateway B.
+	done, err := router.isRequestAllowed(context.Background(), "GatewayB")
+	require.NoError(t, err)
+	done(false)
+
+	decision, err := router.DryRun(models.TransactionRequest{Amount: money.MustParse("1000", "USD"), PaymentMethod: "card"})
+	require.NoError(t, err)
+	assert.Equal(t, models.RoutingDecision{
+		Rule: "large-usd-cards",
+		Gateways: []models.RoutedGateway{
+			{Name: "GatewayB", Circuit: "open"},
+			{Name: "GatewayA", Circuit: "closed"},
+		},
+	}, decision)
+}
+
+func TestRouter_WeightedSplit(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	rules, err := NewRules(RuleSet{Rules: []Rule{{
+		Name:     "usd",
+		Gateways: []string{"GatewayA", "GatewayB"},
+		Weights:  map[string]int{"GatewayA": 80, "GatewayB": 20},
+	}}})
+	require.NoError(t, err)
+	router := NewRouter(reg, gobreaker.Settings{}, rules, nil, nil)
+
+	firstGateways := func() map[string]string {
+		first := make(map[string]string)
+		for i := range 10000 {
+			customerID := fmt.Sprintf("cust%d", i)
+			decision, err := router.Route(models.TransactionRequest{CustomerID: customerID, Amount: money.MustParse("10", "USD")})
+			require.NoError(t, err)
+			require.Len(t, decision.Gateways, 2)
+			first[customerID] = decision.Gateways[0].Name()
+		}
+		return first
+	}
+	countOf := func(first map[string]string, name string) int {
+		count := 0
+		for _, g := range first {
+			if g == name {
+				count++
+			}
+		}
+		return count
+	}
+
+	first := firstGateways()
+	assert.InDelta(t, 8000, countOf(first, "GatewayA"), 300)
+
+	t.Run("Customers keep their gateway", func(t *testing.T) {
+		assert.Equal(t, first, firstGateways())
+	})
+
+	t.Run("Preferred gateway first", func(t *testing.T) {
+		for customerID, g := range first {
+			if g != "GatewayA" {
+				continue
+			}
+			decision, err := router.Route(models.TransactionRequest{CustomerID: customerID, Amount: money.MustParse("10", "USD"), PreferredGateway: "GatewayB"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"GatewayB", "GatewayA"}, gatewayNames(decision.Gateways))
+			break
+		}
+	})
+
+	t.Run("Changed weights", func(t *testing.T) {
+		require.NoError(t, router.SetWeights("usd", map[string]int{"GatewayA": 0, "GatewayB": 1}))
+		assert.Equal(t, 10000, countOf(firstGateways(), "GatewayB"))
+
+		require.NoError(t, router.SetWeights("usd", nil))
+		assert.Equal(t, 10000, countOf(firstGateways(), "GatewayA"))
+	})
+
+	t.Run("Invalid weights", func(t *testing.T) {
+		assert.ErrorIs(t, router.SetWeights("usd", map[string]int{"GatewayC": 1}), ErrInvalidRule)
+		assert.ErrorIs(t, router.SetWeights("eur", map[string]int{"GatewayA": 1}), ErrRuleNotFound)
+	})
+}
@@ -0,0 +1,199 @@
+package router
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rauf/payment-service/internal/gateway"
+)
+
+// ScorerConfig tunes the adaptive ranking of the gateways.
+type ScorerConfig struct {
+	// Window is how far back outcomes are counted. It is split into Buckets slots that expire one at a time.
+	Window  time.Duration
+	Buckets int
+	// LatencyTarget is the p95 latency that halves the score of a gateway.
+	LatencyTarget time.Duration
+	// Exploration is the fraction of transactions sent first to a random gateway other than the best scored
+	// one, so that recovering gateways keep getting sampled.
+	Exploration float64
+}
+
+// latencyBounds are the upper bounds of the latency histogram. The p95 latency is reported as the bound of
+// the bucket it falls into.
+var latencyBounds = [...]time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Scorer tracks the rolling success rate and p95 latency of each gateway, per currency and payment method,
+// and ranks the gateways by them.
+type Scorer struct {
+	config  ScorerConfig
+	mu      sync.Mutex
+	windows map[scoreKey]*window
+	now     func() time.Time
+	random  func() float64
+}
+
+type scoreKey struct {
+	gateway, currency, paymentMethod string
+}
+
+// window is a ring of time slots holding the outcomes of one gateway, currency and payment method.
+type window struct {
+	slots []slot
+}
+
+type slot struct {
+	// start is the beginning of the period the slot counts. Slots of earlier periods are stale.
+	start     time.Time
+	successes int
+	failures  int
+	// latencies counts the outcomes per latency bucket, the last one being slower than every bound.
+	latencies [len(latencyBounds) + 1]int
+}
+
+// Score is the standing of a gateway for a currency and payment method over the window.
+type Score struct {
+	Successes  int
+	Failures   int
+	P95Latency time.Duration
+	// Value ranks the gateways, higher is better. It is the success rate, smoothed towards 0.5 while there
+	// are few outcomes, divided by 1 + P95Latency/LatencyTarget.
+	Value float64
+}
+
+func NewScorer(config ScorerConfig) *Scorer {
+	config.Buckets = max(config.Buckets, 1)
+	return &Scorer{
+		config:  config,
+		windows: make(map[scoreKey]*window),
+		now:     time.Now,
+		random:  rand.Float64,
+	}
+}
+
+// Record counts the outcome of a request sent to the gateway.
+func (s *Scorer) Record(gatewayName, currency, paymentMethod string, success bool, latency time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := newScoreKey(gatewayName, currency, paymentMethod)
+	w, ok := s.windows[key]
+	if !ok {
+		w = &window{slots: make([]slot, s.config.Buckets)}
+		s.windows[key] = w
+	}
+
+	slotLength := s.slotLength()
+	start := s.now().Truncate(slotLength)
+	sl := &w.slots[int(start.UnixNano()/int64(slotLength))%len(w.slots)]
+	if !sl.start.Equal(start) {
+		*sl = slot{start: start}
+	}
+	if success {
+		sl.successes++
+	} else {
+		sl.failures++
+	}
+	i, _ := slices.BinarySearch(latencyBounds[:], latency)
+	sl.latencies[i]++
+}
+
+// Score returns the score of the gateway for the currency and payment method.
+func (s *Scorer) Score(gatewayName, currency, paymentMethod string) Score {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var score Score
+	var latencies [len(latencyBounds) + 1]int
+	if w, ok := s.windows[newScoreKey(gatewayName, currency, paymentMethod)]; ok {
+		oldest := s.now().Truncate(s.slotLength()).Add(-s.slotLength() * time.Duration(len(w.slots)-1))
+		for _, sl := range w.slots {
+			if sl.start.Before(oldest) {
+				continue
+			}
+			score.Successes += sl.successes
+			score.Failures += sl.failures
+			for i, n := range sl.latencies {
+				latencies[i] += n
+			}
+		}
+	}
+
+	total := score.Successes + score.Failures
+	if total > 0 {
+		// The rank of the p95 outcome, rounded up.
+		rank := (total*95 + 99) / 100
+		for i, n := range latencies {
+			if rank -= n; rank <= 0 {
+				score.P95Latency = latencyBounds[min(i, len(latencyBounds)-1)]
+				break
+			}
+		}
+	}
+	score.Value = (float64(score.Successes) + 1) / (float64(total) + 2)
+	if s.config.LatencyTarget > 0 {
+		score.Value /= 1 + float64(score.P95Latency)/float64(s.config.LatencyTarget)
+	}
+	return score
+}
+
+// Rank orders the gateways by their score for the currency and payment method, best first. Gateways with
+// the same score keep their order. A share of the calls moves a random other gateway to the front instead.
+func (s *Scorer) Rank(gateways []gateway.PaymentGateway, currency, paymentMethod string) []gateway.PaymentGateway {
+	ranked, _ := s.rank(gateways, currency, paymentMethod)
+	return ranked
+}
+
+// rank is Rank that also returns the name of the gateway it moved to the front to explore it, if any.
+func (s *Scorer) rank(gateways []gateway.PaymentGateway, currency, paymentMethod string) ([]gateway.PaymentGateway, string) {
+	if s == nil || len(gateways) < 2 {
+		return gateways, ""
+	}
+	scores := make(map[string]float64, len(gateways))
+	for _, g := range gateways {
+		scores[g.Name()] = s.Score(g.Name(), currency, paymentMethod).Value
+	}
+	ranked := slices.Clone(gateways)
+	slices.SortStableFunc(ranked, func(a, b gateway.PaymentGateway) int {
+		return cmp.Compare(scores[b.Name()], scores[a.Name()])
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.random() < s.config.Exploration {
+		i := 1 + int(s.random()*float64(len(ranked)-1))
+		explored := ranked[i]
+		return append([]gateway.PaymentGateway{explored}, slices.Delete(ranked, i, i+1)...), explored.Name()
+	}
+	return ranked, ""
+}
+
+func (s *Scorer) slotLength() time.Duration {
+	return max(s.config.Window/time.Duration(s.config.Buckets), time.Nanosecond)
+}
+
+func newScoreKey(gatewayName, currency, paymentMethod string) scoreKey {
+	return scoreKey{
+		gateway:       gatewayName,
+		currency:      strings.ToUpper(currency),
+		paymentMethod: strings.ToLower(paymentMethod),
+	}
+}
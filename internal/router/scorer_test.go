@@ -0,0 +1,241 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/fee"
+	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/registry"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScorer(config ScorerConfig) (*Scorer, *time.Time) {
+	now := time.Date(2024, 9, 29, 12, 0, 0, 0, time.UTC)
+	scorer := NewScorer(config)
+	scorer.now = func() time.Time { return now }
+	scorer.random = func() float64 { return 1 }
+	return scorer, &now
+}
+
+func TestScorer_Score(t *testing.T) {
+	scorer, now := newTestScorer(ScorerConfig{Window: 10 * time.Minute, Buckets: 10, LatencyTarget: time.Second})
+
+	for i := range 20 {
+		scorer.Record("GatewayA", "usd", "CARD", i != 0, 40*time.Millisecond)
+	}
+	scorer.Record("GatewayA", "USD", "card", true, 3*time.Second)
+	scorer.Record("GatewayA", "EUR", "card", false, time.Second)
+
+	score := scorer.Score("GatewayA", "USD", "card")
+	assert.Equal(t, 20, score.Successes)
+	assert.Equal(t, 1, score.Failures)
+	// The 20th fastest of 21 outcomes.
+	assert.Equal(t, 50*time.Millisecond, score.P95Latency)
+	assert.InDelta(t, 21.0/23/1.05, score.Value, 1e-9)
+
+	t.Run("Other bucket", func(t *testing.T) {
+		score := scorer.Score("GatewayA", "EUR", "card")
+		assert.Equal(t, 0, score.Successes)
+		assert.Equal(t, 1, score.Failures)
+		assert.Equal(t, time.Second, score.P95Latency)
+	})
+
+	t.Run("No outcomes", func(t *testing.T) {
+		assert.Equal(t, Score{Value: 0.5}, scorer.Score("GatewayB", "USD", "card"))
+	})
+
+	t.Run("Slow outcomes", func(t *testing.T) {
+		for range 5 {
+			scorer.Record("GatewayC", "USD", "card", true, time.Minute)
+		}
+		assert.Equal(t, 10*time.Second, scorer.Score("GatewayC", "USD", "card").P95Latency)
+	})
+
+	t.Run("Expired outcomes", func(t *testing.T) {
+		*now = now.Add(5 * time.Minute)
+		scorer.Record("GatewayA", "USD", "card", false, 40*time.Millisecond)
+		assert.Equal(t, 20, scorer.Score("GatewayA", "USD", "card").Successes)
+
+		*now = now.Add(5 * time.Minute)
+		score := scorer.Score("GatewayA", "USD", "card")
+		assert.Equal(t, 0, score.Successes)
+		assert.Equal(t, 1, score.Failures)
+	})
+}
+
+func TestScorer_Rank(t *testing.T) {
+	gateways := []gateway.PaymentGateway{
+		&mockGateway{name: "GatewayA"},
+		&mockGateway{name: "GatewayB"},
+		&mockGateway{name: "GatewayC"},
+	}
+	scorer, _ := newTestScorer(ScorerConfig{Window: time.Minute, Buckets: 6, LatencyTarget: time.Second, Exploration: 0.1})
+	for range 10 {
+		scorer.Record("GatewayA", "USD", "card", false, 100*time.Millisecond)
+		scorer.Record("GatewayC", "USD", "card", true, 100*time.Millisecond)
+	}
+
+	tests := []struct {
+		name          string
+		currency      string
+		random        []float64
+		expectedNames []string
+	}{
+		{
+			name:          "Best score first",
+			currency:      "USD",
+			random:        []float64{0.5},
+			expectedNames: []string{"GatewayC", "GatewayB", "GatewayA"},
+		},
+		{
+			name:          "Exploration",
+			currency:      "USD",
+			random:        []float64{0.05, 0.9},
+			expectedNames: []string{"GatewayA", "GatewayC", "GatewayB"},
+		},
+		{
+			name:          "Same scores keep their order",
+			currency:      "EUR",
+			random:        []float64{0.5},
+			expectedNames: []string{"GatewayA", "GatewayB", "GatewayC"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			random := tt.random
+			scorer.random = func() float64 {
+				r := random[0]
+				random = random[1:]
+				return r
+			}
+			assert.Equal(t, tt.expectedNames, gatewayNames(scorer.Rank(gateways, tt.currency, "card")))
+			assert.Empty(t, random)
+		})
+	}
+}
+
+func TestRouter_RouteWithScorer(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	require.NoError(t, reg.Register("GatewayC", &mockGateway{name: "GatewayC"}))
+	scorer, _ := newTestScorer(ScorerConfig{Window: time.Minute, Buckets: 6})
+	for range 10 {
+		scorer.Record("GatewayA", "USD", "card", false, 100*time.Millisecond)
+	}
+	router := NewRouter(reg, gobreaker.Settings{}, nil, scorer, nil)
+
+	tests := []struct {
+		name             string
+		preferredGateway string
+		expectedGateways []string
+	}{
+		{
+			name:             "Ranked by score",
+			expectedGateways: []string{"GatewayB", "GatewayC", "GatewayA"},
+		},
+		{
+			name:             "Preferred gateway stays first",
+			preferredGateway: "GatewayA",
+			expectedGateways: []string{"GatewayA", "GatewayB", "GatewayC"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := router.Route(models.TransactionRequest{
+				Amount:           money.MustParse("10", "USD"),
+				PaymentMethod:    "card",
+				PreferredGateway: tt.preferredGateway,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGateways, gatewayNames(decision.Gateways))
+		})
+	}
+
+	t.Run("Attempts are recorded", func(t *testing.T) {
+		transaction := models.TransactionRequest{Amount: money.MustParse("10", "USD"), PaymentMethod: "card"}
+		_, err := router.SendMessage(context.Background(), transaction, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+			if g.Name() == "GatewayB" {
+				return models.TransactionResponse{}, gateway.ErrGatewayUnavailable
+			}
+			return models.TransactionResponse{Status: "FAILED"}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, scorer.Score("GatewayB", "USD", "card").Failures)
+		assert.Equal(t, 1, scorer.Score("GatewayC", "USD", "card").Failures)
+	})
+}
+
+func TestRouter_RouteByCostKeepsExploredGatewayFirst(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	require.NoError(t, reg.Register("GatewayC", &mockGateway{name: "GatewayC"}))
+	costs, err := fee.NewSchedules([]fee.Schedule{
+		{Gateway: "GatewayA", Rates: []fee.Rate{{Currency: "USD", Percentage: "3"}}},
+		{Gateway: "GatewayB", Rates: []fee.Rate{{Currency: "USD", Percentage: "2"}}},
+		{Gateway: "GatewayC", Rates: []fee.Rate{{Currency: "USD", Percentage: "1"}}},
+	})
+	require.NoError(t, err)
+	scorer, _ := newTestScorer(ScorerConfig{Window: time.Minute, Buckets: 6, Exploration: 0.1})
+	router := NewRouter(reg, gobreaker.Settings{}, nil, scorer, costs)
+
+	tests := []struct {
+		name             string
+		random           []float64
+		expectedGateways []string
+	}{
+		{
+			name:             "Cheapest first",
+			random:           []float64{0.5},
+			expectedGateways: []string{"GatewayC", "GatewayB", "GatewayA"},
+		},
+		{
+			name:             "Explored gateway first",
+			random:           []float64{0.05, 0.1},
+			expectedGateways: []string{"GatewayB", "GatewayC", "GatewayA"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			random := tt.random
+			scorer.random = func() float64 {
+				r := random[0]
+				random = random[1:]
+				return r
+			}
+			decision, err := router.Route(models.TransactionRequest{Amount: money.MustParse("100", "USD"), PaymentMethod: "card"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGateways, gatewayNames(decision.Gateways))
+			assert.Empty(t, random)
+		})
+	}
+}
+
+func TestRouter_RuleGatewaysAreNotRanked(t *testing.T) {
+	reg := registry.NewRegistry[gateway.PaymentGateway]()
+	require.NoError(t, reg.Register("GatewayA", &mockGateway{name: "GatewayA"}))
+	require.NoError(t, reg.Register("GatewayB", &mockGateway{name: "GatewayB"}))
+	scorer, _ := newTestScorer(ScorerConfig{Window: time.Minute, Buckets: 6})
+	for range 10 {
+		scorer.Record("GatewayA", "USD", "card", false, 100*time.Millisecond)
+	}
+	rules, err := NewRules(RuleSet{Rules: []Rule{{Name: "usd", Currencies: []string{"USD"}, Gateways: []string{"GatewayA", "GatewayB"}}}})
+	require.NoError(t, err)
+	router := NewRouter(reg, gobreaker.Settings{}, rules, scorer, nil)
+
+	decision, err := router.Route(models.TransactionRequest{Amount: money.MustParse("10", "USD"), PaymentMethod: "card"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "usd", decision.Rule)
+	assert.Equal(t, []string{"GatewayA", "GatewayB"}, gatewayNames(decision.Gateways))
+}
@@ -1,18 +1,584 @@
 package serde
 
-import "io"
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
 
+// ISO8583Serde serializes and deserializes ISO 8583 messages according to a field spec.
+//
+// Data can either be an ISO8583Message or a struct whose fields are tagged with `iso8583:"mti"` for the
+// message type indicator and `iso8583:"<field number>"` for the data elements. The bitmaps are derived from
+// the fields that are set and must not be provided by the caller.
 type ISO8583Serde struct {
+	spec ISO8583Spec
 }
 
-func NewISO8583Serde() *ISO8583Serde {
-	return &ISO8583Serde{}
+// ISO8583Message is a generic ISO 8583 message. Binary fields hold their value as a hex string.
+type ISO8583Message struct {
+	MTI    string
+	Fields map[int]string
+}
+
+func NewISO8583Serde(spec ISO8583Spec) *ISO8583Serde {
+	return &ISO8583Serde{
+		spec: spec,
+	}
 }
 
 func (h *ISO8583Serde) Serialize(w io.Writer, data any) error {
-	return nil
+	msg, err := toISO8583Message(data)
+	if err != nil {
+		return err
+	}
+	packed, err := h.pack(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(packed)
+	return err
 }
 
 func (h *ISO8583Serde) Deserialize(r io.Reader, v any) error {
+	msg, err := h.unpack(bufio.NewReader(r))
+	if err != nil {
+		return err
+	}
+	return fromISO8583Message(msg, v)
+}
+
+// CorrelationKey returns a function that extracts the given fields from a packed message, joined
+// with '|'. It is meant for matching responses to requests on a multiplexed link, so the MTI is
+// left out as it differs between a request and its response.
+func (h *ISO8583Serde) CorrelationKey(fields ...int) func([]byte) (string, error) {
+	return func(data []byte) (string, error) {
+		msg, err := h.unpack(bufio.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return "", err
+		}
+		values := make([]string, len(fields))
+		for i, field := range fields {
+			value, ok := msg.Fields[field]
+			if !ok {
+				return "", fmt.Errorf("correlation field %d is missing", field)
+			}
+			values[i] = value
+		}
+		return strings.Join(values, "|"), nil
+	}
+}
+
+func (h *ISO8583Serde) pack(msg ISO8583Message) ([]byte, error) {
+	if err := h.spec.checkMTI(msg.MTI); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	mti, err := encodeDigits(msg.MTI, h.spec.MTIEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MTI: %w", err)
+	}
+	buf.Write(mti)
+
+	numbers := make([]int, 0, len(msg.Fields))
+	for n := range msg.Fields {
+		if n == 1 {
+			return nil, errors.New("field 1 is the secondary bitmap and is set automatically")
+		}
+		if n < 2 || n > 128 {
+			return nil, fmt.Errorf("field %d is out of range", n)
+		}
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	bitmap := make([]byte, 8)
+	if len(numbers) > 0 && numbers[len(numbers)-1] > 64 {
+		bitmap = make([]byte, 16)
+		setBit(bitmap, 1)
+	}
+	for _, n := range numbers {
+		setBit(bitmap, n)
+	}
+	buf.Write(h.spec.encodeBitmap(bitmap[:8]))
+	if len(bitmap) == 16 {
+		buf.Write(h.spec.encodeBitmap(bitmap[8:]))
+	}
+
+	for _, n := range numbers {
+		fieldSpec, ok := h.spec.Fields[n]
+		if !ok {
+			return nil, fmt.Errorf("field %d is not defined in the spec", n)
+		}
+		packed, err := fieldSpec.pack(msg.Fields[n])
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", n, err)
+		}
+		buf.Write(packed)
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *ISO8583Serde) unpack(r *bufio.Reader) (ISO8583Message, error) {
+	mti, err := readDigits(r, 4, h.spec.MTIEncoding)
+	if err != nil {
+		return ISO8583Message{}, fmt.Errorf("failed to read MTI: %w", err)
+	}
+	if err := h.spec.checkMTI(mti); err != nil {
+		return ISO8583Message{}, err
+	}
+
+	bitmap, err := h.spec.readBitmap(r)
+	if err != nil {
+		return ISO8583Message{}, fmt.Errorf("failed to read primary bitmap: %w", err)
+	}
+	if bitIsSet(bitmap, 1) {
+		secondary, err := h.spec.readBitmap(r)
+		if err != nil {
+			return ISO8583Message{}, fmt.Errorf("failed to read secondary bitmap: %w", err)
+		}
+		bitmap = append(bitmap, secondary...)
+	}
+
+	msg := ISO8583Message{
+		MTI:    mti,
+		Fields: make(map[int]string),
+	}
+	for n := 2; n <= len(bitmap)*8; n++ {
+		if !bitIsSet(bitmap, n) {
+			continue
+		}
+		fieldSpec, ok := h.spec.Fields[n]
+		if !ok {
+			return ISO8583Message{}, fmt.Errorf("field %d is not defined in the spec", n)
+		}
+		value, err := fieldSpec.unpack(r)
+		if err != nil {
+			return ISO8583Message{}, fmt.Errorf("field %d: %w", n, err)
+		}
+		msg.Fields[n] = value
+	}
+	return msg, nil
+}
+
+func (s ISO8583Spec) checkMTI(mti string) error {
+	if len(mti) != 4 || !isDigits(mti) {
+		return fmt.Errorf("invalid MTI %q: must be 4 digits", mti)
+	}
+	var expected byte
+	switch s.Version {
+	case ISO8583Version1987:
+		expected = '0'
+	case ISO8583Version1993:
+		expected = '1'
+	default:
+		return nil
+	}
+	if mti[0] != expected {
+		return fmt.Errorf("invalid MTI %q: version digit must be %c for ISO 8583:%d", mti, expected, s.Version)
+	}
+	return nil
+}
+
+func (s ISO8583Spec) encodeBitmap(bitmap []byte) []byte {
+	if s.BitmapEncoding == ISO8583EncodingASCII {
+		return []byte(strings.ToUpper(hex.EncodeToString(bitmap)))
+	}
+	return bitmap
+}
+
+func (s ISO8583Spec) readBitmap(r io.Reader) ([]byte, error) {
+	if s.BitmapEncoding == ISO8583EncodingASCII {
+		raw, err := readN(r, 16)
+		if err != nil {
+			return nil, err
+		}
+		return hex.DecodeString(string(raw))
+	}
+	return readN(r, 8)
+}
+
+func (f ISO8583FieldSpec) pack(value string) ([]byte, error) {
+	content, length, err := f.encodeContent(value)
+	if err != nil {
+		return nil, err
+	}
+	if length > f.Length {
+		return nil, fmt.Errorf("value length %d exceeds maximum length %d", length, f.Length)
+	}
+	if f.LengthType == ISO8583Fixed {
+		return content, nil
+	}
+
+	prefix, err := encodeLength(length, f.LengthType.digits(), f.lengthEncoding())
+	if err != nil {
+		return nil, err
+	}
+	return append(prefix, content...), nil
+}
+
+// encodeContent encodes the value and returns it with its logical length (digits, characters or bytes).
+func (f ISO8583FieldSpec) encodeContent(value string) ([]byte, int, error) {
+	if f.Type == ISO8583TypeBinary {
+		raw, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("binary value must be hex encoded: %w", err)
+		}
+		if f.LengthType == ISO8583Fixed {
+			if len(raw) > f.Length {
+				return nil, 0, fmt.Errorf("value length %d exceeds maximum length %d", len(raw), f.Length)
+			}
+			raw = append(raw, make([]byte, f.Length-len(raw))...)
+		}
+		if f.Encoding == ISO8583EncodingASCII {
+			return []byte(strings.ToUpper(hex.EncodeToString(raw))), len(raw), nil
+		}
+		return raw, len(raw), nil
+	}
+
+	if err := f.Type.validate(value); err != nil {
+		return nil, 0, err
+	}
+	if f.LengthType == ISO8583Fixed && len(value) < f.Length {
+		if f.Type == ISO8583TypeNumeric {
+			value = strings.Repeat("0", f.Length-len(value)) + value
+		} else {
+			value += strings.Repeat(" ", f.Length-len(value))
+		}
+	}
+	if f.Encoding == ISO8583EncodingBCD {
+		encoded, err := encodeDigits(value, ISO8583EncodingBCD)
+		return encoded, len(value), err
+	}
+	return []byte(value), len(value), nil
+}
+
+func (f ISO8583FieldSpec) unpack(r *bufio.Reader) (string, error) {
+	length := f.Length
+	if f.LengthType != ISO8583Fixed {
+		var err error
+		length, err = readLength(r, f.LengthType.digits(), f.lengthEncoding())
+		if err != nil {
+			return "", fmt.Errorf("failed to read length: %w", err)
+		}
+		if length > f.Length {
+			return "", fmt.Errorf("value length %d exceeds maximum length %d", length, f.Length)
+		}
+	}
+
+	switch {
+	case f.Type == ISO8583TypeBinary && f.Encoding == ISO8583EncodingASCII:
+		raw, err := readN(r, length*2)
+		if err != nil {
+			return "", err
+		}
+		if _, err := hex.DecodeString(string(raw)); err != nil {
+			return "", fmt.Errorf("invalid hex value: %w", err)
+		}
+		return strings.ToUpper(string(raw)), nil
+	case f.Type == ISO8583TypeBinary:
+		raw, err := readN(r, length)
+		if err != nil {
+			return "", err
+		}
+		return strings.ToUpper(hex.EncodeToString(raw)), nil
+	case f.Encoding == ISO8583EncodingBCD:
+		return readDigits(r, length, ISO8583EncodingBCD)
+	default:
+		raw, err := readN(r, length)
+		if err != nil {
+			return "", err
+		}
+		value := string(raw)
+		if err := f.Type.validate(value); err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+}
+
+func (f ISO8583FieldSpec) lengthEncoding() ISO8583Encoding {
+	if f.LengthEncoding != "" {
+		return f.LengthEncoding
+	}
+	if f.Encoding == ISO8583EncodingBCD {
+		return ISO8583EncodingBCD
+	}
+	return ISO8583EncodingASCII
+}
+
+func (t ISO8583FieldType) validate(value string) error {
+	for _, c := range value {
+		var ok bool
+		switch t {
+		case ISO8583TypeNumeric:
+			ok = c >= '0' && c <= '9'
+		case ISO8583TypeAlpha:
+			ok = isLetter(c) || c == ' '
+		case ISO8583TypeAlphaNumeric:
+			ok = isLetter(c) || (c >= '0' && c <= '9') || c == ' '
+		case ISO8583TypeTrack:
+			ok = (c >= '0' && c <= '9') || c == '=' || c == 'D' || c == 'd'
+		default:
+			ok = c >= 0x20 && c <= 0x7e
+		}
+		if !ok {
+			return fmt.Errorf("invalid character %q for field type %s", c, t)
+		}
+	}
+	return nil
+}
+
+func (l ISO8583LengthType) digits() int {
+	if l == ISO8583LLLVar {
+		return 3
+	}
+	return 2
+}
+
+func encodeLength(length, digits int, encoding ISO8583Encoding) ([]byte, error) {
+	switch encoding {
+	case ISO8583EncodingBinary:
+		if digits == 2 {
+			return []byte{byte(length)}, nil
+		}
+		return []byte{byte(length >> 8), byte(length)}, nil
+	default:
+		return encodeDigits(fmt.Sprintf("%0*d", digits, length), encoding)
+	}
+}
+
+func readLength(r io.Reader, digits int, encoding ISO8583Encoding) (int, error) {
+	if encoding == ISO8583EncodingBinary {
+		raw, err := readN(r, digits-1)
+		if err != nil {
+			return 0, err
+		}
+		if len(raw) == 1 {
+			return int(raw[0]), nil
+		}
+		return int(raw[0])<<8 | int(raw[1]), nil
+	}
+	value, err := readDigits(r, digits, encoding)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// encodeDigits encodes a numeric string as ASCII or packed BCD. Odd lengths are left padded with a zero nibble.
+func encodeDigits(value string, encoding ISO8583Encoding) ([]byte, error) {
+	if !isDigits(value) {
+		return nil, fmt.Errorf("%q is not numeric", value)
+	}
+	if encoding != ISO8583EncodingBCD {
+		return []byte(value), nil
+	}
+	if len(value)%2 != 0 {
+		value = "0" + value
+	}
+	out := make([]byte, len(value)/2)
+	for i := range out {
+		out[i] = (value[2*i]-'0')<<4 | (value[2*i+1] - '0')
+	}
+	return out, nil
+}
+
+// readDigits reads a numeric string of the given number of digits encoded as ASCII or packed BCD.
+func readDigits(r io.Reader, digits int, encoding ISO8583Encoding) (string, error) {
+	if encoding != ISO8583EncodingBCD {
+		raw, err := readN(r, digits)
+		if err != nil {
+			return "", err
+		}
+		if !isDigits(string(raw)) {
+			return "", fmt.Errorf("%q is not numeric", raw)
+		}
+		return string(raw), nil
+	}
+
+	raw, err := readN(r, (digits+1)/2)
+	if err != nil {
+		return "", err
+	}
+	out := make([]byte, 0, len(raw)*2)
+	for _, b := range raw {
+		hi, lo := b>>4, b&0x0f
+		if hi > 9 || lo > 9 {
+			return "", fmt.Errorf("invalid BCD byte %#x", b)
+		}
+		out = append(out, '0'+hi, '0'+lo)
+	}
+	return string(out[len(out)-digits:]), nil
+}
+
+func readN(r io.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("failed to read %d bytes: %w", n, err)
+	}
+	return buf, nil
+}
+
+func setBit(bitmap []byte, n int) {
+	bitmap[(n-1)/8] |= 0x80 >> ((n - 1) % 8)
+}
+
+func bitIsSet(bitmap []byte, n int) bool {
+	return bitmap[(n-1)/8]&(0x80>>((n-1)%8)) != 0
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// toISO8583Message converts the data passed to Serialize into a message.
+func toISO8583Message(data any) (ISO8583Message, error) {
+	switch m := data.(type) {
+	case ISO8583Message:
+		return m, nil
+	case *ISO8583Message:
+		if m == nil {
+			return ISO8583Message{}, errors.New("nil ISO 8583 message")
+		}
+		return *m, nil
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ISO8583Message{}, errors.New("nil ISO 8583 message")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ISO8583Message{}, fmt.Errorf("unsupported type %T for ISO 8583 serialization", data)
+	}
+
+	msg := ISO8583Message{Fields: make(map[int]string)}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("iso8583")
+		if !ok || tag == "-" {
+			continue
+		}
+		value, set, err := fieldToString(v.Field(i))
+		if err != nil {
+			return ISO8583Message{}, fmt.Errorf("field %s: %w", t.Field(i).Name, err)
+		}
+		if tag == "mti" {
+			msg.MTI = value
+			continue
+		}
+		n, err := strconv.Atoi(tag)
+		if err != nil {
+			return ISO8583Message{}, fmt.Errorf("field %s: invalid iso8583 tag %q", t.Field(i).Name, tag)
+		}
+		if set {
+			msg.Fields[n] = value
+		}
+	}
+	return msg, nil
+}
+
+// fromISO8583Message stores the message into v, which must be a pointer to an ISO8583Message or a tagged struct.
+func fromISO8583Message(msg ISO8583Message, v any) error {
+	if m, ok := v.(*ISO8583Message); ok {
+		*m = msg
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported type %T for ISO 8583 deserialization, expected pointer to struct", v)
+	}
+	rv = rv.Elem()
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("iso8583")
+		if !ok || tag == "-" {
+			continue
+		}
+		var value string
+		if tag == "mti" {
+			value = msg.MTI
+		} else {
+			n, err := strconv.Atoi(tag)
+			if err != nil {
+				return fmt.Errorf("field %s: invalid iso8583 tag %q", t.Field(i).Name, tag)
+			}
+			if value, ok = msg.Fields[n]; !ok {
+				continue
+			}
+		}
+		if err := stringToField(value, rv.Field(i)); err != nil {
+			return fmt.Errorf("field %s: %w", t.Field(i).Name, err)
+		}
+	}
+	return nil
+}
+
+// fieldToString returns the string value of a struct field and whether it is set (non-zero).
+func fieldToString(v reflect.Value) (string, bool, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), v.String() != "", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), v.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), v.Uint() != 0, nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return hex.EncodeToString(v.Bytes()), v.Len() > 0, nil
+		}
+	}
+	return "", false, fmt.Errorf("unsupported field type %s", v.Type())
+}
+
+func stringToField(value string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported field type %s", v.Type())
+		}
+		raw, err := hex.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(raw)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
 	return nil
 }
@@ -0,0 +1,383 @@
+package serde
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+)
+
+// ISO8583Version is the version of the ISO 8583 standard. It is checked against the first digit of the MTI.
+type ISO8583Version int
+
+const (
+	ISO8583Version1987 ISO8583Version = 1987
+	ISO8583Version1993 ISO8583Version = 1993
+)
+
+// ISO8583Encoding is the encoding used on the wire for MTI, bitmaps, lengths and field values.
+type ISO8583Encoding string
+
+const (
+	ISO8583EncodingASCII  ISO8583Encoding = "ascii"
+	ISO8583EncodingBCD    ISO8583Encoding = "bcd"
+	ISO8583EncodingBinary ISO8583Encoding = "binary"
+)
+
+// ISO8583LengthType tells whether a field has a fixed length or a 2 or 3 digit length prefix.
+type ISO8583LengthType string
+
+const (
+	ISO8583Fixed  ISO8583LengthType = "fixed"
+	ISO8583LLVar  ISO8583LengthType = "llvar"
+	ISO8583LLLVar ISO8583LengthType = "lllvar"
+)
+
+// ISO8583FieldType is the content type of a field as defined by the standard.
+type ISO8583FieldType string
+
+const (
+	ISO8583TypeNumeric      ISO8583FieldType = "n"
+	ISO8583TypeAlpha        ISO8583FieldType = "a"
+	ISO8583TypeAlphaNumeric ISO8583FieldType = "an"
+	ISO8583TypeAlphaNumSpec ISO8583FieldType = "ans"
+	ISO8583TypeTrack        ISO8583FieldType = "z"
+	ISO8583TypeBinary       ISO8583FieldType = "b"
+)
+
+const (
+	iso8583MinFieldNumber  = 2
+	iso8583MaxFieldNumber  = 128
+	iso8583MaxLLVarLength  = 99
+	iso8583MaxLLLVarLength = 999
+)
+
+// ISO8583FieldSpec describes a single data element. Length is the exact length of fixed fields and the maximum
+// length of variable fields, counted in digits for numeric fields, bytes for binary fields and characters otherwise.
+type ISO8583FieldSpec struct {
+	Description    string            `json:"description,omitempty"`
+	Type           ISO8583FieldType  `json:"type"`
+	Length         int               `json:"length"`
+	LengthType     ISO8583LengthType `json:"length_type,omitempty"`
+	Encoding       ISO8583Encoding   `json:"encoding,omitempty"`
+	LengthEncoding ISO8583Encoding   `json:"length_encoding,omitempty"`
+}
+
+// ISO8583Spec describes the layout of the messages exchanged with a host.
+type ISO8583Spec struct {
+	Version        ISO8583Version           `json:"version"`
+	MTIEncoding    ISO8583Encoding          `json:"mti_encoding"`
+	BitmapEncoding ISO8583Encoding          `json:"bitmap_encoding"`
+	Fields         map[int]ISO8583FieldSpec `json:"fields"`
+}
+
+// iso8583SpecFile is the file representation of a spec. JSON object keys are strings, so the field numbers are parsed separately.
+type iso8583SpecFile struct {
+	Version        ISO8583Version              `json:"version"`
+	MTIEncoding    ISO8583Encoding             `json:"mti_encoding"`
+	BitmapEncoding ISO8583Encoding             `json:"bitmap_encoding"`
+	Fields         map[string]ISO8583FieldSpec `json:"fields"`
+}
+
+// LoadISO8583Spec reads a JSON spec, fills in the defaults and validates it.
+func LoadISO8583Spec(r io.Reader) (ISO8583Spec, error) {
+	var file iso8583SpecFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return ISO8583Spec{}, fmt.Errorf("failed to decode ISO 8583 spec: %w", err)
+	}
+
+	spec := ISO8583Spec{
+		Version:        file.Version,
+		MTIEncoding:    file.MTIEncoding,
+		BitmapEncoding: file.BitmapEncoding,
+		Fields:         make(map[int]ISO8583FieldSpec, len(file.Fields)),
+	}
+	for key, field := range file.Fields {
+		n, err := strconv.Atoi(key)
+		if err != nil {
+			return ISO8583Spec{}, fmt.Errorf("invalid field number %q", key)
+		}
+		spec.Fields[n] = field
+	}
+	spec = spec.withDefaults()
+	if err := spec.Validate(); err != nil {
+		return ISO8583Spec{}, err
+	}
+	return spec, nil
+}
+
+// LoadISO8583SpecFile reads a JSON spec from the given file.
+func LoadISO8583SpecFile(path string) (ISO8583Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ISO8583Spec{}, fmt.Errorf("failed to open ISO 8583 spec: %w", err)
+	}
+	defer f.Close()
+	return LoadISO8583Spec(f)
+}
+
+// Validate checks that the spec can be used to pack and unpack messages.
+func (s ISO8583Spec) Validate() error {
+	if s.Version != 0 && s.Version != ISO8583Version1987 && s.Version != ISO8583Version1993 {
+		return fmt.Errorf("unsupported ISO 8583 version %d", s.Version)
+	}
+	if s.MTIEncoding != ISO8583EncodingASCII && s.MTIEncoding != ISO8583EncodingBCD {
+		return fmt.Errorf("unsupported MTI encoding %q", s.MTIEncoding)
+	}
+	if s.BitmapEncoding != ISO8583EncodingASCII && s.BitmapEncoding != ISO8583EncodingBinary {
+		return fmt.Errorf("unsupported bitmap encoding %q", s.BitmapEncoding)
+	}
+	for n, f := range s.Fields {
+		if err := f.validate(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f ISO8583FieldSpec) validate(n int) error {
+	if n < iso8583MinFieldNumber || n > iso8583MaxFieldNumber {
+		return fmt.Errorf("field %d: field number must be between %d and %d", n, iso8583MinFieldNumber, iso8583MaxFieldNumber)
+	}
+	if f.Length <= 0 {
+		return fmt.Errorf("field %d: length must be positive", n)
+	}
+	switch f.Type {
+	case ISO8583TypeNumeric, ISO8583TypeAlpha, ISO8583TypeAlphaNumeric, ISO8583TypeAlphaNumSpec, ISO8583TypeTrack, ISO8583TypeBinary:
+	default:
+		return fmt.Errorf("field %d: unsupported type %q", n, f.Type)
+	}
+	switch f.LengthType {
+	case ISO8583Fixed:
+	case ISO8583LLVar:
+		if f.Length > iso8583MaxLLVarLength {
+			return fmt.Errorf("field %d: LLVAR length cannot exceed %d", n, iso8583MaxLLVarLength)
+		}
+	case ISO8583LLLVar:
+		if f.Length > iso8583MaxLLLVarLength {
+			return fmt.Errorf("field %d: LLLVAR length cannot exceed %d", n, iso8583MaxLLLVarLength)
+		}
+	default:
+		return fmt.Errorf("field %d: unsupported length type %q", n, f.LengthType)
+	}
+	switch f.Encoding {
+	case ISO8583EncodingASCII:
+	case ISO8583EncodingBCD:
+		if f.Type != ISO8583TypeNumeric {
+			return fmt.Errorf("field %d: BCD encoding is only supported for numeric fields", n)
+		}
+	case ISO8583EncodingBinary:
+		if f.Type != ISO8583TypeBinary {
+			return fmt.Errorf("field %d: binary encoding is only supported for binary fields", n)
+		}
+	default:
+		return fmt.Errorf("field %d: unsupported encoding %q", n, f.Encoding)
+	}
+	switch f.LengthEncoding {
+	case "", ISO8583EncodingASCII, ISO8583EncodingBCD, ISO8583EncodingBinary:
+	default:
+		return fmt.Errorf("field %d: unsupported length encoding %q", n, f.LengthEncoding)
+	}
+	return nil
+}
+
+func (s ISO8583Spec) withDefaults() ISO8583Spec {
+	if s.MTIEncoding == "" {
+		s.MTIEncoding = ISO8583EncodingASCII
+	}
+	if s.BitmapEncoding == "" {
+		s.BitmapEncoding = ISO8583EncodingBinary
+	}
+	for n, f := range s.Fields {
+		if f.LengthType == "" {
+			f.LengthType = ISO8583Fixed
+		}
+		if f.Encoding == "" {
+			f.Encoding = ISO8583EncodingASCII
+			if f.Type == ISO8583TypeBinary {
+				f.Encoding = ISO8583EncodingBinary
+			}
+		}
+		s.Fields[n] = f
+	}
+	return s
+}
+
+// WithEncoding returns a copy of the spec that uses the given encoding for the MTI, numeric fields and
+// variable lengths. Binary bitmaps and binary fields are kept as they are.
+func (s ISO8583Spec) WithEncoding(encoding ISO8583Encoding) ISO8583Spec {
+	fields := make(map[int]ISO8583FieldSpec, len(s.Fields))
+	for n, f := range s.Fields {
+		if f.Type == ISO8583TypeNumeric {
+			f.Encoding = encoding
+		}
+		if f.Type != ISO8583TypeBinary {
+			f.LengthEncoding = encoding
+		}
+		fields[n] = f
+	}
+	s.MTIEncoding = encoding
+	s.Fields = fields
+	return s
+}
+
+func fixed(t ISO8583FieldType, length int, description string) ISO8583FieldSpec {
+	return ISO8583FieldSpec{Description: description, Type: t, Length: length, LengthType: ISO8583Fixed}
+}
+
+func llvar(t ISO8583FieldType, length int, description string) ISO8583FieldSpec {
+	return ISO8583FieldSpec{Description: description, Type: t, Length: length, LengthType: ISO8583LLVar}
+}
+
+func lllvar(t ISO8583FieldType, length int, description string) ISO8583FieldSpec {
+	return ISO8583FieldSpec{Description: description, Type: t, Length: length, LengthType: ISO8583LLLVar}
+}
+
+var iso8583Fields1987 = map[int]ISO8583FieldSpec{
+	2:   llvar("n", 19, "Primary account number"),
+	3:   fixed("n", 6, "Processing code"),
+	4:   fixed("n", 12, "Amount, transaction"),
+	5:   fixed("n", 12, "Amount, settlement"),
+	6:   fixed("n", 12, "Amount, cardholder billing"),
+	7:   fixed("n", 10, "Transmission date and time"),
+	8:   fixed("n", 8, "Amount, cardholder billing fee"),
+	9:   fixed("n", 8, "Conversion rate, settlement"),
+	10:  fixed("n", 8, "Conversion rate, cardholder billing"),
+	11:  fixed("n", 6, "System trace audit number"),
+	12:  fixed("n", 6, "Time, local transaction"),
+	13:  fixed("n", 4, "Date, local transaction"),
+	14:  fixed("n", 4, "Date, expiration"),
+	15:  fixed("n", 4, "Date, settlement"),
+	16:  fixed("n", 4, "Date, conversion"),
+	17:  fixed("n", 4, "Date, capture"),
+	18:  fixed("n", 4, "Merchant type"),
+	19:  fixed("n", 3, "Acquiring institution country code"),
+	20:  fixed("n", 3, "PAN extended, country code"),
+	21:  fixed("n", 3, "Forwarding institution country code"),
+	22:  fixed("n", 3, "Point of service entry mode"),
+	23:  fixed("n", 3, "Card sequence number"),
+	24:  fixed("n", 3, "Network international identifier"),
+	25:  fixed("n", 2, "Point of service condition code"),
+	26:  fixed("n", 2, "Point of service capture code"),
+	27:  fixed("n", 1, "Authorizing identification response length"),
+	28:  fixed("ans", 9, "Amount, transaction fee"),
+	29:  fixed("ans", 9, "Amount, settlement fee"),
+	30:  fixed("ans", 9, "Amount, transaction processing fee"),
+	31:  fixed("ans", 9, "Amount, settlement processing fee"),
+	32:  llvar("n", 11, "Acquiring institution identification code"),
+	33:  llvar("n", 11, "Forwarding institution identification code"),
+	34:  llvar("ans", 28, "Primary account number, extended"),
+	35:  llvar("z", 37, "Track 2 data"),
+	36:  lllvar("n", 104, "Track 3 data"),
+	37:  fixed("an", 12, "Retrieval reference number"),
+	38:  fixed("an", 6, "Authorization identification response"),
+	39:  fixed("an", 2, "Response code"),
+	40:  fixed("an", 3, "Service restriction code"),
+	41:  fixed("ans", 8, "Card acceptor terminal identification"),
+	42:  fixed("ans", 15, "Card acceptor identification code"),
+	43:  fixed("ans", 40, "Card acceptor name/location"),
+	44:  llvar("ans", 25, "Additional response data"),
+	45:  llvar("ans", 76, "Track 1 data"),
+	46:  lllvar("ans", 999, "Additional data, ISO"),
+	47:  lllvar("ans", 999, "Additional data, national"),
+	48:  lllvar("ans", 999, "Additional data, private"),
+	49:  fixed("n", 3, "Currency code, transaction"),
+	50:  fixed("n", 3, "Currency code, settlement"),
+	51:  fixed("n", 3, "Currency code, cardholder billing"),
+	52:  fixed("b", 8, "Personal identification number data"),
+	53:  fixed("n", 16, "Security related control information"),
+	54:  lllvar("ans", 120, "Additional amounts"),
+	55:  lllvar("ans", 999, "ICC data"),
+	56:  lllvar("ans", 999, "Reserved, ISO"),
+	57:  lllvar("ans", 999, "Reserved, national"),
+	58:  lllvar("ans", 999, "Reserved, national"),
+	59:  lllvar("ans", 999, "Reserved, national"),
+	60:  lllvar("ans", 999, "Reserved, national"),
+	61:  lllvar("ans", 999, "Reserved, private"),
+	62:  lllvar("ans", 999, "Reserved, private"),
+	63:  lllvar("ans", 999, "Reserved, private"),
+	64:  fixed("b", 8, "Message authentication code"),
+	66:  fixed("n", 1, "Settlement code"),
+	67:  fixed("n", 2, "Extended payment code"),
+	68:  fixed("n", 3, "Receiving institution country code"),
+	69:  fixed("n", 3, "Settlement institution country code"),
+	70:  fixed("n", 3, "Network management information code"),
+	71:  fixed("n", 4, "Message number"),
+	72:  fixed("n", 4, "Message number, last"),
+	73:  fixed("n", 6, "Date, action"),
+	74:  fixed("n", 10, "Number of credits"),
+	75:  fixed("n", 10, "Credits, reversal number"),
+	76:  fixed("n", 10, "Number of debits"),
+	77:  fixed("n", 10, "Debits, reversal number"),
+	78:  fixed("n", 10, "Transfer number"),
+	79:  fixed("n", 10, "Transfer, reversal number"),
+	80:  fixed("n", 10, "Number of inquiries"),
+	81:  fixed("n", 10, "Number of authorizations"),
+	82:  fixed("n", 12, "Credits, processing fee amount"),
+	83:  fixed("n", 12, "Credits, transaction fee amount"),
+	84:  fixed("n", 12, "Debits, processing fee amount"),
+	85:  fixed("n", 12, "Debits, transaction fee amount"),
+	86:  fixed("n", 16, "Total amount of credits"),
+	87:  fixed("n", 16, "Credits, reversal amount"),
+	88:  fixed("n", 16, "Total amount of debits"),
+	89:  fixed("n", 16, "Debits, reversal amount"),
+	90:  fixed("n", 42, "Original data elements"),
+	91:  fixed("an", 1, "File update code"),
+	92:  fixed("an", 2, "File security code"),
+	93:  fixed("an", 5, "Response indicator"),
+	94:  fixed("an", 7, "Service indicator"),
+	95:  fixed("an", 42, "Replacement amounts"),
+	96:  fixed("b", 8, "Message security code"),
+	97:  fixed("ans", 17, "Amount, net settlement"),
+	98:  fixed("ans", 25, "Payee"),
+	99:  llvar("n", 11, "Settlement institution identification code"),
+	100: llvar("n", 11, "Receiving institution identification code"),
+	101: llvar("ans", 17, "File name"),
+	102: llvar("ans", 28, "Account identification 1"),
+	103: llvar("ans", 28, "Account identification 2"),
+	104: lllvar("ans", 100, "Transaction description"),
+	128: fixed("b", 8, "Message authentication code"),
+}
+
+// iso8583Fields1993 lists the data elements that changed in the 1993 version of the standard.
+var iso8583Fields1993 = map[int]ISO8583FieldSpec{
+	12: fixed("n", 12, "Date and time, local transaction"),
+	22: fixed("an", 12, "Point of service data code"),
+	24: fixed("n", 3, "Function code"),
+	25: fixed("n", 4, "Message reason code"),
+	26: fixed("n", 4, "Card acceptor business code"),
+	28: fixed("n", 6, "Date, reconciliation"),
+	29: fixed("n", 3, "Reconciliation indicator"),
+	30: fixed("n", 24, "Amounts, original"),
+	31: llvar("ans", 99, "Acquirer reference data"),
+	38: fixed("ans", 6, "Approval code"),
+	39: fixed("n", 3, "Action code"),
+	43: llvar("ans", 99, "Card acceptor name/location"),
+	53: llvar("b", 48, "Security related control information"),
+	56: llvar("n", 35, "Original data elements"),
+	90: fixed("n", 42, "Reserved, ISO"),
+}
+
+// ISO8583Spec1987 returns the spec of the 1987 version of the standard with ASCII encoding and a binary bitmap.
+func ISO8583Spec1987() ISO8583Spec {
+	return ISO8583Spec{
+		Version:        ISO8583Version1987,
+		MTIEncoding:    ISO8583EncodingASCII,
+		BitmapEncoding: ISO8583EncodingBinary,
+		Fields:         maps.Clone(iso8583Fields1987),
+	}.withDefaults()
+}
+
+// ISO8583Spec1993 returns the spec of the 1993 version of the standard with ASCII encoding and a binary bitmap.
+func ISO8583Spec1993() ISO8583Spec {
+	fields := maps.Clone(iso8583Fields1987)
+	maps.Copy(fields, iso8583Fields1993)
+	return ISO8583Spec{
+		Version:        ISO8583Version1993,
+		MTIEncoding:    ISO8583EncodingASCII,
+		BitmapEncoding: ISO8583EncodingBinary,
+		Fields:         fields,
+	}.withDefaults()
+}
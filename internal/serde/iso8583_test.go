@@ -0,0 +1,298 @@
+package serde
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestISO8583Serde_ASCII(t *testing.T) {
+	iso := NewISO8583Serde(ISO8583Spec1987())
+
+	t.Run("Serialize network management message", func(t *testing.T) {
+		msg := ISO8583Message{
+			MTI:    "0800",
+			Fields: map[int]string{11: "123", 70: "301"},
+		}
+
+		var buf bytes.Buffer
+		if err := iso.Serialize(&buf, msg); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := "0800" +
+			string(mustHex(t, "8020000000000000")) +
+			string(mustHex(t, "0400000000000000")) +
+			"000123" +
+			"301"
+		if buf.String() != expected {
+			t.Errorf("Expected %x, got %x", expected, buf.Bytes())
+		}
+	})
+
+	t.Run("Round trip with variable length fields", func(t *testing.T) {
+		msg := ISO8583Message{
+			MTI: "0200",
+			Fields: map[int]string{
+				2:   "4111111111111111",
+				3:   "000000",
+				4:   "000000010050",
+				11:  "000042",
+				37:  "123456789012",
+				41:  "TERM0001",
+				43:  "ACME STORE            BERLIN        DE",
+				48:  "some private data",
+				52:  "0123456789ABCDEF",
+				102: "ACC-1",
+			},
+		}
+
+		var buf bytes.Buffer
+		if err := iso.Serialize(&buf, msg); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("164111111111111111")) {
+			t.Errorf("Expected LLVAR PAN in %q", buf.String())
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("017some private data")) {
+			t.Errorf("Expected LLLVAR field 48 in %q", buf.String())
+		}
+
+		var result ISO8583Message
+		if err := iso.Deserialize(&buf, &result); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		msg.Fields[43] = "ACME STORE            BERLIN        DE  "
+		if !reflect.DeepEqual(result, msg) {
+			t.Errorf("Expected %v, got %v", msg, result)
+		}
+	})
+}
+
+func TestISO8583Serde_BCD(t *testing.T) {
+	iso := NewISO8583Serde(ISO8583Spec1987().WithEncoding(ISO8583EncodingBCD))
+
+	msg := ISO8583Message{
+		MTI: "0200",
+		Fields: map[int]string{
+			2: "411111111111111",
+			4: "1000",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := iso.Serialize(&buf, msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := mustHex(t, "0200"+"5000000000000000"+"15"+"0411111111111111"+"000000001000")
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Expected %x, got %x", expected, buf.Bytes())
+	}
+
+	var result ISO8583Message
+	if err := iso.Deserialize(bytes.NewReader(buf.Bytes()), &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	msg.Fields[4] = "000000001000"
+	if !reflect.DeepEqual(result, msg) {
+		t.Errorf("Expected %v, got %v", msg, result)
+	}
+}
+
+func TestISO8583Serde_Struct(t *testing.T) {
+	type authorization struct {
+		MTI          string `iso8583:"mti"`
+		PAN          string `iso8583:"2"`
+		Amount       int64  `iso8583:"4"`
+		STAN         int    `iso8583:"11"`
+		ResponseCode string `iso8583:"39"`
+		PINBlock     []byte `iso8583:"52"`
+		Ignored      string
+	}
+	iso := NewISO8583Serde(ISO8583Spec1987())
+
+	req := authorization{
+		MTI:      "0100",
+		PAN:      "5500000000000004",
+		Amount:   1999,
+		STAN:     7,
+		PINBlock: []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef},
+		Ignored:  "not serialized",
+	}
+
+	var buf bytes.Buffer
+	if err := iso.Serialize(&buf, req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var res authorization
+	if err := iso.Deserialize(&buf, &res); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req.Ignored = ""
+	if !reflect.DeepEqual(res, req) {
+		t.Errorf("Expected %+v, got %+v", req, res)
+	}
+}
+
+func TestISO8583Serde_1993(t *testing.T) {
+	iso := NewISO8583Serde(ISO8583Spec1993())
+
+	msg := ISO8583Message{
+		MTI:    "1100",
+		Fields: map[int]string{12: "240917103000", 24: "100", 39: "000"},
+	}
+
+	var buf bytes.Buffer
+	if err := iso.Serialize(&buf, msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var result ISO8583Message
+	if err := iso.Deserialize(&buf, &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, msg) {
+		t.Errorf("Expected %v, got %v", msg, result)
+	}
+}
+
+func TestISO8583Serde_Errors(t *testing.T) {
+	iso := NewISO8583Serde(ISO8583Spec1987())
+
+	tests := []struct {
+		name    string
+		msg     ISO8583Message
+		wantErr string
+	}{
+		{
+			name:    "MTI version mismatch",
+			msg:     ISO8583Message{MTI: "1200"},
+			wantErr: "version digit",
+		},
+		{
+			name:    "Invalid MTI",
+			msg:     ISO8583Message{MTI: "02A0"},
+			wantErr: "must be 4 digits",
+		},
+		{
+			name:    "Value too long",
+			msg:     ISO8583Message{MTI: "0200", Fields: map[int]string{3: "1234567"}},
+			wantErr: "exceeds maximum length",
+		},
+		{
+			name:    "Non numeric value",
+			msg:     ISO8583Message{MTI: "0200", Fields: map[int]string{11: "12A"}},
+			wantErr: "invalid character",
+		},
+		{
+			name:    "Undefined field",
+			msg:     ISO8583Message{MTI: "0200", Fields: map[int]string{110: "x"}},
+			wantErr: "not defined in the spec",
+		},
+		{
+			name:    "Secondary bitmap set by caller",
+			msg:     ISO8583Message{MTI: "0200", Fields: map[int]string{1: "00"}},
+			wantErr: "secondary bitmap",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := iso.Serialize(&buf, tt.msg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("Truncated message", func(t *testing.T) {
+		var result ISO8583Message
+		err := iso.Deserialize(bytes.NewReader(append([]byte("0200"), mustHex(t, "2000000000000000")...)), &result)
+		if err == nil {
+			t.Errorf("Expected an error, but got none")
+		}
+	})
+}
+
+func TestLoadISO8583Spec(t *testing.T) {
+	spec, err := LoadISO8583Spec(strings.NewReader(`{
+		"version": 1987,
+		"mti_encoding": "bcd",
+		"bitmap_encoding": "ascii",
+		"fields": {
+			"2": {"type": "n", "length": 19, "length_type": "llvar", "encoding": "bcd"},
+			"4": {"type": "n", "length": 12, "encoding": "bcd"},
+			"41": {"type": "ans", "length": 8}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if spec.Fields[41].LengthType != ISO8583Fixed || spec.Fields[41].Encoding != ISO8583EncodingASCII {
+		t.Errorf("Expected defaults to be applied, got %+v", spec.Fields[41])
+	}
+
+	iso := NewISO8583Serde(spec)
+	var buf bytes.Buffer
+	err = iso.Serialize(&buf, ISO8583Message{MTI: "0200", Fields: map[int]string{4: "500", 41: "T1"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := append(mustHex(t, "0200"), []byte("1000000000800000")...)
+	expected = append(expected, mustHex(t, "000000000500")...)
+	expected = append(expected, []byte("T1      ")...)
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Expected %x, got %x", expected, buf.Bytes())
+	}
+
+	_, err = LoadISO8583Spec(strings.NewReader(`{"fields": {"2": {"type": "an", "length": 10, "encoding": "bcd"}}}`))
+	if err == nil {
+		t.Errorf("Expected an error for BCD encoded alphanumeric field, but got none")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func TestISO8583Serde_CorrelationKey(t *testing.T) {
+	iso := NewISO8583Serde(ISO8583Spec1987())
+	key := iso.CorrelationKey(11, 37)
+
+	var req, res bytes.Buffer
+	if err := iso.Serialize(&req, ISO8583Message{MTI: "0200", Fields: map[int]string{11: "000042", 37: "123456789012"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := iso.Serialize(&res, ISO8583Message{MTI: "0210", Fields: map[int]string{11: "000042", 37: "123456789012", 39: "00"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reqKey, err := key(req.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resKey, err := key(res.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reqKey != "000042|123456789012" || reqKey != resKey {
+		t.Errorf("Expected matching keys, got %q and %q", reqKey, resKey)
+	}
+
+	var partial bytes.Buffer
+	if err := iso.Serialize(&partial, ISO8583Message{MTI: "0200", Fields: map[int]string{11: "000042"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := key(partial.Bytes()); err == nil {
+		t.Errorf("Expected an error for missing correlation field, but got none")
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rauf/payment-service/internal/config"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/repo"
+)
+
+var ErrCallbackNotFound = errors.New("callback not found")
+
+// CallbackService keeps every gateway callback in an inbox before it is applied. Redelivered callbacks are
+// applied once, and callbacks that arrive before their transaction is stored are parked until it is.
+type CallbackService struct {
+	paymentService *PaymentService
+	paymentRepo    *repo.PaymentRepo
+	config         config.CallbackInboxConfig
+}
+
+func NewCallbackService(paymentService *PaymentService, paymentRepo *repo.PaymentRepo, config config.CallbackInboxConfig) *CallbackService {
+	return &CallbackService{
+		paymentService: paymentService,
+		paymentRepo:    paymentRepo,
+		config:         config,
+	}
+}
+
+// Receive stores the callback in the inbox and applies it. It reports whether the callback is a duplicate
+// of one that was already handled, in which case it is not applied again. Callbacks for unknown transactions
+// are parked rather than failed.
+func (s *CallbackService) Receive(ctx context.Context, callback models.GatewayCallback) (models.CallbackInboxEntry, bool, error) {
+	entry, created, err := s.paymentRepo.CreateCallbackInboxEntry(ctx, repo.CreateCallbackInboxEntry{
+		Gateway:           callback.Gateway,
+		RefID:             callback.RefID,
+		DedupeKey:         callback.DedupeKey,
+		ReportedStatus:    models.TransactionStatus(strings.ToUpper(callback.Status)),
+		GatewayStatusCode: callback.GatewayStatusCode,
+		DeclineReason:     callback.DeclineReason,
+		RawPayload:        callback.RawPayload,
+	})
+	if err != nil {
+		return models.CallbackInboxEntry{}, false, fmt.Errorf("failed to store callback: %w", err)
+	}
+	// A callback that is still received failed to apply before, so its redelivery is applied again.
+	if !created && entry.Status != models.CallbackInboxStatusRECEIVED {
+		slog.InfoContext(ctx, "Ignoring duplicate callback", "gateway", entry.Gateway, "ref_id", entry.RefID, "dedupe_key", entry.DedupeKey)
+		return toCallbackInboxEntry(entry), true, nil
+	}
+
+	applied, err := s.apply(ctx, entry)
+	return toCallbackInboxEntry(applied), false, err
+}
+
+// ListInbox returns a page of the callback inbox, newest first.
+func (s *CallbackService) ListInbox(ctx context.Context, filter models.CallbackInboxFilter) (models.CallbackInboxPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	list := repo.ListCallbackInboxEntries{
+		Gateway: filter.Gateway,
+		RefID:   filter.RefID,
+		Status:  models.CallbackInboxStatus(strings.ToUpper(filter.Status)),
+		// One extra row tells whether there is a next page.
+		Limit: int32(limit + 1),
+	}
+	if filter.Cursor != "" {
+		id, err := decodeIDCursor(filter.Cursor)
+		if err != nil {
+			return models.CallbackInboxPage{}, err
+		}
+		list.CursorID = id
+	}
+
+	entries, err := s.paymentRepo.ListCallbackInboxEntries(ctx, list)
+	if err != nil {
+		return models.CallbackInboxPage{}, fmt.Errorf("failed to list callback inbox: %w", err)
+	}
+
+	var page models.CallbackInboxPage
+	if len(entries) > limit {
+		entries = entries[:limit]
+		page.NextCursor = encodeIDCursor(entries[limit-1].ID)
+	}
+	page.Entries = make([]models.CallbackInboxEntry, 0, len(entries))
+	for _, entry := range entries {
+		page.Entries = append(page.Entries, toCallbackInboxEntry(entry))
+	}
+	return page, nil
+}
+
+// Replay applies a stored callback again, whatever its inbox status. The stored status is applied, so the
+// callback is not verified again.
+func (s *CallbackService) Replay(ctx context.Context, id int64) (models.CallbackInboxEntry, error) {
+	entry, err := s.paymentRepo.GetCallbackInboxEntry(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrCallbackNotFound) {
+			return models.CallbackInboxEntry{}, ErrCallbackNotFound
+		}
+		return models.CallbackInboxEntry{}, fmt.Errorf("failed to get callback: %w", err)
+	}
+	slog.InfoContext(ctx, "Replaying callback", "id", entry.ID, "gateway", entry.Gateway, "ref_id", entry.RefID)
+
+	applied, err := s.apply(ctx, entry)
+	return toCallbackInboxEntry(applied), err
+}
+
+// ApplyParked applies the parked callbacks whose transactions or refunds got their gateway reference since
+// they arrived.
+func (s *CallbackService) ApplyParked(ctx context.Context) error {
+	entries, err := s.paymentRepo.ListUnparkableCallbacks(ctx, s.config.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list parked callbacks: %w", err)
+	}
+	for _, entry := range entries {
+		slog.InfoContext(ctx, "Applying parked callback", "id", entry.ID, "gateway", entry.Gateway, "ref_id", entry.RefID)
+		if _, err := s.apply(ctx, entry); err != nil {
+			slog.WarnContext(ctx, "Failed to apply parked callback", "id", entry.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// apply updates the transaction with the callback and records the outcome in the inbox. Callbacks for
+// unknown transactions are parked, and callbacks the status machine does not allow are rejected. Other
+// failures leave the callback received, so that it is applied again when the gateway redelivers it.
+func (s *CallbackService) apply(ctx context.Context, entry models.CallbackInbox) (models.CallbackInbox, error) {
+	applyErr := s.paymentService.UpdateStatus(ctx, models.UpdateStatusRequest{
+		Gateway:           entry.Gateway,
+		RefID:             entry.RefID,
+		Status:            strings.ToLower(string(entry.ReportedStatus)),
+		GatewayStatusCode: entry.GatewayStatusCode.String,
+		DeclineReason:     strings.ToLower(string(entry.DeclineReason.DeclineReason)),
+		Source:            models.StatusChangeSourceCALLBACK,
+		RawPayload:        entry.RawPayload,
+	})
+
+	status, lastError := models.CallbackInboxStatusAPPLIED, ""
+	switch {
+	case applyErr == nil:
+	case errors.Is(applyErr, ErrTransactionNotFound):
+		// The transaction may not be committed yet. The callback is applied once it is.
+		status, lastError, applyErr = models.CallbackInboxStatusPARKED, applyErr.Error(), nil
+	case errors.Is(applyErr, ErrIllegalTransition):
+		status, lastError = models.CallbackInboxStatusREJECTED, applyErr.Error()
+	default:
+		status, lastError = models.CallbackInboxStatusRECEIVED, applyErr.Error()
+	}
+
+	updated, err := s.paymentRepo.UpdateCallbackInboxEntry(ctx, entry.ID, status, lastError)
+	if err != nil {
+		return entry, errors.Join(applyErr, fmt.Errorf("failed to update callback inbox: %w", err))
+	}
+	return updated, applyErr
+}
+
+func toCallbackInboxEntry(entry models.CallbackInbox) models.CallbackInboxEntry {
+	return models.CallbackInboxEntry{
+		ID:                entry.ID,
+		Gateway:           entry.Gateway,
+		RefID:             entry.RefID,
+		DedupeKey:         entry.DedupeKey,
+		Status:            strings.ToLower(string(entry.Status)),
+		ReportedStatus:    strings.ToLower(string(entry.ReportedStatus)),
+		GatewayStatusCode: entry.GatewayStatusCode.String,
+		DeclineReason:     strings.ToLower(string(entry.DeclineReason.DeclineReason)),
+		RawPayload:        entry.RawPayload,
+		Attempts:          entry.Attempts,
+		LastError:         entry.LastError.String,
+		CreatedAt:         entry.CreatedAt,
+		UpdatedAt:         entry.UpdatedAt,
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+)
+
+// RefreshFeeVolumes recomputes the volume of each gateway since the start of the month, which picks the tier
+// of its fee schedule.
+func (s *PaymentService) RefreshFeeVolumes(ctx context.Context) error {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	volumes, err := s.paymentRepo.ListGatewayVolumes(ctx, monthStart)
+	if err != nil {
+		return fmt.Errorf("failed to refresh fee volumes: %w", err)
+	}
+	s.fees.SetVolumes(volumes)
+	slog.DebugContext(ctx, "Refreshed fee volumes", "volumes", len(volumes))
+	return nil
+}
+
+// expectedFee returns the fee the gateway is expected to charge for the transaction. It returns no amount,
+// stored as NULL, if the gateway has no rate for the transaction or the fee cannot be computed.
+func (s *PaymentService) expectedFee(ctx context.Context, gateway string, transaction models.TransactionRequest) money.Money {
+	fee, priced, err := s.fees.Expected(gateway, transaction)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to compute expected fee", "reference", transaction.Reference, "gateway", gateway, "error", err)
+		return money.Money{}
+	}
+	if !priced {
+		slog.DebugContext(ctx, "Gateway has no fee rate for the transaction", "reference", transaction.Reference, "gateway", gateway)
+		return money.Money{}
+	}
+	return fee
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rauf/payment-service/internal/gatewayerr"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/repo"
+)
+
+var (
+	ErrIdempotencyKeyInUse  = errors.New("a request with this idempotency key is in progress")
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+)
+
+// withIdempotency runs fn at most once per idempotency key. A repeated request with the same key and payload
+// gets the stored response of the first one. The key is only released when fn fails without having made any
+// change, so the request can be retried; after other failures, such as a gateway timeout, the gateway may have
+// processed the request and the key stays in progress until it expires. The response of such a failure is
+// returned with the error, e.g. the reference of a transaction whose outcome is unknown. A repeated request
+// whose key is still in progress gets the current state of the transaction the key references from current,
+// or ErrIdempotencyKeyInUse while the first request has not stored its transaction yet.
+func withIdempotency[T any](ctx context.Context, paymentRepo *repo.PaymentRepo, key string, request any, fn func() (T, error), current func(reference string) (T, error)) (T, error) {
+	var zero T
+	if key == "" {
+		return fn()
+	}
+
+	hash, err := fingerprint(request)
+	if err != nil {
+		return zero, err
+	}
+	stored, created, err := paymentRepo.ReserveIdempotencyKey(ctx, key, hash)
+	if err != nil {
+		return zero, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	if !created {
+		if stored.RequestHash != hash {
+			return zero, ErrIdempotencyKeyReused
+		}
+		if stored.Status != models.IdempotencyStatusCOMPLETED {
+			if !stored.Reference.Valid {
+				return zero, ErrIdempotencyKeyInUse
+			}
+			slog.InfoContext(ctx, "Returning current state for idempotency key in progress", "idempotency_key", key, "reference", stored.Reference.String)
+			return current(stored.Reference.String)
+		}
+
+		slog.InfoContext(ctx, "Replaying response for idempotency key", "idempotency_key", key)
+		var response T
+		if err := json.Unmarshal(stored.Response.RawMessage, &response); err != nil {
+			return zero, fmt.Errorf("failed to decode stored response: %w", err)
+		}
+		return response, nil
+	}
+
+	// The outcome has to be recorded even if the client has gone away in the meantime.
+	storeCtx := context.WithoutCancel(ctx)
+
+	response, err := fn()
+	if err != nil {
+		if !notProcessed(err) {
+			slog.WarnContext(ctx, "Keeping idempotency key in progress, the request may have been processed", "idempotency_key", key, "error", err)
+			return response, err
+		}
+		if releaseErr := paymentRepo.ReleaseIdempotencyKey(storeCtx, key); releaseErr != nil {
+			slog.ErrorContext(ctx, "Failed to release idempotency key", "idempotency_key", key, "error", releaseErr)
+		}
+		return zero, err
+	}
+
+	encoded, err := json.Marshal(response)
+	if err == nil {
+		err = paymentRepo.CompleteIdempotencyKey(storeCtx, key, encoded)
+	}
+	if err != nil {
+		// The request succeeded, so the key stays in progress rather than allowing a second attempt.
+		slog.ErrorContext(ctx, "Failed to store response for idempotency key", "idempotency_key", key, "error", err)
+	}
+	return response, nil
+}
+
+// notProcessed reports whether the error proves that the request made no change: it was rejected before
+// anything was stored, the transaction could not be stored before it was sent, or no gateway processed it. Declines are not
+// errors; their failed transaction is stored and replayed like any other response.
+func notProcessed(err error) bool {
+	switch {
+	case errors.Is(err, ErrInsufficientBalance), errors.Is(err, ErrInvalidAmount), errors.Is(err, errNotInitiated):
+		return true
+	}
+	kind := gatewayerr.KindOf(err)
+	return kind == gatewayerr.KindUnavailable || kind == gatewayerr.KindInvalidRequest
+}
+
+// fingerprint hashes the request payload so that a key reused for a different request can be detected.
+func fingerprint(request any) (string, error) {
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes idempotency keys older than the configured TTL.
+func (s *PaymentService) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	deleted, err := s.paymentRepo.DeleteExpiredIdempotencyKeys(ctx, time.Now().UTC().Add(-s.config.IdempotencyKeyTTL))
+	if err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Deleted expired idempotency keys", "count", deleted)
+	}
+	return nil
+}
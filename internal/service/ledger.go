@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rauf/payment-service/internal/ledger"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/repo"
+)
+
+// maxUnbalancedEntries bounds the unbalanced entries returned by a ledger check.
+const maxUnbalancedEntries = 100
+
+var (
+	ErrInvalidLedgerAccount = errors.New("invalid ledger account")
+	ErrLedgerUnbalanced     = errors.New("ledger is unbalanced")
+)
+
+// LedgerService reads the balances of the double-entry ledger, posts the entries that are not caused by
+// transactions and checks that the ledger is consistent. Transactions and refunds post their entries in
+// the repository, in the database transaction of their status change.
+type LedgerService struct {
+	paymentRepo *repo.PaymentRepo
+}
+
+func NewLedgerService(paymentRepo *repo.PaymentRepo) *LedgerService {
+	return &LedgerService{
+		paymentRepo: paymentRepo,
+	}
+}
+
+// GetCustomerBalances returns the balances of a customer, one per currency the customer has moved money in.
+func (s *LedgerService) GetCustomerBalances(ctx context.Context, customerID string) ([]models.LedgerBalance, error) {
+	rows, err := s.paymentRepo.ListLedgerBalances(ctx, repo.ListLedgerBalances{
+		Type:  models.LedgerAccountTypeCUSTOMER,
+		Owner: customerID,
+		Limit: maxPageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer balances: %w", err)
+	}
+	balances := make([]models.LedgerBalance, 0, len(rows))
+	for _, row := range rows {
+		balance, err := toLedgerBalance(row)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// ListAccounts returns a page of ledger accounts with their balances, oldest first.
+func (s *LedgerService) ListAccounts(ctx context.Context, filter models.LedgerAccountFilter) (models.LedgerAccountPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	list := repo.ListLedgerBalances{
+		Type:  models.LedgerAccountType(strings.ToUpper(filter.AccountType)),
+		Owner: filter.Owner,
+		// One extra row tells whether there is a next page.
+		Limit: int32(limit + 1),
+	}
+	if filter.Cursor != "" {
+		id, err := decodeIDCursor(filter.Cursor)
+		if err != nil {
+			return models.LedgerAccountPage{}, err
+		}
+		list.CursorID = id
+	}
+
+	rows, err := s.paymentRepo.ListLedgerBalances(ctx, list)
+	if err != nil {
+		return models.LedgerAccountPage{}, fmt.Errorf("failed to list ledger accounts: %w", err)
+	}
+
+	var page models.LedgerAccountPage
+	if len(rows) > limit {
+		rows = rows[:limit]
+		page.NextCursor = encodeIDCursor(rows[limit-1].ID)
+	}
+	page.Accounts = make([]models.LedgerBalance, 0, len(rows))
+	for _, row := range rows {
+		balance, err := toLedgerBalance(row)
+		if err != nil {
+			return models.LedgerAccountPage{}, err
+		}
+		page.Accounts = append(page.Accounts, balance)
+	}
+	return page, nil
+}
+
+// PostFee posts a fee charged by a gateway, which reduces the money the gateway owes us.
+func (s *LedgerService) PostFee(ctx context.Context, req models.FeeRequest) (models.JournalEntry, error) {
+	entry := ledger.Fee(req.Gateway, req.Amount)
+	entry.Description = req.Description
+	return s.post(ctx, entry)
+}
+
+// PostAdjustment moves money between an account and suspense, e.g. to attribute money parked in suspense.
+func (s *LedgerService) PostAdjustment(ctx context.Context, req models.AdjustmentRequest) (models.JournalEntry, error) {
+	accountType := ledger.AccountType(strings.ToLower(req.AccountType))
+	switch accountType {
+	case ledger.AccountCustomer, ledger.AccountGatewayClearing, ledger.AccountFees:
+	default:
+		return models.JournalEntry{}, fmt.Errorf("%w: cannot adjust %s accounts", ErrInvalidLedgerAccount, req.AccountType)
+	}
+	if req.Owner == "" {
+		return models.JournalEntry{}, fmt.Errorf("%w: %s accounts have an owner", ErrInvalidLedgerAccount, accountType)
+	}
+
+	entry := ledger.Adjustment(ledger.Account{
+		Type:     accountType,
+		Owner:    req.Owner,
+		Currency: req.Amount.Currency().String(),
+	}, req.Amount)
+	entry.Description = req.Description
+	return s.post(ctx, entry)
+}
+
+func (s *LedgerService) post(ctx context.Context, entry ledger.Entry) (models.JournalEntry, error) {
+	posted, err := s.paymentRepo.PostLedgerEntry(ctx, entry)
+	if err != nil {
+		return models.JournalEntry{}, fmt.Errorf("failed to post %s: %w", entry.Kind, err)
+	}
+	slog.InfoContext(ctx, "Posted ledger entry", "id", posted.ID, "kind", entry.Kind, "description", entry.Description)
+
+	journalEntry := models.JournalEntry{
+		ID:          posted.ID,
+		Kind:        string(entry.Kind),
+		Description: entry.Description,
+		Postings:    make([]models.LedgerPostingDetails, 0, len(entry.Postings)),
+		CreatedAt:   posted.CreatedAt,
+	}
+	for _, p := range entry.Postings {
+		journalEntry.Postings = append(journalEntry.Postings, models.LedgerPostingDetails{
+			AccountType: string(p.Account.Type),
+			Owner:       p.Account.Owner,
+			Amount:      p.Amount,
+		})
+	}
+	return journalEntry, nil
+}
+
+// CheckLedger checks that the postings of the ledger sum to zero in every currency and lists the entries
+// that do not.
+func (s *LedgerService) CheckLedger(ctx context.Context) (models.LedgerCheck, error) {
+	totals, err := s.paymentRepo.SumLedgerPostings(ctx)
+	if err != nil {
+		return models.LedgerCheck{}, fmt.Errorf("failed to sum ledger postings: %w", err)
+	}
+	unbalanced, err := s.paymentRepo.ListUnbalancedLedgerEntries(ctx, maxUnbalancedEntries)
+	if err != nil {
+		return models.LedgerCheck{}, fmt.Errorf("failed to list unbalanced ledger entries: %w", err)
+	}
+
+	check := models.LedgerCheck{
+		Balanced:          len(unbalanced) == 0,
+		Totals:            make([]models.LedgerTotal, 0, len(totals)),
+		UnbalancedEntries: make([]models.UnbalancedLedgerEntry, 0, len(unbalanced)),
+	}
+	for _, t := range totals {
+		total, err := money.Parse(t.Total, t.Currency)
+		if err != nil {
+			return models.LedgerCheck{}, fmt.Errorf("failed to parse %s ledger total: %w", t.Currency, err)
+		}
+		if !total.IsZero() {
+			check.Balanced = false
+		}
+		check.Totals = append(check.Totals, models.LedgerTotal{Total: total, Postings: t.Postings})
+	}
+	for _, e := range unbalanced {
+		total, err := money.Parse(e.Total, e.Currency)
+		if err != nil {
+			return models.LedgerCheck{}, fmt.Errorf("failed to parse total of ledger entry %d: %w", e.EntryID, err)
+		}
+		check.UnbalancedEntries = append(check.UnbalancedEntries, models.UnbalancedLedgerEntry{EntryID: e.EntryID, Total: total})
+	}
+	return check, nil
+}
+
+// VerifyLedger runs the ledger check and fails if the ledger is unbalanced, so the ledger-check worker
+// logs it.
+func (s *LedgerService) VerifyLedger(ctx context.Context) error {
+	check, err := s.CheckLedger(ctx)
+	if err != nil {
+		return err
+	}
+	if !check.Balanced {
+		ids := make([]int64, 0, len(check.UnbalancedEntries))
+		for _, e := range check.UnbalancedEntries {
+			ids = append(ids, e.EntryID)
+		}
+		return fmt.Errorf("%w: unbalanced entries %v", ErrLedgerUnbalanced, ids)
+	}
+	return nil
+}
+
+// toLedgerBalance converts the sum of the postings of an account to its balance on its normal side.
+func toLedgerBalance(row models.ListLedgerBalancesRow) (models.LedgerBalance, error) {
+	accountType := ledger.AccountType(strings.ToLower(string(row.Type)))
+	sum, err := money.Parse(row.Balance, row.Currency)
+	if err != nil {
+		return models.LedgerBalance{}, fmt.Errorf("failed to parse balance of ledger account %d: %w", row.ID, err)
+	}
+	return models.LedgerBalance{
+		AccountID:   row.ID,
+		AccountType: string(accountType),
+		Owner:       row.Owner,
+		Balance:     accountType.Balance(sum),
+	}, nil
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/repo"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// GetTransaction returns the stored state of a transaction.
+func (s *PaymentService) GetTransaction(ctx context.Context, gatewayName, refID string) (models.TransactionDetails, error) {
+	transaction, err := s.getTransaction(ctx, gatewayName, refID)
+	if err != nil {
+		return models.TransactionDetails{}, err
+	}
+	return toTransactionDetails(transaction)
+}
+
+// ListTransactions returns a page of transactions matching the filter, newest first. The next page is
+// requested by passing the returned NextCursor in the filter.
+func (s *PaymentService) ListTransactions(ctx context.Context, filter models.TransactionFilter) (models.TransactionPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	list := repo.ListTransactions{
+		CustomerID:  filter.CustomerID,
+		Reference:   filter.Reference,
+		Status:      filter.Status,
+		Gateway:     filter.Gateway,
+		Type:        filter.Type,
+		Currency:    filter.Currency,
+		MinAmount:   filter.MinAmount,
+		MaxAmount:   filter.MaxAmount,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		// One extra row tells whether there is a next page.
+		Limit: int32(limit + 1),
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return models.TransactionPage{}, err
+		}
+		list.AfterCreatedAt, list.AfterID = createdAt, id
+	}
+
+	transactions, err := s.paymentRepo.ListTransactions(ctx, list)
+	if err != nil {
+		return models.TransactionPage{}, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	var page models.TransactionPage
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	page.Transactions = make([]models.TransactionDetails, 0, len(transactions))
+	for _, transaction := range transactions {
+		details, err := toTransactionDetails(transaction)
+		if err != nil {
+			return models.TransactionPage{}, err
+		}
+		page.Transactions = append(page.Transactions, details)
+	}
+	return page, nil
+}
+
+// encodeCursor encodes the sort key of the last transaction of a page. The cursor is opaque to clients.
+func encodeCursor(createdAt time.Time, id int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d,%d", createdAt.UnixNano(), id)))
+}
+
+func decodeCursor(cursor string) (time.Time, int32, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	nanos, id, ok := strings.Cut(string(decoded), ",")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	i, err := strconv.ParseInt(id, 10, 32)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return time.Unix(0, n).UTC(), int32(i), nil
+}
+
+// encodeIDCursor encodes the ID of the last row of a page. The cursor is opaque to clients.
+func encodeIDCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeIDCursor(cursor string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	id, err := strconv.ParseInt(string(decoded), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return id, nil
+}
+
+func toTransactionDetails(transaction models.Transaction) (models.TransactionDetails, error) {
+	amount, err := money.Parse(transaction.Amount, transaction.Currency)
+	if err != nil {
+		return models.TransactionDetails{}, fmt.Errorf("failed to parse transaction amount: %w", err)
+	}
+	refunded, err := money.Parse(transaction.RefundedAmount, transaction.Currency)
+	if err != nil {
+		return models.TransactionDetails{}, fmt.Errorf("failed to parse refunded amount: %w", err)
+	}
+	var captured money.Money
+	if transaction.CapturedAmount.Valid {
+		captured, err = money.Parse(transaction.CapturedAmount.String, transaction.Currency)
+		if err != nil {
+			return models.TransactionDetails{}, fmt.Errorf("failed to parse captured amount: %w", err)
+		}
+	}
+	var expectedFee money.Money
+	if transaction.ExpectedFee.Valid {
+		expectedFee, err = money.Parse(transaction.ExpectedFee.String, transaction.Currency)
+		if err != nil {
+			return models.TransactionDetails{}, fmt.Errorf("failed to parse expected fee: %w", err)
+		}
+	}
+
+	return models.TransactionDetails{
+		Reference:              transaction.Reference,
+		Gateway:                transaction.Gateway,
+		RefID:                  transaction.GatewayRefID.String,
+		Type:                   strings.ToLower(string(transaction.Type)),
+		Amount:                 amount,
+		PaymentMethod:          transaction.PaymentMethod,
+		Description:            transaction.Description.String,
+		CustomerID:             transaction.CustomerID,
+		MerchantID:             transaction.MerchantID.String,
+		DestinationCustomerID:  transaction.DestinationCustomerID.String,
+		Status:                 strings.ToLower(string(transaction.Status)),
+		GatewayStatusCode:      transaction.GatewayStatusCode.String,
+		DeclineReason:          strings.ToLower(string(transaction.DeclineReason.DeclineReason)),
+		PreferredGateway:       transaction.PreferredGateway.String,
+		RoutingRule:            transaction.RoutingRule.String,
+		ExpectedFee:            expectedFee,
+		CapturedAmount:         captured,
+		RefundedAmount:         refunded,
+		AuthorizationExpiresAt: transaction.AuthorizationExpiresAt.Time,
+		Metadata:               transaction.Metadata.RawMessage,
+		CreatedAt:              transaction.CreatedAt,
+		UpdatedAt:              transaction.UpdatedAt,
+	}, nil
+}
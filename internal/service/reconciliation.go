@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
+	"github.com/rauf/payment-service/internal/reconcile"
+	"github.com/rauf/payment-service/internal/registry"
+	"github.com/rauf/payment-service/internal/repo"
+)
+
+var (
+	ErrReconciliationNotFound  = errors.New("reconciliation not found")
+	ErrNoSettlementParser      = errors.New("no settlement report parser for gateway")
+	ErrInvalidSettlementReport = reconcile.ErrInvalidReport
+)
+
+// ReconciliationService matches the settlement reports of the gateways against the transactions we recorded
+// and keeps the discrepancies for finance.
+type ReconciliationService struct {
+	paymentRepo *repo.PaymentRepo
+	parsers     *registry.Registry[reconcile.Parser]
+}
+
+func NewReconciliationService(paymentRepo *repo.PaymentRepo, parsers *registry.Registry[reconcile.Parser]) *ReconciliationService {
+	return &ReconciliationService{
+		paymentRepo: paymentRepo,
+		parsers:     parsers,
+	}
+}
+
+// Reconcile parses the settlement report with the parser of the gateway, matches it by gateway reference to
+// the transactions of the gateway, and stores the run with its discrepancies. The reported transactions are
+// matched whenever they were created, while only the settled transactions created in the period can be missing.
+func (s *ReconciliationService) Reconcile(ctx context.Context, req models.ReconcileRequest) (models.Reconciliation, error) {
+	parser, err := s.parsers.Get(req.Gateway)
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("%w: %s", ErrNoSettlementParser, req.Gateway)
+	}
+	records, err := parser.Parse(req.Report)
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("failed to parse settlement report: %w", err)
+	}
+
+	refIDs := make([]string, 0, len(records))
+	for _, record := range records {
+		refIDs = append(refIDs, record.RefID)
+	}
+	reported, err := s.paymentRepo.ListTransactionsByGatewayRefIDs(ctx, req.Gateway, refIDs)
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("failed to list reported transactions: %w", err)
+	}
+	settled, err := s.paymentRepo.ListSettledTransactions(ctx, req.Gateway, req.PeriodFrom, req.PeriodTo)
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("failed to list settled transactions: %w", err)
+	}
+	recorded, err := toRecorded(append(reported, settled...))
+	if err != nil {
+		return models.Reconciliation{}, err
+	}
+
+	result := reconcile.Match(recorded, records)
+	run, err := s.paymentRepo.CreateReconciliation(ctx, repo.CreateReconciliation{
+		Gateway:    req.Gateway,
+		PeriodFrom: req.PeriodFrom,
+		PeriodTo:   req.PeriodTo,
+		Records:    len(records),
+		Result:     result,
+	})
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("failed to save reconciliation: %w", err)
+	}
+	slog.InfoContext(ctx, "Reconciled settlement report", "id", run.ID, "gateway", run.Gateway, "records", run.Records, "matched", run.Matched, "discrepancies", run.Discrepancies)
+
+	reconciliation := toReconciliation(run)
+	reconciliation.Discrepancies = make([]models.ReconciliationDiscrepancyDetails, 0, len(result.Discrepancies))
+	for _, d := range result.Discrepancies {
+		reconciliation.Discrepancies = append(reconciliation.Discrepancies, models.ReconciliationDiscrepancyDetails{
+			Kind:           string(d.Kind),
+			RefID:          d.RefID,
+			RecordedAmount: d.RecordedAmount,
+			RecordedStatus: d.RecordedStatus,
+			SettledAmount:  d.SettledAmount,
+			SettledStatus:  d.SettledStatus,
+		})
+	}
+	return reconciliation, nil
+}
+
+// GetReconciliation returns a reconciliation run with its discrepancies.
+func (s *ReconciliationService) GetReconciliation(ctx context.Context, id int64) (models.Reconciliation, error) {
+	run, err := s.paymentRepo.GetReconciliationRun(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrReconciliationNotFound) {
+			return models.Reconciliation{}, ErrReconciliationNotFound
+		}
+		return models.Reconciliation{}, fmt.Errorf("failed to get reconciliation: %w", err)
+	}
+	discrepancies, err := s.paymentRepo.ListReconciliationDiscrepancies(ctx, id)
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("failed to list discrepancies: %w", err)
+	}
+
+	reconciliation := toReconciliation(run)
+	reconciliation.Discrepancies = make([]models.ReconciliationDiscrepancyDetails, 0, len(discrepancies))
+	for _, d := range discrepancies {
+		details, err := toReconciliationDiscrepancyDetails(d)
+		if err != nil {
+			return models.Reconciliation{}, err
+		}
+		reconciliation.Discrepancies = append(reconciliation.Discrepancies, details)
+	}
+	return reconciliation, nil
+}
+
+// ListReconciliations returns a page of reconciliation runs, newest first, without their discrepancies.
+func (s *ReconciliationService) ListReconciliations(ctx context.Context, filter models.ReconciliationFilter) (models.ReconciliationPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	list := repo.ListReconciliationRuns{
+		Gateway: filter.Gateway,
+		// One extra row tells whether there is a next page.
+		Limit: int32(limit + 1),
+	}
+	if filter.Cursor != "" {
+		id, err := decodeIDCursor(filter.Cursor)
+		if err != nil {
+			return models.ReconciliationPage{}, err
+		}
+		list.CursorID = id
+	}
+
+	runs, err := s.paymentRepo.ListReconciliationRuns(ctx, list)
+	if err != nil {
+		return models.ReconciliationPage{}, fmt.Errorf("failed to list reconciliations: %w", err)
+	}
+
+	var page models.ReconciliationPage
+	if len(runs) > limit {
+		runs = runs[:limit]
+		page.NextCursor = encodeIDCursor(runs[limit-1].ID)
+	}
+	page.Reconciliations = make([]models.Reconciliation, 0, len(runs))
+	for _, run := range runs {
+		page.Reconciliations = append(page.Reconciliations, toReconciliation(run))
+	}
+	return page, nil
+}
+
+// toRecorded converts transactions to the records they should be settled with, once per transaction.
+func toRecorded(transactions []models.Transaction) ([]reconcile.Recorded, error) {
+	recorded := make([]reconcile.Recorded, 0, len(transactions))
+	seen := make(map[int32]bool, len(transactions))
+	for _, t := range transactions {
+		if seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+
+		amount := t.Amount
+		if t.CapturedAmount.Valid {
+			amount = t.CapturedAmount.String
+		}
+		m, err := money.Parse(amount, t.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse amount of transaction %d: %w", t.ID, err)
+		}
+		recorded = append(recorded, reconcile.Recorded{
+			RefID:  t.GatewayRefID.String,
+			Amount: m,
+			Status: strings.ToLower(string(t.Status)),
+		})
+	}
+	return recorded, nil
+}
+
+func toReconciliation(run models.ReconciliationRun) models.Reconciliation {
+	return models.Reconciliation{
+		ID:               run.ID,
+		Gateway:          run.Gateway,
+		PeriodFrom:       run.PeriodFrom,
+		PeriodTo:         run.PeriodTo,
+		Records:          run.Records,
+		Matched:          run.Matched,
+		DiscrepancyCount: run.Discrepancies,
+		CreatedAt:        run.CreatedAt,
+	}
+}
+
+func toReconciliationDiscrepancyDetails(d models.ReconciliationDiscrepancy) (models.ReconciliationDiscrepancyDetails, error) {
+	details := models.ReconciliationDiscrepancyDetails{
+		Kind:           strings.ToLower(string(d.Kind)),
+		RefID:          d.RefID,
+		RecordedStatus: d.RecordedStatus.String,
+		SettledStatus:  d.SettledStatus.String,
+	}
+	var err error
+	if d.RecordedAmount.Valid {
+		if details.RecordedAmount, err = money.Parse(d.RecordedAmount.String, d.RecordedCurrency.String); err != nil {
+			return models.ReconciliationDiscrepancyDetails{}, fmt.Errorf("failed to parse recorded amount: %w", err)
+		}
+	}
+	if d.SettledAmount.Valid {
+		if details.SettledAmount, err = money.Parse(d.SettledAmount.String, d.SettledCurrency.String); err != nil {
+			return models.ReconciliationDiscrepancyDetails{}, fmt.Errorf("failed to parse settled amount: %w", err)
+		}
+	}
+	return details, nil
+}
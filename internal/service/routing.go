@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/router"
+)
+
+var (
+	ErrRoutingRuleNotFound   = errors.New("routing rule not found")
+	ErrInvalidRoutingWeights = errors.New("invalid routing weights")
+)
+
+// DryRunRouting returns the gateways the transaction would be sent to and the routing rule that picked them,
+// without storing or sending it.
+func (s *PaymentService) DryRunRouting(ctx context.Context, transaction models.TransactionRequest) (models.RoutingDecision, error) {
+	decision, err := s.router.DryRun(transaction)
+	if err != nil {
+		return models.RoutingDecision{}, fmt.Errorf("failed to route transaction: %w", err)
+	}
+	return decision, nil
+}
+
+// ListRoutingRules returns the routing rules in evaluation order, with their current weights.
+func (s *PaymentService) ListRoutingRules(_ context.Context) []models.RoutingRule {
+	rules := s.router.Rules()
+	list := make([]models.RoutingRule, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, models.RoutingRule{
+			Name:     rule.Name,
+			Gateways: rule.Gateways,
+			Weights:  rule.Weights,
+		})
+	}
+	return list
+}
+
+// SetRoutingWeights changes how the traffic of a routing rule is split between its gateways. The change
+// lasts until the service restarts with the weights of the rules file.
+func (s *PaymentService) SetRoutingWeights(ctx context.Context, rule string, weights map[string]int) error {
+	err := s.router.SetWeights(rule, weights)
+	switch {
+	case errors.Is(err, router.ErrRuleNotFound):
+		return fmt.Errorf("%w: %w", ErrRoutingRuleNotFound, err)
+	case errors.Is(err, router.ErrInvalidRule):
+		return fmt.Errorf("%w: %w", ErrInvalidRoutingWeights, err)
+	case err != nil:
+		return err
+	}
+	slog.InfoContext(ctx, "Changed routing weights", "rule", rule, "weights", weights)
+	return nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/repo"
+)
+
+// statusPollBatchSize is the number of pending transactions queried per run.
+const statusPollBatchSize = 100
+
+// PollPendingTransactions queries the gateways for the status of transactions and refunds that stayed pending
+// for longer than the poll threshold, because their callback never arrived or their request timed out. Settled
+// statuses are applied like a callback; those that are still pending are queried again with backoff until the
+// retries are exhausted.
+func (s *PaymentService) PollPendingTransactions(ctx context.Context) error {
+	now := time.Now().UTC()
+	list := repo.ListPendingTransactionsToPoll{
+		CreatedBefore: now.Add(-s.config.StatusPollThreshold),
+		MaxAttempts:   int32(s.config.StatusPollRetry.MaxRetries),
+		Now:           now,
+		Limit:         statusPollBatchSize,
+	}
+	transactions, err := s.paymentRepo.ListPendingTransactionsToPoll(ctx, list)
+	if err != nil {
+		return fmt.Errorf("failed to list pending transactions: %w", err)
+	}
+
+	for _, transaction := range transactions {
+		if err := s.pollStatus(ctx, transaction, now); err != nil {
+			slog.WarnContext(ctx, "Failed to poll transaction status", "ref_id", transaction.GatewayRefID.String, "gateway", transaction.Gateway, "error", err)
+		}
+	}
+
+	refunds, err := s.paymentRepo.ListPendingRefundsToPoll(ctx, list)
+	if err != nil {
+		return fmt.Errorf("failed to list pending refunds: %w", err)
+	}
+
+	for _, refund := range refunds {
+		if err := s.pollRefundStatus(ctx, refund, now); err != nil {
+			slog.WarnContext(ctx, "Failed to poll refund status", "refund_id", refund.ID, "gateway", refund.Gateway, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *PaymentService) pollStatus(ctx context.Context, transaction models.Transaction, now time.Time) error {
+	refID := transaction.GatewayRefID.String
+	slog.InfoContext(ctx, "Querying transaction status", "ref_id", refID, "gateway", transaction.Gateway, "attempt", transaction.StatusPollAttempts+1)
+
+	var response models.TransactionResponse
+	err := s.router.SendToGateway(ctx, transaction.Gateway, func(g gateway.PaymentGateway) error {
+		var gatewayErr error
+		response, gatewayErr = g.QueryStatus(ctx, models.StatusQuery{RefID: refID})
+		return gatewayErr
+	})
+	if err != nil {
+		err = fmt.Errorf("status query failed: %w", err)
+	} else if !strings.EqualFold(response.Status, string(models.TransactionStatusPENDING)) {
+		err = s.UpdateStatus(ctx, models.UpdateStatusRequest{
+			Gateway:           transaction.Gateway,
+			RefID:             refID,
+			Status:            response.Status,
+			GatewayStatusCode: response.GatewayStatusCode,
+			DeclineReason:     response.DeclineReason,
+			Source:            models.StatusChangeSourceSYSTEM,
+		})
+		if err == nil {
+			return nil
+		}
+	}
+
+	retry := s.config.StatusPollRetry
+	attempts := int(transaction.StatusPollAttempts) + 1
+	next := now.Add(retry.Backoff.NextBackoff(attempts - 1))
+	if scheduleErr := s.paymentRepo.ScheduleStatusPoll(ctx, transaction.ID, next); scheduleErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to schedule status query: %w", scheduleErr))
+	}
+	if attempts >= retry.MaxRetries {
+		slog.WarnContext(ctx, "Giving up querying transaction status", "ref_id", refID, "gateway", transaction.Gateway, "attempts", attempts)
+	}
+	return err
+}
+
+// pollRefundStatus queries the status of a pending refund, by its merchant reference if the gateway never
+// answered the refund. A refund the gateway does not know was never made, so its amount is released.
+func (s *PaymentService) pollRefundStatus(ctx context.Context, refund models.Refund, now time.Time) error {
+	query := models.StatusQuery{RefID: refund.GatewayRefID.String}
+	if query.RefID == "" {
+		query.MerchantReference = refundReference(refund.ID)
+	}
+	slog.InfoContext(ctx, "Querying refund status", "refund_id", refund.ID, "gateway", refund.Gateway, "attempt", refund.StatusPollAttempts+1)
+
+	var response models.TransactionResponse
+	var unknown bool
+	err := s.router.SendToGateway(ctx, refund.Gateway, func(g gateway.PaymentGateway) error {
+		var gatewayErr error
+		response, gatewayErr = g.QueryStatus(ctx, query)
+		if query.RefID == "" && errors.Is(gatewayErr, gateway.ErrUnknownReference) {
+			unknown = true
+			return nil
+		}
+		return gatewayErr
+	})
+	switch {
+	case err != nil:
+		err = fmt.Errorf("status query failed: %w", err)
+	case unknown:
+		slog.WarnContext(ctx, "Failing refund unknown to the gateway", "refund_id", refund.ID, "gateway", refund.Gateway)
+		response.Status = strings.ToLower(string(models.TransactionStatusFAILED))
+		fallthrough
+	default:
+		err = s.settleRefund(ctx, refund, response.RefID, response.Status)
+		if err == nil && !strings.EqualFold(response.Status, string(models.TransactionStatusPENDING)) {
+			return nil
+		}
+	}
+
+	retry := s.config.StatusPollRetry
+	attempts := int(refund.StatusPollAttempts) + 1
+	next := now.Add(retry.Backoff.NextBackoff(attempts - 1))
+	if scheduleErr := s.paymentRepo.ScheduleRefundStatusPoll(ctx, refund.ID, next); scheduleErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to schedule refund status query: %w", scheduleErr))
+	}
+	if attempts >= retry.MaxRetries {
+		slog.WarnContext(ctx, "Giving up querying refund status", "refund_id", refund.ID, "gateway", refund.Gateway, "attempts", attempts)
+	}
+	return err
+}
@@ -2,82 +2,894 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/rauf/payment-service/internal/config"
+	"github.com/rauf/payment-service/internal/fee"
 	"github.com/rauf/payment-service/internal/gateway"
+	"github.com/rauf/payment-service/internal/gatewayerr"
 	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/money"
 	"github.com/rauf/payment-service/internal/repo"
 	"github.com/rauf/payment-service/internal/router"
 )
 
-var ErrTransactionNotFound = errors.New("transaction not found")
+var (
+	ErrTransactionNotFound      = errors.New("transaction not found")
+	ErrTransactionNotRefundable = errors.New("transaction cannot be refunded")
+	ErrRefundAmountExceeded     = errors.New("refund amount exceeds refundable amount")
+	ErrTransactionNotAuthorized = errors.New("transaction is not authorized")
+	ErrAuthorizationExpired     = errors.New("authorization has expired")
+	ErrCaptureAmountExceeded    = errors.New("capture amount exceeds authorized amount")
+	ErrCaptureDeclined          = errors.New("capture declined by gateway")
+	ErrInvalidAmount            = errors.New("invalid amount")
+	ErrIllegalTransition        = errors.New("illegal status transition")
+	ErrInsufficientBalance      = errors.New("insufficient balance")
+
+	// errNotInitiated is returned when a transaction could not be stored before it was sent to a gateway.
+	errNotInitiated = errors.New("failed to save transaction")
+)
+
+const (
+	// expiredAuthorizationsBatchSize is the number of expired authorizations voided per run.
+	expiredAuthorizationsBatchSize = 100
+	// initiatedSweepBatchSize is the number of stuck initiated transactions resolved per run.
+	initiatedSweepBatchSize = 100
+	// transferPaymentMethod is the payment method of transfers that do not name one.
+	transferPaymentMethod = "balance"
+)
 
 // PaymentService is a service that handles payment transactions
 type PaymentService struct {
 	router      *router.Router
 	paymentRepo *repo.PaymentRepo
+	config      config.PaymentConfig
+	// fees price the transactions to record the fee the gateway is expected to charge. They are nil without
+	// fee schedules.
+	fees *fee.Schedules
 }
 
-func NewPaymentService(router *router.Router, paymentRepo *repo.PaymentRepo) *PaymentService {
+func NewPaymentService(router *router.Router, paymentRepo *repo.PaymentRepo, config config.PaymentConfig, fees *fee.Schedules) *PaymentService {
 	return &PaymentService{
 		router:      router,
 		paymentRepo: paymentRepo,
+		config:      config,
+		fees:        fees,
 	}
 }
 
+// CreateTransaction sends the transaction to the first available gateway, or moves the money between the
+// two customers of a transfer. Requests carrying an idempotency key are processed once, and repeated
+// requests get the original response, or the current state of the transaction while its outcome is unknown.
 func (s *PaymentService) CreateTransaction(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
-	response, err := s.router.SendMessage(ctx, transaction.PreferredGateway, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
-		return g.Transact(ctx, transaction)
+	return withIdempotency(ctx, s.paymentRepo, transaction.IdempotencyKey, transaction, func() (models.TransactionResponse, error) {
+		return s.createTransaction(ctx, transaction)
+	}, func(reference string) (models.TransactionResponse, error) {
+		return s.currentTransaction(ctx, reference)
 	})
+}
 
-	slog.InfoContext(ctx, "Received response from gateway", "response", response, "error", err)
+// currentTransaction returns the stored state of the transaction with the reference as a response.
+func (s *PaymentService) currentTransaction(ctx context.Context, reference string) (models.TransactionResponse, error) {
+	transaction, err := s.getTransactionByReference(ctx, reference)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+	return models.TransactionResponse{
+		Reference:         transaction.Reference,
+		Gateway:           transaction.Gateway,
+		RefID:             transaction.GatewayRefID.String,
+		Status:            strings.ToLower(string(transaction.Status)),
+		GatewayStatusCode: transaction.GatewayStatusCode.String,
+		DeclineReason:     strings.ToLower(string(transaction.DeclineReason.DeclineReason)),
+		CreatedAt:         transaction.CreatedAt,
+	}, nil
+}
 
-	if errors.Is(err, gateway.ErrGatewayUnavailable) {
-		return models.TransactionResponse{}, fmt.Errorf("all payment gateways are currently unavailable: %w", err)
+func (s *PaymentService) createTransaction(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
+	if strings.EqualFold(transaction.Type, string(models.TransactionTypeTRANSFER)) {
+		return s.transfer(ctx, transaction)
 	}
+
+	transaction, initiated, err := s.initiate(ctx, transaction, time.Time{})
 	if err != nil {
-		return models.TransactionResponse{}, fmt.Errorf("transaction failed: %w", err)
+		return models.TransactionResponse{}, err
 	}
-	err = s.paymentRepo.CreateTransaction(ctx, repo.CreateTransaction{
-		TransactionRequest: transaction,
-		Gateway:            response.Gateway,
-		GatewayRefID:       response.Data.RefID,
+
+	response, err := s.router.SendMessage(ctx, transaction, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+		return g.Transact(ctx, transaction)
 	})
+
+	slog.InfoContext(ctx, "Received response from gateway", "reference", transaction.Reference, "response", response, "error", err)
+
 	if err != nil {
+		return s.gatewayFailure(ctx, "transaction", transaction, initiated, response, err)
+	}
+	expectedFee := s.expectedFee(ctx, response.Gateway, transaction)
+	err = s.paymentRepo.CompleteInitiatedTransaction(ctx, repo.CompleteInitiatedTransaction{
+		ID:                initiated.ID,
+		From:              models.TransactionStatusINITIATED,
+		Gateway:           response.Gateway,
+		GatewayRefID:      response.Data.RefID,
+		Status:            response.Data.Status,
+		GatewayStatusCode: response.Data.GatewayStatusCode,
+		DeclineReason:     response.Data.DeclineReason,
+		RoutingRule:       response.Rule,
+		ExpectedFee:       expectedFee,
+		Source:            models.StatusChangeSourceAPI,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save gateway response", "reference", transaction.Reference, "gateway", response.Gateway, "ref_id", response.Data.RefID, "error", err)
 		return models.TransactionResponse{}, fmt.Errorf("failed to save transaction: %w", err)
 	}
 	return models.TransactionResponse{
-		Gateway:   response.Gateway,
-		RefID:     response.Data.RefID,
-		Status:    response.Data.Status,
-		CreatedAt: response.Data.CreatedAt,
+		Reference:         transaction.Reference,
+		Gateway:           response.Gateway,
+		RefID:             response.Data.RefID,
+		Status:            response.Data.Status,
+		GatewayStatusCode: response.Data.GatewayStatusCode,
+		DeclineReason:     response.Data.DeclineReason,
+		CreatedAt:         response.Data.CreatedAt,
 	}, nil
 }
 
-func (s *PaymentService) UpdateStatus(ctx context.Context, req models.UpdateStatusRequest) error {
-	slog.InfoContext(ctx, "Updating transaction status", "ref_id", req.RefID, "status", req.Status)
+// transfer moves money from the customer to the destination customer in the ledger, without a gateway. It
+// fails with ErrInsufficientBalance if the customer holds less than the amount.
+func (s *PaymentService) transfer(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
+	reference, err := newReference()
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+	transaction.Reference = reference
+	if transaction.PaymentMethod == "" {
+		transaction.PaymentMethod = transferPaymentMethod
+	}
+
+	created, err := s.paymentRepo.CreateTransfer(ctx, transaction)
+	if err != nil {
+		if errors.Is(err, repo.ErrInsufficientBalance) {
+			return models.TransactionResponse{}, fmt.Errorf("%w: %w", ErrInsufficientBalance, err)
+		}
+		return models.TransactionResponse{}, fmt.Errorf("failed to save transfer: %w", err)
+	}
+	slog.InfoContext(ctx, "Transferred between customers", "reference", reference, "from", transaction.CustomerID, "to", transaction.DestinationCustomerID, "amount", transaction.Amount)
+
+	return models.TransactionResponse{
+		Reference: reference,
+		Gateway:   created.Gateway,
+		RefID:     created.GatewayRefID.String,
+		Status:    strings.ToLower(string(created.Status)),
+		CreatedAt: created.CreatedAt,
+	}, nil
+}
+
+// initiate stores the transaction under a new reference before it is sent to a gateway, so that a crash
+// after the gateway processed it cannot lose it. A request that timed out or whose outcome is unknown leaves
+// the transaction initiated, since the gateway may have processed it anyway; the sweeper resolves it once it
+// timed out. Authorizations are initiated with their expiry.
+func (s *PaymentService) initiate(ctx context.Context, transaction models.TransactionRequest, authorizationExpiresAt time.Time) (models.TransactionRequest, models.Transaction, error) {
+	reference, err := newReference()
+	if err != nil {
+		return transaction, models.Transaction{}, err
+	}
+	transaction.Reference = reference
+
+	initiated, err := s.paymentRepo.InitiateTransaction(ctx, transaction, authorizationExpiresAt)
+	if err != nil {
+		return transaction, models.Transaction{}, fmt.Errorf("%w: %w", errNotInitiated, err)
+	}
+	return transaction, initiated, nil
+}
+
+// gatewayFailure handles a failed gateway request of an initiated transaction. When the outcome is known,
+// the transaction is failed right away: a declined transaction is answered like a declined response, and a
+// transaction no gateway processed is failed as technical. Timeouts and unknown outcomes leave it initiated,
+// and their error comes with the initiated transaction so that the client can look it up by its reference.
+func (s *PaymentService) gatewayFailure(ctx context.Context, operation string, transaction models.TransactionRequest, initiated models.Transaction, response router.Response, gatewayErr error) (models.TransactionResponse, error) {
+	declineReason, known := declineReasonOf(gatewayErr)
+	if known {
+		err := s.paymentRepo.CompleteInitiatedTransaction(ctx, repo.CompleteInitiatedTransaction{
+			ID:            initiated.ID,
+			From:          models.TransactionStatusINITIATED,
+			Gateway:       response.Gateway,
+			Status:        string(models.TransactionStatusFAILED),
+			DeclineReason: declineReason,
+			RoutingRule:   response.Rule,
+			Source:        models.StatusChangeSourceAPI,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to save failed gateway request", "operation", operation, "reference", transaction.Reference, "gateway", response.Gateway, "gateway_error", gatewayErr, "error", err)
+			return models.TransactionResponse{}, fmt.Errorf("failed to save failed %s: %w", operation, err)
+		}
+	}
+
+	switch {
+	case errors.Is(gatewayErr, gatewayerr.ErrDecline):
+		return models.TransactionResponse{
+			Reference:     transaction.Reference,
+			Gateway:       response.Gateway,
+			Status:        strings.ToLower(string(models.TransactionStatusFAILED)),
+			DeclineReason: declineReason,
+			CreatedAt:     time.Now().UTC(),
+		}, nil
+	case errors.Is(gatewayErr, gateway.ErrGatewayUnavailable):
+		return models.TransactionResponse{}, fmt.Errorf("all payment gateways are currently unavailable: %w", gatewayErr)
+	}
+	return models.TransactionResponse{
+		Reference: transaction.Reference,
+		Gateway:   response.Gateway,
+		Status:    strings.ToLower(string(models.TransactionStatusINITIATED)),
+		CreatedAt: initiated.CreatedAt,
+	}, fmt.Errorf("%s failed: %w", operation, gatewayErr)
+}
+
+// declineReasonOf returns the decline reason of a transaction whose gateway request failed with the error,
+// and whether the outcome of the request is known. Declines carry no reason of the issuer.
+func declineReasonOf(err error) (string, bool) {
+	switch gatewayerr.KindOf(err) {
+	case gatewayerr.KindDecline:
+		return gateway.DeclineReasonDoNotHonor, true
+	case gatewayerr.KindInvalidRequest, gatewayerr.KindUnavailable:
+		return gateway.DeclineReasonTechnical, true
+	}
+	return "", false
+}
+
+// referenceBytes is the number of random bytes of a transaction reference.
+const referenceBytes = 12
+
+// newReference returns a random transaction reference.
+func newReference() (string, error) {
+	b := make([]byte, referenceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate transaction reference: %w", err)
+	}
+	return "txn_" + hex.EncodeToString(b), nil
+}
+
+// SweepInitiatedTransactions resolves transactions that stayed initiated for longer than the initiated
+// timeout, i.e. whose gateway request timed out or whose gateway answer was never saved. The gateways are
+// asked for the transaction by its reference, and the answer of the gateway that knows it is applied. A
+// transaction no gateway knows was never processed and is failed. One the gateways could not be asked about
+// stays initiated and is asked about again with backoff; it is parked for manual review once the status query
+// retries are exhausted.
+func (s *PaymentService) SweepInitiatedTransactions(ctx context.Context) error {
+	now := time.Now().UTC()
+	transactions, err := s.paymentRepo.ListStaleInitiatedTransactions(ctx, now.Add(-s.config.InitiatedTimeout), now, initiatedSweepBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list initiated transactions: %w", err)
+	}
+
+	for _, transaction := range transactions {
+		err := s.resolveInitiated(ctx, transaction, now)
+		if err != nil && !errors.Is(err, repo.ErrStatusChanged) {
+			return fmt.Errorf("failed to resolve initiated transaction %s: %w", transaction.Reference, err)
+		}
+	}
+	return nil
+}
+
+func (s *PaymentService) resolveInitiated(ctx context.Context, transaction models.Transaction, now time.Time) error {
+	var queryErrs []error
+	for _, name := range s.router.Gateways() {
+		var response models.TransactionResponse
+		var unknown bool
+		err := s.router.SendToGateway(ctx, name, func(g gateway.PaymentGateway) error {
+			var gatewayErr error
+			response, gatewayErr = g.QueryStatus(ctx, models.StatusQuery{MerchantReference: transaction.Reference})
+			// A gateway that does not know the transaction answered; it must not count against its circuit.
+			if errors.Is(gatewayErr, gateway.ErrUnknownReference) {
+				unknown = true
+				return nil
+			}
+			return gatewayErr
+		})
+		if err != nil {
+			queryErrs = append(queryErrs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if !unknown {
+			slog.InfoContext(ctx, "Resolving initiated transaction with the gateway status", "reference", transaction.Reference, "gateway", name, "ref_id", response.RefID, "status", response.Status)
+			return s.completeInitiated(ctx, transaction, name, response, models.StatusChangeSourceSYSTEM)
+		}
+	}
 
-	_, err := s.paymentRepo.GetTransactionByRefID(ctx, repo.GetTransactionByRefID{
-		Gateway: req.Gateway,
-		RefID:   req.RefID,
+	if len(queryErrs) > 0 {
+		return s.retryInitiated(ctx, transaction, now, errors.Join(queryErrs...))
+	}
+
+	slog.WarnContext(ctx, "Failing initiated transaction unknown to all gateways", "reference", transaction.Reference, "created_at", transaction.CreatedAt)
+	return s.paymentRepo.TransitionTransactionStatus(ctx, repo.TransitionTransactionStatus{
+		ID:            transaction.ID,
+		From:          models.TransactionStatusINITIATED,
+		To:            models.TransactionStatusFAILED,
+		Source:        models.StatusChangeSourceSYSTEM,
+		DeclineReason: gateway.DeclineReasonTechnical,
 	})
+}
+
+// retryInitiated schedules the next status query of an initiated transaction the gateways could not be asked
+// about, with the backoff of the status queries. The transaction is parked for manual review once the
+// retries are exhausted.
+func (s *PaymentService) retryInitiated(ctx context.Context, transaction models.Transaction, now time.Time, queryErr error) error {
+	retry := s.config.StatusPollRetry
+	attempts := int(transaction.StatusPollAttempts) + 1
+	if attempts < retry.MaxRetries {
+		slog.WarnContext(ctx, "Failed to query initiated transaction, it is queried again later", "reference", transaction.Reference, "attempt", attempts, "error", queryErr)
+		next := now.Add(retry.Backoff.NextBackoff(attempts - 1))
+		if err := s.paymentRepo.ScheduleStatusPoll(ctx, transaction.ID, next); err != nil {
+			return fmt.Errorf("failed to schedule status query: %w", err)
+		}
+		return nil
+	}
+
+	slog.WarnContext(ctx, "Parking initiated transaction for review, the gateways could not be asked about it", "reference", transaction.Reference, "created_at", transaction.CreatedAt, "attempts", attempts, "error", queryErr)
+	return s.paymentRepo.TransitionTransactionStatus(ctx, repo.TransitionTransactionStatus{
+		ID:     transaction.ID,
+		From:   models.TransactionStatusINITIATED,
+		To:     models.TransactionStatusNEEDSREVIEW,
+		Source: models.StatusChangeSourceSYSTEM,
+	})
+}
+
+// completeInitiated stores the status a gateway reported for an initiated or parked transaction.
+// Authorizations the gateway did not fail are authorized until their initial expiry, like in
+// AuthorizeTransaction.
+func (s *PaymentService) completeInitiated(ctx context.Context, transaction models.Transaction, gatewayName string, response models.TransactionResponse, source models.StatusChangeSource) error {
+	amount, err := money.Parse(transaction.Amount, transaction.Currency)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("%w: transaction with ref_id %s not found", ErrTransactionNotFound, req.RefID)
+		return fmt.Errorf("failed to parse transaction amount: %w", err)
+	}
+	expectedFee := s.expectedFee(ctx, gatewayName, models.TransactionRequest{
+		Type:          string(transaction.Type),
+		Amount:        amount,
+		PaymentMethod: transaction.PaymentMethod,
+		CustomerID:    transaction.CustomerID,
+		Reference:     transaction.Reference,
+	})
+
+	status := response.Status
+	var expiresAt time.Time
+	if transaction.AuthorizationExpiresAt.Valid && !strings.EqualFold(status, string(models.TransactionStatusFAILED)) {
+		status = string(models.TransactionStatusAUTHORIZED)
+		expiresAt = transaction.AuthorizationExpiresAt.Time
+	}
+
+	return s.paymentRepo.CompleteInitiatedTransaction(ctx, repo.CompleteInitiatedTransaction{
+		ID:                     transaction.ID,
+		From:                   transaction.Status,
+		Gateway:                gatewayName,
+		GatewayRefID:           response.RefID,
+		Status:                 status,
+		GatewayStatusCode:      response.GatewayStatusCode,
+		DeclineReason:          response.DeclineReason,
+		AuthorizationExpiresAt: expiresAt,
+		ExpectedFee:            expectedFee,
+		Source:                 source,
+	})
+}
+
+// ResolveTransaction completes a transaction parked for manual review with the outcome an operator looked up
+// at the gateway. The gateway and its reference are only optional for a failed transaction, which keeps the
+// gateway it was initiated with. Transactions that are not parked are rejected with ErrIllegalTransition.
+func (s *PaymentService) ResolveTransaction(ctx context.Context, req models.ResolveTransactionRequest) (models.TransactionDetails, error) {
+	transaction, err := s.getTransactionByReference(ctx, req.Reference)
+	if err != nil {
+		return models.TransactionDetails{}, err
+	}
+	if transaction.Status != models.TransactionStatusNEEDSREVIEW {
+		return models.TransactionDetails{}, fmt.Errorf("%w: %s transaction is not awaiting review", ErrIllegalTransition, transaction.Status)
+	}
+
+	gatewayName := req.Gateway
+	if gatewayName == "" {
+		gatewayName = transaction.Gateway
+	}
+	slog.InfoContext(ctx, "Resolving transaction parked for review", "reference", req.Reference, "gateway", gatewayName, "ref_id", req.RefID, "status", req.Status)
+	err = s.completeInitiated(ctx, transaction, gatewayName, models.TransactionResponse{
+		RefID:         req.RefID,
+		Status:        req.Status,
+		DeclineReason: req.DeclineReason,
+	}, models.StatusChangeSourceAPI)
+	if errors.Is(err, repo.ErrStatusChanged) {
+		return models.TransactionDetails{}, fmt.Errorf("%w: transaction is no longer awaiting review", ErrIllegalTransition)
+	}
+	if err != nil {
+		return models.TransactionDetails{}, fmt.Errorf("failed to resolve transaction: %w", err)
+	}
+
+	transaction, err = s.getTransactionByReference(ctx, req.Reference)
+	if err != nil {
+		return models.TransactionDetails{}, err
+	}
+	return toTransactionDetails(transaction)
+}
+
+// UpdateStatus moves a transaction to the reported status. Transitions that the status machine does not
+// allow, such as a late pending callback for a successful transaction, are rejected with ErrIllegalTransition.
+// Reporting the current status again is a no-op. A reference no transaction has is looked up among the refunds.
+func (s *PaymentService) UpdateStatus(ctx context.Context, req models.UpdateStatusRequest) error {
+	slog.InfoContext(ctx, "Updating transaction status", "ref_id", req.RefID, "status", req.Status, "source", req.Source)
+
+	transaction, err := s.getTransaction(ctx, req.Gateway, req.RefID)
+	if errors.Is(err, ErrTransactionNotFound) {
+		if refund, refundErr := s.paymentRepo.GetRefundByRefID(ctx, repo.GetTransactionByRefID{Gateway: req.Gateway, RefID: req.RefID}); refundErr == nil {
+			return s.updateRefundStatus(ctx, refund, req)
+		} else if !errors.Is(refundErr, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get refund: %w", refundErr)
 		}
-		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if err != nil {
+		return err
 	}
 
-	err = s.paymentRepo.UpdateTransactionStatus(ctx, repo.UpdateTransactionStatus{
-		Gateway: req.Gateway,
-		RefID:   req.RefID,
-		Status:  req.Status,
+	to := models.TransactionStatus(strings.ToUpper(req.Status))
+	if transaction.Status == to {
+		return nil
+	}
+	if !transaction.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", ErrIllegalTransition, transaction.Status, to)
+	}
+
+	err = s.paymentRepo.TransitionTransactionStatus(ctx, repo.TransitionTransactionStatus{
+		ID:                transaction.ID,
+		From:              transaction.Status,
+		To:                to,
+		Source:            req.Source,
+		RawPayload:        req.RawPayload,
+		GatewayStatusCode: req.GatewayStatusCode,
+		DeclineReason:     req.DeclineReason,
 	})
 	if err != nil {
+		if errors.Is(err, repo.ErrStatusChanged) {
+			return fmt.Errorf("%w: %w", ErrIllegalTransition, err)
+		}
 		return fmt.Errorf("failed to update transaction status: %w", err)
 	}
 	return nil
 }
+
+// updateRefundStatus moves a pending refund to the reported status, like UpdateStatus does for transactions.
+func (s *PaymentService) updateRefundStatus(ctx context.Context, refund models.Refund, req models.UpdateStatusRequest) error {
+	to := models.TransactionStatus(strings.ToUpper(req.Status))
+	if refund.Status == to {
+		return nil
+	}
+	if refund.Status != models.TransactionStatusPENDING {
+		return fmt.Errorf("%w: refund %s to %s", ErrIllegalTransition, refund.Status, to)
+	}
+	err := s.settleRefund(ctx, refund, req.RefID, req.Status)
+	if errors.Is(err, repo.ErrStatusChanged) {
+		return fmt.Errorf("%w: %w", ErrIllegalTransition, err)
+	}
+	if err != nil && !errors.Is(err, ErrIllegalTransition) {
+		return fmt.Errorf("failed to update refund status: %w", err)
+	}
+	return err
+}
+
+// GetStatusHistory returns the status transitions of a transaction, oldest first.
+func (s *PaymentService) GetStatusHistory(ctx context.Context, gatewayName, refID string) ([]models.StatusChange, error) {
+	transaction, err := s.getTransaction(ctx, gatewayName, refID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.paymentRepo.ListStatusHistory(ctx, transaction.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status history: %w", err)
+	}
+
+	changes := make([]models.StatusChange, 0, len(history))
+	for _, h := range history {
+		changes = append(changes, models.StatusChange{
+			From:      strings.ToLower(string(h.FromStatus)),
+			To:        strings.ToLower(string(h.ToStatus)),
+			Source:    strings.ToLower(string(h.Source)),
+			CreatedAt: h.CreatedAt,
+		})
+	}
+	return changes, nil
+}
+
+// AuthorizeTransaction places a hold on the funds through the first available gateway. The authorization
+// has to be captured or voided before it expires.
+func (s *PaymentService) AuthorizeTransaction(ctx context.Context, transaction models.TransactionRequest) (models.TransactionResponse, error) {
+	expiresAt := time.Now().UTC().Add(s.config.AuthorizationTTL)
+	transaction, initiated, err := s.initiate(ctx, transaction, expiresAt)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+
+	response, err := s.router.SendMessage(ctx, transaction, func(g gateway.PaymentGateway) (models.TransactionResponse, error) {
+		return g.Authorize(ctx, transaction)
+	})
+
+	slog.InfoContext(ctx, "Received authorization response from gateway", "reference", transaction.Reference, "response", response, "error", err)
+
+	if err != nil {
+		return s.gatewayFailure(ctx, "authorization", transaction, initiated, response, err)
+	}
+
+	status := models.TransactionStatusAUTHORIZED
+	if strings.EqualFold(response.Data.Status, string(models.TransactionStatusFAILED)) {
+		status = models.TransactionStatusFAILED
+		expiresAt = time.Time{}
+	}
+	// The fee is expected on the authorized amount; a partial capture may cost less.
+	expectedFee := s.expectedFee(ctx, response.Gateway, transaction)
+
+	err = s.paymentRepo.CompleteInitiatedTransaction(ctx, repo.CompleteInitiatedTransaction{
+		ID:                     initiated.ID,
+		From:                   models.TransactionStatusINITIATED,
+		Gateway:                response.Gateway,
+		GatewayRefID:           response.Data.RefID,
+		Status:                 string(status),
+		GatewayStatusCode:      response.Data.GatewayStatusCode,
+		DeclineReason:          response.Data.DeclineReason,
+		AuthorizationExpiresAt: expiresAt,
+		RoutingRule:            response.Rule,
+		ExpectedFee:            expectedFee,
+		Source:                 models.StatusChangeSourceAPI,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save gateway authorization", "reference", transaction.Reference, "gateway", response.Gateway, "ref_id", response.Data.RefID, "error", err)
+		return models.TransactionResponse{}, fmt.Errorf("failed to save authorization: %w", err)
+	}
+	return models.TransactionResponse{
+		Reference:         transaction.Reference,
+		Gateway:           response.Gateway,
+		RefID:             response.Data.RefID,
+		Status:            strings.ToLower(string(status)),
+		GatewayStatusCode: response.Data.GatewayStatusCode,
+		DeclineReason:     response.Data.DeclineReason,
+		CreatedAt:         response.Data.CreatedAt,
+	}, nil
+}
+
+// CaptureTransaction captures an authorized transaction. When no amount is given, the full authorized amount is captured.
+func (s *PaymentService) CaptureTransaction(ctx context.Context, req models.CaptureTransactionRequest) (models.TransactionResponse, error) {
+	slog.InfoContext(ctx, "Capturing transaction", "ref_id", req.TransactionRefID, "gateway", req.Gateway, "amount", req.Amount)
+
+	transaction, err := s.getAuthorizedTransaction(ctx, req.Gateway, req.TransactionRefID)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+	if transaction.AuthorizationExpiresAt.Valid && transaction.AuthorizationExpiresAt.Time.Before(time.Now().UTC()) {
+		return models.TransactionResponse{}, fmt.Errorf("%w: authorization expired at %s", ErrAuthorizationExpired, transaction.AuthorizationExpiresAt.Time)
+	}
+
+	authorized, err := money.Parse(transaction.Amount, transaction.Currency)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("failed to parse transaction amount: %w", err)
+	}
+	amount, err := parseRequestAmount(req.Amount, transaction.Currency)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+	if amount.IsZero() {
+		amount = authorized
+	}
+	exceeded, err := amount.Cmp(authorized)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("failed to compare capture amount: %w", err)
+	}
+	if exceeded > 0 {
+		return models.TransactionResponse{}, fmt.Errorf("%w: authorized amount is %s", ErrCaptureAmountExceeded, authorized)
+	}
+
+	var response models.TransactionResponse
+	err = s.router.SendToGateway(ctx, transaction.Gateway, func(g gateway.PaymentGateway) error {
+		var gatewayErr error
+		response, gatewayErr = g.Capture(ctx, models.CaptureRequest{
+			Gateway:          transaction.Gateway,
+			TransactionRefID: transaction.GatewayRefID.String,
+			Amount:           amount,
+		})
+		return gatewayErr
+	})
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("capture failed: %w", err)
+	}
+	// A declined capture leaves the authorization as it is, so it can still be captured again or voided.
+	if strings.EqualFold(response.Status, string(models.TransactionStatusFAILED)) {
+		slog.WarnContext(ctx, "Gateway declined capture", "ref_id", req.TransactionRefID, "gateway", transaction.Gateway, "gateway_status_code", response.GatewayStatusCode, "decline_reason", response.DeclineReason)
+		return models.TransactionResponse{}, fmt.Errorf("%w: gateway status code %s", ErrCaptureDeclined, response.GatewayStatusCode)
+	}
+
+	err = s.paymentRepo.CaptureTransaction(ctx, repo.CaptureTransaction{
+		ID:     transaction.ID,
+		Amount: amount,
+		Source: models.StatusChangeSourceAPI,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrNotAuthorized) {
+			return models.TransactionResponse{}, fmt.Errorf("%w: %w", ErrTransactionNotAuthorized, err)
+		}
+		return models.TransactionResponse{}, fmt.Errorf("failed to save capture: %w", err)
+	}
+	return models.TransactionResponse{
+		Gateway:   transaction.Gateway,
+		RefID:     transaction.GatewayRefID.String,
+		Status:    strings.ToLower(string(models.TransactionStatusCAPTURED)),
+		CreatedAt: response.CreatedAt,
+	}, nil
+}
+
+// VoidTransaction releases the hold of an authorized transaction.
+func (s *PaymentService) VoidTransaction(ctx context.Context, req models.VoidRequest) (models.TransactionResponse, error) {
+	slog.InfoContext(ctx, "Voiding transaction", "ref_id", req.TransactionRefID, "gateway", req.Gateway)
+
+	transaction, err := s.getAuthorizedTransaction(ctx, req.Gateway, req.TransactionRefID)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+
+	response, err := s.void(ctx, transaction)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+	return models.TransactionResponse{
+		Gateway:   transaction.Gateway,
+		RefID:     transaction.GatewayRefID.String,
+		Status:    strings.ToLower(string(models.TransactionStatusVOIDED)),
+		CreatedAt: response.CreatedAt,
+	}, nil
+}
+
+// ExpireAuthorizations voids authorizations that were neither captured nor voided before they expired.
+// A transaction is only voided once the gateway released its hold; authorizations whose void failed stay
+// authorized and are voided again on the next run.
+func (s *PaymentService) ExpireAuthorizations(ctx context.Context) error {
+	transactions, err := s.paymentRepo.ListExpiredAuthorizations(ctx, time.Now().UTC(), expiredAuthorizationsBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list expired authorizations: %w", err)
+	}
+
+	for _, transaction := range transactions {
+		slog.InfoContext(ctx, "Expiring authorization", "ref_id", transaction.GatewayRefID.String, "gateway", transaction.Gateway)
+
+		if _, err := s.voidAtGateway(ctx, transaction); err != nil {
+			slog.WarnContext(ctx, "Failed to void expired authorization at gateway, retrying on the next run", "ref_id", transaction.GatewayRefID.String, "error", err)
+			continue
+		}
+		err := s.paymentRepo.VoidTransaction(ctx, repo.VoidTransaction{ID: transaction.ID, Source: models.StatusChangeSourceSYSTEM})
+		if err != nil && !errors.Is(err, repo.ErrNotAuthorized) {
+			return fmt.Errorf("failed to void expired authorization %s: %w", transaction.GatewayRefID.String, err)
+		}
+	}
+	return nil
+}
+
+func (s *PaymentService) void(ctx context.Context, transaction models.Transaction) (models.TransactionResponse, error) {
+	response, err := s.voidAtGateway(ctx, transaction)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+
+	if err := s.paymentRepo.VoidTransaction(ctx, repo.VoidTransaction{ID: transaction.ID, Source: models.StatusChangeSourceAPI}); err != nil {
+		if errors.Is(err, repo.ErrNotAuthorized) {
+			return models.TransactionResponse{}, fmt.Errorf("%w: %w", ErrTransactionNotAuthorized, err)
+		}
+		return models.TransactionResponse{}, fmt.Errorf("failed to save void: %w", err)
+	}
+	return response, nil
+}
+
+func (s *PaymentService) voidAtGateway(ctx context.Context, transaction models.Transaction) (models.TransactionResponse, error) {
+	var response models.TransactionResponse
+	err := s.router.SendToGateway(ctx, transaction.Gateway, func(g gateway.PaymentGateway) error {
+		var gatewayErr error
+		response, gatewayErr = g.Void(ctx, models.VoidRequest{
+			Gateway:          transaction.Gateway,
+			TransactionRefID: transaction.GatewayRefID.String,
+		})
+		return gatewayErr
+	})
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("void failed: %w", err)
+	}
+	return response, nil
+}
+
+// CreateRefund refunds the given amount of a successful transaction. When no amount is given, the
+// remaining refundable amount is refunded. Multiple partial refunds are allowed up to the transaction amount.
+func (s *PaymentService) CreateRefund(ctx context.Context, req models.CreateRefundRequest) (models.RefundResponse, error) {
+	slog.InfoContext(ctx, "Creating refund", "ref_id", req.TransactionRefID, "gateway", req.Gateway, "amount", req.Amount)
+
+	transaction, err := s.getTransaction(ctx, req.Gateway, req.TransactionRefID)
+	if err != nil {
+		return models.RefundResponse{}, err
+	}
+	if transaction.Status != models.TransactionStatusSUCCESS && transaction.Status != models.TransactionStatusCAPTURED {
+		return models.RefundResponse{}, fmt.Errorf("%w: transaction status is %s", ErrTransactionNotRefundable, transaction.Status)
+	}
+	if transaction.Type == models.TransactionTypeTRANSFER {
+		return models.RefundResponse{}, fmt.Errorf("%w: transfers are not made through a gateway", ErrTransactionNotRefundable)
+	}
+
+	amount, err := parseRequestAmount(req.Amount, transaction.Currency)
+	if err != nil {
+		return models.RefundResponse{}, err
+	}
+	if amount.IsZero() {
+		amount, err = refundableAmount(transaction)
+		if err != nil {
+			return models.RefundResponse{}, err
+		}
+	}
+	if !amount.IsPositive() {
+		return models.RefundResponse{}, fmt.Errorf("%w: transaction is fully refunded", ErrRefundAmountExceeded)
+	}
+
+	refund, err := s.paymentRepo.CreateRefund(ctx, repo.CreateRefund{
+		TransactionID: transaction.ID,
+		Amount:        amount,
+		Gateway:       transaction.Gateway,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrRefundLimitExceeded) {
+			return models.RefundResponse{}, fmt.Errorf("%w: %w", ErrRefundAmountExceeded, err)
+		}
+		return models.RefundResponse{}, fmt.Errorf("failed to save refund: %w", err)
+	}
+
+	var response models.RefundResponse
+	err = s.router.SendToGateway(ctx, transaction.Gateway, func(g gateway.PaymentGateway) error {
+		var gatewayErr error
+		response, gatewayErr = g.Refund(ctx, models.RefundRequest{
+			Gateway:          transaction.Gateway,
+			TransactionRefID: transaction.GatewayRefID.String,
+			Amount:           amount,
+			Reason:           req.Reason,
+			Reference:        refundReference(refund.ID),
+		})
+		return gatewayErr
+	})
+	if err != nil {
+		// The gateway may have made a refund that timed out, so its amount stays reserved until the status
+		// poller learns the outcome.
+		if !refundNotProcessed(err) {
+			slog.WarnContext(ctx, "Refund outcome unknown, keeping it pending", "refund_id", refund.ID, "error", err)
+			return models.RefundResponse{}, fmt.Errorf("refund not confirmed: %w", err)
+		}
+		if failErr := s.paymentRepo.FailRefund(ctx, repo.FailRefund{
+			ID:            refund.ID,
+			TransactionID: transaction.ID,
+			Amount:        refund.Amount,
+		}); failErr != nil {
+			slog.ErrorContext(ctx, "Failed to mark refund as failed", "refund_id", refund.ID, "error", failErr)
+		}
+		return models.RefundResponse{}, fmt.Errorf("refund failed: %w", err)
+	}
+
+	if err := s.settleRefund(ctx, refund, response.RefID, response.Status); err != nil {
+		return models.RefundResponse{}, fmt.Errorf("failed to update refund: %w", err)
+	}
+	return models.RefundResponse{
+		ID:               refund.ID,
+		Gateway:          refund.Gateway,
+		RefID:            response.RefID,
+		TransactionRefID: transaction.GatewayRefID.String,
+		Amount:           amount,
+		Status:           response.Status,
+		CreatedAt:        refund.CreatedAt,
+	}, nil
+}
+
+// refundNotProcessed reports whether the refund error proves that the gateway made no refund: it declined or
+// rejected the refund, or could not be reached.
+func refundNotProcessed(err error) bool {
+	switch gatewayerr.KindOf(err) {
+	case gatewayerr.KindDecline, gatewayerr.KindInvalidRequest, gatewayerr.KindUnavailable:
+		return true
+	}
+	return false
+}
+
+// refundReference returns our reference of the refund, which is sent to the gateway as its merchant reference.
+func refundReference(id int32) string {
+	return fmt.Sprintf("rfd_%d", id)
+}
+
+// settleRefund applies a status the gateway reported for a pending refund. A successful refund is posted to the
+// ledger and the amount of a failed one is released, so it can be refunded again. A pending refund only learns
+// its gateway reference.
+func (s *PaymentService) settleRefund(ctx context.Context, refund models.Refund, refID, status string) error {
+	switch to := models.TransactionStatus(strings.ToUpper(status)); to {
+	case models.TransactionStatusFAILED:
+		return s.paymentRepo.FailRefund(ctx, repo.FailRefund{
+			ID:            refund.ID,
+			TransactionID: refund.TransactionID,
+			Amount:        refund.Amount,
+			GatewayRefID:  refID,
+		})
+	case models.TransactionStatusPENDING, models.TransactionStatusSUCCESS:
+		if to == models.TransactionStatusPENDING && (refID == "" || refID == refund.GatewayRefID.String) {
+			return nil
+		}
+		_, err := s.paymentRepo.CompleteRefund(ctx, repo.CompleteRefund{
+			ID:           refund.ID,
+			GatewayRefID: refID,
+			Status:       status,
+		})
+		return err
+	default:
+		return fmt.Errorf("%w: refund cannot move to %s", ErrIllegalTransition, to)
+	}
+}
+
+func (s *PaymentService) getTransaction(ctx context.Context, gatewayName, refID string) (models.Transaction, error) {
+	transaction, err := s.paymentRepo.GetTransactionByRefID(ctx, repo.GetTransactionByRefID{
+		Gateway: gatewayName,
+		RefID:   refID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Transaction{}, fmt.Errorf("%w: transaction with ref_id %s not found", ErrTransactionNotFound, refID)
+		}
+		return models.Transaction{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return transaction, nil
+}
+
+func (s *PaymentService) getTransactionByReference(ctx context.Context, reference string) (models.Transaction, error) {
+	transaction, err := s.paymentRepo.GetTransactionByReference(ctx, reference)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Transaction{}, fmt.Errorf("%w: transaction with reference %s not found", ErrTransactionNotFound, reference)
+		}
+		return models.Transaction{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return transaction, nil
+}
+
+func (s *PaymentService) getAuthorizedTransaction(ctx context.Context, gatewayName, refID string) (models.Transaction, error) {
+	transaction, err := s.getTransaction(ctx, gatewayName, refID)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	if transaction.Status != models.TransactionStatusAUTHORIZED {
+		return models.Transaction{}, fmt.Errorf("%w: transaction status is %s", ErrTransactionNotAuthorized, transaction.Status)
+	}
+	return transaction, nil
+}
+
+// parseRequestAmount parses an optional amount given in the currency of the transaction. An empty amount is zero.
+func parseRequestAmount(amount, currency string) (money.Money, error) {
+	if amount == "" {
+		return money.New(0, currency)
+	}
+	m, err := money.Parse(amount, currency)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("%w: %w", ErrInvalidAmount, err)
+	}
+	if m.IsNegative() {
+		return money.Money{}, fmt.Errorf("%w: amount cannot be negative", ErrInvalidAmount)
+	}
+	return m, nil
+}
+
+// refundableAmount returns the captured amount of the transaction that has not been refunded yet.
+func refundableAmount(transaction models.Transaction) (money.Money, error) {
+	captured := transaction.Amount
+	if transaction.CapturedAmount.Valid {
+		captured = transaction.CapturedAmount.String
+	}
+	amount, err := money.Parse(captured, transaction.Currency)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to parse transaction amount: %w", err)
+	}
+	refunded, err := money.Parse(transaction.RefundedAmount, transaction.Currency)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to parse refunded amount: %w", err)
+	}
+	return amount.Sub(refunded)
+}
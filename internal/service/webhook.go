@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rauf/payment-service/internal/config"
+	"github.com/rauf/payment-service/internal/models"
+	"github.com/rauf/payment-service/internal/outbox"
+	"github.com/rauf/payment-service/internal/repo"
+	"github.com/rauf/payment-service/internal/webhook"
+)
+
+var (
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookEventTypes are the events delivered to merchant webhook endpoints.
+var WebhookEventTypes = []string{
+	outbox.TransactionCreated,
+	outbox.TransactionSucceeded,
+	outbox.TransactionFailed,
+	outbox.TransactionCaptured,
+	outbox.TransactionVoided,
+}
+
+// WebhookService delivers the events of merchants' transactions to the webhook endpoints they registered.
+type WebhookService struct {
+	paymentRepo *repo.PaymentRepo
+	sender      *webhook.Sender
+	config      config.WebhookConfig
+}
+
+func NewWebhookService(paymentRepo *repo.PaymentRepo, sender *webhook.Sender, config config.WebhookConfig) *WebhookService {
+	return &WebhookService{
+		paymentRepo: paymentRepo,
+		sender:      sender,
+		config:      config,
+	}
+}
+
+// RegisterEndpoint adds a webhook endpoint for the merchant. The returned secret signs the deliveries and is
+// not returned again.
+func (s *WebhookService) RegisterEndpoint(ctx context.Context, merchantID, url string) (models.WebhookEndpointDetails, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return models.WebhookEndpointDetails{}, err
+	}
+	endpoint, err := s.paymentRepo.CreateWebhookEndpoint(ctx, repo.CreateWebhookEndpoint{
+		MerchantID: merchantID,
+		URL:        url,
+		Secret:     secret,
+	})
+	if err != nil {
+		return models.WebhookEndpointDetails{}, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	details := toWebhookEndpointDetails(endpoint)
+	details.Secret = endpoint.Secret
+	return details, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, merchantID string) ([]models.WebhookEndpointDetails, error) {
+	endpoints, err := s.paymentRepo.ListWebhookEndpoints(ctx, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	details := make([]models.WebhookEndpointDetails, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		details = append(details, toWebhookEndpointDetails(endpoint))
+	}
+	return details, nil
+}
+
+// DeleteEndpoint stops the deliveries to the endpoint. Pending deliveries are not sent.
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, merchantID string, id int32) error {
+	if err := s.paymentRepo.DeactivateWebhookEndpoint(ctx, merchantID, id); err != nil {
+		if errors.Is(err, repo.ErrWebhookEndpointNotFound) {
+			return ErrWebhookEndpointNotFound
+		}
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns a page of the merchant's delivery log, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) (models.WebhookDeliveryPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	list := repo.ListWebhookDeliveries{
+		MerchantID: filter.MerchantID,
+		Status:     models.WebhookDeliveryStatus(strings.ToUpper(filter.Status)),
+		// One extra row tells whether there is a next page.
+		Limit: int32(limit + 1),
+	}
+	if filter.Cursor != "" {
+		id, err := decodeIDCursor(filter.Cursor)
+		if err != nil {
+			return models.WebhookDeliveryPage{}, err
+		}
+		list.CursorID = id
+	}
+
+	deliveries, err := s.paymentRepo.ListWebhookDeliveries(ctx, list)
+	if err != nil {
+		return models.WebhookDeliveryPage{}, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	var page models.WebhookDeliveryPage
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+		page.NextCursor = encodeIDCursor(deliveries[limit-1].ID)
+	}
+	page.Deliveries = make([]models.WebhookDeliveryDetails, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		page.Deliveries = append(page.Deliveries, toWebhookDeliveryDetails(delivery))
+	}
+	return page, nil
+}
+
+// Redeliver schedules a delivery to be sent again right away with a fresh set of attempts. It also revives
+// dead-lettered deliveries.
+func (s *WebhookService) Redeliver(ctx context.Context, merchantID string, id int64) (models.WebhookDeliveryDetails, error) {
+	delivery, err := s.paymentRepo.RedeliverWebhookDelivery(ctx, merchantID, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrWebhookDeliveryNotFound) {
+			return models.WebhookDeliveryDetails{}, ErrWebhookDeliveryNotFound
+		}
+		return models.WebhookDeliveryDetails{}, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	return toWebhookDeliveryDetails(delivery), nil
+}
+
+// EnqueueDeliveries schedules the event for delivery to the webhook endpoints of the transaction's merchant.
+// It is an outbox handler for the WebhookEventTypes.
+func (s *WebhookService) EnqueueDeliveries(ctx context.Context, event outbox.Event) error {
+	var payload outbox.TransactionPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+	}
+	if payload.MerchantID == "" {
+		return nil
+	}
+
+	endpoints, err := s.paymentRepo.ListWebhookEndpoints(ctx, payload.MerchantID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+	if err := s.paymentRepo.CreateWebhookDeliveries(ctx, endpoints, event); err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// DeliverPending sends the deliveries that are due. Failed deliveries are retried with exponential backoff
+// and dead-lettered once the retries are exhausted.
+func (s *WebhookService) DeliverPending(ctx context.Context) error {
+	claimed, err := s.paymentRepo.ClaimWebhookDeliveries(ctx, s.config.Lease, s.config.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	// The deliveries are sent concurrently so a batch of slow endpoints finishes within the lease.
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, delivery := range claimed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.deliver(ctx, delivery); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery models.ClaimWebhookDeliveriesRow) error {
+	code, sendErr := s.sender.Send(ctx, webhook.Delivery{
+		ID:        delivery.ID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Payload:   delivery.Payload,
+		CreatedAt: delivery.CreatedAt,
+		URL:       delivery.Url,
+		Secret:    delivery.Secret,
+	})
+	if ctx.Err() != nil {
+		// Shutting down; the delivery is tried again once its lease expires.
+		return nil
+	}
+
+	now := time.Now().UTC()
+	update := repo.UpdateWebhookDelivery{
+		ID:            delivery.ID,
+		Status:        models.WebhookDeliveryStatusDELIVERED,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: now,
+		ResponseCode:  code,
+	}
+	if sendErr != nil {
+		update.LastError = sendErr.Error()
+		if next, ok := webhook.NextAttempt(s.config.Retry, int(update.Attempts), now); ok {
+			update.Status = models.WebhookDeliveryStatusPENDING
+			update.NextAttemptAt = next
+			slog.WarnContext(ctx, "Webhook delivery failed, retrying", "delivery_id", delivery.ID, "attempts", update.Attempts, "next_attempt_at", next, "error", sendErr)
+		} else {
+			update.Status = models.WebhookDeliveryStatusDEAD
+			slog.ErrorContext(ctx, "Webhook delivery dead-lettered", "delivery_id", delivery.ID, "attempts", update.Attempts, "error", sendErr)
+		}
+	}
+
+	if err := s.paymentRepo.UpdateWebhookDelivery(ctx, update); err != nil {
+		return fmt.Errorf("failed to update webhook delivery %d: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+func toWebhookEndpointDetails(endpoint models.WebhookEndpoint) models.WebhookEndpointDetails {
+	return models.WebhookEndpointDetails{
+		ID:         endpoint.ID,
+		MerchantID: endpoint.MerchantID,
+		URL:        endpoint.Url,
+		CreatedAt:  endpoint.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryDetails(delivery models.WebhookDelivery) models.WebhookDeliveryDetails {
+	return models.WebhookDeliveryDetails{
+		ID:            delivery.ID,
+		EndpointID:    delivery.EndpointID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Status:        strings.ToLower(string(delivery.Status)),
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		ResponseCode:  delivery.ResponseCode.Int32,
+		LastError:     delivery.LastError.String,
+		CreatedAt:     delivery.CreatedAt,
+		UpdatedAt:     delivery.UpdatedAt,
+	}
+}
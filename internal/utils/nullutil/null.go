@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/sqlc-dev/pqtype"
 )
@@ -22,3 +23,10 @@ func NewNullRawMessage(m json.RawMessage) pqtype.NullRawMessage {
 	}
 	return pqtype.NullRawMessage{RawMessage: m, Valid: true}
 }
+
+func NewNullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t, Valid: true}
+}
@@ -0,0 +1,17 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+)
+
+// NextAttempt returns when a delivery that failed its attempts-th attempt is tried again. It returns false
+// once the retries are exhausted and the delivery has to be dead-lettered.
+func NextAttempt(retry backoff.RetryConfig, attempts int, now time.Time) (time.Time, bool) {
+	retries := attempts - 1
+	if retries >= retry.MaxRetries {
+		return time.Time{}, false
+	}
+	return now.Add(retry.Backoff.NextBackoff(retries)), true
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rauf/payment-service/internal/backoff"
+)
+
+func TestNextAttempt(t *testing.T) {
+	now := time.Date(2024, 9, 19, 9, 0, 0, 0, time.UTC)
+	retry := backoff.RetryConfig{
+		MaxRetries: 3,
+		Backoff:    backoff.NewExponentialBackoff(time.Minute, 2, 3*time.Minute),
+	}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+		wantOK   bool
+	}{
+		{attempts: 1, want: time.Minute, wantOK: true},
+		{attempts: 2, want: 2 * time.Minute, wantOK: true},
+		{attempts: 3, want: 3 * time.Minute, wantOK: true},
+		{attempts: 4, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		next, ok := NextAttempt(retry, tt.attempts, now)
+		if ok != tt.wantOK {
+			t.Errorf("Attempt %d: expected ok %v, got %v", tt.attempts, tt.wantOK, ok)
+			continue
+		}
+		if ok && !next.Equal(now.Add(tt.want)) {
+			t.Errorf("Attempt %d: expected next attempt after %v, got %v", tt.attempts, tt.want, next.Sub(now))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxErrorBodyBytes is how much of a failed response is kept as the delivery error.
+const maxErrorBodyBytes = 512
+
+// Delivery is an event to be sent to a merchant endpoint.
+type Delivery struct {
+	ID        int64
+	EventID   int64
+	EventType string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	URL       string
+	Secret    string
+}
+
+// Body is the JSON body of a delivery.
+type Body struct {
+	// ID is the event ID. Receivers can use it to ignore events they already processed.
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sender posts signed deliveries to merchant endpoints.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewSender(client *http.Client) *Sender {
+	return &Sender{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Send posts the delivery and returns the response status code. Any response other than 2xx is an error;
+// the status code is 0 when no response was received.
+func (s *Sender) Send(ctx context.Context, delivery Delivery) (int, error) {
+	body, err := json.Marshal(Body{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, s.now(), body))
+	req.Header.Set(DeliveryIDHeader, fmt.Sprint(delivery.ID))
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodyBytes))
+		return res.StatusCode, fmt.Errorf("webhook endpoint responded with %d: %s", res.StatusCode, msg)
+	}
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, res.Body)
+	return res.StatusCode, nil
+}
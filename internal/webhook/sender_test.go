@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSender_Send(t *testing.T) {
+	sentAt := time.Unix(1726736400, 0)
+	createdAt := time.Date(2024, 9, 19, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{name: "accepted", statusCode: http.StatusOK},
+		{name: "no content", statusCode: http.StatusNoContent},
+		{name: "server error", statusCode: http.StatusInternalServerError, wantErr: true},
+		{name: "redirect", statusCode: http.StatusNotModified, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				header http.Header
+				body   []byte
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			sender := NewSender(server.Client())
+			sender.now = func() time.Time { return sentAt }
+
+			code, err := sender.Send(context.Background(), Delivery{
+				ID:        7,
+				EventID:   42,
+				EventType: "transaction.succeeded",
+				Payload:   json.RawMessage(`{"transaction_id":1}`),
+				CreatedAt: createdAt,
+				URL:       server.URL,
+				Secret:    "whsec_test",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if code != tt.statusCode {
+				t.Errorf("Expected status code %d, got %d", tt.statusCode, code)
+			}
+
+			wantBody := `{"id":42,"type":"transaction.succeeded","created_at":"2024-09-19T09:00:00Z","data":{"transaction_id":1}}`
+			if string(body) != wantBody {
+				t.Errorf("Expected body %s, got %s", wantBody, body)
+			}
+			if got := header.Get(SignatureHeader); got != Sign("whsec_test", sentAt, body) {
+				t.Errorf("Unexpected signature %s", got)
+			}
+			if got := header.Get(DeliveryIDHeader); got != "7" {
+				t.Errorf("Expected delivery ID 7, got %s", got)
+			}
+			if got := header.Get(EventTypeHeader); got != "transaction.succeeded" {
+				t.Errorf("Expected event type transaction.succeeded, got %s", got)
+			}
+		})
+	}
+}
+
+func TestSender_SendUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	code, err := NewSender(http.DefaultClient).Send(context.Background(), Delivery{URL: url, Payload: json.RawMessage(`{}`)})
+	if err == nil || !strings.Contains(err.Error(), "failed to send webhook") {
+		t.Errorf("Expected send error, got %v", err)
+	}
+	if code != 0 {
+		t.Errorf("Expected status code 0, got %d", code)
+	}
+}
@@ -0,0 +1,49 @@
+// Package webhook delivers payment events to the HTTP endpoints registered by merchants.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the timestamp and the HMAC-SHA256 signature of a delivery, e.g.
+	// "t=1726736400,v1=5257a869...". The signature is computed over "<timestamp>.<body>" with the endpoint
+	// secret, so receivers can reject both forged and replayed deliveries.
+	SignatureHeader = "X-Webhook-Signature"
+	// DeliveryIDHeader identifies the delivery. It stays the same across retries.
+	DeliveryIDHeader = "X-Webhook-Delivery"
+	// EventTypeHeader is the type of the delivered event.
+	EventTypeHeader = "X-Webhook-Event"
+)
+
+// secretBytes is the number of random bytes of an endpoint secret.
+const secretBytes = 32
+
+// NewSecret returns a random secret for signing the deliveries of an endpoint.
+func NewSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the SignatureHeader value of the body sent at the given time.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + signature(secret, t, body)
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
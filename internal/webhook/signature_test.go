@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign("whsec_test", time.Unix(1726736400, 0), []byte(`{"id":1}`))
+	want := "t=1726736400,v1=4f47122bbb55b2483d15209876ccc17c596e34cf87273f8f169dbfd6b447b04e"
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, err := NewSecret()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+2*secretBytes {
+		t.Errorf("Unexpected secret format: %s", a)
+	}
+	if a == b {
+		t.Errorf("Expected different secrets, got %s twice", a)
+	}
+}
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Worker is a background job that runs periodically.
+type Worker struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start runs the worker every interval until the context is cancelled. Errors are logged and do not stop the worker.
+func (w Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	slog.InfoContext(ctx, "Starting worker", "worker", w.Name, "interval", w.Interval)
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Stopping worker", "worker", w.Name)
+			return
+		case <-ticker.C:
+			if err := w.Run(ctx); err != nil {
+				slog.ErrorContext(ctx, "Worker run failed", "worker", w.Name, "error", err)
+			}
+		}
+	}
+}